@@ -1,36 +1,104 @@
 package main
 
 import (
+	"api/src/administracao"
+	"api/src/autenticacao"
+	"api/src/banco"
+	"api/src/cache"
+	"api/src/ciclo"
 	"api/src/config"
+	"api/src/migracoes"
+	"api/src/paginacao"
+	"api/src/repositorios"
 	"api/src/router"
+	"api/src/tarefas"
+	"api/src/tenancia"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 
 	"github.com/gorilla/handlers" // Importando o pacote para lidar com CORS
-	"github.com/joho/godotenv"    //remover se tiver em produção
 
 	"api/src/controllers" // Importando o pacote de controllers
 )
 
 func main() {
-	// Carrega as variáveis do .env remover se tiver em produção
-	erro := godotenv.Load()
+	// Carregar as configurações: padrões, arquivo (--config), variáveis de ambiente (e .env, se existir) e flags
+	argumentos, erro := config.Carregar(os.Args[1:])
 	if erro != nil {
-		log.Fatal("Erro ao carregar o arquivo .env")
+		log.Fatal(erro)
 	}
 
-	// Carregar as configurações
-	config.Carregar()
+	// Subcomando de diagnóstico: main config mostra a configuração efetiva, com as senhas escondidas
+	if len(argumentos) > 0 && argumentos[0] == "config" {
+		fmt.Print(config.Atual())
+		return
+	}
+
+	// Um único pool de conexões, compartilhado por todos os repositórios
+	if erro = banco.Iniciar(context.Background()); erro != nil {
+		log.Fatalf("Erro ao conectar ao banco de dados: %v", erro)
+	}
+	log.Println("Conexão com o banco de dados estabelecida com sucesso!")
+
+	// Subcomando de manutenção do esquema: main migrate up|down|status
+	if len(argumentos) > 0 && argumentos[0] == "migrate" {
+		erro = migracoes.Executar(argumentos[1:])
+		banco.Fechar()
+		if erro != nil {
+			log.Fatal(erro)
+		}
+		return
+	}
+
+	// Subcomando de operação das organizações: main organizacao dono <slug> <email>
+	if len(argumentos) > 0 && argumentos[0] == "organizacao" {
+		erro = administracao.Executar(argumentos[1:])
+		banco.Fechar()
+		if erro != nil {
+			log.Fatal(erro)
+		}
+		return
+	}
+
+	// O pool é o último a fechar: as requisições e tarefas que ainda terminam durante o encerramento usam o banco
+	ciclo.AoEncerrar("banco de dados", func(context.Context) error {
+		banco.Fechar()
+		return nil
+	})
+
+	// A API não sobe com um esquema que ela não conhece
+	if erro = migracoes.Preparar(banco.Conexao(), config.Atual().MigrarAoIniciar); erro != nil {
+		log.Fatalf("Erro no esquema do banco de dados: %v", erro)
+	}
+
+	// As organizações vêm do PostgreSQL e ficam num cache limitado em memória
+	tenancia.Configurar(repositorios.NovoRepositorioDeOrganizacoes(banco.Conexao()))
+
+	// Papéis e permissões são resolvidos a cada requisição autenticada, e não copiados para o token
+	autenticacao.ConfigurarAcesso(repositorios.NovoRepositorioDeAcessos(banco.Conexao()))
 
-	r := router.Gerar()
+	// Os controllers recebem os repositórios em vez de montá-los a cada requisição
+	controladores := controllers.NovosControladores(controllers.Dependencias{
+		Usuarios: repositorios.NovaFabricaDeUsuarios(banco.Conexao()),
+		DB:       banco.Conexao(),
+	})
+
+	r := router.Gerar(controladores)
 
-	// Inicializar o Redis
-	_, err := config.InicializarRedis()
-	if err != nil {
-		log.Fatalf("Erro ao conectar ao Redis: %v", err)
+	// Cache do login: Redis, com o PostgreSQL de reserva, ou só o PostgreSQL quando REDIS_URL não está definida
+	if erro = cache.Iniciar(); erro != nil {
+		log.Fatalf("Erro ao configurar o cache: %v", erro)
 	}
+	ciclo.AoEncerrar("Redis", func(context.Context) error { return config.FecharRedis() })
+
+	// Apagar de vez as contas excluídas depois da carência
+	ciclo.Executar("expurgo", tarefas.Expurgo)
+
+	// Limites do login, duração dos tokens e origens do CORS são recarregados com SIGHUP ou ao mudar o arquivo
+	ciclo.Executar("recarga da configuração", config.ObservarRecarga)
 
 	// Rota para servir o arquivo index.html (quando a URL raiz for acessada)
 	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -58,15 +126,34 @@ func main() {
 	fs := http.FileServer(http.Dir("/app/static"))
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static", fs))
 
-	// Habilitar CORS para permitir requisições de qualquer origem
+	// Habilitar CORS para as origens de CORS_ORIGENS (todas, por padrão), consultadas a cada requisição
+	// para valerem as recargas. Toda requisição passa antes pela resolução da organização (tenant).
 	corsHandler := handlers.CORS(
-		handlers.AllowedOrigins([]string{"*"}),                                       // Permite todas as origens
-		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}), // Permite métodos HTTP
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),           // Permite cabeçalhos específicos
-	)(r)
-
-	// Iniciar o servidor HTTP
-	fmt.Printf("Escutando na porta %d\n", config.Porta)
-	log.Println("Porta configurada:", config.Porta)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.Porta), corsHandler))
+		handlers.AllowedOriginValidator(func(origem string) bool { return config.Atual().OrigemPermitida(origem) }),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),             // Permite métodos HTTP
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", tenancia.CabecalhoOrganizacao}), // Permite cabeçalhos específicos
+		handlers.ExposedHeaders([]string{"Link", paginacao.CabecalhoProximoCursor}),                       // O navegador só deixa ler estes se expostos
+	)(tenancia.Middleware(r))
+
+	// As sondas de saúde ficam na frente da resolução da organização e do CORS: o orquestrador não manda tenant
+	raiz := router.GerarInfraestrutura()
+	raiz.PathPrefix("/").Handler(corsHandler)
+
+	// Iniciar o servidor HTTP, com limites de tempo para conexões lentas ou presas não segurarem recursos
+	configuracao := config.Atual()
+	servidor := &http.Server{
+		Addr:              fmt.Sprintf(":%d", configuracao.Porta),
+		Handler:           raiz,
+		ReadTimeout:       configuracao.Servidor.TimeoutLeitura,
+		ReadHeaderTimeout: configuracao.Servidor.TimeoutCabecalho,
+		WriteTimeout:      configuracao.Servidor.TimeoutEscrita,
+		IdleTimeout:       configuracao.Servidor.TimeoutOcioso,
+	}
+	fmt.Printf("Escutando na porta %d\n", configuracao.Porta)
+	log.Println("Porta configurada:", configuracao.Porta)
+
+	// Atende até SIGINT/SIGTERM e então encerra em ordem: readiness, requisições, tarefas, Redis e banco
+	if erro = ciclo.Servir(servidor); erro != nil {
+		log.Fatal(erro)
+	}
 }
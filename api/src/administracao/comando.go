@@ -0,0 +1,54 @@
+package administracao
+
+import (
+	"api/src/banco"
+	"api/src/repositorios"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// uso descreve o subcomando organizacao
+const uso = `uso: main organizacao <comando>
+
+  dono <slug> <email>   torna a conta do e-mail a dona da organização; o dono anterior passa a ser admin`
+
+// Executar roda o subcomando organizacao com os argumentos que vêm depois dele na linha de comando
+func Executar(argumentos []string) error {
+	if len(argumentos) == 0 {
+		return errors.New(uso)
+	}
+
+	switch argumentos[0] {
+	case "dono":
+		if len(argumentos) != 3 {
+			return fmt.Errorf("informe o slug da organização e o e-mail do novo dono\n\n%s", uso)
+		}
+		return definirDono(context.Background(), strings.ToLower(argumentos[1]), argumentos[2])
+	}
+
+	return fmt.Errorf("comando desconhecido: %q\n\n%s", argumentos[0], uso)
+}
+
+// definirDono procura a organização e a conta pelo e-mail e troca o dono
+func definirDono(ctx context.Context, slug, email string) error {
+	db := banco.Conexao()
+
+	organizacao, erro := repositorios.NovoRepositorioDeOrganizacoes(db).BuscarPorSlug(ctx, slug)
+	if erro != nil {
+		return erro
+	}
+
+	usuario, erro := repositorios.NovoRepositorioDeUsuarios(db, organizacao.ID).BuscarPorEmail(ctx, email)
+	if erro != nil {
+		return erro
+	}
+
+	if erro = repositorios.NovoRepositorioDeMembros(db, organizacao.ID).DefinirDono(ctx, usuario.ID); erro != nil {
+		return erro
+	}
+
+	fmt.Printf("%s (ID %d) agora é o dono de %s\n", email, usuario.ID, slug)
+	return nil
+}
@@ -0,0 +1,159 @@
+package autenticacao
+
+import (
+	"api/src/cache"
+	"api/src/modelos"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// duracaoCacheAcesso é quanto tempo o acesso resolvido vale sem consultar o banco. As mudanças feitas pela API
+	// (papéis atribuídos ou removidos, por exemplo) descartam o cache na hora com InvalidarAcessos.
+	duracaoCacheAcesso = 30 * time.Second
+
+	// duracaoGeracao mantém o contador de InvalidarAcessos bem além da vida de qualquer acesso guardado
+	duracaoGeracao = 24 * time.Hour
+)
+
+// ErrContaInativa recusa os tokens de contas desativadas ou excluídas depois da emissão
+var ErrContaInativa = errors.New("a conta do token foi desativada ou excluída")
+
+// FonteDeAcesso resolve os papéis e as permissões de um usuário; em produção, o repositório do PostgreSQL
+type FonteDeAcesso interface {
+	Acesso(ctx context.Context, orgID, usuarioID uint64, papeisExternos []string) (modelos.Acesso, error)
+}
+
+type chaveAcesso struct{}
+
+var (
+	fonteDeAcesso FonteDeAcesso
+	mutexAcesso   sync.RWMutex
+)
+
+// ConfigurarAcesso define de onde vêm os papéis e as permissões; deve ser chamada antes de o servidor atender
+func ConfigurarAcesso(fonte FonteDeAcesso) {
+	mutexAcesso.Lock()
+	defer mutexAcesso.Unlock()
+	fonteDeAcesso = fonte
+}
+
+// CarregarAcesso valida o token e resolve o acesso atual do usuário, guardando-o no contexto da requisição retornada.
+// As permissões não vêm do token: remover um papel vale na próxima requisição, e não só quando o token expira.
+// Do mesmo jeito, o token de uma conta desativada ou excluída deixa de valer (ErrContaInativa).
+func CarregarAcesso(r *http.Request) (*http.Request, error) {
+	if _, ok := r.Context().Value(chaveAcesso{}).(modelos.Acesso); ok {
+		return r, nil
+	}
+
+	permissoes, erro := permissoesDaRequisicao(r)
+	if erro != nil {
+		return r, erro
+	}
+
+	var acesso modelos.Acesso
+	if _, anonimo := permissoes["anonimo"].(bool); !anonimo {
+		usuarioID, erro := UsuarioIDDasPermissoes(permissoes)
+		if erro != nil {
+			return r, erro
+		}
+
+		if acesso, erro = resolverAcesso(r.Context(), OrganizacaoDasPermissoes(permissoes), usuarioID, PapeisExternos(permissoes)); erro != nil {
+			return r, erro
+		}
+		if acesso.Inativa {
+			return r, ErrContaInativa
+		}
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), chaveAcesso{}, acesso)), nil
+}
+
+// resolverAcesso consulta o cache e, se necessário, a fonte. A chave inclui a geração da organização,
+// então InvalidarAcessos descarta de uma vez tudo o que estava guardado para ela.
+func resolverAcesso(ctx context.Context, orgID, usuarioID uint64, papeisExternos []string) (modelos.Acesso, error) {
+	mutexAcesso.RLock()
+	fonte := fonteDeAcesso
+	mutexAcesso.RUnlock()
+	if fonte == nil {
+		return modelos.Acesso{}, errors.New("fonte de acesso não configurada")
+	}
+
+	armazenamento := cache.Atual()
+	geracao, erro := armazenamento.Buscar(ctx, chaveGeracao(orgID))
+	if errors.Is(erro, cache.ErrChaveNaoEncontrada) {
+		geracao, erro = "0", nil
+	}
+
+	// Com o cache fora do ar, a requisição consulta o banco direto
+	var chave string
+	if erro == nil {
+		externos := append([]string(nil), papeisExternos...)
+		sort.Strings(externos)
+		chave = fmt.Sprintf("acesso:%d:%s:%d:%s", orgID, geracao, usuarioID, strings.Join(externos, ","))
+
+		var acesso modelos.Acesso
+		if valor, erro := armazenamento.Buscar(ctx, chave); erro == nil && json.Unmarshal([]byte(valor), &acesso) == nil {
+			return acesso, nil
+		}
+	}
+
+	acesso, erro := fonte.Acesso(ctx, orgID, usuarioID, papeisExternos)
+	if erro != nil {
+		return modelos.Acesso{}, erro
+	}
+
+	if chave != "" {
+		if valor, erro := json.Marshal(acesso); erro == nil {
+			armazenamento.Salvar(ctx, chave, string(valor), duracaoCacheAcesso)
+		}
+	}
+	return acesso, nil
+}
+
+// InvalidarAcessos descarta o acesso guardado de todos os usuários da organização. É chamada depois de mudanças
+// em papéis e na situação das contas; se o cache falhar, as mudanças valem quando o acesso guardado expira (duracaoCacheAcesso).
+func InvalidarAcessos(ctx context.Context, orgID uint64) {
+	if _, erro := cache.Atual().Incrementar(ctx, chaveGeracao(orgID), duracaoGeracao); erro != nil {
+		log.Printf("Erro ao invalidar o acesso guardado da organização %d: %v", orgID, erro)
+	}
+}
+
+func chaveGeracao(orgID uint64) string {
+	return fmt.Sprintf("acesso_geracao:%d", orgID)
+}
+
+// acessoDaRequisicao retorna o acesso guardado por CarregarAcesso ou, fora do middleware, resolve-o na hora
+func acessoDaRequisicao(r *http.Request) (modelos.Acesso, error) {
+	if acesso, ok := r.Context().Value(chaveAcesso{}).(modelos.Acesso); ok {
+		return acesso, nil
+	}
+
+	r, erro := CarregarAcesso(r)
+	if erro != nil {
+		return modelos.Acesso{}, erro
+	}
+	return r.Context().Value(chaveAcesso{}).(modelos.Acesso), nil
+}
+
+// PapeisExternos retorna os papéis da claim "papeis_externos", vindos do autenticador no login (grupos do LDAP)
+func PapeisExternos(permissoes jwt.MapClaims) []string {
+	valores, _ := permissoes["papeis_externos"].([]interface{})
+	papeis := make([]string, 0, len(valores))
+	for _, valor := range valores {
+		if papel, ok := valor.(string); ok {
+			papeis = append(papeis, papel)
+		}
+	}
+	return papeis
+}
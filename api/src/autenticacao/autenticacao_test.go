@@ -0,0 +1,328 @@
+package autenticacao
+
+import (
+	"api/src/metricas"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/tenancia"
+	"api/src/testes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const audienciaDeTeste = "https://api.exemplo.com"
+
+var organizacaoDeTeste = modelos.Organizacao{ID: tenancia.IDPadrao, Slug: tenancia.SlugPadrao, Nome: "Padrão"}
+
+// fonteFixa serve só a organização de teste
+type fonteFixa struct{}
+
+func (fonteFixa) BuscarPorID(_ context.Context, ID uint64) (modelos.Organizacao, error) {
+	if ID == organizacaoDeTeste.ID {
+		return organizacaoDeTeste, nil
+	}
+	return modelos.Organizacao{}, repositorios.ErrOrganizacaoNaoEncontrada
+}
+
+func (fonte fonteFixa) BuscarPorSlug(ctx context.Context, slug string) (modelos.Organizacao, error) {
+	return fonte.BuscarPorID(ctx, 0)
+}
+
+func (fonte fonteFixa) BuscarPorDominio(ctx context.Context, dominio string) (modelos.Organizacao, error) {
+	return fonte.BuscarPorID(ctx, 0)
+}
+
+// acessosDeTeste guarda as permissões de cada usuário e conta as consultas, para mostrar quando o cache foi usado
+type acessosDeTeste struct {
+	mutex      sync.Mutex
+	permissoes map[uint64][]string
+	inativas   map[uint64]bool
+	consultas  int
+}
+
+func (acessos *acessosDeTeste) Acesso(_ context.Context, _, usuarioID uint64, papeisExternos []string) (modelos.Acesso, error) {
+	acessos.mutex.Lock()
+	defer acessos.mutex.Unlock()
+
+	acessos.consultas++
+	if acessos.inativas[usuarioID] {
+		return modelos.Acesso{Inativa: true}, nil
+	}
+	return modelos.Acesso{Papeis: papeisExternos, Permissoes: acessos.permissoes[usuarioID]}, nil
+}
+
+func (acessos *acessosDeTeste) definir(usuarioID uint64, permissoes ...string) {
+	acessos.mutex.Lock()
+	defer acessos.mutex.Unlock()
+	acessos.permissoes[usuarioID] = permissoes
+}
+
+func (acessos *acessosDeTeste) desativar(usuarioID uint64) {
+	acessos.mutex.Lock()
+	defer acessos.mutex.Unlock()
+	acessos.inativas[usuarioID] = true
+}
+
+func (acessos *acessosDeTeste) totalDeConsultas() int {
+	acessos.mutex.Lock()
+	defer acessos.mutex.Unlock()
+	return acessos.consultas
+}
+
+func TestMain(m *testing.M) {
+	tenancia.Configurar(fonteFixa{})
+	m.Run()
+}
+
+// configurar carrega a configuração e começa cada teste com permissões e cache de acesso limpos
+func configurar(t *testing.T) *acessosDeTeste {
+	t.Helper()
+	testes.Configurar(t, map[string]string{"TOKEN_AUDIENCIA": audienciaDeTeste})
+
+	acessos := &acessosDeTeste{permissoes: map[uint64][]string{}, inativas: map[uint64]bool{}}
+	ConfigurarAcesso(acessos)
+	InvalidarAcessos(context.Background(), organizacaoDeTeste.ID)
+	t.Cleanup(func() { ConfigurarAcesso(nil) })
+	return acessos
+}
+
+func requisicaoCom(token string) *http.Request {
+	requisicao := httptest.NewRequest(http.MethodGet, "/usuarios", nil)
+	requisicao.Header.Set("Authorization", "Bearer "+token)
+	return requisicao.WithContext(tenancia.NoContexto(requisicao.Context(), organizacaoDeTeste))
+}
+
+func tokenDelegado(t *testing.T, audiencias, escopos []string) string {
+	t.Helper()
+	token, erro := CriarTokenDelegado(Delegacao{
+		Organizacao: organizacaoDeTeste,
+		UsuarioID:   7,
+		ClientID:    "servico-pedidos",
+		Audiencias:  audiencias,
+		Escopos:     escopos,
+		Expiracao:   time.Now().Add(time.Minute),
+	})
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	return token
+}
+
+func TestTokenDelegadoParaOutraAudienciaERecusado(t *testing.T) {
+	configurar(t)
+
+	token := tokenDelegado(t, []string{"servico-estoque"}, []string{"usuarios:ler"})
+	if _, erro := PermiteEscopo(requisicaoCom(token), "usuarios:ler"); erro != ErrAudienciaInvalida {
+		t.Errorf("erro = %v, quer ErrAudienciaInvalida", erro)
+	}
+	if _, erro := ExtrairPermissoes(token); erro != ErrAudienciaInvalida {
+		t.Errorf("ExtrairPermissoes: erro = %v, quer ErrAudienciaInvalida", erro)
+	}
+
+	token = tokenDelegado(t, []string{"servico-estoque", audienciaDeTeste}, []string{"usuarios:ler"})
+	if permite, erro := PermiteEscopo(requisicaoCom(token), "usuarios:ler"); erro != nil || !permite {
+		t.Errorf("audiência desta API: permite = %v, erro = %v", permite, erro)
+	}
+}
+
+func TestEscopoDoTokenDelegado(t *testing.T) {
+	acessos := configurar(t)
+	acessos.definir(7, "papeis:gerenciar", "usuarios:excluir")
+
+	token := tokenDelegado(t, []string{audienciaDeTeste}, []string{"usuarios:ler", "papeis:gerenciar"})
+	requisicao := requisicaoCom(token)
+
+	casos := []struct {
+		escopo string
+		quer   bool
+	}{
+		{"usuarios:ler", true},
+		{"usuarios:editar", false},
+		{"", false}, // rota sem escopo declarado
+	}
+	for _, caso := range casos {
+		if permite, erro := PermiteEscopo(requisicao, caso.escopo); erro != nil || permite != caso.quer {
+			t.Errorf("PermiteEscopo(%q) = %v, %v; quer %v", caso.escopo, permite, erro, caso.quer)
+		}
+	}
+
+	// Só valem as permissões do usuário que também estão no escopo
+	if possui, _ := PossuiPermissao(requisicao, "papeis:gerenciar"); !possui {
+		t.Error("papeis:gerenciar está no escopo e nas permissões do usuário")
+	}
+	if possui, _ := PossuiPermissao(requisicao, "usuarios:excluir"); possui {
+		t.Error("usuarios:excluir não está no escopo")
+	}
+}
+
+func TestTokenDoLoginNaoERestrito(t *testing.T) {
+	acessos := configurar(t)
+	acessos.definir(7, "usuarios:excluir")
+
+	token, erro := CriarToken(context.Background(), organizacaoDeTeste, 7, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	requisicao := requisicaoCom(token)
+
+	if permite, erro := PermiteEscopo(requisicao, ""); erro != nil || !permite {
+		t.Errorf("PermiteEscopo = %v, %v; quer true", permite, erro)
+	}
+	if possui, _ := PossuiPermissao(requisicao, "usuarios:excluir"); !possui {
+		t.Error("a permissão do token do login deveria valer")
+	}
+}
+
+func TestTokenDelegadoEntraNasMetricas(t *testing.T) {
+	configurar(t)
+
+	antes := tokensEmitidos(t, metricas.TokenDelegado)
+	tokenDelegado(t, []string{audienciaDeTeste}, nil)
+	if depois := tokensEmitidos(t, metricas.TokenDelegado); depois != antes+1 {
+		t.Errorf("api_tokens_emitidos_total{tipo=delegado} = %v, quer %v", depois, antes+1)
+	}
+}
+
+// tokensEmitidos lê o contador do /metrics
+func tokensEmitidos(t *testing.T, tipo string) float64 {
+	t.Helper()
+
+	resposta := httptest.NewRecorder()
+	metricas.Handler().ServeHTTP(resposta, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	corpo, _ := io.ReadAll(resposta.Body)
+
+	linha := regexp.MustCompile(`api_tokens_emitidos_total\{tipo="` + tipo + `"\} (\S+)`).FindSubmatch(corpo)
+	if linha == nil {
+		t.Fatalf("contador do tipo %s ausente em /metrics", tipo)
+	}
+	valor, erro := strconv.ParseFloat(string(linha[1]), 64)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	return valor
+}
+
+func TestPermissoesSaoResolvidasACadaRequisicao(t *testing.T) {
+	acessos := configurar(t)
+	acessos.definir(7, "papeis:gerenciar")
+
+	token, erro := CriarToken(context.Background(), organizacaoDeTeste, 7, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if possui, erro := PossuiPermissao(requisicaoCom(token), "papeis:gerenciar"); erro != nil || !possui {
+		t.Fatalf("antes da remoção: possui = %v, erro = %v", possui, erro)
+	}
+
+	// O papel é removido no banco: o acesso guardado vale até ser invalidado
+	acessos.definir(7)
+	if possui, _ := PossuiPermissao(requisicaoCom(token), "papeis:gerenciar"); !possui {
+		t.Error("o acesso guardado deveria ser reaproveitado até a invalidação")
+	}
+	if consultas := acessos.totalDeConsultas(); consultas != 1 {
+		t.Errorf("consultas à fonte = %d, quer 1", consultas)
+	}
+
+	InvalidarAcessos(context.Background(), organizacaoDeTeste.ID)
+	if possui, _ := PossuiPermissao(requisicaoCom(token), "papeis:gerenciar"); possui {
+		t.Error("o mesmo token continuou com a permissão depois da remoção do papel")
+	}
+}
+
+func TestPapeisEPermissoesNoToken(t *testing.T) {
+	acessos := configurar(t)
+	acessos.definir(7, "papeis:gerenciar")
+
+	token, erro := CriarToken(context.Background(), organizacaoDeTeste, 7, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	permissoes, erro := ExtrairPermissoes(token)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if lista, _ := permissoes["permissoes"].([]interface{}); len(lista) != 1 || lista[0] != "papeis:gerenciar" {
+		t.Errorf("claim permissoes = %v", permissoes["permissoes"])
+	}
+	if _, existe := permissoes["papeis"].([]interface{}); !existe {
+		t.Errorf("claim papeis = %v, quer uma lista", permissoes["papeis"])
+	}
+
+	// A claim é só informativa: a remoção do papel vale com o mesmo token
+	acessos.definir(7)
+	InvalidarAcessos(context.Background(), organizacaoDeTeste.ID)
+	if possui, _ := PossuiPermissao(requisicaoCom(token), "papeis:gerenciar"); possui {
+		t.Error("a permissão da claim valeu depois da remoção do papel")
+	}
+
+	// Conta desativada não recebe token
+	acessos.desativar(7)
+	InvalidarAcessos(context.Background(), organizacaoDeTeste.ID)
+	if _, erro = CriarToken(context.Background(), organizacaoDeTeste, 7, nil); erro != ErrContaInativa {
+		t.Errorf("token de conta desativada: erro = %v, quer ErrContaInativa", erro)
+	}
+}
+
+func TestPapeisExternosFicamNoToken(t *testing.T) {
+	configurar(t)
+
+	token, erro := CriarToken(context.Background(), organizacaoDeTeste, 7, []string{"desenvolvedores"})
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	permissoes, erro := ExtrairPermissoes(token)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if externos := PapeisExternos(permissoes); len(externos) != 1 || externos[0] != "desenvolvedores" {
+		t.Errorf("papeis_externos = %v", externos)
+	}
+
+	requisicao, erro := CarregarAcesso(requisicaoCom(token))
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	acesso, _ := acessoDaRequisicao(requisicao)
+	if len(acesso.Papeis) != 1 || acesso.Papeis[0] != "desenvolvedores" {
+		t.Errorf("papéis resolvidos = %v, quer os externos do login", acesso.Papeis)
+	}
+}
+
+func TestTokenDeContaDesativadaERecusado(t *testing.T) {
+	acessos := configurar(t)
+
+	token, erro := CriarToken(context.Background(), organizacaoDeTeste, 7, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if _, erro = CarregarAcesso(requisicaoCom(token)); erro != nil {
+		t.Fatalf("conta ativa: %v", erro)
+	}
+
+	// A desativação pela API invalida o acesso guardado; o token ainda não expirou, mas deixa de valer
+	acessos.desativar(7)
+	InvalidarAcessos(context.Background(), organizacaoDeTeste.ID)
+
+	if _, erro = CarregarAcesso(requisicaoCom(token)); erro != ErrContaInativa {
+		t.Errorf("erro = %v, quer ErrContaInativa", erro)
+	}
+	if possui, erro := PossuiPermissao(requisicaoCom(token), "usuarios:ler"); erro != ErrContaInativa || possui {
+		t.Errorf("PossuiPermissao = %v, %v; quer false, ErrContaInativa", possui, erro)
+	}
+
+	// O token anônimo não representa uma conta e não passa pela verificação
+	anonimo, erro := CriarTokenAnonimo(organizacaoDeTeste)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if _, erro = CarregarAcesso(requisicaoCom(anonimo)); erro != nil {
+		t.Errorf("token anônimo: %v", erro)
+	}
+}
@@ -2,40 +2,67 @@ package autenticacao
 
 import (
 	"api/src/config"
+	"api/src/metricas"
+	"api/src/modelos"
+	"api/src/tenancia"
+	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
-// CriarToken retorna um token assinado com as permissões do usuário
-func CriarToken(usuarioID uint64) (string, error) {
+// CriarToken retorna um token assinado do usuário com os papéis e as permissões do momento da emissão nas claims
+// "papeis" e "permissoes", para quem lê o token (o front-end ou outros serviços). A API não confia nessas claims:
+// o acesso é resolvido de novo a cada requisição (ver CarregarAcesso), e remover um papel vale antes de o token expirar.
+// Os papéis vindos do autenticador no login (papeisExternos) ficam também em "papeis_externos", de onde são relidos.
+// A duração e a chave de assinatura são as da organização do usuário; sem duração própria, vale TOKEN_DURACAO.
+func CriarToken(ctx context.Context, organizacao modelos.Organizacao, usuarioID uint64, papeisExternos []string) (string, error) {
+	duracao := organizacao.DuracaoToken
+	if duracao <= 0 {
+		duracao = config.Atual().Tokens.Duracao
+	}
+
+	acesso, erro := resolverAcesso(ctx, organizacao.ID, usuarioID, papeisExternos)
+	if erro != nil {
+		return "", erro
+	}
+	if acesso.Inativa {
+		return "", ErrContaInativa
+	}
+
 	permissoes := jwt.MapClaims{}
 	permissoes["authorized"] = true
-	permissoes["exp"] = time.Now().Add(time.Hour * 6).Unix()
+	permissoes["exp"] = time.Now().Add(duracao).Unix()
 	permissoes["usuarioId"] = usuarioID
+	permissoes["org"] = organizacao.ID
+	permissoes["papeis"] = naoNula(acesso.Papeis)
+	permissoes["permissoes"] = naoNula(acesso.Permissoes)
+	if len(papeisExternos) > 0 {
+		permissoes["papeis_externos"] = papeisExternos
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissoes)
-	return token.SignedString([]byte(config.SecretKey))
+	return assinar(token, organizacao, metricas.TokenUsuario)
+}
+
+// naoNula faz a lista vazia ir para o token como [] em vez de null
+func naoNula(lista []string) []string {
+	if lista == nil {
+		return []string{}
+	}
+	return lista
 }
 
 // ValidarToken verifica se o token passado na requisição é válido e retorna se é anônimo ou não
 func ValidarToken(r *http.Request) (bool, error) {
-	tokenString := extrairToken(r)
-	token, erro := jwt.Parse(tokenString, retornarChaveDeVerificacao)
+	permissoes, erro := permissoesDaRequisicao(r)
 	if erro != nil {
 		return false, erro
 	}
 
-	permissoes, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return false, errors.New("token inválido")
-	}
-
 	// Se o token tem "anonimo" = true, ele é um login anônimo
 	if _, anonimo := permissoes["anonimo"].(bool); anonimo {
 		return true, nil
@@ -46,64 +73,61 @@ func ValidarToken(r *http.Request) (bool, error) {
 
 // ExtrairUsuarioID retorna o usuarioId ou 0 se for anônimo
 func ExtrairUsuarioID(r *http.Request) (uint64, error) {
-	tokenString := extrairToken(r)
-	log.Printf("Token ok") // Log do token JWT
-
-	token, erro := jwt.Parse(tokenString, retornarChaveDeVerificacao)
+	permissoes, erro := permissoesDaRequisicao(r)
 	if erro != nil {
-		log.Printf("Erro ao parsear token: %v", erro)
 		return 0, erro
 	}
 
-	permissoes, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		log.Printf("Token inválido")
-		return 0, errors.New("token inválido")
-	}
-
-	log.Printf("Permissões extraídas: %+v", permissoes) // Log completo das permissões
-
 	// Verifica se o token é anônimo
 	if _, anonimo := permissoes["anonimo"].(bool); anonimo {
-		log.Printf("Usuário anônimo")
 		return 0, nil
 	}
 
 	// Verifica se usuarioId está presente no token
 	valor, ok := permissoes["usuarioId"].(float64)
 	if !ok {
-		log.Printf("Erro: usuarioId não encontrado ou em formato inválido")
 		return 0, errors.New("usuarioId ausente ou inválido no token")
 	}
 
-	usuarioID := uint64(valor)
-	log.Printf("usuarioId extraído: %d", usuarioID) // Log do usuarioID extraído
-
-	return usuarioID, nil
+	return uint64(valor), nil
 }
 
-// ExtrairUsuarioIDComTokenString retorna o usuarioId que está salvo no token
-func ExtrairUsuarioIDComTokenString(tokenString string) (uint64, error) {
-	token, erro := jwt.Parse(tokenString, retornarChaveDeVerificacao)
+// PossuiPermissao verifica se o usuário do token tem agora a permissão de RBAC informada.
+// Num token restrito por escopo, a permissão também precisa estar entre os escopos.
+func PossuiPermissao(r *http.Request, permissao string) (bool, error) {
+	permissoes, erro := permissoesDaRequisicao(r)
 	if erro != nil {
-		return 0, erro
+		return false, erro
 	}
 
-	if permissoes, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		usuarioID, erro := strconv.ParseUint(fmt.Sprintf("%.0f", permissoes["usuarioId"]), 10, 64)
-		if erro != nil {
-			return 0, erro
-		}
+	if escopos := EscoposDasPermissoes(permissoes); escopos != nil && !contem(escopos, permissao) {
+		return false, nil
+	}
 
-		return usuarioID, nil
+	acesso, erro := acessoDaRequisicao(r)
+	if erro != nil {
+		return false, erro
 	}
+	return contem(acesso.Permissoes, permissao), nil
+}
 
-	return 0, errors.New("token inválido")
+// PermiteEscopo verifica se o token da requisição pode usar uma rota com o escopo informado.
+// Tokens sem a claim "scope" (os do login) podem tudo; os restritos, só os escopos da lista, e nunca escopo vazio.
+func PermiteEscopo(r *http.Request, escopo string) (bool, error) {
+	permissoes, erro := permissoesDaRequisicao(r)
+	if erro != nil {
+		return false, erro
+	}
+
+	escopos := EscoposDasPermissoes(permissoes)
+	if escopos == nil {
+		return true, nil
+	}
+	return escopo != "" && contem(escopos, escopo), nil
 }
 
 func extrairToken(r *http.Request) string {
 	token := r.Header.Get("Authorization")
-	log.Printf("Token extraído com sucesso") // Log do token extraído
 	if len(strings.Split(token, " ")) == 2 {
 		return strings.Split(token, " ")[1]
 	}
@@ -111,35 +135,80 @@ func extrairToken(r *http.Request) string {
 	return ""
 }
 
+// permissoesDaRequisicao valida o token da requisição e garante que ele foi emitido para a organização resolvida
+func permissoesDaRequisicao(r *http.Request) (jwt.MapClaims, error) {
+	permissoes, erro := ExtrairPermissoes(extrairToken(r))
+	if erro != nil {
+		return nil, erro
+	}
+
+	if OrganizacaoDasPermissoes(permissoes) != tenancia.DaRequisicao(r).ID {
+		return nil, errors.New("token emitido para outra organização")
+	}
+
+	return permissoes, nil
+}
+
+// OrganizacaoDasPermissoes retorna a organização do token; tokens sem a claim "org" são da organização padrão
+func OrganizacaoDasPermissoes(permissoes jwt.MapClaims) uint64 {
+	if orgID, ok := permissoes["org"].(float64); ok {
+		return uint64(orgID)
+	}
+	return tenancia.IDPadrao
+}
+
+// retornarChaveDeVerificacao escolhe a chave pela organização indicada no próprio token.
+// A claim só é confiável depois que a assinatura confere com a chave dessa organização.
 func retornarChaveDeVerificacao(token *jwt.Token) (interface{}, error) {
 	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 		return nil, fmt.Errorf("método de assinatura inesperado! %v", token.Header["alg"])
 	}
 
-	return config.SecretKey, nil
-}
+	permissoes, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("token inválido")
+	}
 
-// ValidarTokenComTokenString valida o token JWT passado diretamente como string
-func ValidarTokenComTokenString(tokenString string) error {
-	token, erro := jwt.Parse(tokenString, retornarChaveDeVerificacao)
+	organizacao, erro := tenancia.BuscarPorID(OrganizacaoDasPermissoes(permissoes))
 	if erro != nil {
-		return erro
+		return nil, erro
 	}
 
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
-	}
+	return chaveDeAssinatura(organizacao), nil
+}
 
-	return errors.New("token inválido")
+// chaveDeAssinatura retorna a chave própria da organização ou, se ela não tiver uma, a SECRET_KEY global
+func chaveDeAssinatura(organizacao modelos.Organizacao) []byte {
+	if len(organizacao.ChaveAssinatura) > 0 {
+		return organizacao.ChaveAssinatura
+	}
+	return []byte(config.Atual().SecretKey)
 }
 
-// CriarTokenAnonimo gera um token para usuários anônimos
-func CriarTokenAnonimo() (string, error) {
+// CriarTokenAnonimo gera um token para usuários anônimos da organização
+func CriarTokenAnonimo(organizacao modelos.Organizacao) (string, error) {
+	duracao := organizacao.DuracaoTokenAnonimo
+	if duracao <= 0 {
+		duracao = config.Atual().Tokens.DuracaoAnonimo
+	}
+
 	permissoes := jwt.MapClaims{}
-	permissoes["exp"] = time.Now().Add(time.Hour * 24).Unix() // Expira em 24h
-	permissoes["anonimo"] = true                              // Define como usuário anônimo
+	permissoes["exp"] = time.Now().Add(duracao).Unix() // Expira conforme a organização (TOKEN_ANONIMO_DURACAO por padrão)
+	permissoes["anonimo"] = true                       // Define como usuário anônimo
+	permissoes["org"] = organizacao.ID
 
 	// Criar token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissoes)
-	return token.SignedString([]byte(config.SecretKey))
+	return assinar(token, organizacao, metricas.TokenAnonimo)
+}
+
+// assinar assina o token com a chave da organização e conta a emissão nas métricas
+func assinar(token *jwt.Token, organizacao modelos.Organizacao, tipo string) (string, error) {
+	assinado, erro := token.SignedString(chaveDeAssinatura(organizacao))
+	if erro != nil {
+		return "", erro
+	}
+
+	metricas.TokenEmitido(tipo)
+	return assinado, nil
 }
@@ -0,0 +1,148 @@
+package autenticacao
+
+import (
+	"api/src/config"
+	"api/src/metricas"
+	"api/src/modelos"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Delegacao reúne os dados de um token emitido pela troca de tokens (RFC 8693)
+type Delegacao struct {
+	// Organizacao é a do token original; o token emitido é assinado com a chave dela
+	Organizacao modelos.Organizacao
+	UsuarioID   uint64
+	ClientID    string
+	Audiencias  []string
+	Escopos     []string
+	// PapeisExternos são os do token original; as permissões são resolvidas a cada requisição e limitadas por Escopos
+	PapeisExternos []string
+	// Ator é a claim "act"; fica nil quando a troca é uma personificação
+	Ator      map[string]interface{}
+	Expiracao time.Time
+}
+
+// ErrAudienciaInvalida indica um token da troca emitido para outro serviço
+var ErrAudienciaInvalida = errors.New("token emitido para outra audiência")
+
+// ExtrairPermissoes valida um token emitido por esta API e para ela, e retorna as suas permissões
+func ExtrairPermissoes(tokenString string) (jwt.MapClaims, error) {
+	token, erro := jwt.Parse(tokenString, retornarChaveDeVerificacao)
+	if erro != nil {
+		return nil, erro
+	}
+
+	permissoes, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("token inválido")
+	}
+
+	if erro = verificarAudiencia(permissoes); erro != nil {
+		return nil, erro
+	}
+
+	return permissoes, nil
+}
+
+// verificarAudiencia aceita tokens sem a claim "aud" (os do login) e os que incluem TOKEN_AUDIENCIA nela
+func verificarAudiencia(permissoes jwt.MapClaims) error {
+	valor, existe := permissoes["aud"]
+	if !existe {
+		return nil
+	}
+
+	var audiencias []interface{}
+	switch aud := valor.(type) {
+	case string:
+		audiencias = []interface{}{aud}
+	case []interface{}:
+		audiencias = aud
+	}
+
+	esperada := config.Atual().AudienciaTokens
+	for _, audiencia := range audiencias {
+		if esperada != "" && audiencia == esperada {
+			return nil
+		}
+	}
+	return ErrAudienciaInvalida
+}
+
+// UsuarioIDDasPermissoes retorna o usuarioId das permissões ou erro se o token for anônimo
+func UsuarioIDDasPermissoes(permissoes jwt.MapClaims) (uint64, error) {
+	if _, anonimo := permissoes["anonimo"].(bool); anonimo {
+		return 0, errors.New("token anônimo não representa um usuário")
+	}
+
+	valor, ok := permissoes["usuarioId"].(float64)
+	if !ok {
+		return 0, errors.New("usuarioId ausente ou inválido no token")
+	}
+
+	return uint64(valor), nil
+}
+
+// EscoposDasPermissoes retorna os escopos da claim "scope" ou nil se o token não for restrito.
+// Um token restrito só usa as rotas cujo escopo (ou permissão exigida) está na lista.
+func EscoposDasPermissoes(permissoes jwt.MapClaims) []string {
+	escopo, ok := permissoes["scope"].(string)
+	if !ok {
+		return nil
+	}
+	return strings.Fields(escopo)
+}
+
+// ExpiracaoDasPermissoes retorna o momento em que o token expira
+func ExpiracaoDasPermissoes(permissoes jwt.MapClaims) time.Time {
+	if exp, ok := permissoes["exp"].(float64); ok {
+		return time.Unix(int64(exp), 0)
+	}
+	return time.Time{}
+}
+
+// NovoAtor monta a claim "act" para o ator informado, encadeando a delegação anterior, se houver
+func NovoAtor(atorID uint64, permissoesDoSujeito jwt.MapClaims) map[string]interface{} {
+	ator := map[string]interface{}{"sub": strconv.FormatUint(atorID, 10)}
+	if anterior, ok := permissoesDoSujeito["act"].(map[string]interface{}); ok {
+		ator["act"] = anterior
+	}
+	return ator
+}
+
+// CriarTokenDelegado retorna um token assinado com audiência e escopo reduzidos
+func CriarTokenDelegado(delegacao Delegacao) (string, error) {
+	permissoes := jwt.MapClaims{}
+	permissoes["authorized"] = true
+	permissoes["iat"] = time.Now().Unix()
+	permissoes["exp"] = delegacao.Expiracao.Unix()
+	permissoes["usuarioId"] = delegacao.UsuarioID
+	permissoes["org"] = delegacao.Organizacao.ID
+	permissoes["sub"] = strconv.FormatUint(delegacao.UsuarioID, 10)
+	permissoes["client_id"] = delegacao.ClientID
+	permissoes["aud"] = delegacao.Audiencias
+	permissoes["scope"] = strings.Join(delegacao.Escopos, " ")
+	if delegacao.Ator != nil {
+		permissoes["act"] = delegacao.Ator
+	}
+
+	if len(delegacao.PapeisExternos) > 0 {
+		permissoes["papeis_externos"] = delegacao.PapeisExternos
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissoes)
+	return assinar(token, delegacao.Organizacao, metricas.TokenDelegado)
+}
+
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if item == valor {
+			return true
+		}
+	}
+	return false
+}
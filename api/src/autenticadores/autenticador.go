@@ -0,0 +1,78 @@
+package autenticadores
+
+import (
+	"api/src/config"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrCredenciaisInvalidas indica que o e-mail ou a senha não conferem no autenticador
+var ErrCredenciaisInvalidas = errors.New("credenciais inválidas")
+
+// Resultado é o usuário autenticado e os papéis atribuídos pelo autenticador
+type Resultado struct {
+	Usuario modelos.Usuario
+	Papeis  []string
+	Origem  string
+}
+
+// Autenticador verifica as credenciais informadas no login
+type Autenticador interface {
+	Autenticar(ctx context.Context, email, senha string) (Resultado, error)
+}
+
+// Cadeia tenta cada autenticador na ordem configurada até que um aceite as credenciais
+type Cadeia []Autenticador
+
+// Autenticar retorna o primeiro resultado aceito. Se nenhum aceitar, retorna ErrCredenciaisInvalidas,
+// a não ser que algum autenticador tenha falhado por outro motivo (diretório fora do ar, por exemplo).
+func (cadeia Cadeia) Autenticar(ctx context.Context, email, senha string) (Resultado, error) {
+	var falha error
+	for _, autenticador := range cadeia {
+		resultado, erro := autenticador.Autenticar(ctx, email, senha)
+		if erro == nil {
+			return resultado, nil
+		}
+
+		if !errors.Is(erro, ErrCredenciaisInvalidas) {
+			log.Printf("Erro no autenticador %T: %v", autenticador, erro)
+			if falha == nil {
+				falha = erro
+			}
+		}
+	}
+
+	if falha != nil {
+		return Resultado{}, falha
+	}
+	return Resultado{}, ErrCredenciaisInvalidas
+}
+
+// Configurados monta a cadeia de autenticadores definida em AUTENTICADORES sobre o repositório de usuários da organização.
+// O LDAP só entra na cadeia da organização de LDAP_ORGANIZACAO: o diretório não cria contas nem autentica em outros tenants.
+func Configurados(organizacao modelos.Organizacao, repositorio repositorios.RepositorioUsuarios) (Autenticador, error) {
+	if len(config.Atual().Autenticadores) == 0 {
+		return nil, errors.New("nenhum autenticador configurado")
+	}
+
+	var cadeia Cadeia
+	for _, nome := range config.Atual().Autenticadores {
+		switch nome {
+		case "banco":
+			cadeia = append(cadeia, NovoAutenticadorBanco(repositorio))
+		case "ldap":
+			if organizacao.Slug == config.Atual().LDAP.Organizacao {
+				cadeia = append(cadeia, NovoAutenticadorLDAP(config.Atual().LDAP, repositorio))
+			}
+		default:
+			return nil, fmt.Errorf("autenticador %s desconhecido", nome)
+		}
+	}
+
+	// Uma cadeia vazia (só o LDAP, numa organização sem ele) recusa as credenciais
+	return cadeia, nil
+}
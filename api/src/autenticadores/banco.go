@@ -0,0 +1,37 @@
+package autenticadores
+
+import (
+	"api/src/repositorios"
+	"api/src/seguranca"
+	"context"
+	"errors"
+)
+
+// Banco verifica as credenciais com a senha (bcrypt) salva na tabela usuarios
+type Banco struct {
+	repositorio repositorios.RepositorioUsuarios
+}
+
+// NovoAutenticadorBanco cria o autenticador que confere a senha guardada no repositório de usuários da organização
+func NovoAutenticadorBanco(repositorio repositorios.RepositorioUsuarios) *Banco {
+	return &Banco{repositorio}
+}
+
+// Autenticar busca o usuário pelo e-mail e compara a senha com o hash salvo
+func (autenticador Banco) Autenticar(ctx context.Context, email, senha string) (Resultado, error) {
+	usuario, erro := autenticador.repositorio.BuscarPorEmail(ctx, email)
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		return Resultado{}, ErrCredenciaisInvalidas
+	}
+	if erro != nil {
+		return Resultado{}, erro
+	}
+
+	if erro = seguranca.VerificarSenha(usuario.Senha, senha); erro != nil {
+		return Resultado{}, ErrCredenciaisInvalidas
+	}
+
+	usuario.Senha = ""
+	usuario.Email = email
+	return Resultado{Usuario: usuario, Origem: "banco"}, nil
+}
@@ -0,0 +1,177 @@
+package autenticadores
+
+import (
+	"api/src/config"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAP verifica as credenciais no diretório corporativo (search-then-bind)
+type LDAP struct {
+	config      config.ConfigLDAP
+	repositorio repositorios.RepositorioUsuarios
+}
+
+// NovoAutenticadorLDAP cria o autenticador baseado em LDAP / Active Directory; os usuários ficam na organização informada
+func NovoAutenticadorLDAP(configLDAP config.ConfigLDAP, repositorio repositorios.RepositorioUsuarios) *LDAP {
+	return &LDAP{configLDAP, repositorio}
+}
+
+// Autenticar localiza a entrada do usuário com a conta de serviço, faz o bind com a senha dele,
+// mapeia os grupos para papéis e cria o usuário em usuarios no primeiro acesso
+func (autenticador LDAP) Autenticar(ctx context.Context, email, senha string) (Resultado, error) {
+	// Bind com senha vazia é um bind anônimo e seria aceito por muitos servidores
+	if senha == "" {
+		return Resultado{}, ErrCredenciaisInvalidas
+	}
+
+	conexao, erro := autenticador.conectar()
+	if erro != nil {
+		return Resultado{}, erro
+	}
+	defer conexao.Close()
+
+	entrada, erro := autenticador.buscarEntrada(conexao, email)
+	if erro != nil {
+		return Resultado{}, erro
+	}
+
+	if erro = conexao.Bind(entrada.DN, senha); erro != nil {
+		if ldap.IsErrorWithCode(erro, ldap.LDAPResultInvalidCredentials) {
+			return Resultado{}, ErrCredenciaisInvalidas
+		}
+		return Resultado{}, fmt.Errorf("erro no bind LDAP: %v", erro)
+	}
+
+	emailDoDiretorio := strings.ToLower(strings.TrimSpace(entrada.GetAttributeValue(autenticador.config.AtributoEmail)))
+	if emailDoDiretorio == "" {
+		emailDoDiretorio = email
+	}
+
+	usuario, erro := autenticador.usuarioLocal(ctx, entrada, emailDoDiretorio)
+	if erro != nil {
+		return Resultado{}, erro
+	}
+
+	return Resultado{
+		Usuario: usuario,
+		Papeis:  autenticador.papeis(entrada.GetAttributeValues(autenticador.config.AtributoGrupos)),
+		Origem:  "ldap",
+	}, nil
+}
+
+func (autenticador LDAP) conectar() (*ldap.Conn, error) {
+	conexao, erro := ldap.DialURL(autenticador.config.URL, ldap.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}))
+	if erro != nil {
+		return nil, fmt.Errorf("erro ao conectar ao LDAP: %v", erro)
+	}
+	conexao.SetTimeout(10 * time.Second)
+
+	if autenticador.config.StartTLS {
+		endereco, erro := url.Parse(autenticador.config.URL)
+		if erro != nil {
+			conexao.Close()
+			return nil, erro
+		}
+
+		if erro = conexao.StartTLS(&tls.Config{ServerName: endereco.Hostname()}); erro != nil {
+			conexao.Close()
+			return nil, fmt.Errorf("erro no StartTLS: %v", erro)
+		}
+	}
+
+	return conexao, nil
+}
+
+// buscarEntrada faz o bind da conta de serviço e procura exatamente uma entrada para o e-mail
+func (autenticador LDAP) buscarEntrada(conexao *ldap.Conn, email string) (*ldap.Entry, error) {
+	if autenticador.config.BindDN != "" {
+		if erro := conexao.Bind(autenticador.config.BindDN, string(autenticador.config.BindSenha)); erro != nil {
+			return nil, fmt.Errorf("erro no bind da conta de serviço LDAP: %v", erro)
+		}
+	}
+
+	// Duas entradas bastam para saber que o e-mail não é único
+	busca := ldap.NewSearchRequest(
+		autenticador.config.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		10,
+		false,
+		fmt.Sprintf(autenticador.config.Filtro, ldap.EscapeFilter(email)),
+		[]string{
+			"dn",
+			autenticador.config.AtributoEmail,
+			autenticador.config.AtributoNome,
+			autenticador.config.AtributoNick,
+			autenticador.config.AtributoGrupos,
+		},
+		nil,
+	)
+
+	// Nenhuma entrada ou mais de uma (o servidor avisa que passou do limite): não dá para saber com qual conta
+	// fazer o bind
+	resultado, erro := conexao.Search(busca)
+	if ldap.IsErrorWithCode(erro, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, ErrCredenciaisInvalidas
+	}
+	if erro != nil {
+		return nil, fmt.Errorf("erro na busca LDAP: %v", erro)
+	}
+	if len(resultado.Entries) != 1 {
+		return nil, ErrCredenciaisInvalidas
+	}
+
+	return resultado.Entries[0], nil
+}
+
+// usuarioLocal retorna o registro em usuarios correspondente à entrada, criando-o se necessário
+func (autenticador LDAP) usuarioLocal(ctx context.Context, entrada *ldap.Entry, email string) (modelos.Usuario, error) {
+	usuario, erro := autenticador.repositorio.BuscarPorEmail(ctx, email)
+	if erro == nil {
+		return modelos.Usuario{ID: usuario.ID, Email: email}, nil
+	}
+	if !errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		return modelos.Usuario{}, erro
+	}
+
+	if !autenticador.config.CriarUsuarios {
+		return modelos.Usuario{}, ErrCredenciaisInvalidas
+	}
+
+	nome := entrada.GetAttributeValue(autenticador.config.AtributoNome)
+	usuarioID, erro := ProvisionarUsuario(
+		ctx,
+		autenticador.repositorio,
+		nome,
+		entrada.GetAttributeValue(autenticador.config.AtributoNick),
+		email,
+	)
+	if erro != nil {
+		return modelos.Usuario{}, fmt.Errorf("erro ao criar usuário do LDAP: %v", erro)
+	}
+
+	return modelos.Usuario{ID: usuarioID, Nome: nome, Email: email}, nil
+}
+
+// papeis traduz os DNs dos grupos do usuário para papéis da aplicação
+func (autenticador LDAP) papeis(grupos []string) []string {
+	var papeis []string
+	for _, grupo := range grupos {
+		if papel, ok := autenticador.config.PapeisPorGrupo[strings.ToLower(grupo)]; ok {
+			papeis = append(papeis, papel)
+		}
+	}
+	return papeis
+}
@@ -0,0 +1,335 @@
+package autenticadores
+
+import (
+	"api/src/config"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/testes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// entradaDeTeste é uma pessoa do diretório em memória
+type entradaDeTeste struct {
+	dn        string
+	senha     string
+	atributos map[string][]string
+}
+
+// diretorioDeTeste é um servidor LDAP mínimo, em processo: responde a bind, search e unbind como um diretório real,
+// inclusive com resultCode 4 (sizeLimitExceeded) quando a busca encontra mais entradas que o limite pedido
+type diretorioDeTeste struct {
+	listener net.Listener
+	entradas []entradaDeTeste
+}
+
+const (
+	servicoDN    = "cn=servico,dc=empresa,dc=com"
+	servicoSenha = "segredo-do-servico"
+	grupoAdmins  = "cn=admins,ou=grupos,dc=empresa,dc=com"
+)
+
+func novoDiretorio(t *testing.T, entradas ...entradaDeTeste) *diretorioDeTeste {
+	t.Helper()
+
+	listener, erro := net.Listen("tcp", "127.0.0.1:0")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	diretorio := &diretorioDeTeste{listener, entradas}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conexao, erro := listener.Accept()
+			if erro != nil {
+				return
+			}
+			go diretorio.atender(conexao)
+		}
+	}()
+
+	return diretorio
+}
+
+func (diretorio *diretorioDeTeste) url() string {
+	return "ldap://" + diretorio.listener.Addr().String()
+}
+
+func (diretorio *diretorioDeTeste) atender(conexao net.Conn) {
+	defer conexao.Close()
+
+	for {
+		pacote, erro := ber.ReadPacket(conexao)
+		if erro != nil || len(pacote.Children) < 2 {
+			return
+		}
+		id := pacote.Children[0].Value.(int64)
+		operacao := pacote.Children[1]
+
+		switch operacao.Tag {
+		case ldap.ApplicationBindRequest:
+			codigo := uint16(ldap.LDAPResultInvalidCredentials)
+			if diretorio.bind(operacao.Children[1].Data.String(), operacao.Children[2].Data.String()) {
+				codigo = ldap.LDAPResultSuccess
+			}
+			responder(conexao, id, ldap.ApplicationBindResponse, codigo)
+
+		case ldap.ApplicationSearchRequest:
+			filtro, erro := ldap.DecompileFilter(operacao.Children[6])
+			if erro != nil {
+				responder(conexao, id, ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError)
+				continue
+			}
+			limite := int(operacao.Children[3].Value.(int64))
+
+			codigo := uint16(ldap.LDAPResultSuccess)
+			for i, entrada := range diretorio.buscar(filtro) {
+				if limite > 0 && i == limite {
+					codigo = ldap.LDAPResultSizeLimitExceeded
+					break
+				}
+				enviarEntrada(conexao, id, entrada)
+			}
+			responder(conexao, id, ldap.ApplicationSearchResultDone, codigo)
+
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func (diretorio *diretorioDeTeste) bind(dn, senha string) bool {
+	if dn == servicoDN {
+		return senha == servicoSenha
+	}
+	for _, entrada := range diretorio.entradas {
+		if entrada.dn == dn {
+			return senha != "" && senha == entrada.senha
+		}
+	}
+	return false
+}
+
+// buscar entende só o filtro padrão do autenticador: a entrada casa quando o filtro tem (mail=<e-mail dela>)
+func (diretorio *diretorioDeTeste) buscar(filtro string) []entradaDeTeste {
+	var encontradas []entradaDeTeste
+	for _, entrada := range diretorio.entradas {
+		for _, email := range entrada.atributos["mail"] {
+			if strings.Contains(filtro, "(mail="+email+")") {
+				encontradas = append(encontradas, entrada)
+				break
+			}
+		}
+	}
+	return encontradas
+}
+
+func mensagem(id int64, operacao *ber.Packet) []byte {
+	pacote := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	pacote.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	pacote.AppendChild(operacao)
+	return pacote.Bytes()
+}
+
+func responder(conexao net.Conn, id int64, tag ber.Tag, codigo uint16) {
+	resultado := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	resultado.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, uint64(codigo), ""))
+	resultado.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	resultado.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	conexao.Write(mensagem(id, resultado))
+}
+
+func enviarEntrada(conexao net.Conn, id int64, entrada entradaDeTeste) {
+	resultado := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	resultado.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entrada.dn, ""))
+
+	atributos := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for nome, valores := range entrada.atributos {
+		atributo := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		atributo.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, nome, ""))
+		conjunto := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, valor := range valores {
+			conjunto.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, valor, ""))
+		}
+		atributo.AppendChild(conjunto)
+		atributos.AppendChild(atributo)
+	}
+	resultado.AppendChild(atributos)
+
+	conexao.Write(mensagem(id, resultado))
+}
+
+func pessoa(uid, email, senha string, grupos ...string) entradaDeTeste {
+	return entradaDeTeste{
+		dn:    "uid=" + uid + ",ou=pessoas,dc=empresa,dc=com",
+		senha: senha,
+		atributos: map[string][]string{
+			"mail":     {email},
+			"cn":       {strings.ToUpper(uid[:1]) + uid[1:]},
+			"uid":      {uid},
+			"memberOf": grupos,
+		},
+	}
+}
+
+func configuracaoLDAP(url string) config.ConfigLDAP {
+	return config.ConfigLDAP{
+		URL:            url,
+		BindDN:         servicoDN,
+		BindSenha:      servicoSenha,
+		BaseDN:         "dc=empresa,dc=com",
+		Filtro:         "(&(objectClass=person)(mail=%s))",
+		AtributoEmail:  "mail",
+		AtributoNome:   "cn",
+		AtributoNick:   "uid",
+		AtributoGrupos: "memberOf",
+		PapeisPorGrupo: map[string]string{grupoAdmins: "admin"},
+		CriarUsuarios:  true,
+	}
+}
+
+func TestAutenticadorLDAP(t *testing.T) {
+	diretorio := novoDiretorio(t,
+		pessoa("ana", "ana@empresa.com", "senha-da-ana", grupoAdmins),
+		pessoa("bruno", "bruno@empresa.com", "senha-do-bruno"),
+		// Três entradas com o mesmo e-mail: passam do limite de 2 da busca
+		pessoa("dup1", "duplicado@empresa.com", "senha"),
+		pessoa("dup2", "duplicado@empresa.com", "senha"),
+		pessoa("dup3", "duplicado@empresa.com", "senha"),
+		// Duas entradas: cabem no limite, mas o e-mail não é único
+		pessoa("par1", "par@empresa.com", "senha"),
+		pessoa("par2", "par@empresa.com", "senha"),
+	)
+	repositorio := repositorios.NovaFabricaDeUsuariosEmMemoria()(1)
+	autenticador := NovoAutenticadorLDAP(configuracaoLDAP(diretorio.url()), repositorio)
+	ctx := context.Background()
+
+	t.Run("primeiro acesso cria o usuário e mapeia os grupos", func(t *testing.T) {
+		resultado, erro := autenticador.Autenticar(ctx, "ana@empresa.com", "senha-da-ana")
+		if erro != nil {
+			t.Fatalf("Autenticar: %v", erro)
+		}
+		if resultado.Origem != "ldap" || len(resultado.Papeis) != 1 || resultado.Papeis[0] != "admin" {
+			t.Errorf("resultado = %+v, quer origem ldap e papel admin", resultado)
+		}
+
+		local, erro := repositorio.BuscarPorID(ctx, resultado.Usuario.ID)
+		if erro != nil || local.Email != "ana@empresa.com" || local.Nick != "ana" || local.Nome != "Ana" {
+			t.Fatalf("usuário local = %+v, %v", local, erro)
+		}
+
+		// O segundo acesso reaproveita a conta criada
+		resultado, erro = autenticador.Autenticar(ctx, "ana@empresa.com", "senha-da-ana")
+		if erro != nil || resultado.Usuario.ID != local.ID {
+			t.Errorf("segundo acesso: ID = %d, %v; quer %d", resultado.Usuario.ID, erro, local.ID)
+		}
+	})
+
+	casos := []struct {
+		nome, email, senha string
+	}{
+		{"senha errada", "bruno@empresa.com", "outra"},
+		{"senha vazia", "bruno@empresa.com", ""},
+		{"e-mail fora do diretório", "ninguem@empresa.com", "senha"},
+		{"mais entradas que o limite da busca", "duplicado@empresa.com", "senha"},
+		{"duas entradas para o e-mail", "par@empresa.com", "senha"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if _, erro := autenticador.Autenticar(ctx, caso.email, caso.senha); !errors.Is(erro, ErrCredenciaisInvalidas) {
+				t.Errorf("erro = %v, quer ErrCredenciaisInvalidas", erro)
+			}
+		})
+	}
+
+	t.Run("sem criar usuários", func(t *testing.T) {
+		configuracao := configuracaoLDAP(diretorio.url())
+		configuracao.CriarUsuarios = false
+		sem := NovoAutenticadorLDAP(configuracao, repositorios.NovaFabricaDeUsuariosEmMemoria()(1))
+
+		if _, erro := sem.Autenticar(ctx, "bruno@empresa.com", "senha-do-bruno"); !errors.Is(erro, ErrCredenciaisInvalidas) {
+			t.Errorf("erro = %v, quer ErrCredenciaisInvalidas", erro)
+		}
+	})
+
+	t.Run("conta de serviço recusada", func(t *testing.T) {
+		configuracao := configuracaoLDAP(diretorio.url())
+		configuracao.BindSenha = "errada"
+		autenticador := NovoAutenticadorLDAP(configuracao, repositorio)
+
+		_, erro := autenticador.Autenticar(ctx, "bruno@empresa.com", "senha-do-bruno")
+		if erro == nil || errors.Is(erro, ErrCredenciaisInvalidas) {
+			t.Errorf("erro = %v, quer uma falha de configuração, e não credenciais inválidas", erro)
+		}
+	})
+}
+
+func TestCadeiaComDiretorioForaDoAr(t *testing.T) {
+	listener, erro := net.Listen("tcp", "127.0.0.1:0")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	url := "ldap://" + listener.Addr().String()
+	listener.Close()
+
+	repositorio := repositorios.NovaFabricaDeUsuariosEmMemoria()(1)
+	cadeia := Cadeia{NovoAutenticadorBanco(repositorio), NovoAutenticadorLDAP(configuracaoLDAP(url), repositorio)}
+
+	// O banco não conhece o e-mail e o diretório não responde: a falha não vira "credenciais inválidas"
+	if _, erro = cadeia.Autenticar(context.Background(), "ana@empresa.com", "senha"); erro == nil || errors.Is(erro, ErrCredenciaisInvalidas) {
+		t.Errorf("erro = %v, quer a falha de conexão com o LDAP", erro)
+	}
+}
+
+func TestLDAPSoNaOrganizacaoConfigurada(t *testing.T) {
+	testes.Configurar(t, map[string]string{
+		"AUTENTICADORES":   "banco,ldap",
+		"LDAP_URL":         "ldap://127.0.0.1:1",
+		"LDAP_BASE_DN":     "dc=empresa,dc=com",
+		"LDAP_ORGANIZACAO": "Empresa",
+	})
+	repositorio := repositorios.NovaFabricaDeUsuariosEmMemoria()(1)
+
+	temLDAP := func(organizacao modelos.Organizacao) bool {
+		t.Helper()
+		autenticador, erro := Configurados(organizacao, repositorio)
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		for _, elo := range autenticador.(Cadeia) {
+			if _, ldap := elo.(*LDAP); ldap {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !temLDAP(modelos.Organizacao{ID: 2, Slug: "empresa"}) {
+		t.Error("o LDAP ficou fora da organização configurada")
+	}
+	if temLDAP(modelos.Organizacao{ID: 3, Slug: "outra"}) {
+		t.Error("o LDAP entrou na cadeia de outra organização")
+	}
+
+	// Só com o LDAP, as outras organizações recusam as credenciais sem consultar o diretório
+	testes.Configurar(t, map[string]string{
+		"AUTENTICADORES":   "ldap",
+		"LDAP_URL":         "ldap://127.0.0.1:1",
+		"LDAP_BASE_DN":     "dc=empresa,dc=com",
+		"LDAP_ORGANIZACAO": "empresa",
+	})
+	autenticador, erro := Configurados(modelos.Organizacao{ID: 3, Slug: "outra"}, repositorio)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if _, erro = autenticador.Autenticar(context.Background(), "ana@empresa.com", "senha"); !errors.Is(erro, ErrCredenciaisInvalidas) {
+		t.Errorf("erro = %v, quer ErrCredenciaisInvalidas", erro)
+	}
+}
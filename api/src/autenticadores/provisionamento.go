@@ -0,0 +1,78 @@
+package autenticadores
+
+import (
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/seguranca"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var caracteresInvalidosNoNick = regexp.MustCompile(`[^a-z0-9_.]`)
+
+// ProvisionarUsuario cria em usuarios uma conta vinda de uma fonte externa (diretório ou provedor OIDC).
+// A senha é aleatória, já que o usuário se autentica pela fonte de origem.
+func ProvisionarUsuario(ctx context.Context, repositorio repositorios.RepositorioUsuarios, nome, nick, email string) (uint64, error) {
+	senha, erro := seguranca.GerarTokenAleatorio(32)
+	if erro != nil {
+		return 0, erro
+	}
+
+	nick, erro = nickDisponivel(ctx, repositorio, nick, email)
+	if erro != nil {
+		return 0, erro
+	}
+
+	nome = strings.TrimSpace(nome)
+	if nome == "" {
+		nome = nick
+	}
+
+	usuario := modelos.Usuario{Nome: limitarTamanho(nome, 50), Nick: nick, Email: email, Senha: senha}
+	if erro = usuario.Preparar("cadastro"); erro != nil {
+		return 0, erro
+	}
+
+	return repositorio.Criar(ctx, usuario)
+}
+
+// nickDisponivel deriva um nick do valor sugerido ou do e-mail e acrescenta um sufixo até ficar único
+func nickDisponivel(ctx context.Context, repositorio repositorios.RepositorioUsuarios, sugestao, email string) (string, error) {
+	base := sugestao
+	if base == "" {
+		base = strings.Split(email, "@")[0]
+	}
+	base = limitarTamanho(caracteresInvalidosNoNick.ReplaceAllString(strings.ToLower(base), ""), 40)
+	if base == "" {
+		base = "usuario"
+	}
+
+	nick := base
+	for tentativa := 1; tentativa <= 20; tentativa++ {
+		existe, erro := repositorio.ExisteNick(ctx, nick)
+		if erro != nil {
+			return "", erro
+		}
+		if !existe {
+			return nick, nil
+		}
+
+		sufixo, erro := seguranca.GerarCodigo()
+		if erro != nil {
+			return "", erro
+		}
+		nick = base + sufixo
+	}
+
+	return "", errors.New("não foi possível gerar um nick disponível")
+}
+
+func limitarTamanho(texto string, tamanho int) string {
+	runas := []rune(texto)
+	if len(runas) > tamanho {
+		return string(runas[:tamanho])
+	}
+	return texto
+}
@@ -2,24 +2,70 @@ package banco
 
 import (
 	"api/src/config"
+	"context"
 	"database/sql"
 
-	_ "github.com/lib/pq" // Driver PostgreSQL
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
-// Conectar abre a conexão com o banco de dados e a retorna
-func Conectar() (*sql.DB, error) {
-	// Usando o driver PostgreSQL em vez de MySQL
-	db, erro := sql.Open("postgres", config.StringConexaoBanco)
+var (
+	pool *pgxpool.Pool
+	db   *sql.DB
+)
+
+// Iniciar cria o pool de conexões compartilhado pela API inteira e confere se o banco responde.
+// Deve ser chamado uma vez, na inicialização, antes de qualquer acesso ao banco.
+func Iniciar(ctx context.Context) error {
+	configuracaoBanco := config.Atual().Banco
+	configuracao, erro := pgxpool.ParseConfig(configuracaoBanco.StringConexao())
 	if erro != nil {
-		return nil, erro
+		return erro
 	}
 
-	// Verifica a conexão com o banco de dados
-	if erro = db.Ping(); erro != nil {
-		db.Close()
-		return nil, erro
+	configuracao.MaxConns = int32(configuracaoBanco.MaxConexoes)
+	configuracao.MinConns = int32(configuracaoBanco.MinConexoes)
+	configuracao.MaxConnLifetime = configuracaoBanco.VidaMaximaConexao
+	configuracao.MaxConnIdleTime = configuracaoBanco.OciosidadeMaxima
+
+	// Cada conexão guarda os comandos já preparados; sem cache, cada consulta é descrita e executada de novo
+	configuracao.ConnConfig.StatementCacheCapacity = configuracaoBanco.CapacidadeCacheSQL
+	if configuracaoBanco.CapacidadeCacheSQL == 0 {
+		configuracao.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+	}
+
+	novoPool, erro := pgxpool.NewWithConfig(ctx, configuracao)
+	if erro != nil {
+		return erro
+	}
+
+	if erro = novoPool.Ping(ctx); erro != nil {
+		novoPool.Close()
+		return erro
 	}
 
-	return db, nil
+	pool, db = novoPool, stdlib.OpenDBFromPool(novoPool)
+	return nil
+}
+
+// Conexao retorna o pool compartilhado no formato database/sql usado pelos repositórios.
+// As conexões voltam para o pool ao fim de cada consulta; não feche o valor retornado.
+func Conexao() *sql.DB {
+	return db
+}
+
+// Pool retorna o pool do pgx, para quem precisa das estatísticas de uso
+func Pool() *pgxpool.Pool {
+	return pool
+}
+
+// Fechar encerra o pool, esperando as conexões em uso voltarem
+func Fechar() {
+	if db != nil {
+		db.Close()
+	}
+	if pool != nil {
+		pool.Close()
+	}
 }
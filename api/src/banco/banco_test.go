@@ -0,0 +1,90 @@
+package banco_test
+
+import (
+	"api/src/banco"
+	"api/src/testes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestIniciarSemBancoNoAr(t *testing.T) {
+	// Uma porta sem nada escutando: a inicialização falha em vez de deixar um pool quebrado para os repositórios
+	ouvinte, erro := net.Listen("tcp", "127.0.0.1:0")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	endereco := ouvinte.Addr().(*net.TCPAddr)
+	ouvinte.Close()
+
+	testes.Configurar(t, map[string]string{"DB_HOST": "127.0.0.1", "DB_PORT": strconv.Itoa(endereco.Port)})
+
+	ctx, cancelar := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelar()
+	if erro = banco.Iniciar(ctx); erro == nil {
+		banco.Fechar()
+		t.Fatal("Iniciar sem o banco no ar não retornou erro")
+	}
+
+	// O contexto cancelado interrompe a tentativa
+	cancelado, cancelar := context.WithCancel(context.Background())
+	cancelar()
+	if erro = banco.Iniciar(cancelado); !errors.Is(erro, context.Canceled) {
+		t.Errorf("Iniciar com o contexto cancelado = %v, quer context.Canceled", erro)
+	}
+}
+
+func TestPoolCompartilhado(t *testing.T) {
+	testes.BancoDeDados(t)
+
+	conexao, erro := pgx.ParseConfig(os.Getenv(testes.VariavelBanco))
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	testes.Configurar(t, map[string]string{
+		"DB_HOST":         conexao.Host,
+		"DB_PORT":         strconv.Itoa(int(conexao.Port)),
+		"DB_USUARIO":      conexao.User,
+		"DB_SENHA":        conexao.Password,
+		"DB_NOME":         conexao.Database,
+		"DB_MAX_CONEXOES": "3",
+		"DB_CACHE_SQL":    "0",
+	})
+
+	if erro = banco.Iniciar(context.Background()); erro != nil {
+		t.Fatal(erro)
+	}
+	t.Cleanup(banco.Fechar)
+
+	configuracao := banco.Pool().Config()
+	if configuracao.MaxConns != 3 || configuracao.ConnConfig.DefaultQueryExecMode != pgx.QueryExecModeExec {
+		t.Errorf("pool com MaxConns %d e modo %v, quer 3 e QueryExecModeExec", configuracao.MaxConns, configuracao.ConnConfig.DefaultQueryExecMode)
+	}
+
+	// database/sql e pgx usam o mesmo pool
+	db := banco.Conexao()
+	var resultado int
+	if erro = db.QueryRowContext(context.Background(), "SELECT 1").Scan(&resultado); erro != nil || resultado != 1 {
+		t.Fatalf("SELECT 1 = %d, %v", resultado, erro)
+	}
+	if total := banco.Pool().Stat().TotalConns(); total < 1 || total > 3 {
+		t.Errorf("conexões no pool = %d", total)
+	}
+
+	// Uma consulta cujo contexto expira é cancelada no servidor em vez de segurar a conexão
+	ctx, cancelar := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelar()
+	inicio := time.Now()
+	if _, erro = db.ExecContext(ctx, "SELECT pg_sleep(5)"); erro == nil {
+		t.Fatal("pg_sleep terminou apesar do prazo")
+	}
+	if duracao := time.Since(inicio); duracao > 2*time.Second {
+		t.Errorf("consulta cancelada depois de %v", duracao)
+	}
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"api/src/banco"
+	"api/src/config"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrChaveNaoEncontrada é retornado por Buscar e Validade quando a chave não existe ou já expirou
+var ErrChaveNaoEncontrada = errors.New("chave não encontrada no cache")
+
+// Armazenamento é o cache chave-valor usado para tentativas e bloqueio de login, sessões em cache,
+// estados do login externo e sugestões. Uma duração <= 0 guarda a chave sem expiração.
+type Armazenamento interface {
+	// Buscar traz o valor da chave ou ErrChaveNaoEncontrada
+	Buscar(ctx context.Context, chave string) (string, error)
+	// Salvar grava o valor, substituindo o anterior, com a validade informada
+	Salvar(ctx context.Context, chave, valor string, duracao time.Duration) error
+	// Incrementar soma 1 ao contador (criado com 0 se não existir), renova a validade e retorna o novo valor
+	Incrementar(ctx context.Context, chave string, duracao time.Duration) (int64, error)
+	// Apagar remove as chaves; chaves que não existem são ignoradas
+	Apagar(ctx context.Context, chaves ...string) error
+	// CompararETrocar grava novo só se o valor atual for esperado, de forma atômica.
+	// Com esperado vazio, grava só se a chave não existir. Retorna se gravou.
+	CompararETrocar(ctx context.Context, chave, esperado, novo string, duracao time.Duration) (bool, error)
+	// Validade retorna quanto falta para a chave expirar (0 se não expira) ou ErrChaveNaoEncontrada
+	Validade(ctx context.Context, chave string) (time.Duration, error)
+}
+
+var atual Armazenamento = NovaMemoria()
+
+// Iniciar escolhe o armazenamento conforme CACHE (redis, postgres ou memoria).
+// Sem CACHE, usa o Redis quando REDIS_URL está definida e o PostgreSQL (tabela UNLOGGED) quando não está.
+// Com o Redis fora do ar, as operações passam para o PostgreSQL até ele voltar; a API sobe mesmo assim.
+// Deve ser chamado depois de banco.Iniciar.
+func Iniciar() error {
+	escolha := config.Atual().Cache
+	if escolha == "" {
+		escolha = "postgres"
+		if config.RedisConfigurado() {
+			escolha = "redis"
+		}
+	}
+
+	switch escolha {
+	case "memoria":
+		atual = NovaMemoria()
+		log.Println("Cache em memória: tentativas de login e sessões não são compartilhadas entre instâncias")
+	case "postgres":
+		atual = NovoPostgres(banco.Conexao())
+		log.Println("Cache no PostgreSQL (tabela UNLOGGED cache)")
+	case "redis":
+		cliente, erro := config.InicializarRedis()
+		if cliente == nil {
+			return erro
+		}
+		comReserva := NovoComReserva(NovoRedis(cliente), NovoPostgres(banco.Conexao()))
+		if erro != nil {
+			// Sem esperar o timeout do Redis na primeira requisição: a reserva atende desde já
+			comReserva.principalFalhou(erro)
+		}
+		atual = comReserva
+	default:
+		return fmt.Errorf("CACHE inválido: %q (use redis, postgres ou memoria)", escolha)
+	}
+
+	return nil
+}
+
+// Atual retorna o armazenamento escolhido em Iniciar; antes disso, um cache em memória
+func Atual() Armazenamento {
+	return atual
+}
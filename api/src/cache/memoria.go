@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// intervaloDeLimpeza é de quanto em quanto tempo as escritas aproveitam para descartar as chaves expiradas
+const intervaloDeLimpeza = time.Minute
+
+// Memoria guarda o cache no próprio processo. Serve para uma única instância ou para desenvolvimento:
+// cada instância tem o seu cache e tudo se perde ao reiniciar.
+type Memoria struct {
+	mutex         sync.Mutex
+	itens         map[string]itemEmMemoria
+	ultimaLimpeza time.Time
+}
+
+type itemEmMemoria struct {
+	valor    string
+	expiraEm time.Time // zero quando não expira
+}
+
+// NovaMemoria cria um cache em memória vazio
+func NovaMemoria() *Memoria {
+	return &Memoria{itens: map[string]itemEmMemoria{}, ultimaLimpeza: time.Now()}
+}
+
+// Buscar traz o valor da chave
+func (armazenamento *Memoria) Buscar(ctx context.Context, chave string) (string, error) {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	item, ok := armazenamento.buscar(chave, time.Now())
+	if !ok {
+		return "", ErrChaveNaoEncontrada
+	}
+	return item.valor, nil
+}
+
+// Salvar grava o valor com a validade informada
+func (armazenamento *Memoria) Salvar(ctx context.Context, chave, valor string, duracao time.Duration) error {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	armazenamento.salvar(chave, valor, duracao, time.Now())
+	return nil
+}
+
+// Incrementar soma 1 ao contador e renova a validade
+func (armazenamento *Memoria) Incrementar(ctx context.Context, chave string, duracao time.Duration) (int64, error) {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	agora := time.Now()
+	var contador int64
+	if item, ok := armazenamento.buscar(chave, agora); ok {
+		valor, erro := strconv.ParseInt(item.valor, 10, 64)
+		if erro != nil {
+			return 0, erro
+		}
+		contador = valor
+	}
+
+	contador++
+	armazenamento.salvar(chave, strconv.FormatInt(contador, 10), duracao, agora)
+	return contador, nil
+}
+
+// Apagar remove as chaves
+func (armazenamento *Memoria) Apagar(ctx context.Context, chaves ...string) error {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	for _, chave := range chaves {
+		delete(armazenamento.itens, chave)
+	}
+	return nil
+}
+
+// CompararETrocar grava novo só se o valor atual for esperado (ou se a chave não existir, com esperado vazio)
+func (armazenamento *Memoria) CompararETrocar(ctx context.Context, chave, esperado, novo string, duracao time.Duration) (bool, error) {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	agora := time.Now()
+	item, existe := armazenamento.buscar(chave, agora)
+	if (esperado == "" && existe) || (esperado != "" && (!existe || item.valor != esperado)) {
+		return false, nil
+	}
+
+	armazenamento.salvar(chave, novo, duracao, agora)
+	return true, nil
+}
+
+// Validade retorna quanto falta para a chave expirar
+func (armazenamento *Memoria) Validade(ctx context.Context, chave string) (time.Duration, error) {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	agora := time.Now()
+	item, ok := armazenamento.buscar(chave, agora)
+	if !ok {
+		return 0, ErrChaveNaoEncontrada
+	}
+	if item.expiraEm.IsZero() {
+		return 0, nil
+	}
+	return item.expiraEm.Sub(agora), nil
+}
+
+// buscar traz o item se ele existir e não tiver expirado; quem chama já tem o mutex
+func (armazenamento *Memoria) buscar(chave string, agora time.Time) (itemEmMemoria, bool) {
+	item, ok := armazenamento.itens[chave]
+	if ok && !item.expiraEm.IsZero() && !agora.Before(item.expiraEm) {
+		delete(armazenamento.itens, chave)
+		return itemEmMemoria{}, false
+	}
+	return item, ok
+}
+
+// salvar grava o item e, de tempos em tempos, descarta as chaves expiradas que ninguém mais leu
+func (armazenamento *Memoria) salvar(chave, valor string, duracao time.Duration, agora time.Time) {
+	item := itemEmMemoria{valor: valor}
+	if duracao > 0 {
+		item.expiraEm = agora.Add(duracao)
+	}
+	armazenamento.itens[chave] = item
+
+	if agora.Sub(armazenamento.ultimaLimpeza) < intervaloDeLimpeza {
+		return
+	}
+	for outraChave, outro := range armazenamento.itens {
+		if !outro.expiraEm.IsZero() && !agora.Before(outro.expiraEm) {
+			delete(armazenamento.itens, outraChave)
+		}
+	}
+	armazenamento.ultimaLimpeza = agora
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Postgres guarda o cache na tabela UNLOGGED cache: é compartilhado entre as instâncias como o Redis,
+// mas sem gravar no WAL, e o conteúdo pode sumir depois de uma queda do banco, o que é aceitável para cache.
+// As chaves expiradas são ignoradas nas leituras e apagadas de vez pelo expurgo (ver ApagarExpirados).
+type Postgres struct {
+	db *sql.DB
+}
+
+// NovoPostgres cria o armazenamento sobre o pool compartilhado
+func NovoPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db}
+}
+
+// expiracao é a duração em milissegundos para o parâmetro de validadeSQL; NULL quando a chave não expira
+func expiracao(duracao time.Duration) interface{} {
+	if duracao <= 0 {
+		return nil
+	}
+	return float64(duracao.Milliseconds())
+}
+
+// validadeSQL é a data de expiração calculada a partir do parâmetro $n (milissegundos ou NULL)
+func validadeSQL(parametro int) string {
+	return fmt.Sprintf("now() + $%d::float8 * interval '1 millisecond'", parametro)
+}
+
+// Buscar traz o valor da chave
+func (armazenamento Postgres) Buscar(ctx context.Context, chave string) (string, error) {
+	var valor string
+	erro := armazenamento.db.QueryRowContext(ctx,
+		"SELECT valor FROM cache WHERE chave = $1 AND (expiraEm IS NULL OR expiraEm > now())", chave,
+	).Scan(&valor)
+	if errors.Is(erro, sql.ErrNoRows) {
+		return "", ErrChaveNaoEncontrada
+	}
+	return valor, erro
+}
+
+// Salvar grava o valor com a validade informada
+func (armazenamento Postgres) Salvar(ctx context.Context, chave, valor string, duracao time.Duration) error {
+	_, erro := armazenamento.db.ExecContext(ctx, `
+		INSERT INTO cache (chave, valor, expiraEm) VALUES ($1, $2, `+validadeSQL(3)+`)
+		ON CONFLICT (chave) DO UPDATE SET valor = EXCLUDED.valor, expiraEm = EXCLUDED.expiraEm`,
+		chave, valor, expiracao(duracao),
+	)
+	return erro
+}
+
+// Incrementar soma 1 ao contador e renova a validade num único comando; um contador expirado recomeça do 1
+func (armazenamento Postgres) Incrementar(ctx context.Context, chave string, duracao time.Duration) (int64, error) {
+	var contador int64
+	erro := armazenamento.db.QueryRowContext(ctx, `
+		INSERT INTO cache (chave, valor, expiraEm) VALUES ($1, '1', `+validadeSQL(2)+`)
+		ON CONFLICT (chave) DO UPDATE SET
+			valor = CASE
+				WHEN cache.expiraEm IS NOT NULL AND cache.expiraEm <= now() THEN '1'
+				ELSE (cache.valor::bigint + 1)::text
+			END,
+			expiraEm = EXCLUDED.expiraEm
+		RETURNING valor::bigint`,
+		chave, expiracao(duracao),
+	).Scan(&contador)
+	return contador, erro
+}
+
+// Apagar remove as chaves
+func (armazenamento Postgres) Apagar(ctx context.Context, chaves ...string) error {
+	if len(chaves) == 0 {
+		return nil
+	}
+	_, erro := armazenamento.db.ExecContext(ctx, "DELETE FROM cache WHERE chave = ANY($1)", chaves)
+	return erro
+}
+
+// CompararETrocar usa a trava de linha do próprio comando: o INSERT ... ON CONFLICT e o UPDATE com o valor
+// esperado no WHERE só gravam se a condição ainda valer no momento da escrita
+func (armazenamento Postgres) CompararETrocar(ctx context.Context, chave, esperado, novo string, duracao time.Duration) (bool, error) {
+	var (
+		resultado sql.Result
+		erro      error
+	)
+	if esperado == "" {
+		resultado, erro = armazenamento.db.ExecContext(ctx, `
+			INSERT INTO cache (chave, valor, expiraEm) VALUES ($1, $2, `+validadeSQL(3)+`)
+			ON CONFLICT (chave) DO UPDATE SET valor = EXCLUDED.valor, expiraEm = EXCLUDED.expiraEm
+			WHERE cache.expiraEm IS NOT NULL AND cache.expiraEm <= now()`,
+			chave, novo, expiracao(duracao),
+		)
+	} else {
+		resultado, erro = armazenamento.db.ExecContext(ctx, `
+			UPDATE cache SET valor = $3, expiraEm = `+validadeSQL(4)+`
+			WHERE chave = $1 AND valor = $2 AND (expiraEm IS NULL OR expiraEm > now())`,
+			chave, esperado, novo, expiracao(duracao),
+		)
+	}
+	if erro != nil {
+		return false, erro
+	}
+
+	linhas, erro := resultado.RowsAffected()
+	return linhas == 1, erro
+}
+
+// Validade retorna quanto falta para a chave expirar
+func (armazenamento Postgres) Validade(ctx context.Context, chave string) (time.Duration, error) {
+	var milissegundos sql.NullFloat64
+	erro := armazenamento.db.QueryRowContext(ctx, `
+		SELECT (EXTRACT(EPOCH FROM expiraEm - now()) * 1000)::float8 FROM cache
+		WHERE chave = $1 AND (expiraEm IS NULL OR expiraEm > now())`, chave,
+	).Scan(&milissegundos)
+	if errors.Is(erro, sql.ErrNoRows) {
+		return 0, ErrChaveNaoEncontrada
+	}
+	if erro != nil || !milissegundos.Valid {
+		return 0, erro
+	}
+	return time.Duration(milissegundos.Float64) * time.Millisecond, nil
+}
+
+// ApagarExpirados remove de vez as chaves vencidas, retornando quantas foram apagadas
+func (armazenamento Postgres) ApagarExpirados(ctx context.Context) (int64, error) {
+	resultado, erro := armazenamento.db.ExecContext(ctx, "DELETE FROM cache WHERE expiraEm <= now()")
+	if erro != nil {
+		return 0, erro
+	}
+	return resultado.RowsAffected()
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// compararETrocar troca o valor só se o atual for o esperado; esperado vazio significa "a chave não existe"
+var compararETrocar = redis.NewScript(`
+local atual = redis.call("GET", KEYS[1])
+if (ARGV[1] == "" and atual) or (ARGV[1] ~= "" and atual ~= ARGV[1]) then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1`)
+
+// Redis guarda o cache no Redis configurado em REDIS_URL
+type Redis struct {
+	cliente redis.UniversalClient
+}
+
+// NovoRedis cria o armazenamento sobre um cliente Redis já conectado
+func NovoRedis(cliente redis.UniversalClient) *Redis {
+	return &Redis{cliente}
+}
+
+// Buscar traz o valor da chave
+func (armazenamento Redis) Buscar(ctx context.Context, chave string) (string, error) {
+	valor, erro := armazenamento.cliente.Get(ctx, chave).Result()
+	if errors.Is(erro, redis.Nil) {
+		return "", ErrChaveNaoEncontrada
+	}
+	return valor, erro
+}
+
+// Salvar grava o valor com a validade informada
+func (armazenamento Redis) Salvar(ctx context.Context, chave, valor string, duracao time.Duration) error {
+	return armazenamento.cliente.Set(ctx, chave, valor, semExpiracaoNegativa(duracao)).Err()
+}
+
+// Incrementar soma 1 e renova a validade na mesma transação (MULTI/EXEC)
+func (armazenamento Redis) Incrementar(ctx context.Context, chave string, duracao time.Duration) (int64, error) {
+	var incremento *redis.IntCmd
+	_, erro := armazenamento.cliente.TxPipelined(ctx, func(transacao redis.Pipeliner) error {
+		incremento = transacao.Incr(ctx, chave)
+		if duracao > 0 {
+			transacao.PExpire(ctx, chave, duracao)
+		}
+		return nil
+	})
+	if erro != nil {
+		return 0, erro
+	}
+	return incremento.Val(), nil
+}
+
+// Apagar remove as chaves
+func (armazenamento Redis) Apagar(ctx context.Context, chaves ...string) error {
+	if len(chaves) == 0 {
+		return nil
+	}
+	return armazenamento.cliente.Del(ctx, chaves...).Err()
+}
+
+// CompararETrocar roda a comparação e a escrita num script Lua, que o Redis executa de forma atômica
+func (armazenamento Redis) CompararETrocar(ctx context.Context, chave, esperado, novo string, duracao time.Duration) (bool, error) {
+	gravou, erro := compararETrocar.Run(ctx, armazenamento.cliente, []string{chave},
+		esperado, novo, semExpiracaoNegativa(duracao).Milliseconds(),
+	).Int()
+	return gravou == 1, erro
+}
+
+// Validade retorna quanto falta para a chave expirar
+func (armazenamento Redis) Validade(ctx context.Context, chave string) (time.Duration, error) {
+	duracao, erro := armazenamento.cliente.PTTL(ctx, chave).Result()
+	if erro != nil {
+		return 0, erro
+	}
+
+	// O Redis responde -2 quando a chave não existe e -1 quando ela não expira (o cliente repassa os valores crus)
+	switch duracao {
+	case -2:
+		return 0, ErrChaveNaoEncontrada
+	case -1:
+		return 0, nil
+	}
+	return duracao, nil
+}
+
+// semExpiracaoNegativa faz duração negativa valer como "sem expiração", como nos outros armazenamentos
+func semExpiracaoNegativa(duracao time.Duration) time.Duration {
+	if duracao < 0 {
+		return 0
+	}
+	return duracao
+}
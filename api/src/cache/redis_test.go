@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"api/src/testes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRedis(t *testing.T) {
+	armazenamento := NovoRedis(testes.Redis(t))
+	ctx := context.Background()
+	prefixo := fmt.Sprintf("teste:%d:", time.Now().UnixNano())
+	t.Cleanup(func() {
+		armazenamento.Apagar(ctx, prefixo+"valor", prefixo+"contador", prefixo+"trava")
+	})
+
+	if _, erro := armazenamento.Buscar(ctx, prefixo+"valor"); !errors.Is(erro, ErrChaveNaoEncontrada) {
+		t.Errorf("chave ausente: %v, quer ErrChaveNaoEncontrada", erro)
+	}
+	if _, erro := armazenamento.Validade(ctx, prefixo+"valor"); !errors.Is(erro, ErrChaveNaoEncontrada) {
+		t.Errorf("validade da chave ausente: %v, quer ErrChaveNaoEncontrada", erro)
+	}
+
+	// Duração negativa vale como sem expiração, como nos outros armazenamentos
+	if erro := armazenamento.Salvar(ctx, prefixo+"valor", "a", -time.Second); erro != nil {
+		t.Fatal(erro)
+	}
+	if validade, erro := armazenamento.Validade(ctx, prefixo+"valor"); erro != nil || validade != 0 {
+		t.Errorf("validade sem expiração = %v, %v", validade, erro)
+	}
+
+	for esperado := int64(1); esperado <= 2; esperado++ {
+		if valor, erro := armazenamento.Incrementar(ctx, prefixo+"contador", time.Minute); erro != nil || valor != esperado {
+			t.Fatalf("Incrementar = %d, %v; quer %d", valor, erro, esperado)
+		}
+	}
+	if validade, _ := armazenamento.Validade(ctx, prefixo+"contador"); validade <= 0 || validade > time.Minute {
+		t.Errorf("validade do contador = %v", validade)
+	}
+
+	// Esperado vazio só grava se a chave não existe
+	if gravou, erro := armazenamento.CompararETrocar(ctx, prefixo+"trava", "", "1", time.Minute); erro != nil || !gravou {
+		t.Fatalf("primeira troca = %v, %v", gravou, erro)
+	}
+	if gravou, _ := armazenamento.CompararETrocar(ctx, prefixo+"trava", "", "2", time.Minute); gravou {
+		t.Error("trocou uma chave que já existia")
+	}
+	if gravou, _ := armazenamento.CompararETrocar(ctx, prefixo+"trava", "1", "2", time.Minute); !gravou {
+		t.Error("não trocou com o valor esperado")
+	}
+	if valor, _ := armazenamento.Buscar(ctx, prefixo+"trava"); valor != "2" {
+		t.Errorf("valor depois da troca = %q", valor)
+	}
+
+	if erro := armazenamento.Apagar(ctx); erro != nil {
+		t.Errorf("Apagar sem chaves: %v", erro)
+	}
+	if erro := armazenamento.Apagar(ctx, prefixo+"valor", prefixo+"trava"); erro != nil {
+		t.Fatal(erro)
+	}
+	if _, erro := armazenamento.Buscar(ctx, prefixo+"trava"); !errors.Is(erro, ErrChaveNaoEncontrada) {
+		t.Errorf("chave apagada: %v", erro)
+	}
+}
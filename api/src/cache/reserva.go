@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// pausaDoPrincipal é quanto tempo a reserva atende sozinha depois de uma falha, antes de tentar o principal de novo
+const pausaDoPrincipal = 30 * time.Second
+
+// ComReserva usa o armazenamento principal (Redis) e, quando ele falha, passa para a reserva (PostgreSQL).
+// Durante a falha o login continua limitando tentativas e bloqueando, só que pelo banco; as chaves gravadas
+// no principal antes da queda não são vistas pela reserva, então os contadores recomeçam.
+//
+// As chaves gravadas ou apagadas só na reserva ficam pendentes e são apagadas do principal antes de ele voltar
+// a atender: sem isso, um token revogado ou um perfil alterado durante a queda voltaria com o valor antigo.
+// Apagar vai sempre aos dois, para a reserva também não guardar um valor velho até a próxima queda.
+type ComReserva struct {
+	principal, reserva Armazenamento
+
+	mutex         sync.Mutex
+	principalAte  time.Time // enquanto agora for anterior, o principal é pulado
+	avisouDaFalha bool
+	pendentes     map[string]struct{} // chaves a apagar do principal antes de usá-lo de novo
+}
+
+// NovoComReserva cria o armazenamento com reserva
+func NovoComReserva(principal, reserva Armazenamento) *ComReserva {
+	return &ComReserva{principal: principal, reserva: reserva, pendentes: map[string]struct{}{}}
+}
+
+// Buscar traz o valor da chave
+func (armazenamento *ComReserva) Buscar(ctx context.Context, chave string) (string, error) {
+	var valor string
+	erro := armazenamento.executar(ctx, nil, func(alvo Armazenamento) (erro error) {
+		valor, erro = alvo.Buscar(ctx, chave)
+		return erro
+	})
+	return valor, erro
+}
+
+// Salvar grava o valor com a validade informada
+func (armazenamento *ComReserva) Salvar(ctx context.Context, chave, valor string, duracao time.Duration) error {
+	return armazenamento.executar(ctx, []string{chave}, func(alvo Armazenamento) error {
+		return alvo.Salvar(ctx, chave, valor, duracao)
+	})
+}
+
+// Incrementar soma 1 ao contador e renova a validade
+func (armazenamento *ComReserva) Incrementar(ctx context.Context, chave string, duracao time.Duration) (int64, error) {
+	var contador int64
+	erro := armazenamento.executar(ctx, []string{chave}, func(alvo Armazenamento) (erro error) {
+		contador, erro = alvo.Incrementar(ctx, chave, duracao)
+		return erro
+	})
+	return contador, erro
+}
+
+// Apagar remove as chaves dos dois armazenamentos e ignora o erro do que está fora do ar; se for o principal,
+// as chaves ficam pendentes para quando ele voltar
+func (armazenamento *ComReserva) Apagar(ctx context.Context, chaves ...string) error {
+	erroDaReserva := armazenamento.reserva.Apagar(ctx, chaves...)
+
+	if armazenamento.usarPrincipal(ctx) {
+		erro := armazenamento.principal.Apagar(ctx, chaves...)
+		if erro == nil {
+			armazenamento.principalRespondeu()
+			if erroDaReserva != nil {
+				log.Printf("Erro ao apagar chaves da reserva do cache: %v", erroDaReserva)
+			}
+			return nil
+		}
+		if errors.Is(erro, context.Canceled) {
+			return erro
+		}
+		armazenamento.principalFalhou(erro)
+	}
+
+	armazenamento.marcarPendentes(chaves)
+	return erroDaReserva
+}
+
+// CompararETrocar grava novo só se o valor atual for esperado
+func (armazenamento *ComReserva) CompararETrocar(ctx context.Context, chave, esperado, novo string, duracao time.Duration) (bool, error) {
+	var gravou bool
+	erro := armazenamento.executar(ctx, []string{chave}, func(alvo Armazenamento) (erro error) {
+		gravou, erro = alvo.CompararETrocar(ctx, chave, esperado, novo, duracao)
+		return erro
+	})
+	return gravou, erro
+}
+
+// Validade retorna quanto falta para a chave expirar
+func (armazenamento *ComReserva) Validade(ctx context.Context, chave string) (time.Duration, error) {
+	var duracao time.Duration
+	erro := armazenamento.executar(ctx, nil, func(alvo Armazenamento) (erro error) {
+		duracao, erro = alvo.Validade(ctx, chave)
+		return erro
+	})
+	return duracao, erro
+}
+
+// executar roda a operação no principal; se ele falhar (erro que não seja chave inexistente), roda na reserva
+// e deixa o principal de lado por pausaDoPrincipal. As chaves alteradas pela operação na reserva ficam pendentes.
+func (armazenamento *ComReserva) executar(ctx context.Context, chaves []string, operacao func(Armazenamento) error) error {
+	if armazenamento.usarPrincipal(ctx) {
+		erro := operacao(armazenamento.principal)
+		if erro == nil || errors.Is(erro, ErrChaveNaoEncontrada) {
+			armazenamento.principalRespondeu()
+			return erro
+		}
+		// Requisição cancelada pelo cliente não quer dizer que o principal caiu
+		if errors.Is(erro, context.Canceled) {
+			return erro
+		}
+		armazenamento.principalFalhou(erro)
+	}
+
+	armazenamento.marcarPendentes(chaves)
+	return operacao(armazenamento.reserva)
+}
+
+// usarPrincipal diz se a operação vai ao principal. Na volta dele, apaga antes as chaves pendentes; se não
+// conseguir, o principal continua de lado.
+func (armazenamento *ComReserva) usarPrincipal(ctx context.Context) bool {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	if time.Now().Before(armazenamento.principalAte) {
+		return false
+	}
+	if len(armazenamento.pendentes) == 0 {
+		return true
+	}
+
+	chaves := make([]string, 0, len(armazenamento.pendentes))
+	for chave := range armazenamento.pendentes {
+		chaves = append(chaves, chave)
+	}
+	if erro := armazenamento.principal.Apagar(ctx, chaves...); erro != nil {
+		if !errors.Is(erro, context.Canceled) {
+			armazenamento.pausarPrincipal(erro)
+		}
+		return false
+	}
+	armazenamento.pendentes = map[string]struct{}{}
+	return true
+}
+
+func (armazenamento *ComReserva) marcarPendentes(chaves []string) {
+	if len(chaves) == 0 {
+		return
+	}
+
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	for _, chave := range chaves {
+		armazenamento.pendentes[chave] = struct{}{}
+	}
+}
+
+// principalFalhou deixa o principal de lado por pausaDoPrincipal e avisa no log só na primeira falha seguida
+func (armazenamento *ComReserva) principalFalhou(erro error) {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	armazenamento.pausarPrincipal(erro)
+}
+
+// pausarPrincipal é o principalFalhou para quem já tem a trava
+func (armazenamento *ComReserva) pausarPrincipal(erro error) {
+	armazenamento.principalAte = time.Now().Add(pausaDoPrincipal)
+	if !armazenamento.avisouDaFalha {
+		log.Printf("Cache principal indisponível (%v); usando a reserva", erro)
+		armazenamento.avisouDaFalha = true
+	}
+}
+
+func (armazenamento *ComReserva) principalRespondeu() {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+
+	if armazenamento.avisouDaFalha {
+		log.Println("Cache principal voltou a responder")
+		armazenamento.avisouDaFalha = false
+	}
+}
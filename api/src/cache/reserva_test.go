@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errForaDoAr = errors.New("conexão recusada")
+
+// instavel é um armazenamento em memória que pode ser derrubado e religado pelo teste
+type instavel struct {
+	*Memoria
+
+	mutex sync.Mutex
+	fora  bool
+}
+
+func (armazenamento *instavel) derrubar(fora bool) {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+	armazenamento.fora = fora
+}
+
+func (armazenamento *instavel) falha() error {
+	armazenamento.mutex.Lock()
+	defer armazenamento.mutex.Unlock()
+	if armazenamento.fora {
+		return errForaDoAr
+	}
+	return nil
+}
+
+func (armazenamento *instavel) Buscar(ctx context.Context, chave string) (string, error) {
+	if erro := armazenamento.falha(); erro != nil {
+		return "", erro
+	}
+	return armazenamento.Memoria.Buscar(ctx, chave)
+}
+
+func (armazenamento *instavel) Salvar(ctx context.Context, chave, valor string, duracao time.Duration) error {
+	if erro := armazenamento.falha(); erro != nil {
+		return erro
+	}
+	return armazenamento.Memoria.Salvar(ctx, chave, valor, duracao)
+}
+
+func (armazenamento *instavel) Incrementar(ctx context.Context, chave string, duracao time.Duration) (int64, error) {
+	if erro := armazenamento.falha(); erro != nil {
+		return 0, erro
+	}
+	return armazenamento.Memoria.Incrementar(ctx, chave, duracao)
+}
+
+func (armazenamento *instavel) Apagar(ctx context.Context, chaves ...string) error {
+	if erro := armazenamento.falha(); erro != nil {
+		return erro
+	}
+	return armazenamento.Memoria.Apagar(ctx, chaves...)
+}
+
+func (armazenamento *instavel) CompararETrocar(ctx context.Context, chave, esperado, novo string, duracao time.Duration) (bool, error) {
+	if erro := armazenamento.falha(); erro != nil {
+		return false, erro
+	}
+	return armazenamento.Memoria.CompararETrocar(ctx, chave, esperado, novo, duracao)
+}
+
+func (armazenamento *instavel) Validade(ctx context.Context, chave string) (time.Duration, error) {
+	if erro := armazenamento.falha(); erro != nil {
+		return 0, erro
+	}
+	return armazenamento.Memoria.Validade(ctx, chave)
+}
+
+func novoComReservaDeTeste() (*ComReserva, *instavel, *instavel) {
+	principal, reserva := &instavel{Memoria: NovaMemoria()}, &instavel{Memoria: NovaMemoria()}
+	return NovoComReserva(principal, reserva), principal, reserva
+}
+
+// religar traz o principal de volta sem esperar a pausaDoPrincipal
+func religar(armazenamento *ComReserva, principal *instavel) {
+	principal.derrubar(false)
+	armazenamento.mutex.Lock()
+	armazenamento.principalAte = time.Time{}
+	armazenamento.mutex.Unlock()
+}
+
+func TestChaveApagadaDuranteAQuedaNaoVoltaComOPrincipal(t *testing.T) {
+	ctx := context.Background()
+	armazenamento, principal, _ := novoComReservaDeTeste()
+
+	if erro := armazenamento.Salvar(ctx, "auth_token:1", "valido", time.Hour); erro != nil {
+		t.Fatal(erro)
+	}
+	if erro := armazenamento.Salvar(ctx, "user_data:1", "nome antigo", time.Hour); erro != nil {
+		t.Fatal(erro)
+	}
+
+	// O Redis cai; o logout e a edição do perfil acontecem só na reserva
+	principal.derrubar(true)
+	if erro := armazenamento.Apagar(ctx, "auth_token:1"); erro != nil {
+		t.Fatalf("Apagar com o principal fora do ar: %v", erro)
+	}
+	if erro := armazenamento.Salvar(ctx, "user_data:1", "nome novo", time.Hour); erro != nil {
+		t.Fatal(erro)
+	}
+
+	religar(armazenamento, principal)
+
+	if valor, erro := armazenamento.Buscar(ctx, "auth_token:1"); !errors.Is(erro, ErrChaveNaoEncontrada) {
+		t.Errorf("auth_token:1 = %q, %v; quer ErrChaveNaoEncontrada", valor, erro)
+	}
+	if valor, erro := armazenamento.Buscar(ctx, "user_data:1"); !errors.Is(erro, ErrChaveNaoEncontrada) {
+		t.Errorf("user_data:1 = %q, %v; quer ErrChaveNaoEncontrada em vez do valor de antes da queda", valor, erro)
+	}
+	if len(armazenamento.pendentes) != 0 {
+		t.Errorf("pendentes = %v, quer vazio depois da volta", armazenamento.pendentes)
+	}
+}
+
+func TestApagarChegaAosDoisArmazenamentos(t *testing.T) {
+	ctx := context.Background()
+	armazenamento, principal, reserva := novoComReservaDeTeste()
+
+	// A reserva guardou um valor numa queda anterior
+	reserva.Memoria.Salvar(ctx, "sugestoes:1", "antigas", time.Hour)
+	principal.Memoria.Salvar(ctx, "sugestoes:1", "antigas", time.Hour)
+
+	if erro := armazenamento.Apagar(ctx, "sugestoes:1"); erro != nil {
+		t.Fatal(erro)
+	}
+	for nome, alvo := range map[string]*instavel{"principal": principal, "reserva": reserva} {
+		if _, erro := alvo.Memoria.Buscar(ctx, "sugestoes:1"); !errors.Is(erro, ErrChaveNaoEncontrada) {
+			t.Errorf("a chave continuou no %s: %v", nome, erro)
+		}
+	}
+
+	// Com a reserva fora do ar, o erro dela é ignorado
+	reserva.derrubar(true)
+	principal.Memoria.Salvar(ctx, "sugestoes:2", "antigas", time.Hour)
+	if erro := armazenamento.Apagar(ctx, "sugestoes:2"); erro != nil {
+		t.Errorf("Apagar com a reserva fora do ar: %v", erro)
+	}
+
+	// Com os dois fora do ar não há onde apagar
+	principal.derrubar(true)
+	if erro := armazenamento.Apagar(ctx, "sugestoes:2"); !errors.Is(erro, errForaDoAr) {
+		t.Errorf("erro = %v, quer o da reserva", erro)
+	}
+}
+
+func TestPrincipalSoVoltaDepoisDeApagarAsPendentes(t *testing.T) {
+	ctx := context.Background()
+	armazenamento, principal, _ := novoComReservaDeTeste()
+
+	armazenamento.Salvar(ctx, "auth_token:1", "valido", time.Hour)
+	principal.derrubar(true)
+	armazenamento.Apagar(ctx, "auth_token:1")
+
+	// A pausa acabou, mas o principal ainda não responde: a reserva continua atendendo
+	armazenamento.mutex.Lock()
+	armazenamento.principalAte = time.Time{}
+	armazenamento.mutex.Unlock()
+	if _, erro := armazenamento.Buscar(ctx, "auth_token:1"); !errors.Is(erro, ErrChaveNaoEncontrada) {
+		t.Errorf("erro = %v, quer a resposta da reserva", erro)
+	}
+	if _, pendente := armazenamento.pendentes["auth_token:1"]; !pendente {
+		t.Error("a chave deixou de estar pendente sem ter sido apagada do principal")
+	}
+
+	religar(armazenamento, principal)
+	if _, erro := armazenamento.Buscar(ctx, "auth_token:1"); !errors.Is(erro, ErrChaveNaoEncontrada) {
+		t.Errorf("erro = %v, quer ErrChaveNaoEncontrada", erro)
+	}
+}
@@ -0,0 +1,159 @@
+package ciclo
+
+import (
+	"api/src/config"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// encerramento é um recurso fechado no fim do ciclo, como o pool do banco ou o cliente do Redis
+type encerramento struct {
+	nome   string
+	fechar func(context.Context) error
+}
+
+var (
+	// contexto é cancelado quando o encerramento começa; as tarefas em segundo plano param com ele
+	contexto, cancelar = context.WithCancel(context.Background())
+
+	tarefas sync.WaitGroup
+	pronto  atomic.Bool
+
+	mutex         sync.Mutex
+	encerrando    bool
+	encerramentos []encerramento
+)
+
+// Contexto retorna o contexto da vida da API, cancelado quando o encerramento começa
+func Contexto() context.Context {
+	return contexto
+}
+
+// Executar roda a tarefa em segundo plano. O encerramento cancela o ctx recebido e espera a tarefa terminar,
+// até ENCERRAMENTO_PRAZO. Depois que o encerramento começou, novas tarefas não são iniciadas.
+func Executar(nome string, tarefa func(ctx context.Context)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if encerrando {
+		log.Printf("Tarefa %s não iniciada: a API está encerrando", nome)
+		return
+	}
+
+	tarefas.Add(1)
+	go func() {
+		defer tarefas.Done()
+		tarefa(contexto)
+	}()
+}
+
+// AoEncerrar registra um recurso para ser fechado depois que as requisições e as tarefas terminarem.
+// Os recursos são fechados na ordem inversa do registro: quem foi aberto por último fecha primeiro.
+func AoEncerrar(nome string, fechar func(context.Context) error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	encerramentos = append(encerramentos, encerramento{nome: nome, fechar: fechar})
+}
+
+// Pronto informa se a instância deve receber tráfego: falso antes de Servir e desde o início do encerramento
+func Pronto() bool {
+	return pronto.Load()
+}
+
+// Servir atende as requisições até receber SIGINT ou SIGTERM e então encerra a API em ordem:
+//  1. o /readyz passa a responder 503 e, por ENCERRAMENTO_ESPERA, as requisições continuam sendo atendidas
+//     enquanto o balanceador tira a instância;
+//  2. o servidor para de aceitar conexões e espera as requisições em andamento;
+//  3. as tarefas em segundo plano são canceladas e esperadas;
+//  4. os recursos registrados em AoEncerrar são fechados.
+//
+// Os passos 2 e 3 dividem ENCERRAMENTO_PRAZO. Um segundo sinal durante a espera do passo 1 pula direto para o 2.
+// Retorna o erro do servidor quando ele não consegue atender (porta em uso, por exemplo).
+func Servir(servidor *http.Server) error {
+	sinais := make(chan os.Signal, 2)
+	signal.Notify(sinais, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sinais)
+
+	erroServidor := make(chan error, 1)
+	go func() {
+		erroServidor <- servidor.ListenAndServe()
+	}()
+	pronto.Store(true)
+
+	var erro error
+	select {
+	case sinal := <-sinais:
+		log.Printf("Sinal %v recebido, encerrando a API", sinal)
+		pronto.Store(false)
+		esperarBalanceador(sinais)
+	case erro = <-erroServidor:
+		pronto.Store(false)
+		log.Printf("Servidor HTTP parou: %v", erro)
+	}
+
+	encerrar(servidor)
+	return erro
+}
+
+// esperarBalanceador dá tempo para o balanceador ver o /readyz em 503 antes de as conexões serem recusadas
+func esperarBalanceador(sinais <-chan os.Signal) {
+	espera := config.Atual().Servidor.EsperaEncerramento
+	log.Printf("Aguardando %v para o balanceador tirar a instância", espera)
+
+	temporizador := time.NewTimer(espera)
+	defer temporizador.Stop()
+
+	select {
+	case <-temporizador.C:
+	case sinal := <-sinais:
+		log.Printf("Sinal %v recebido de novo, encerrando sem esperar", sinal)
+	}
+}
+
+func encerrar(servidor *http.Server) {
+	prazo, cancelarPrazo := context.WithTimeout(context.Background(), config.Atual().Servidor.PrazoEncerramento)
+	defer cancelarPrazo()
+
+	if erro := servidor.Shutdown(prazo); erro != nil && !errors.Is(erro, http.ErrServerClosed) {
+		log.Printf("Requisições interrompidas no encerramento: %v", erro)
+	}
+
+	mutex.Lock()
+	encerrando = true
+	mutex.Unlock()
+
+	cancelar()
+	esperarTarefas(prazo)
+
+	// Fechar em ordem inversa: o que foi aberto por último pode depender do que foi aberto antes
+	for i := len(encerramentos) - 1; i >= 0; i-- {
+		if erro := encerramentos[i].fechar(prazo); erro != nil {
+			log.Printf("Erro ao fechar %s: %v", encerramentos[i].nome, erro)
+		}
+	}
+	log.Println("API encerrada")
+}
+
+// esperarTarefas espera as tarefas em segundo plano até o prazo; as que não terminarem são abandonadas
+func esperarTarefas(prazo context.Context) {
+	terminaram := make(chan struct{})
+	go func() {
+		tarefas.Wait()
+		close(terminaram)
+	}()
+
+	select {
+	case <-terminaram:
+	case <-prazo.Done():
+		log.Println("Prazo de encerramento esgotado com tarefas em segundo plano ainda rodando")
+	}
+}
@@ -0,0 +1,127 @@
+package ciclo_test
+
+import (
+	"api/src/ciclo"
+	"api/src/testes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestEncerramentoEmOrdem percorre o ciclo inteiro: o estado do pacote é da vida do processo e não volta ao início
+func TestEncerramentoEmOrdem(t *testing.T) {
+	if ciclo.Contexto().Err() != nil {
+		t.Skip("o ciclo já foi encerrado neste processo (go test -count maior que 1)")
+	}
+	testes.Configurar(t, map[string]string{"ENCERRAMENTO_ESPERA": "200ms", "ENCERRAMENTO_PRAZO": "2s"})
+
+	ouvinte, erro := net.Listen("tcp", "127.0.0.1:0")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	endereco := ouvinte.Addr().String()
+	ouvinte.Close()
+
+	var mutex sync.Mutex
+	var eventos []string
+	registrar := func(evento string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		eventos = append(eventos, evento)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lento", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(400 * time.Millisecond)
+		registrar("requisição")
+	})
+	servidor := &http.Server{Addr: endereco, Handler: mux}
+
+	ciclo.Executar("tarefa", func(ctx context.Context) {
+		<-ctx.Done()
+		registrar("tarefa")
+	})
+	ciclo.AoEncerrar("banco", func(context.Context) error { registrar("banco"); return nil })
+	ciclo.AoEncerrar("redis", func(context.Context) error { registrar("redis"); return nil })
+
+	if ciclo.Pronto() {
+		t.Error("pronto antes de Servir")
+	}
+
+	resultado := make(chan error, 1)
+	go func() { resultado <- ciclo.Servir(servidor) }()
+
+	limite := time.Now().Add(5 * time.Second)
+	for !ciclo.Pronto() {
+		if time.Now().After(limite) {
+			t.Fatal("Servir não ficou pronto")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Uma requisição em andamento quando o sinal chega ainda é respondida
+	resposta := make(chan int, 1)
+	go func() {
+		for time.Now().Before(limite) {
+			r, erro := http.Get("http://" + endereco + "/lento")
+			if erro != nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			io.Copy(io.Discard, r.Body)
+			r.Body.Close()
+			resposta <- r.StatusCode
+			return
+		}
+		resposta <- 0
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	processo, _ := os.FindProcess(os.Getpid())
+	if erro = processo.Signal(syscall.SIGTERM); erro != nil {
+		t.Skipf("SIGTERM indisponível: %v", erro)
+	}
+
+	select {
+	case erro = <-resultado:
+		if erro != nil {
+			t.Errorf("Servir = %v", erro)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Servir não encerrou")
+	}
+
+	if status := <-resposta; status != http.StatusOK {
+		t.Errorf("requisição em andamento: status %d", status)
+	}
+	if ciclo.Pronto() {
+		t.Error("pronto depois do encerramento")
+	}
+	if ciclo.Contexto().Err() == nil {
+		t.Error("o contexto da API não foi cancelado")
+	}
+
+	// Requisições, depois tarefas, depois os recursos na ordem inversa do registro
+	mutex.Lock()
+	esperado := []string{"requisição", "tarefa", "redis", "banco"}
+	if !reflect.DeepEqual(eventos, esperado) {
+		t.Errorf("ordem do encerramento = %v, quer %v", eventos, esperado)
+	}
+	mutex.Unlock()
+
+	// Depois do encerramento, novas tarefas não são iniciadas
+	iniciou := make(chan struct{}, 1)
+	ciclo.Executar("atrasada", func(context.Context) { iniciou <- struct{}{} })
+	select {
+	case <-iniciou:
+		t.Error("tarefa iniciada depois do encerramento")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// ConfigBanco guarda a conexão com o PostgreSQL e os limites do pool de conexões
+type ConfigBanco struct {
+	Host    string
+	Porta   string
+	Usuario string
+	Senha   Segredo
+	Nome    string
+	SSLMode string
+
+	MaxConexoes        int
+	MinConexoes        int
+	VidaMaximaConexao  time.Duration
+	OciosidadeMaxima   time.Duration
+	CapacidadeCacheSQL int
+}
+
+// carregarBanco lê DB_HOST, DB_PORT, DB_USUARIO, DB_SENHA, DB_NOME e DB_SSLMODE, os limites do pool
+// DB_MAX_CONEXOES, DB_MIN_CONEXOES, DB_VIDA_MAXIMA_CONEXAO e DB_OCIOSIDADE_MAXIMA (durações do Go, como 30m ou 1h)
+// e DB_CACHE_SQL, a quantidade de comandos preparados guardados por conexão.
+// DB_CACHE_SQL=0 desliga o cache, necessário atrás de um PgBouncer em modo transaction.
+func carregarBanco(l *leitor) ConfigBanco {
+	configuracao := ConfigBanco{
+		Host:    l.texto("DB_HOST", ""),
+		Porta:   l.texto("DB_PORT", "5432"),
+		Usuario: l.texto("DB_USUARIO", ""),
+		Senha:   l.segredo("DB_SENHA"),
+		Nome:    l.texto("DB_NOME", ""),
+		SSLMode: l.texto("DB_SSLMODE", "disable"), // use require se o banco estiver em outra máquina
+
+		MaxConexoes:        l.inteiro("DB_MAX_CONEXOES", 10, 1),
+		MinConexoes:        l.inteiro("DB_MIN_CONEXOES", 0, 0),
+		CapacidadeCacheSQL: l.inteiro("DB_CACHE_SQL", 512, 0),
+		VidaMaximaConexao:  l.duracao("DB_VIDA_MAXIMA_CONEXAO", time.Hour),
+		OciosidadeMaxima:   l.duracao("DB_OCIOSIDADE_MAXIMA", 30*time.Minute),
+	}
+
+	if configuracao.Host == "" || configuracao.Usuario == "" || configuracao.Nome == "" {
+		l.errof("DB_HOST, DB_USUARIO e DB_NOME são obrigatórias")
+	}
+	switch configuracao.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		l.errof("DB_SSLMODE inválido: %q (use disable, prefer, require, verify-ca ou verify-full)", configuracao.SSLMode)
+	}
+	if configuracao.MinConexoes > configuracao.MaxConexoes {
+		l.errof("DB_MIN_CONEXOES (%d) maior que DB_MAX_CONEXOES (%d)", configuracao.MinConexoes, configuracao.MaxConexoes)
+	}
+
+	return configuracao
+}
+
+// StringConexao monta a URL de conexão com o PostgreSQL. Ela contém a senha: não deve ir para o log.
+func (configuracao ConfigBanco) StringConexao() string {
+	endereco := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(configuracao.Usuario, string(configuracao.Senha)),
+		Host:     net.JoinHostPort(configuracao.Host, configuracao.Porta),
+		Path:     "/" + configuracao.Nome,
+		RawQuery: fmt.Sprintf("sslmode=%s", url.QueryEscape(configuracao.SSLMode)),
+	}
+	return endereco.String()
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestStringConexao(t *testing.T) {
+	configuracao := ConfigBanco{Host: "banco.interno", Porta: "5433", Usuario: "api", Senha: "p@ss:w/rd?#", Nome: "devbook", SSLMode: "require"}
+
+	// A senha com caracteres reservados de URL precisa chegar intacta ao pgx
+	interpretada, erro := pgxpool.ParseConfig(configuracao.StringConexao())
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	conexao := interpretada.ConnConfig
+	if conexao.Host != "banco.interno" || conexao.Port != 5433 || conexao.User != "api" || conexao.Password != "p@ss:w/rd?#" || conexao.Database != "devbook" {
+		t.Errorf("conexão interpretada = %s@%s:%d/%s (senha %q)", conexao.User, conexao.Host, conexao.Port, conexao.Database, conexao.Password)
+	}
+	if conexao.TLSConfig == nil {
+		t.Error("sslmode=require sem TLS")
+	}
+
+	// Endereços IPv6 ficam entre colchetes
+	configuracao.Host = "::1"
+	if url := configuracao.StringConexao(); !strings.Contains(url, "@[::1]:5433/") {
+		t.Errorf("URL com IPv6 = %s", url)
+	}
+}
+
+func TestCarregarBanco(t *testing.T) {
+	obrigatorias := map[string]string{"DB_HOST": "localhost", "DB_USUARIO": "api", "DB_NOME": "devbook"}
+	comFlags := func(extras map[string]string) map[string]string {
+		flags := map[string]string{}
+		for _, fonte := range []map[string]string{obrigatorias, extras} {
+			for variavel, valor := range fonte {
+				flags[variavel] = valor
+			}
+		}
+		return flags
+	}
+
+	l := novoLeitor(comFlags(nil), nil)
+	configuracao := carregarBanco(l)
+	if len(l.erros) != 0 {
+		t.Fatal(l.erros)
+	}
+	if configuracao.Porta != "5432" || configuracao.SSLMode != "disable" || configuracao.MaxConexoes != 10 || configuracao.MinConexoes != 0 ||
+		configuracao.CapacidadeCacheSQL != 512 || configuracao.VidaMaximaConexao != time.Hour || configuracao.OciosidadeMaxima != 30*time.Minute {
+		t.Errorf("padrões = %+v", configuracao)
+	}
+
+	l = novoLeitor(comFlags(map[string]string{"DB_MAX_CONEXOES": "4", "DB_MIN_CONEXOES": "2", "DB_CACHE_SQL": "0", "DB_OCIOSIDADE_MAXIMA": "5m"}), nil)
+	if configuracao = carregarBanco(l); len(l.erros) != 0 || configuracao.MaxConexoes != 4 || configuracao.MinConexoes != 2 ||
+		configuracao.CapacidadeCacheSQL != 0 || configuracao.OciosidadeMaxima != 5*time.Minute {
+		t.Errorf("pool configurado = %+v, erros %v", configuracao, l.erros)
+	}
+
+	invalidos := []map[string]string{
+		{"DB_SSLMODE": "sim"},
+		{"DB_MAX_CONEXOES": "0"},
+		{"DB_MAX_CONEXOES": "2", "DB_MIN_CONEXOES": "3"},
+		{"DB_CACHE_SQL": "-1"},
+		{"DB_VIDA_MAXIMA_CONEXAO": "uma hora"},
+		{"DB_HOST": ""},
+	}
+	for _, extras := range invalidos {
+		l = novoLeitor(comFlags(extras), nil)
+		if carregarBanco(l); len(l.erros) == 0 {
+			t.Errorf("%v aceito", extras)
+		}
+	}
+}
@@ -1,137 +1,203 @@
 package config
 
 import (
-	"context"
-	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
 	"os"
-	"strconv"
+	"sort"
+	"strings"
+	"sync/atomic"
 
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/joho/godotenv" //remover se tiver em produção
+	"github.com/joho/godotenv"
 )
 
-var (
-	DB *pgxpool.Pool
+// Config reúne a configuração da API. Cada valor vem, da maior para a menor prioridade, de uma flag
+// (--db-host), da variável de ambiente (DB_HOST ou DB_HOST_FILE), do arquivo de configuração ou do padrão.
+type Config struct {
+	// Porta onde a API vai estar rodando
+	Porta    int
+	Servidor ConfigServidor
 
-	// StringConexaoBanco é a string de conexão com o PostgreSQL
-	StringConexaoBanco = ""
+	// URLBase é o endereço público da API, usado nos redirecionamentos do login externo e em links enviados
+	URLBase string
 
-	// Porta onde a API vai estar rodando
-	Porta = 8080
+	// AudienciaTokens identifica esta API na claim "aud": tokens da troca emitidos para outra audiência são recusados
+	AudienciaTokens string
 
 	// SecretKey é a chave que vai ser usada para assinar o token
-	SecretKey []byte
+	SecretKey Segredo
 
-	// Pool de conexões com o banco de dados
-)
+	// MigrarAoIniciar aplica as migrações pendentes na inicialização; com MIGRAR_AO_INICIAR=false a API
+	// recusa subir até que alguém rode o migrate up
+	MigrarAoIniciar bool
+
+	Banco ConfigBanco
+
+	// Cache escolhe onde ficam tentativas de login, bloqueios e sessões: redis, postgres ou memoria.
+	// Vazio usa o Redis quando REDIS_URL está definida e o PostgreSQL quando não está.
+	Cache string
+	Redis ConfigRedis
+
+	SMTP ConfigSMTP
+
+	// Autenticadores define a ordem em que as credenciais do login são verificadas
+	Autenticadores []string
+	LDAP           ConfigLDAP
+	ProvedoresOIDC []ProvedorOIDC
+	SAML           ConfigSAML
+
+	Contas ConfigContas
 
-// Carregar vai inicializar as variáveis de ambiente
-func Carregar() {
-	var erro error
+	// Login, Tokens e OrigensCORS são recarregados sem reiniciar a API (ver Recarregar)
+	Login       ConfigLogin
+	Tokens      ConfigTokens
+	OrigensCORS []string
 
-	//Descomente para usar local e comente para usar em prod
-	// Carregar as variáveis de ambiente primeiro
-	//Remover função abaixo se tiver em produção
-	if erro = godotenv.Load(); erro != nil {
-		log.Fatal(erro)
+	// lidos guarda cada variável com o valor e a fonte, para String
+	lidos map[string]valorLido
+}
+
+var atual atomic.Pointer[Config]
+
+// Atual retorna a configuração carregada. Quem precisa de vários valores coerentes entre si deve
+// chamar Atual uma vez e usar o mesmo ponteiro.
+func Atual() *Config {
+	if configuracao := atual.Load(); configuracao != nil {
+		return configuracao
 	}
+	return &Config{}
+}
 
-	Porta, erro = strconv.Atoi(os.Getenv("APP_PORT"))
+// Carregar monta a configuração a partir dos argumentos da linha de comando (sem o nome do programa),
+// das variáveis de ambiente, do arquivo YAML indicado em --config ou CONFIG_ARQUIVO e do .env, se existir.
+// Retorna os argumentos que não são flags, como "migrate up". Todos os problemas encontrados vêm num erro só.
+func Carregar(argumentos []string) ([]string, error) {
+	flags, posicionais, erro := interpretarArgumentos(argumentos, variaveisBooleanas())
 	if erro != nil {
-		Porta = 9000
+		return nil, erro
+	}
+
+	// O .env é opcional (em contêineres as variáveis vêm do ambiente) e não sobrescreve o que já está definido
+	if erro = godotenv.Load(); erro != nil && !errors.Is(erro, fs.ErrNotExist) {
+		return nil, fmt.Errorf("erro ao ler o arquivo .env: %w", erro)
+	}
+
+	caminho := flags["CONFIG"]
+	delete(flags, "CONFIG")
+	if caminho == "" {
+		caminho = os.Getenv("CONFIG_ARQUIVO")
 	}
 
-	/*fmt.Println("APP_PORT:", os.Getenv("APP_PORT"))
-	fmt.Println("DB_USUARIO:", os.Getenv("DB_USUARIO"))
-	fmt.Println("DB_SENHA:", os.Getenv("DB_SENHA"))*/
-
-	// Alterar a string de conexão para PostgreSQL
-	StringConexaoBanco = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", //altere o sslmode para sslmode=require se estiver em produção,
-		os.Getenv("DB_USUARIO"),
-		os.Getenv("DB_SENHA"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NOME"),
-	)
-	log.Println(StringConexaoBanco)
-	SecretKey = []byte(os.Getenv("SECRET_KEY"))
-
-	// Conecta ao banco de dados usando pgxpool
-	DB, erro = pgxpool.New(context.Background(), StringConexaoBanco)
+	configuracao, erro := ler(flags, caminho)
 	if erro != nil {
-		log.Fatalf("Erro ao conectar ao banco de dados: %v", erro)
+		return nil, erro
 	}
 
-	// Verifica se a conexão foi estabelecida com sucesso
-	conn, err := DB.Acquire(context.Background())
-	if err != nil {
-		log.Fatalf("Erro ao adquirir conexão do pool: %v", err)
+	// Recarregar lê de novo as mesmas fontes
+	fontes.Lock()
+	fontes.flags, fontes.caminho = flags, caminho
+	fontes.Unlock()
+
+	atual.Store(configuracao)
+	return posicionais, nil
+}
+
+// ler monta a configuração a partir das flags, do arquivo e do ambiente
+func ler(flags map[string]string, caminho string) (*Config, error) {
+	arquivo, erro := lerArquivo(caminho)
+	if erro != nil {
+		return nil, erro
 	}
-	conn.Release()
+	return montar(novoLeitor(flags, arquivo))
+}
 
-	log.Println("Conexão com o banco de dados estabelecida com sucesso!")
+// montar lê todas as seções e valida o resultado
+func montar(l *leitor) (*Config, error) {
+	configuracao := &Config{
+		Porta:           l.inteiro("APP_PORT", 9000, 1),
+		SecretKey:       l.segredo("SECRET_KEY"),
+		MigrarAoIniciar: l.booleano("MIGRAR_AO_INICIAR", true),
+	}
+	configuracao.Servidor = carregarServidor(l)
+	configuracao.URLBase = strings.TrimSuffix(l.texto("APP_URL", fmt.Sprintf("http://localhost:%d", configuracao.Porta)), "/")
+	configuracao.AudienciaTokens = l.texto("TOKEN_AUDIENCIA", configuracao.URLBase)
+
+	configuracao.Banco = carregarBanco(l)
+	configuracao.Cache = strings.ToLower(l.texto("CACHE", ""))
+	configuracao.Redis = carregarRedis(l)
+	configuracao.SMTP = carregarSMTP(l)
+	configuracao.Autenticadores = l.lista("AUTENTICADORES", "banco")
+	configuracao.LDAP = carregarLDAP(l)
+	configuracao.ProvedoresOIDC = carregarProvedoresOIDC(l)
+	configuracao.SAML = carregarSAML(l)
+	configuracao.Contas = carregarContas(l)
+	configuracao.Login = carregarLogin(l)
+	configuracao.Tokens = carregarTokens(l)
+	configuracao.OrigensCORS = carregarOrigensCORS(l)
+
+	l.conferirDesconhecidas()
+	configuracao.validar(l)
+	if erro := juntarErros(l.erros); erro != nil {
+		return nil, erro
+	}
 
-	verificarBanco()
+	configuracao.lidos = l.lidos
+	return configuracao, nil
 }
 
-// Função para verificar a conexão e a existência das tabelas
-// Função para verificar a conexão e a existência das tabelas
-func verificarBanco() {
-	// Estabelecer a conexão com o banco de dados PostgreSQL
-	db, err := sql.Open("postgres", StringConexaoBanco)
-	if err != nil {
-		log.Fatalf("Erro ao conectar no banco de dados: %v\n", err)
+// validar confere as regras que envolvem mais de um valor
+func (configuracao *Config) validar(l *leitor) {
+	if configuracao.Porta > 65535 {
+		l.errof("APP_PORT inválido (%d): a porta vai até 65535", configuracao.Porta)
+	}
+	if configuracao.SecretKey == "" {
+		l.errof("SECRET_KEY é obrigatória: é a chave que assina os tokens")
 	}
-	defer db.Close()
-
-	// Comandos para verificar as tabelas
-	tabelas := []string{"usuarios"}
-
-	// Itera sobre as tabelas e verifica se existem
-	for _, tabela := range tabelas {
-		var resultado sql.NullString // Use NullString para lidar com valores NULL
-		err = db.QueryRow(fmt.Sprintf("SELECT to_regclass('public.%s')", tabela)).Scan(&resultado)
-		if err != nil || !resultado.Valid || resultado.String == "" {
-			log.Printf("A tabela '%s' não existe ou ocorreu um erro: %v\n", tabela, err)
-			log.Printf("Criando a tabela '%s'...\n", tabela)
-			// Chama a função para criar a tabela
-			criarTabela(db, tabela)
-		} else {
-			log.Printf("Tabela '%s' existe.\n", tabela)
+
+	switch configuracao.Cache {
+	case "", "memoria", "postgres":
+	case "redis":
+		if !configuracao.Redis.Configurado() {
+			l.errof("CACHE=redis exige REDIS_URL")
+		}
+	default:
+		l.errof("CACHE inválido: %q (use redis, postgres ou memoria)", configuracao.Cache)
+	}
+
+	for _, nome := range configuracao.Autenticadores {
+		switch nome {
+		case "banco":
+		case "ldap":
+			if configuracao.LDAP.URL == "" || configuracao.LDAP.BaseDN == "" {
+				l.errof("o autenticador ldap exige LDAP_URL e LDAP_BASE_DN")
+			}
+		default:
+			l.errof("AUTENTICADORES: autenticador %q desconhecido (use banco ou ldap)", nome)
 		}
 	}
 }
 
-// Função para criar as tabelas caso elas não existam
-func criarTabela(db *sql.DB, tabela string) {
-	// Consultar os comandos SQL para as tabelas
-	queries := obterComandosSQL(tabela)
+// String lista a configuração, uma variável por linha com a fonte do valor; senhas e chaves aparecem como ***
+func (configuracao *Config) String() string {
+	variaveis := make([]string, 0, len(configuracao.lidos))
+	for variavel := range configuracao.lidos {
+		variaveis = append(variaveis, variavel)
+	}
+	sort.Strings(variaveis)
 
-	for _, query := range queries {
-		_, err := db.Exec(query)
-		if err != nil {
-			log.Fatalf("Erro ao executar a criação da tabela '%s': %v\n", tabela, err)
-		}
-		log.Printf("Tabela '%s' criada com sucesso.\n", tabela)
+	var texto strings.Builder
+	for _, variavel := range variaveis {
+		lido := configuracao.lidos[variavel]
+		fmt.Fprintf(&texto, "%s=%s (%s)\n", variavel, lido.exibir(), lido.origem)
 	}
+	return texto.String()
 }
 
-// Função que retorna os comandos SQL para a criação das tabelas
-func obterComandosSQL(tabela string) []string {
-	switch tabela {
-	case "usuarios":
-		return []string{
-			`CREATE TABLE IF NOT EXISTS usuarios (
-				id serial PRIMARY KEY,
-				nome varchar(50) NOT NULL,
-				nick varchar(50) NOT NULL UNIQUE,
-				email varchar(50) NOT NULL UNIQUE,
-				senha varchar(100) NOT NULL,
-				criadoEm timestamp default current_timestamp
-			);`,
-		}
+// exibir esconde os segredos, mostrando só se estão definidos
+func (lido valorLido) exibir() string {
+	if lido.segredo {
+		return Segredo(lido.valor).String()
 	}
-	return nil
+	return lido.valor
 }
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"time"
+)
+
+// ConfigContas guarda os prazos da exclusão de contas
+type ConfigContas struct {
+	// CarenciaExclusao é o tempo em que uma conta excluída ainda pode ser restaurada com um login
+	CarenciaExclusao time.Duration
+
+	// IntervaloExpurgo é de quanto em quanto tempo as contas com a carência vencida são apagadas de vez
+	IntervaloExpurgo time.Duration
+}
+
+// carregarContas lê CARENCIA_EXCLUSAO_DIAS e INTERVALO_EXPURGO (duração do Go, como 30m ou 6h)
+func carregarContas(l *leitor) ConfigContas {
+	return ConfigContas{
+		CarenciaExclusao: time.Duration(l.inteiro("CARENCIA_EXCLUSAO_DIAS", 30, 0)) * 24 * time.Hour,
+		IntervaloExpurgo: l.duracao("INTERVALO_EXPURGO", time.Hour),
+	}
+}
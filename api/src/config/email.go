@@ -0,0 +1,26 @@
+package config
+
+// ConfigSMTP guarda o servidor usado para enviar e-mails (convites, por exemplo)
+type ConfigSMTP struct {
+	Host      string
+	Porta     int
+	Usuario   string
+	Senha     Segredo
+	Remetente string
+}
+
+// carregarSMTP lê as variáveis SMTP_*; com SMTP_HOST vazio os e-mails não são enviados
+func carregarSMTP(l *leitor) ConfigSMTP {
+	configuracao := ConfigSMTP{
+		Host:      l.texto("SMTP_HOST", ""),
+		Porta:     l.inteiro("SMTP_PORTA", 587, 1),
+		Usuario:   l.texto("SMTP_USUARIO", ""),
+		Senha:     l.segredo("SMTP_SENHA"),
+		Remetente: l.texto("SMTP_REMETENTE", "nao-responda@localhost"),
+	}
+
+	if configuracao.Porta > 65535 {
+		l.errof("SMTP_PORTA inválido (%d): a porta vai até 65535", configuracao.Porta)
+	}
+	return configuracao
+}
@@ -0,0 +1,345 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origens possíveis de um valor, da menor para a maior prioridade
+const (
+	origemPadrao   = "padrão"
+	origemArquivo  = "arquivo"
+	origemAmbiente = "ambiente"
+	origemSegredo  = "arquivo de segredo"
+	origemFlag     = "flag"
+)
+
+// Segredo é um valor que não pode aparecer em logs: impresso ou serializado, vira "***"
+type Segredo string
+
+// String esconde o valor
+func (segredo Segredo) String() string {
+	if segredo == "" {
+		return ""
+	}
+	return "***"
+}
+
+// GoString esconde o valor também no formato %#v
+func (segredo Segredo) GoString() string {
+	return strconv.Quote(segredo.String())
+}
+
+// MarshalJSON esconde o valor na serialização
+func (segredo Segredo) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(segredo.String())), nil
+}
+
+// valorLido guarda de onde veio cada variável, para imprimir a configuração e comparar recarregamentos
+type valorLido struct {
+	valor   string
+	origem  string
+	segredo bool
+}
+
+// leitor procura cada variável nas fontes, da maior para a menor prioridade: flags, variável de ambiente
+// (ou <VARIAVEL>_FILE, com o valor dentro do arquivo indicado), arquivo de configuração e o valor padrão.
+// Valores inválidos viram erros de validação em vez de cair no padrão em silêncio.
+type leitor struct {
+	flags   map[string]string
+	arquivo map[string]string
+	lidos   map[string]valorLido
+	erros   []error
+
+	consultadas map[string]bool // todas as variáveis procuradas, para recusar flags e chaves desconhecidas
+	booleanas   map[string]bool // as lidas como booleano, que aceitam a flag sem valor
+}
+
+func novoLeitor(flags, arquivo map[string]string) *leitor {
+	return &leitor{
+		flags:       flags,
+		arquivo:     arquivo,
+		lidos:       map[string]valorLido{},
+		consultadas: map[string]bool{},
+		booleanas:   map[string]bool{},
+	}
+}
+
+// buscar traz o valor da variável e a fonte de onde ele veio; origem vazia quando não está em nenhuma
+func (l *leitor) buscar(variavel string) (string, string) {
+	l.consultadas[variavel] = true
+
+	if valor, ok := l.flags[variavel]; ok {
+		return valor, origemFlag
+	}
+
+	valor := os.Getenv(variavel)
+	arquivoSegredo := os.Getenv(variavel + "_FILE")
+	if valor != "" && arquivoSegredo != "" {
+		l.errof("%s e %s_FILE definidas ao mesmo tempo; use só uma", variavel, variavel)
+	}
+	if valor != "" {
+		return valor, origemAmbiente
+	}
+	if arquivoSegredo != "" {
+		conteudo, erro := os.ReadFile(arquivoSegredo)
+		if erro != nil {
+			l.errof("%s_FILE: %v", variavel, erro)
+			return "", ""
+		}
+		return strings.TrimRight(string(conteudo), "\r\n"), origemSegredo
+	}
+
+	if valor, ok := l.arquivo[variavel]; ok && valor != "" {
+		return valor, origemArquivo
+	}
+	return "", ""
+}
+
+func (l *leitor) registrar(variavel, valor, origem string, segredo bool) {
+	l.lidos[variavel] = valorLido{valor: valor, origem: origem, segredo: segredo}
+}
+
+func (l *leitor) errof(formato string, argumentos ...interface{}) {
+	l.erros = append(l.erros, fmt.Errorf(formato, argumentos...))
+}
+
+// texto lê uma variável de texto
+func (l *leitor) texto(variavel, padrao string) string {
+	valor, origem := l.buscar(variavel)
+	if origem == "" {
+		valor, origem = padrao, origemPadrao
+	}
+	l.registrar(variavel, valor, origem, false)
+	return valor
+}
+
+// segredo lê uma senha ou chave; o valor fica fora da configuração impressa
+func (l *leitor) segredo(variavel string) Segredo {
+	valor, origem := l.buscar(variavel)
+	if origem == "" {
+		origem = origemPadrao
+	}
+	l.registrar(variavel, valor, origem, true)
+	return Segredo(valor)
+}
+
+// inteiro lê um número inteiro que não pode ser menor que minimo
+func (l *leitor) inteiro(variavel string, padrao, minimo int) int {
+	valor, origem := l.buscar(variavel)
+	if origem == "" {
+		l.registrar(variavel, strconv.Itoa(padrao), origemPadrao, false)
+		return padrao
+	}
+	l.registrar(variavel, valor, origem, false)
+
+	numero, erro := strconv.Atoi(strings.TrimSpace(valor))
+	if erro != nil || numero < minimo {
+		l.errof("%s inválido (%q): informe um número inteiro maior ou igual a %d", variavel, valor, minimo)
+		return padrao
+	}
+	return numero
+}
+
+// duracao lê uma duração do Go, como 30m ou 1h, que precisa ser positiva
+func (l *leitor) duracao(variavel string, padrao time.Duration) time.Duration {
+	valor, origem := l.buscar(variavel)
+	if origem == "" {
+		l.registrar(variavel, padrao.String(), origemPadrao, false)
+		return padrao
+	}
+	l.registrar(variavel, valor, origem, false)
+
+	duracao, erro := time.ParseDuration(strings.TrimSpace(valor))
+	if erro != nil || duracao <= 0 {
+		l.errof("%s inválido (%q): informe uma duração positiva, como 30s, 15m ou 1h", variavel, valor)
+		return padrao
+	}
+	return duracao
+}
+
+// booleano lê true ou false (também aceita 1 e 0)
+func (l *leitor) booleano(variavel string, padrao bool) bool {
+	l.booleanas[variavel] = true
+	valor, origem := l.buscar(variavel)
+	if origem == "" {
+		l.registrar(variavel, strconv.FormatBool(padrao), origemPadrao, false)
+		return padrao
+	}
+	l.registrar(variavel, valor, origem, false)
+
+	resultado, erro := strconv.ParseBool(strings.TrimSpace(valor))
+	if erro != nil {
+		l.errof("%s inválido (%q): use true ou false", variavel, valor)
+		return padrao
+	}
+	return resultado
+}
+
+// lista lê valores separados por vírgula, sem espaços e em minúsculas
+func (l *leitor) lista(variavel, padrao string) []string {
+	var itens []string
+	for _, item := range strings.Split(l.texto(variavel, padrao), ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			itens = append(itens, item)
+		}
+	}
+	return itens
+}
+
+// conferirDesconhecidas recusa as flags e as chaves do arquivo que nenhuma seção procurou: um nome digitado
+// errado deixaria o valor padrão valendo sem aviso
+func (l *leitor) conferirDesconhecidas() {
+	for variavel := range l.flags {
+		if !l.consultadas[variavel] {
+			l.errof("flag --%s desconhecida", nomeDaFlag(variavel))
+		}
+	}
+	for variavel := range l.arquivo {
+		if !l.consultadas[variavel] {
+			l.errof("arquivo de configuração: chave %s desconhecida", variavel)
+		}
+	}
+}
+
+// variaveisBooleanas descobre as variáveis lidas como booleano montando a configuração sem flags nem arquivo.
+// O resultado dessa montagem é descartado.
+func variaveisBooleanas() map[string]bool {
+	l := novoLeitor(map[string]string{}, map[string]string{})
+	montar(l)
+	return l.booleanas
+}
+
+// interpretarArgumentos separa as flags (--db-host=valor ou --db-host valor, que valem DB_HOST) dos argumentos
+// posicionais, como "migrate up". Só as flags das variáveis booleanas podem vir sem valor, e então valem true:
+// elas nunca tomam o argumento seguinte, para --migrar-ao-iniciar migrate up manter o "migrate up".
+// Depois de "--" tudo é posicional.
+func interpretarArgumentos(argumentos []string, booleanas map[string]bool) (map[string]string, []string, error) {
+	flags := map[string]string{}
+	var posicionais []string
+
+	for i := 0; i < len(argumentos); i++ {
+		argumento := argumentos[i]
+		if argumento == "--" {
+			posicionais = append(posicionais, argumentos[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(argumento, "-") || argumento == "-" {
+			posicionais = append(posicionais, argumento)
+			continue
+		}
+
+		nome, valor, temValor := strings.Cut(strings.TrimLeft(argumento, "-"), "=")
+		if nome == "" {
+			return nil, nil, fmt.Errorf("flag inválida: %q", argumento)
+		}
+		variavel := nomeDaVariavel(nome)
+		switch {
+		case temValor:
+		case booleanas[variavel]:
+			valor = "true"
+		case i+1 < len(argumentos) && !strings.HasPrefix(argumentos[i+1], "-"):
+			valor = argumentos[i+1]
+			i++
+		default:
+			return nil, nil, fmt.Errorf("a flag %s precisa de um valor (--%s=valor)", argumento, nome)
+		}
+		flags[variavel] = valor
+	}
+
+	return flags, posicionais, nil
+}
+
+// lerArquivo lê o arquivo de configuração, que só pode ser YAML (.yaml ou .yml). As chaves aninhadas são unidas
+// com "_" e valem como as variáveis de ambiente de mesmo nome: db: {host: x} equivale a DB_HOST=x. Listas viram
+// valores separados por vírgula. Chaves que não correspondem a nenhuma variável são recusadas na montagem.
+func lerArquivo(caminho string) (map[string]string, error) {
+	if caminho == "" {
+		return map[string]string{}, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(caminho)) {
+	case ".yaml", ".yml":
+	default:
+		return nil, fmt.Errorf("arquivo de configuração %s: formato não suportado, use YAML (.yaml ou .yml)", caminho)
+	}
+
+	conteudo, erro := os.ReadFile(caminho)
+	if erro != nil {
+		return nil, fmt.Errorf("erro ao ler o arquivo de configuração: %w", erro)
+	}
+
+	var documento map[string]interface{}
+	if erro = yaml.Unmarshal(conteudo, &documento); erro != nil {
+		return nil, fmt.Errorf("arquivo de configuração %s inválido: %w", caminho, erro)
+	}
+
+	valores := map[string]string{}
+	if erro = achatar("", documento, valores); erro != nil {
+		return nil, fmt.Errorf("arquivo de configuração %s: %w", caminho, erro)
+	}
+	return valores, nil
+}
+
+func achatar(prefixo string, documento map[string]interface{}, valores map[string]string) error {
+	for chave, valor := range documento {
+		nome := nomeDaVariavel(chave)
+		if prefixo != "" {
+			nome = prefixo + "_" + nome
+		}
+
+		switch conteudo := valor.(type) {
+		case map[string]interface{}:
+			if erro := achatar(nome, conteudo, valores); erro != nil {
+				return erro
+			}
+		case []interface{}:
+			itens := make([]string, 0, len(conteudo))
+			for _, item := range conteudo {
+				switch item.(type) {
+				case map[string]interface{}, []interface{}:
+					return fmt.Errorf("%s: listas só podem ter valores simples", nome)
+				}
+				itens = append(itens, fmt.Sprint(item))
+			}
+			valores[nome] = strings.Join(itens, ",")
+		case nil:
+			valores[nome] = ""
+		default:
+			valores[nome] = fmt.Sprint(conteudo)
+		}
+	}
+	return nil
+}
+
+// nomeDaVariavel converte db-host ou db.host em DB_HOST
+func nomeDaVariavel(nome string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(nome))
+}
+
+// nomeDaFlag converte DB_HOST em db-host
+func nomeDaFlag(variavel string) string {
+	return strings.ToLower(strings.ReplaceAll(variavel, "_", "-"))
+}
+
+// juntarErros monta um único erro com uma validação por linha
+func juntarErros(erros []error) error {
+	if len(erros) == 0 {
+		return nil
+	}
+
+	mensagens := make([]string, 0, len(erros))
+	for _, erro := range erros {
+		mensagens = append(mensagens, "  - "+erro.Error())
+	}
+	sort.Strings(mensagens)
+	return errors.New("configuração inválida:\n" + strings.Join(mensagens, "\n"))
+}
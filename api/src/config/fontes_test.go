@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpretarArgumentos(t *testing.T) {
+	booleanas := variaveisBooleanas()
+	for _, variavel := range []string{"MIGRAR_AO_INICIAR", "LDAP_STARTTLS", "LDAP_CRIAR_USUARIOS", "REDIS_TLS"} {
+		if !booleanas[variavel] {
+			t.Errorf("%s não foi reconhecida como booleana", variavel)
+		}
+	}
+
+	casos := []struct {
+		nome        string
+		argumentos  []string
+		flags       map[string]string
+		posicionais []string
+	}{
+		{"booleana sem valor não toma o comando", []string{"--migrar-ao-iniciar", "migrate", "up"},
+			map[string]string{"MIGRAR_AO_INICIAR": "true"}, []string{"migrate", "up"}},
+		{"booleana com valor", []string{"--migrar-ao-iniciar=false", "config"},
+			map[string]string{"MIGRAR_AO_INICIAR": "false"}, []string{"config"}},
+		{"texto separado por espaço", []string{"--db-host", "banco", "migrate", "up"},
+			map[string]string{"DB_HOST": "banco"}, []string{"migrate", "up"}},
+		{"texto com igual e um traço", []string{"-db.host=banco"},
+			map[string]string{"DB_HOST": "banco"}, nil},
+		{"depois de --", []string{"--", "--db-host", "banco"},
+			map[string]string{}, []string{"--db-host", "banco"}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			flags, posicionais, erro := interpretarArgumentos(caso.argumentos, booleanas)
+			if erro != nil {
+				t.Fatal(erro)
+			}
+			if !reflect.DeepEqual(flags, caso.flags) || !reflect.DeepEqual(posicionais, caso.posicionais) {
+				t.Errorf("flags = %v, posicionais = %v; quer %v e %v", flags, posicionais, caso.flags, caso.posicionais)
+			}
+		})
+	}
+
+	for _, argumentos := range [][]string{{"--db-host"}, {"--db-host", "--db-nome=api"}, {"--=banco"}} {
+		if _, _, erro := interpretarArgumentos(argumentos, booleanas); erro == nil {
+			t.Errorf("%v aceito", argumentos)
+		}
+	}
+}
+
+// configurarMinimo define as variáveis obrigatórias, como testes.Configurar (que não pode ser importado aqui)
+func configurarMinimo(t *testing.T) {
+	t.Helper()
+	for nome, valor := range map[string]string{"SECRET_KEY": "segredo-de-teste", "DB_HOST": "localhost", "DB_USUARIO": "teste", "DB_NOME": "teste"} {
+		t.Setenv(nome, valor)
+	}
+}
+
+func arquivoDeConfiguracao(t *testing.T, nome, conteudo string) string {
+	t.Helper()
+	caminho := filepath.Join(t.TempDir(), nome)
+	if erro := os.WriteFile(caminho, []byte(conteudo), 0o600); erro != nil {
+		t.Fatal(erro)
+	}
+	return caminho
+}
+
+func TestFlagsEChavesDesconhecidas(t *testing.T) {
+	configurarMinimo(t)
+
+	yaml := arquivoDeConfiguracao(t, "api.yaml", "db:\n  porta_errada: 5433\nlogin:\n  max_tentativas: 3\noidc:\n  provedores: [google]\n  google:\n    issuer: https://accounts.google.com\n    client_id: api\n")
+	_, erro := Carregar([]string{"--config", yaml, "--db-hots=banco", "--migrar-ao-iniciar", "migrate", "up"})
+	if erro == nil {
+		t.Fatal("flag e chave desconhecidas aceitas")
+	}
+	for _, quer := range []string{"flag --db-hots desconhecida", "chave DB_PORTA_ERRADA desconhecida"} {
+		if !strings.Contains(erro.Error(), quer) {
+			t.Errorf("erro sem %q: %v", quer, erro)
+		}
+	}
+	// As chaves conhecidas, inclusive as dos provedores OIDC listados, não entram no erro
+	if strings.Contains(erro.Error(), "LOGIN_MAX_TENTATIVAS") || strings.Contains(erro.Error(), "OIDC_GOOGLE") {
+		t.Errorf("chave conhecida recusada: %v", erro)
+	}
+
+	valido := arquivoDeConfiguracao(t, "api.yml", "login:\n  max_tentativas: 3\n")
+	posicionais, erro := Carregar([]string{"--config=" + valido, "--migrar-ao-iniciar", "migrate", "up"})
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if !reflect.DeepEqual(posicionais, []string{"migrate", "up"}) || !Atual().MigrarAoIniciar || Atual().Login.MaxTentativas != 3 {
+		t.Errorf("posicionais = %v, configuração = %+v", posicionais, Atual())
+	}
+}
+
+func TestArquivoDeConfiguracaoSoYAML(t *testing.T) {
+	configurarMinimo(t)
+
+	for _, nome := range []string{"api.json", "api.toml", "api.env"} {
+		caminho := arquivoDeConfiguracao(t, nome, "{}")
+		if _, erro := Carregar([]string{"--config=" + caminho}); erro == nil || !strings.Contains(erro.Error(), "use YAML") {
+			t.Errorf("%s: erro = %v, quer formato não suportado", nome, erro)
+		}
+	}
+}
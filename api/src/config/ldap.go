@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+)
+
+// ConfigLDAP guarda a configuração do diretório LDAP / Active Directory usado pelo autenticador "ldap"
+type ConfigLDAP struct {
+	URL            string
+	StartTLS       bool
+	BindDN         string
+	BindSenha      Segredo
+	BaseDN         string
+	Filtro         string
+	AtributoEmail  string
+	AtributoNome   string
+	AtributoNick   string
+	AtributoGrupos string
+	PapeisPorGrupo map[string]string
+	CriarUsuarios  bool
+
+	// Organizacao é o slug da única organização em que o diretório autentica; nas outras o LDAP é recusado
+	Organizacao string
+}
+
+// carregarLDAP lê as variáveis LDAP_*; LDAP_ORGANIZACAO vale "padrao" quando não é informada
+func carregarLDAP(l *leitor) ConfigLDAP {
+	configuracao := ConfigLDAP{
+		URL:            l.texto("LDAP_URL", ""),
+		StartTLS:       l.booleano("LDAP_STARTTLS", false),
+		BindDN:         l.texto("LDAP_BIND_DN", ""),
+		BindSenha:      l.segredo("LDAP_BIND_SENHA"),
+		BaseDN:         l.texto("LDAP_BASE_DN", ""),
+		Filtro:         l.texto("LDAP_FILTRO", "(&(objectClass=person)(mail=%s))"),
+		AtributoEmail:  l.texto("LDAP_ATRIBUTO_EMAIL", "mail"),
+		AtributoNome:   l.texto("LDAP_ATRIBUTO_NOME", "cn"),
+		AtributoNick:   l.texto("LDAP_ATRIBUTO_NICK", "uid"),
+		AtributoGrupos: l.texto("LDAP_ATRIBUTO_GRUPOS", "memberOf"),
+		PapeisPorGrupo: make(map[string]string),
+		CriarUsuarios:  l.booleano("LDAP_CRIAR_USUARIOS", true),
+		Organizacao:    strings.ToLower(l.texto("LDAP_ORGANIZACAO", "padrao")),
+	}
+
+	// LDAP_GRUPOS_PAPEIS="cn=admins,ou=grupos,dc=empresa,dc=com:admin;cn=suporte,ou=grupos,dc=empresa,dc=com:suporte"
+	for _, item := range strings.Split(l.texto("LDAP_GRUPOS_PAPEIS", ""), ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		separador := strings.LastIndex(item, ":")
+		if separador <= 0 {
+			l.errof("LDAP_GRUPOS_PAPEIS: item %q sem o papel (use grupo:papel)", item)
+			continue
+		}
+		grupo := strings.ToLower(strings.TrimSpace(item[:separador]))
+		configuracao.PapeisPorGrupo[grupo] = strings.TrimSpace(item[separador+1:])
+	}
+
+	return configuracao
+}
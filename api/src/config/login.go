@@ -0,0 +1,69 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ConfigLogin guarda o limite de tentativas de login e o bloqueio de quem passa dele
+type ConfigLogin struct {
+	// MaxTentativas é quantas senhas erradas são aceitas dentro da janela antes do bloqueio
+	MaxTentativas int
+
+	// JanelaTentativas é quanto tempo as tentativas são contadas; também é a validade do token guardado em cache
+	JanelaTentativas time.Duration
+
+	// TempoBloqueio é quanto tempo o login fica bloqueado depois de atingir o limite
+	TempoBloqueio time.Duration
+}
+
+// ConfigTokens guarda a duração dos tokens das organizações que não definem a sua
+type ConfigTokens struct {
+	Duracao        time.Duration
+	DuracaoAnonimo time.Duration
+}
+
+// carregarLogin lê LOGIN_MAX_TENTATIVAS, LOGIN_JANELA e LOGIN_BLOQUEIO
+func carregarLogin(l *leitor) ConfigLogin {
+	return ConfigLogin{
+		MaxTentativas:    l.inteiro("LOGIN_MAX_TENTATIVAS", 5, 1),
+		JanelaTentativas: l.duracao("LOGIN_JANELA", 15*time.Minute),
+		TempoBloqueio:    l.duracao("LOGIN_BLOQUEIO", time.Minute),
+	}
+}
+
+// carregarTokens lê TOKEN_DURACAO e TOKEN_ANONIMO_DURACAO
+func carregarTokens(l *leitor) ConfigTokens {
+	return ConfigTokens{
+		Duracao:        l.duracao("TOKEN_DURACAO", 6*time.Hour),
+		DuracaoAnonimo: l.duracao("TOKEN_ANONIMO_DURACAO", 24*time.Hour),
+	}
+}
+
+// carregarOrigensCORS lê CORS_ORIGENS: * libera qualquer origem; senão, a lista de origens (esquema e host)
+func carregarOrigensCORS(l *leitor) []string {
+	origens := l.lista("CORS_ORIGENS", "*")
+	for i, origem := range origens {
+		origem = strings.TrimSuffix(origem, "/")
+		origens[i] = origem
+		if origem == "*" {
+			continue
+		}
+		endereco, erro := url.Parse(origem)
+		if erro != nil || endereco.Scheme == "" || endereco.Host == "" || endereco.Path != "" {
+			l.errof("CORS_ORIGENS: origem %q inválida (use esquema e host, como https://app.exemplo.com)", origem)
+		}
+	}
+	return origens
+}
+
+// OrigemPermitida informa se o navegador pode chamar a API a partir da origem
+func (configuracao *Config) OrigemPermitida(origem string) bool {
+	for _, permitida := range configuracao.OrigensCORS {
+		if permitida == "*" || strings.EqualFold(permitida, origem) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+)
+
+// ProvedorOIDC guarda a configuração de um provedor de identidade OpenID Connect
+type ProvedorOIDC struct {
+	Nome                 string
+	NomeExibicao         string
+	Issuer               string
+	ClientID             string
+	ClientSecret         Segredo
+	Escopos              []string
+	ClaimEmail           string
+	ClaimEmailVerificado string
+	ClaimNome            string
+	ClaimNick            string
+}
+
+// carregarProvedoresOIDC lê os provedores listados em OIDC_PROVEDORES.
+// Cada provedor é configurado com variáveis OIDC_<NOME>_*, por exemplo OIDC_GOOGLE_ISSUER.
+func carregarProvedoresOIDC(l *leitor) []ProvedorOIDC {
+	var provedores []ProvedorOIDC
+
+	for _, nome := range l.lista("OIDC_PROVEDORES", "") {
+		prefixo := "OIDC_" + strings.ToUpper(nome) + "_"
+		provedor := ProvedorOIDC{
+			Nome:                 nome,
+			NomeExibicao:         l.texto(prefixo+"NOME_EXIBICAO", nome),
+			Issuer:               l.texto(prefixo+"ISSUER", ""),
+			ClientID:             l.texto(prefixo+"CLIENT_ID", ""),
+			ClientSecret:         l.segredo(prefixo + "CLIENT_SECRET"),
+			Escopos:              strings.Fields(l.texto(prefixo+"ESCOPOS", "openid email profile")),
+			ClaimEmail:           l.texto(prefixo+"CLAIM_EMAIL", "email"),
+			ClaimEmailVerificado: l.texto(prefixo+"CLAIM_EMAIL_VERIFICADO", "email_verified"),
+			ClaimNome:            l.texto(prefixo+"CLAIM_NOME", "name"),
+			ClaimNick:            l.texto(prefixo+"CLAIM_NICK", "preferred_username"),
+		}
+
+		if provedor.Issuer == "" || provedor.ClientID == "" {
+			l.errof("provedor OIDC '%s': %sISSUER e %sCLIENT_ID são obrigatórios", nome, prefixo, prefixo)
+			continue
+		}
+
+		provedores = append(provedores, provedor)
+	}
+
+	return provedores
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// variaveisRecarregaveis são as variáveis aplicadas por Recarregar; as outras só mudam ao reiniciar a API
+var variaveisRecarregaveis = map[string]bool{
+	"LOGIN_MAX_TENTATIVAS":  true,
+	"LOGIN_JANELA":          true,
+	"LOGIN_BLOQUEIO":        true,
+	"TOKEN_DURACAO":         true,
+	"TOKEN_ANONIMO_DURACAO": true,
+	"CORS_ORIGENS":          true,
+}
+
+// intervaloVerificacaoArquivo é de quanto em quanto tempo o arquivo de configuração é comparado com a última leitura
+const intervaloVerificacaoArquivo = 5 * time.Second
+
+// fontes são as flags e o arquivo usados em Carregar, lidos de novo a cada recarga
+var fontes struct {
+	sync.Mutex
+	flags   map[string]string
+	caminho string
+}
+
+// Recarregar lê de novo as fontes e troca, de uma vez, a parte recarregável da configuração (Login, Tokens e
+// OrigensCORS). A configuração lida é validada inteira antes: se for inválida, nada muda e o erro é retornado.
+// Mudanças nas outras variáveis são ignoradas com um aviso, porque só valem ao reiniciar.
+// As variáveis de ambiente são as do processo; o que muda sem reiniciar é o arquivo e os arquivos _FILE.
+func Recarregar() error {
+	fontes.Lock()
+	defer fontes.Unlock()
+
+	lida, erro := ler(fontes.flags, fontes.caminho)
+	if erro != nil {
+		return erro
+	}
+
+	antiga := Atual()
+	aplicadas, ignoradas := compararLidos(antiga.lidos, lida.lidos)
+	if len(ignoradas) > 0 {
+		log.Printf("Recarga da configuração: %s só mudam ao reiniciar a API; valores atuais mantidos", strings.Join(ignoradas, ", "))
+	}
+	if len(aplicadas) == 0 {
+		log.Println("Recarga da configuração: nenhuma mudança a aplicar")
+		return nil
+	}
+
+	nova := *antiga
+	nova.Login, nova.Tokens, nova.OrigensCORS = lida.Login, lida.Tokens, lida.OrigensCORS
+	nova.lidos = make(map[string]valorLido, len(antiga.lidos))
+	for variavel, lido := range antiga.lidos {
+		nova.lidos[variavel] = lido
+	}
+	for _, variavel := range aplicadas {
+		nova.lidos[variavel] = lida.lidos[variavel]
+	}
+	atual.Store(&nova)
+
+	for _, variavel := range aplicadas {
+		log.Printf("Configuração recarregada: %s: %s -> %s", variavel, antiga.lidos[variavel].exibir(), nova.lidos[variavel].exibir())
+	}
+	return nil
+}
+
+// compararLidos separa as variáveis que mudaram de valor entre as recarregáveis e as que só valem ao reiniciar
+func compararLidos(antigos, novos map[string]valorLido) (aplicadas, ignoradas []string) {
+	variaveis := map[string]bool{}
+	for variavel := range antigos {
+		variaveis[variavel] = true
+	}
+	for variavel := range novos {
+		variaveis[variavel] = true
+	}
+
+	for variavel := range variaveis {
+		antigo, existia := antigos[variavel]
+		novo, existe := novos[variavel]
+		if existia == existe && antigo.valor == novo.valor {
+			continue
+		}
+		if variaveisRecarregaveis[variavel] {
+			aplicadas = append(aplicadas, variavel)
+		} else {
+			ignoradas = append(ignoradas, variavel)
+		}
+	}
+
+	sort.Strings(aplicadas)
+	sort.Strings(ignoradas)
+	return aplicadas, ignoradas
+}
+
+// ObservarRecarga recarrega a configuração ao receber SIGHUP e quando o arquivo de configuração muda, até ctx acabar.
+// Uma recarga rejeitada fica só no log; a API continua com a configuração anterior.
+func ObservarRecarga(ctx context.Context) {
+	sinais := make(chan os.Signal, 1)
+	signal.Notify(sinais, syscall.SIGHUP)
+	defer signal.Stop(sinais)
+
+	fontes.Lock()
+	caminho := fontes.caminho
+	fontes.Unlock()
+
+	// Sem arquivo de configuração, só o SIGHUP dispara a recarga
+	var verificacao <-chan time.Time
+	if caminho != "" {
+		ticker := time.NewTicker(intervaloVerificacaoArquivo)
+		defer ticker.Stop()
+		verificacao = ticker.C
+	}
+
+	assinatura := assinaturaDoArquivo(caminho)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sinais:
+			log.Println("SIGHUP recebido, recarregando a configuração")
+		case <-verificacao:
+			if nova := assinaturaDoArquivo(caminho); nova == assinatura {
+				continue
+			}
+			log.Printf("Arquivo de configuração %s alterado, recarregando", caminho)
+		}
+
+		assinatura = assinaturaDoArquivo(caminho)
+		if erro := Recarregar(); erro != nil {
+			log.Printf("Recarga da configuração rejeitada, mantida a anterior: %v", erro)
+		}
+	}
+}
+
+// assinaturaDoArquivo resume o conteúdo do arquivo; compara o conteúdo e não a data, que não muda quando o
+// arquivo é trocado por um link (como nos ConfigMaps do Kubernetes)
+func assinaturaDoArquivo(caminho string) [sha256.Size]byte {
+	if caminho == "" {
+		return [sha256.Size]byte{}
+	}
+
+	conteudo, erro := os.ReadFile(caminho)
+	if erro != nil {
+		return [sha256.Size]byte{}
+	}
+	return sha256.Sum256(conteudo)
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func escreverArquivo(t *testing.T, caminho, conteudo string) {
+	t.Helper()
+	if erro := os.WriteFile(caminho, []byte(conteudo), 0o600); erro != nil {
+		t.Fatal(erro)
+	}
+}
+
+func TestRecarregar(t *testing.T) {
+	configurarMinimo(t)
+	caminho := arquivoDeConfiguracao(t, "api.yaml", "login:\n  max_tentativas: 3\ndb:\n  max_conexoes: 5\n")
+	if _, erro := Carregar([]string{"--config", caminho}); erro != nil {
+		t.Fatal(erro)
+	}
+
+	// O limite de login muda na hora; o pool do banco só ao reiniciar
+	escreverArquivo(t, caminho, "login:\n  max_tentativas: 7\ndb:\n  max_conexoes: 8\ncors:\n  origens: https://app.exemplo.com\n")
+	if erro := Recarregar(); erro != nil {
+		t.Fatal(erro)
+	}
+	configuracao := Atual()
+	if configuracao.Login.MaxTentativas != 7 || !reflect.DeepEqual(configuracao.OrigensCORS, []string{"https://app.exemplo.com"}) {
+		t.Errorf("depois da recarga: login %+v, CORS %v", configuracao.Login, configuracao.OrigensCORS)
+	}
+	if configuracao.Banco.MaxConexoes != 5 {
+		t.Errorf("DB_MAX_CONEXOES = %d, quer 5 até reiniciar", configuracao.Banco.MaxConexoes)
+	}
+	if lido := configuracao.lidos["DB_MAX_CONEXOES"]; lido.valor != "5" {
+		t.Errorf("a configuração impressa mostra DB_MAX_CONEXOES = %s", lido.valor)
+	}
+
+	// Um arquivo inválido é recusado inteiro, mesmo com uma parte válida
+	escreverArquivo(t, caminho, "login:\n  max_tentativas: 9\n  janela: quinze minutos\n")
+	if erro := Recarregar(); erro == nil {
+		t.Fatal("recarga com LOGIN_JANELA inválida aceita")
+	}
+	if Atual() != configuracao {
+		t.Error("a configuração mudou depois de uma recarga recusada")
+	}
+}
+
+func TestCompararLidos(t *testing.T) {
+	antigos := map[string]valorLido{
+		"LOGIN_MAX_TENTATIVAS": {valor: "5"},
+		"TOKEN_DURACAO":        {valor: "6h"},
+		"DB_HOST":              {valor: "banco"},
+		"REDIS_URL":            {valor: "redis:6379"},
+	}
+	novos := map[string]valorLido{
+		"LOGIN_MAX_TENTATIVAS": {valor: "3"},
+		"TOKEN_DURACAO":        {valor: "6h"},
+		"DB_HOST":              {valor: "outro-banco"},
+		"CORS_ORIGENS":         {valor: "https://app.exemplo.com"},
+	}
+
+	aplicadas, ignoradas := compararLidos(antigos, novos)
+	if !reflect.DeepEqual(aplicadas, []string{"CORS_ORIGENS", "LOGIN_MAX_TENTATIVAS"}) {
+		t.Errorf("aplicadas = %v", aplicadas)
+	}
+	if !reflect.DeepEqual(ignoradas, []string{"DB_HOST", "REDIS_URL"}) {
+		t.Errorf("ignoradas = %v", ignoradas)
+	}
+}
+
+func TestRecargaComSIGHUP(t *testing.T) {
+	configurarMinimo(t)
+	caminho := arquivoDeConfiguracao(t, "api.yaml", "login:\n  max_tentativas: 3\n")
+	if _, erro := Carregar([]string{"--config", caminho}); erro != nil {
+		t.Fatal(erro)
+	}
+
+	// Com o sinal registrado também aqui, um SIGHUP enviado antes de ObservarRecarga começar não encerra o processo
+	sinais := make(chan os.Signal, 1)
+	signal.Notify(sinais, syscall.SIGHUP)
+	defer signal.Stop(sinais)
+
+	ctx, cancelar := context.WithCancel(context.Background())
+	defer cancelar()
+	terminou := make(chan struct{})
+	go func() {
+		ObservarRecarga(ctx)
+		close(terminou)
+	}()
+
+	processo, erro := os.FindProcess(os.Getpid())
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	escreverArquivo(t, caminho, "login:\n  max_tentativas: 4\n")
+	limite := time.Now().Add(5 * time.Second)
+	for Atual().Login.MaxTentativas != 4 {
+		if time.Now().After(limite) {
+			t.Fatal("o SIGHUP não recarregou a configuração")
+		}
+		if erro = processo.Signal(syscall.SIGHUP); erro != nil {
+			t.Skipf("SIGHUP indisponível: %v", erro)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	cancelar()
+	select {
+	case <-terminou:
+	case <-time.After(time.Second):
+		t.Error("ObservarRecarga não terminou com o contexto cancelado")
+	}
+}
+
+func TestAssinaturaDoArquivo(t *testing.T) {
+	caminho := arquivoDeConfiguracao(t, "api.yaml", "login:\n  max_tentativas: 3\n")
+	antes := assinaturaDoArquivo(caminho)
+
+	escreverArquivo(t, caminho, "login:\n  max_tentativas: 3\n")
+	if assinaturaDoArquivo(caminho) != antes {
+		t.Error("o mesmo conteúdo gerou outra assinatura")
+	}
+	escreverArquivo(t, caminho, "login:\n  max_tentativas: 4\n")
+	if assinaturaDoArquivo(caminho) == antes {
+		t.Error("o conteúdo mudou e a assinatura não")
+	}
+	if assinaturaDoArquivo("") != assinaturaDoArquivo(caminho+".ausente") {
+		t.Error("sem arquivo e arquivo ausente deveriam dar a mesma assinatura")
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// ConfigSAML guarda o par de chaves do provedor de serviço SAML
+type ConfigSAML struct {
+	ArquivoCertificado string
+	ArquivoChave       string
+
+	// Certificado é publicado nos metadados do provedor de serviço
+	Certificado *x509.Certificate
+
+	// Chave é a chave privada usada para decifrar asserções criptografadas
+	Chave *rsa.PrivateKey
+}
+
+// carregarSAML lê o par de chaves do provedor de serviço SAML, se configurado.
+// Sem ele o SSO continua funcionando, mas o IdP não pode criptografar as asserções.
+func carregarSAML(l *leitor) ConfigSAML {
+	configuracao := ConfigSAML{
+		ArquivoCertificado: l.texto("SAML_CERTIFICADO_ARQUIVO", ""),
+		ArquivoChave:       l.texto("SAML_CHAVE_ARQUIVO", ""),
+	}
+	if configuracao.ArquivoCertificado == "" && configuracao.ArquivoChave == "" {
+		return configuracao
+	}
+	if configuracao.ArquivoCertificado == "" || configuracao.ArquivoChave == "" {
+		l.errof("SAML_CERTIFICADO_ARQUIVO e SAML_CHAVE_ARQUIVO devem ser definidas juntas")
+		return configuracao
+	}
+
+	par, erro := tls.LoadX509KeyPair(configuracao.ArquivoCertificado, configuracao.ArquivoChave)
+	if erro != nil {
+		l.errof("erro ao carregar o certificado SAML: %v", erro)
+		return configuracao
+	}
+
+	chave, ok := par.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		l.errof("a chave SAML precisa ser RSA")
+		return configuracao
+	}
+
+	certificado, erro := x509.ParseCertificate(par.Certificate[0])
+	if erro != nil {
+		l.errof("erro ao ler o certificado SAML: %v", erro)
+		return configuracao
+	}
+
+	configuracao.Certificado, configuracao.Chave = certificado, chave
+	return configuracao
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"time"
+)
+
+// ConfigServidor guarda os limites de tempo do servidor HTTP e do encerramento da API
+type ConfigServidor struct {
+	TimeoutLeitura   time.Duration // requisição inteira, com o corpo
+	TimeoutCabecalho time.Duration // só os cabeçalhos; protege contra conexões que enviam aos poucos
+	TimeoutEscrita   time.Duration
+	TimeoutOcioso    time.Duration // conexões keep-alive sem requisição
+
+	// EsperaEncerramento é quanto tempo o /readyz responde 503 antes de parar de aceitar conexões,
+	// para o balanceador tirar a instância antes
+	EsperaEncerramento time.Duration
+
+	// PrazoEncerramento é o máximo para as requisições em andamento e as tarefas em segundo plano terminarem
+	PrazoEncerramento time.Duration
+
+	// TimeoutSaude limita cada verificação do /readyz; CacheSaude é por quanto tempo o resultado é reaproveitado
+	TimeoutSaude time.Duration
+	CacheSaude   time.Duration
+
+	// TokenMetricas, quando definido, é exigido no /metrics como Authorization: Bearer
+	TokenMetricas Segredo
+}
+
+// carregarServidor lê HTTP_TIMEOUT_*, ENCERRAMENTO_*, SAUDE_* e METRICAS_TOKEN
+func carregarServidor(l *leitor) ConfigServidor {
+	return ConfigServidor{
+		TimeoutLeitura:     l.duracao("HTTP_TIMEOUT_LEITURA", 30*time.Second),
+		TimeoutCabecalho:   l.duracao("HTTP_TIMEOUT_CABECALHO", 10*time.Second),
+		TimeoutEscrita:     l.duracao("HTTP_TIMEOUT_ESCRITA", 60*time.Second),
+		TimeoutOcioso:      l.duracao("HTTP_TIMEOUT_OCIOSO", 2*time.Minute),
+		EsperaEncerramento: l.duracao("ENCERRAMENTO_ESPERA", 5*time.Second),
+		PrazoEncerramento:  l.duracao("ENCERRAMENTO_PRAZO", 30*time.Second),
+		TimeoutSaude:       l.duracao("SAUDE_TIMEOUT", 2*time.Second),
+		CacheSaude:         l.duracao("SAUDE_CACHE", 5*time.Second),
+		TokenMetricas:      l.segredo("METRICAS_TOKEN"),
+	}
+}
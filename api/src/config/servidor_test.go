@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCarregarServidor(t *testing.T) {
+	l := novoLeitor(nil, nil)
+	configuracao := carregarServidor(l)
+	if len(l.erros) != 0 {
+		t.Fatal(l.erros)
+	}
+	if configuracao.TimeoutCabecalho != 10*time.Second || configuracao.EsperaEncerramento != 5*time.Second ||
+		configuracao.PrazoEncerramento != 30*time.Second || configuracao.TokenMetricas != "" {
+		t.Errorf("padrões = %+v", configuracao)
+	}
+
+	l = novoLeitor(map[string]string{"HTTP_TIMEOUT_CABECALHO": "2s", "ENCERRAMENTO_ESPERA": "1s", "ENCERRAMENTO_PRAZO": "1m"}, nil)
+	if configuracao = carregarServidor(l); len(l.erros) != 0 || configuracao.TimeoutCabecalho != 2*time.Second ||
+		configuracao.EsperaEncerramento != time.Second || configuracao.PrazoEncerramento != time.Minute {
+		t.Errorf("configurado = %+v, erros %v", configuracao, l.erros)
+	}
+
+	for _, flags := range []map[string]string{{"HTTP_TIMEOUT_LEITURA": "30"}, {"ENCERRAMENTO_PRAZO": "-1s"}, {"ENCERRAMENTO_ESPERA": "0s"}} {
+		l = novoLeitor(flags, nil)
+		if carregarServidor(l); len(l.erros) == 0 {
+			t.Errorf("%v aceito", flags)
+		}
+	}
+}
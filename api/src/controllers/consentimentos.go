@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/tenancia"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// BuscarConsentimentos mostra a situação atual de cada consentimento do próprio usuário
+func (controladores *Controladores) BuscarConsentimentos(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, ""); status != 0 {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	consentimentos, erro := repositorios.NovoRepositorioDeConsentimentos(controladores.db, tenancia.DaRequisicao(r).ID).BuscarAtuais(r.Context(), usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, consentimentos)
+}
+
+// ConcederConsentimento registra o consentimento do próprio usuário para a finalidade da URL
+func (controladores *Controladores) ConcederConsentimento(w http.ResponseWriter, r *http.Request) {
+	controladores.registrarConsentimento(w, r, true)
+}
+
+// RevogarConsentimento registra a revogação do consentimento para a finalidade da URL
+func (controladores *Controladores) RevogarConsentimento(w http.ResponseWriter, r *http.Request) {
+	controladores.registrarConsentimento(w, r, false)
+}
+
+// registrarConsentimento grava a mudança; só o titular decide sobre os próprios consentimentos
+func (controladores *Controladores) registrarConsentimento(w http.ResponseWriter, r *http.Request, concedido bool) {
+	parametros := mux.Vars(r)
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	finalidade := parametros["finalidade"]
+	if !modelos.FinalidadeValida(finalidade) {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("finalidade deve ter até 50 letras minúsculas, dígitos, _ ou -"))
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, ""); status != 0 {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	organizacao := tenancia.DaRequisicao(r)
+	if _, erro = controladores.usuarios(organizacao.ID).BuscarPorID(r.Context(), usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	erro = repositorios.NovoRepositorioDeConsentimentos(controladores.db, organizacao.ID).Registrar(r.Context(), usuarioID, finalidade, concedido)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"api/src/modelos"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestConsentimentoSoDoProprioUsuario: a finalidade é validada e ninguém decide pelos consentimentos de outro
+// usuário; os dois casos são recusados antes de tocar no banco
+func TestConsentimentoSoDoProprioUsuario(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ana, _ := repositorio.Criar(context.Background(), modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Senha: "hash"})
+	bruno, _ := repositorio.Criar(context.Background(), modelos.Usuario{Nome: "Bruno", Nick: "bruno", Email: "bruno@exemplo.com", Senha: "hash"})
+
+	daAna := map[string]string{"usuarioId": strconv.FormatUint(ana, 10), "finalidade": "newsletter"}
+	if w := requisitarComo(t, bruno, controladores.ConcederConsentimento, http.MethodPut, "/", nil, daAna); w.Code != http.StatusForbidden {
+		t.Errorf("consentimento de outro usuário: status %d, quer 403", w.Code)
+	}
+	if w := requisitarComo(t, bruno, controladores.RevogarConsentimento, http.MethodDelete, "/", nil, daAna); w.Code != http.StatusForbidden {
+		t.Errorf("revogação de outro usuário: status %d, quer 403", w.Code)
+	}
+
+	for _, finalidade := range []string{"Newsletter", "news letter", strings.Repeat("a", 51)} {
+		variaveis := map[string]string{"usuarioId": strconv.FormatUint(ana, 10), "finalidade": finalidade}
+		if w := requisitarComo(t, ana, controladores.ConcederConsentimento, http.MethodPut, "/", nil, variaveis); w.Code != http.StatusBadRequest {
+			t.Errorf("finalidade %q: status %d, quer 400", finalidade, w.Code)
+		}
+	}
+}
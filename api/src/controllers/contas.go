@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/config"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/tenancia"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DesativarUsuario esconde a conta e recusa o login até que o usuário a reative com POST /login?reativar=true
+func (controladores *Controladores) DesativarUsuario(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, "usuarios:editar"); erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	organizacao := tenancia.DaRequisicao(r)
+	repositorio := controladores.usuarios(organizacao.ID)
+	usuario, erro := repositorio.BuscarPorID(r.Context(), usuarioID)
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = repositorio.Desativar(r.Context(), usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	invalidarCacheDeLogin(r.Context(), organizacao.ID, usuario.Email)
+
+	// Os tokens já emitidos para a conta são recusados a partir da próxima requisição
+	autenticacao.InvalidarAcessos(r.Context(), organizacao.ID)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// verificarSituacaoDaConta decide se a conta pode entrar. Uma conta excluída ainda na carência é restaurada;
+// depois da carência ela é tratada como inexistente até o expurgo. Contas desativadas só entram com reativar.
+func (controladores *Controladores) verificarSituacaoDaConta(ctx context.Context, orgID, usuarioID uint64, reativar bool) error {
+	repositorio := controladores.usuarios(orgID)
+	situacao, erro := repositorio.BuscarSituacao(ctx, usuarioID)
+	if erro != nil {
+		return erro
+	}
+
+	if situacao.ExcluidoEm != nil {
+		if time.Since(*situacao.ExcluidoEm) > config.Atual().Contas.CarenciaExclusao {
+			return fmt.Errorf("usuário com ID %d %w", usuarioID, repositorios.ErrUsuarioNaoEncontrado)
+		}
+
+		log.Printf("Conta %d restaurada por login dentro da carência de exclusão.", usuarioID)
+		return reativarConta(ctx, repositorio, orgID, usuarioID)
+	}
+
+	if situacao.DesativadoEm != nil {
+		if !reativar {
+			return repositorios.ErrContaDesativada
+		}
+
+		log.Printf("Conta %d reativada pelo usuário.", usuarioID)
+		return reativarConta(ctx, repositorio, orgID, usuarioID)
+	}
+
+	return nil
+}
+
+// reativarConta devolve a conta e descarta o acesso guardado como inativo, para o token do login valer na hora
+func reativarConta(ctx context.Context, repositorio repositorios.RepositorioUsuarios, orgID, usuarioID uint64) error {
+	if erro := repositorio.Reativar(ctx, usuarioID); erro != nil {
+		return erro
+	}
+
+	autenticacao.InvalidarAcessos(ctx, orgID)
+	return nil
+}
+
+// statusDaSituacaoDaConta traduz os erros de verificarSituacaoDaConta: a conta desativada tem resposta própria (403)
+func statusDaSituacaoDaConta(erro error) int {
+	switch {
+	case errors.Is(erro, repositorios.ErrContaDesativada):
+		return http.StatusForbidden
+	case errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/tenancia"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// organizacaoDeTeste é a organização colocada no contexto das requisições dos testes, como faria o tenancia.Middleware.
+// Tem chave própria para que os tokens não dependam da SECRET_KEY.
+var organizacaoDeTeste = modelos.Organizacao{
+	ID:              tenancia.IDPadrao,
+	Slug:            tenancia.SlugPadrao,
+	Nome:            "Padrão",
+	ChaveAssinatura: []byte("chave-de-teste"),
+}
+
+// fonteDeTeste serve a organização de teste ao tenancia e as mesmas permissões (permissoesDeTeste) a todo usuário
+type fonteDeTeste struct{}
+
+var permissoesDeTeste = map[uint64][]string{}
+
+func (fonteDeTeste) BuscarPorID(_ context.Context, ID uint64) (modelos.Organizacao, error) {
+	if ID == organizacaoDeTeste.ID {
+		return organizacaoDeTeste, nil
+	}
+	return modelos.Organizacao{}, repositorios.ErrOrganizacaoNaoEncontrada
+}
+
+func (fonte fonteDeTeste) BuscarPorSlug(ctx context.Context, _ string) (modelos.Organizacao, error) {
+	return fonte.BuscarPorID(ctx, organizacaoDeTeste.ID)
+}
+
+func (fonte fonteDeTeste) BuscarPorDominio(ctx context.Context, _ string) (modelos.Organizacao, error) {
+	return fonte.BuscarPorID(ctx, 0)
+}
+
+func (fonteDeTeste) Acesso(_ context.Context, _, usuarioID uint64, _ []string) (modelos.Acesso, error) {
+	return modelos.Acesso{Permissoes: permissoesDeTeste[usuarioID]}, nil
+}
+
+func TestMain(m *testing.M) {
+	tenancia.Configurar(fonteDeTeste{})
+	autenticacao.ConfigurarAcesso(fonteDeTeste{})
+	os.Exit(m.Run())
+}
+
+// novosControladoresDeTeste cria os handlers com os usuários em memória e sem banco para os demais repositórios
+func novosControladoresDeTeste() (*Controladores, repositorios.FabricaDeUsuarios) {
+	usuarios := repositorios.NovaFabricaDeUsuariosEmMemoria()
+	return NovosControladores(Dependencias{Usuarios: usuarios}), usuarios
+}
+
+// requisitar chama o handler com o corpo em JSON, as variáveis da rota e a organização de teste no contexto
+func requisitar(handler http.HandlerFunc, metodo, caminho string, corpo interface{}, variaveis map[string]string) *httptest.ResponseRecorder {
+	var leitor io.Reader
+	if corpo != nil {
+		dados, _ := json.Marshal(corpo)
+		leitor = strings.NewReader(string(dados))
+	}
+
+	r := httptest.NewRequest(metodo, caminho, leitor)
+	r = r.WithContext(tenancia.NoContexto(r.Context(), organizacaoDeTeste))
+	if variaveis != nil {
+		r = mux.SetURLVars(r, variaveis)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// requisitarComo faz a requisição com o token de login do usuário informado
+func requisitarComo(t *testing.T, usuarioID uint64, handler http.HandlerFunc, metodo, caminho string, corpo interface{}, variaveis map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	// permissoesDeTeste muda entre os testes e os IDs em memória se repetem: nada do acesso guardado vale
+	autenticacao.InvalidarAcessos(context.Background(), organizacaoDeTeste.ID)
+
+	token, erro := autenticacao.CriarToken(context.Background(), organizacaoDeTeste, usuarioID, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	autenticado := func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+token)
+		handler(w, r)
+	}
+	return requisitar(autenticado, metodo, caminho, corpo, variaveis)
+}
+
+// lerJSON decodifica o corpo da resposta, falhando o teste se não for JSON
+func lerJSON(t *testing.T, w *httptest.ResponseRecorder, destino interface{}) {
+	t.Helper()
+
+	if erro := json.Unmarshal(w.Body.Bytes(), destino); erro != nil {
+		t.Fatalf("resposta não é JSON (%v): %s", erro, w.Body.String())
+	}
+}
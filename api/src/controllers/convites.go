@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/config"
+	"api/src/email"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/seguranca"
+	"api/src/tenancia"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const duracaoConvite = 7 * 24 * time.Hour
+
+// ConvidarMembro cria um convite para o e-mail entrar na equipe e o envia por e-mail
+func (controladores *Controladores) ConvidarMembro(w http.ResponseWriter, r *http.Request) {
+	corpoRequisicao, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var convite modelos.Convite
+	if erro = json.Unmarshal(corpoRequisicao, &convite); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = convite.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	db := controladores.db
+
+	solicitanteID, papel, erro := papelDoSolicitante(r, db)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if status, erro := exigirGestaoDeMembros(r, papel); erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	organizacao := tenancia.DaRequisicao(r)
+	if jaEMembro, erro := controladores.emailJaEMembro(r.Context(), db, organizacao.ID, convite.Email); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	} else if jaEMembro {
+		respostas.Erro(w, http.StatusConflict, errors.New("este e-mail já é membro da organização"))
+		return
+	}
+
+	token, erro := seguranca.GerarTokenAleatorio(32)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	convite.OrgID = organizacao.ID
+	convite.ConvidadoPor = solicitanteID
+	convite.ExpiraEm = time.Now().Add(duracaoConvite)
+
+	repositorio := repositorios.NovoRepositorioDeConvites(db, organizacao.ID)
+	convite.ID, erro = repositorio.Criar(r.Context(), convite, seguranca.HashToken(token))
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	link := fmt.Sprintf("%s/o/%s/convites/%s", config.Atual().URLBase, organizacao.Slug, token)
+	corpo := fmt.Sprintf(
+		"Você foi convidado para entrar na organização %s como %s.\n\nPara aceitar, acesse:\n%s\n\nO convite expira em %s.",
+		organizacao.Nome, convite.Papel, link, convite.ExpiraEm.Format("02/01/2006 15:04"),
+	)
+	if erro = email.Enviar(convite.Email, "Convite para "+organizacao.Nome, corpo); erro != nil {
+		// Sem e-mail, quem convidou recebe o link para repassar ao convidado
+		log.Printf("Convite %d não enviado por e-mail: %v", convite.ID, erro)
+		convite.Link = link
+	}
+
+	respostas.JSON(w, http.StatusCreated, convite)
+}
+
+// BuscarConvites lista os convites pendentes da organização
+func (controladores *Controladores) BuscarConvites(w http.ResponseWriter, r *http.Request) {
+	db := controladores.db
+
+	_, papel, erro := papelDoSolicitante(r, db)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if status, erro := exigirGestaoDeMembros(r, papel); erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	convites, erro := repositorios.NovoRepositorioDeConvites(db, tenancia.DaRequisicao(r).ID).ListarPendentes(r.Context())
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, convites)
+}
+
+// RevogarConvite invalida um convite pendente
+func (controladores *Controladores) RevogarConvite(w http.ResponseWriter, r *http.Request) {
+	conviteID, erro := strconv.ParseUint(mux.Vars(r)["conviteId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	db := controladores.db
+
+	_, papel, erro := papelDoSolicitante(r, db)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if status, erro := exigirGestaoDeMembros(r, papel); erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	if erro = repositorios.NovoRepositorioDeConvites(db, tenancia.DaRequisicao(r).ID).Revogar(r.Context(), conviteID); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarConvite mostra ao convidado os dados do convite recebido por e-mail
+func (controladores *Controladores) BuscarConvite(w http.ResponseWriter, r *http.Request) {
+	db := controladores.db
+
+	convite, status, erro := conviteDoToken(r, db)
+	if erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, convite)
+}
+
+// AceitarConvite coloca o convidado na equipe. Se a conta com o e-mail do convite não existir, ela é criada;
+// se existir, o convidado confirma que é o dono dela com o token de login ou com a senha.
+func (controladores *Controladores) AceitarConvite(w http.ResponseWriter, r *http.Request) {
+	corpoRequisicao, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var aceite modelos.AceiteDeConvite
+	if len(corpoRequisicao) > 0 {
+		if erro = json.Unmarshal(corpoRequisicao, &aceite); erro != nil {
+			respostas.Erro(w, http.StatusBadRequest, erro)
+			return
+		}
+	}
+
+	db := controladores.db
+
+	convite, status, erro := conviteDoToken(r, db)
+	if erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	organizacao := tenancia.DaRequisicao(r)
+	usuarioID, novo, status, erro := controladores.contaDoConvidado(r, organizacao, convite, aceite)
+	if erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	// A conta nova, o consumo do convite e a entrada na equipe são gravados juntos
+	usuarioID, erro = repositorios.NovoRepositorioDeConvites(db, organizacao.ID).Aceitar(r.Context(), convite, usuarioID, novo)
+	switch {
+	case errors.Is(erro, repositorios.ErrConviteIndisponivel):
+		respostas.Erro(w, http.StatusGone, erro)
+		return
+	case errors.Is(erro, repositorios.ErrMembroExistente):
+		respostas.Erro(w, http.StatusConflict, erro)
+		return
+	case erro != nil:
+		respostas.Erro(w, statusDoErroDeBanco(erro), erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, modelos.Membro{
+		UsuarioID: usuarioID,
+		Email:     convite.Email,
+		Papel:     convite.Papel,
+		CriadoEm:  time.Now(),
+	})
+}
+
+// conviteDoToken busca o convite pelo token da URL e confere se ainda está pendente
+func conviteDoToken(r *http.Request, db *sql.DB) (modelos.Convite, int, error) {
+	repositorio := repositorios.NovoRepositorioDeConvites(db, tenancia.DaRequisicao(r).ID)
+	convite, erro := repositorio.BuscarPorToken(r.Context(), seguranca.HashToken(mux.Vars(r)["token"]))
+	if errors.Is(erro, repositorios.ErrConviteNaoEncontrado) {
+		return modelos.Convite{}, http.StatusNotFound, erro
+	}
+	if erro != nil {
+		return modelos.Convite{}, http.StatusInternalServerError, erro
+	}
+
+	if !convite.Pendente() {
+		return modelos.Convite{}, http.StatusGone, errors.New("o convite expirou, foi revogado ou já foi aceito")
+	}
+
+	return convite, 0, nil
+}
+
+// contaDoConvidado retorna o usuário que aceita o convite ou, quando a conta não existe, a conta a ser criada
+// junto com o aceite
+func (controladores *Controladores) contaDoConvidado(r *http.Request, organizacao modelos.Organizacao, convite modelos.Convite, aceite modelos.AceiteDeConvite) (uint64, *modelos.Usuario, int, error) {
+	repositorio := controladores.usuarios(organizacao.ID)
+
+	// Convidado já logado: o e-mail da conta precisa ser o do convite
+	if r.Header.Get("Authorization") != "" {
+		usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+		if erro != nil || usuarioID == 0 {
+			return 0, nil, http.StatusUnauthorized, errors.New("token inválido para aceitar o convite")
+		}
+
+		usuario, erro := repositorio.BuscarPorID(r.Context(), usuarioID)
+		if erro != nil {
+			return 0, nil, http.StatusUnauthorized, erro
+		}
+		if !strings.EqualFold(usuario.Email, convite.Email) {
+			return 0, nil, http.StatusForbidden, errors.New("o convite foi enviado para outro e-mail")
+		}
+
+		return usuarioID, nil, 0, nil
+	}
+
+	existente, erro := repositorio.BuscarPorEmail(r.Context(), convite.Email)
+	if erro == nil {
+		// A senha da conta é conferida com os mesmos limites e o mesmo bloqueio do login
+		if _, erro = conferirTentativasDeSenha(r.Context(), organizacao.ID, convite.Email); erro != nil {
+			return 0, nil, http.StatusTooManyRequests, erro
+		}
+		if seguranca.VerificarSenha(existente.Senha, aceite.Senha) != nil {
+			contarSenhaErrada(r.Context(), organizacao.ID, convite.Email)
+			return 0, nil, http.StatusUnauthorized, errors.New("já existe uma conta com este e-mail; informe a senha dela ou faça login")
+		}
+		zerarTentativasDeSenha(r.Context(), organizacao.ID, convite.Email)
+		return existente.ID, nil, 0, nil
+	}
+	if !errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		return 0, nil, http.StatusInternalServerError, erro
+	}
+
+	if erro = organizacao.PoliticaDeSenha.Validar(aceite.Senha); erro != nil {
+		return 0, nil, http.StatusBadRequest, erro
+	}
+
+	usuario := modelos.Usuario{Nome: aceite.Nome, Nick: aceite.Nick, Email: convite.Email, Senha: aceite.Senha}
+	if erro = usuario.Preparar("cadastro"); erro != nil {
+		return 0, nil, http.StatusBadRequest, erro
+	}
+
+	return 0, &usuario, 0, nil
+}
+
+// emailJaEMembro informa se a conta com o e-mail já faz parte da equipe
+func (controladores *Controladores) emailJaEMembro(ctx context.Context, db *sql.DB, orgID uint64, email string) (bool, error) {
+	usuario, erro := controladores.usuarios(orgID).BuscarPorEmail(ctx, email)
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		return false, nil
+	}
+	if erro != nil {
+		return false, erro
+	}
+
+	_, erro = repositorios.NovoRepositorioDeMembros(db, orgID).BuscarPapel(ctx, usuario.ID)
+	if errors.Is(erro, repositorios.ErrMembroNaoEncontrado) {
+		return false, nil
+	}
+
+	return erro == nil, erro
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"api/src/repositorios"
+	"database/sql"
+)
+
+// Dependencias são os repositórios que os controllers recebem de fora em vez de criar por conta própria.
+// Nos testes e no desenvolvimento sem banco, Usuarios pode ser repositorios.NovaFabricaDeUsuariosEmMemoria().
+type Dependencias struct {
+	// Usuarios cria o repositório de usuários de cada organização
+	Usuarios repositorios.FabricaDeUsuarios
+	// DB é o pool usado pelos demais repositórios (papéis, membros, convites, clientes OAuth, exportações...)
+	DB *sql.DB
+}
+
+// Controladores atende as rotas da API com as dependências recebidas na criação; cada handler é um método
+type Controladores struct {
+	usuarios repositorios.FabricaDeUsuarios
+	db       *sql.DB
+}
+
+// NovosControladores cria os handlers da API com as dependências informadas
+func NovosControladores(dependencias Dependencias) *Controladores {
+	return &Controladores{
+		usuarios: dependencias.Usuarios,
+		db:       dependencias.DB,
+	}
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"api/src/ciclo"
+	"api/src/exportacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/tenancia"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// SolicitarExportacao inicia, em segundo plano, a exportação dos dados pessoais do próprio usuário (LGPD)
+func (controladores *Controladores) SolicitarExportacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, ""); status != 0 {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	db := controladores.db
+
+	organizacao := tenancia.DaRequisicao(r)
+	if _, erro = controladores.usuarios(organizacao.ID).BuscarPorID(r.Context(), usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	novaExportacao, erro := repositorios.NovoRepositorioDeExportacoes(db, organizacao.ID).Criar(r.Context(), usuarioID, exportacao.TempoMaximoDeGeracao)
+	if statusDoErroDeBanco(erro) == http.StatusConflict {
+		respostas.Erro(w, http.StatusConflict, errors.New("já existe uma exportação em andamento"))
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	// A geração continua depois da resposta; o encerramento da API a cancela e espera ela terminar
+	ciclo.Executar("exportação de dados", func(ctx context.Context) { exportacao.Gerar(ctx, organizacao, novaExportacao) })
+
+	w.Header().Set("Location", fmt.Sprintf("/usuarios/%d/exportacao/%d", usuarioID, novaExportacao.ID))
+	respostas.JSON(w, http.StatusAccepted, novaExportacao)
+}
+
+// BuscarExportacao mostra a situação da exportação e, quando pronta, o link assinado de download
+func (controladores *Controladores) BuscarExportacao(w http.ResponseWriter, r *http.Request) {
+	parametros := mux.Vars(r)
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	exportacaoID, erro := strconv.ParseUint(parametros["exportacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, ""); status != 0 {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	db := controladores.db
+
+	organizacao := tenancia.DaRequisicao(r)
+	pedido, erro := repositorios.NovoRepositorioDeExportacoes(db, organizacao.ID).BuscarPorID(r.Context(), exportacaoID)
+	if erro == nil && pedido.UsuarioID != usuarioID {
+		erro = fmt.Errorf("exportação %d: %w", exportacaoID, repositorios.ErrExportacaoNaoEncontrada)
+	}
+	if errors.Is(erro, repositorios.ErrExportacaoNaoEncontrada) {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if pedido.Status == modelos.ExportacaoConcluida && pedido.ExpiraEm != nil && time.Now().Before(*pedido.ExpiraEm) {
+		pedido.URL = exportacao.URLDeDownload(organizacao, pedido.ID, *pedido.ExpiraEm)
+	}
+
+	respostas.JSON(w, http.StatusOK, pedido)
+}
+
+// BaixarExportacao entrega o ZIP para quem tem o link assinado; o link substitui o token de autenticação
+func (controladores *Controladores) BaixarExportacao(w http.ResponseWriter, r *http.Request) {
+	exportacaoID, erro := strconv.ParseUint(mux.Vars(r)["exportacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	orgID := tenancia.DaRequisicao(r).ID
+	parametros := r.URL.Query()
+	if erro = exportacao.VerificarDownload(orgID, exportacaoID, parametros.Get("expira"), parametros.Get("assinatura")); erro != nil {
+		respostas.Erro(w, http.StatusForbidden, erro)
+		return
+	}
+
+	db := controladores.db
+
+	arquivo, erro := repositorios.NovoRepositorioDeExportacoes(db, orgID).BuscarArquivo(r.Context(), exportacaoID)
+	if errors.Is(erro, repositorios.ErrExportacaoNaoEncontrada) {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="exportacao-%d.zip"`, exportacaoID))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(arquivo)
+}
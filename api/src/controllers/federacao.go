@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/autenticadores"
+	"api/src/cache"
+	"api/src/federacao"
+	"api/src/metricas"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/seguranca"
+	"api/src/tenancia"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/oauth2"
+)
+
+const (
+	cookieEstadoOIDC  = "oidc_estado"
+	duracaoEstadoOIDC = 10 * time.Minute
+)
+
+// estadoOIDC é o que fica salvo no Redis entre o redirecionamento e o callback.
+// A organização vai junto porque a URL de callback registrada no provedor é a mesma para todas.
+type estadoOIDC struct {
+	OrgID           uint64 `json:"orgId"`
+	Provedor        string `json:"provedor"`
+	Nonce           string `json:"nonce"`
+	VerificadorPKCE string `json:"verificador"`
+}
+
+// IniciarLoginExterno redireciona o navegador para o provedor de identidade
+func (controladores *Controladores) IniciarLoginExterno(w http.ResponseWriter, r *http.Request) {
+	provedor, erro := federacao.Buscar(mux.Vars(r)["provedor"])
+	if erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	estado, erro := seguranca.GerarTokenAleatorio(32)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	nonce, erro := seguranca.GerarTokenAleatorio(32)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	dados := estadoOIDC{
+		OrgID:           tenancia.DaRequisicao(r).ID,
+		Provedor:        provedor.Nome,
+		Nonce:           nonce,
+		VerificadorPKCE: oauth2.GenerateVerifier(),
+	}
+	dadosJSON, erro := json.Marshal(dados)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = cache.Atual().Salvar(r.Context(), "oidc_estado:"+estado, string(dadosJSON), duracaoEstadoOIDC); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, errors.New("erro ao salvar o estado do login externo"))
+		return
+	}
+
+	urlDeAutorizacao, erro := provedor.URLDeAutorizacao(r.Context(), estado, dados.Nonce, dados.VerificadorPKCE)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadGateway, erro)
+		return
+	}
+
+	// O cookie amarra o state ao navegador que iniciou o login, evitando CSRF no callback
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieEstadoOIDC,
+		Value:    estado,
+		Path:     "/login/" + provedor.Nome,
+		MaxAge:   int(duracaoEstadoOIDC.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, urlDeAutorizacao, http.StatusFound)
+}
+
+// ConcluirLoginExterno recebe o callback do provedor, vincula ou cria a conta e gera o token
+func (controladores *Controladores) ConcluirLoginExterno(w http.ResponseWriter, r *http.Request) {
+	provedor, erro := federacao.Buscar(mux.Vars(r)["provedor"])
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	if erroProvedor := r.URL.Query().Get("error"); erroProvedor != "" {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRecusadoPeloProvedor)
+		respostas.Erro(w, http.StatusUnauthorized, fmt.Errorf("login recusado pelo provedor: %s", erroProvedor))
+		return
+	}
+
+	estado := r.URL.Query().Get("state")
+	cookie, erro := r.Cookie(cookieEstadoOIDC)
+	if erro != nil || estado == "" || cookie.Value != estado {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
+		respostas.Erro(w, http.StatusBadRequest, errors.New("state inválido"))
+		return
+	}
+
+	chaveEstado := "oidc_estado:" + estado
+	dadosJSON, erro := cache.Atual().Buscar(r.Context(), chaveEstado)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
+		respostas.Erro(w, http.StatusBadRequest, errors.New("login externo expirado, tente novamente"))
+		return
+	}
+	cache.Atual().Apagar(r.Context(), chaveEstado)
+
+	var dados estadoOIDC
+	if erro = json.Unmarshal([]byte(dadosJSON), &dados); erro != nil || dados.Provedor != provedor.Nome {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
+		respostas.Erro(w, http.StatusBadRequest, errors.New("state inválido"))
+		return
+	}
+
+	identidade, erro := provedor.Concluir(r.Context(), r.URL.Query().Get("code"), dados.Nonce, dados.VerificadorPKCE)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoCredenciaisInvalidas)
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	organizacao, erro := tenancia.BuscarPorID(dados.OrgID)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	db := controladores.db
+
+	usuarioID, erro := controladores.resolverUsuarioExterno(r.Context(), db, organizacao.ID, identidade)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoVinculoRecusado)
+		respostas.Erro(w, http.StatusConflict, erro)
+		return
+	}
+
+	if erro = controladores.verificarSituacaoDaConta(r.Context(), organizacao.ID, usuarioID, false); erro != nil {
+		metricas.Login(metricas.LoginFalha, motivoDaSituacaoDaConta(erro))
+		respostas.Erro(w, statusDaSituacaoDaConta(erro), erro)
+		return
+	}
+
+	token, erro := autenticacao.CriarToken(r.Context(), organizacao, usuarioID, nil)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoErro)
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	log.Printf("Login realizado com sucesso usando o provedor %s.", provedor.Nome)
+	controladores.registrarLogin(r, usuarioID, metricas.LoginOIDC)
+	metricas.Login(metricas.LoginSucesso, metricas.LoginOIDC)
+
+	// A página /logado lê o token do fragmento, que não é enviado ao servidor nem aparece nos logs
+	fragmento := url.Values{"id": {strconv.FormatUint(usuarioID, 10)}, "token": {token}}
+	http.Redirect(w, r, "/logado#"+fragmento.Encode(), http.StatusFound)
+}
+
+// resolverUsuarioExterno encontra o usuário da organização vinculado à identidade, vincula pelo e-mail verificado ou cria uma conta
+func (controladores *Controladores) resolverUsuarioExterno(ctx context.Context, db *sql.DB, orgID uint64, identidade federacao.Identidade) (uint64, error) {
+	repositorioIdentidades := repositorios.NovoRepositorioDeIdentidadesExternas(db)
+	usuarioID, erro := repositorioIdentidades.BuscarUsuarioID(ctx, orgID, identidade.Provedor, identidade.Sujeito)
+	if erro != nil || usuarioID != 0 {
+		return usuarioID, erro
+	}
+
+	if identidade.Email == "" {
+		return 0, errors.New("o provedor não informou um e-mail para a conta")
+	}
+
+	repositorioUsuarios := controladores.usuarios(orgID)
+	usuarioExistente, erro := repositorioUsuarios.BuscarPorEmail(ctx, identidade.Email)
+	switch {
+	case erro == nil:
+		// Só vincula automaticamente quando o provedor garante que o e-mail pertence ao usuário
+		if !identidade.EmailVerificado {
+			return 0, errors.New("já existe uma conta com este e-mail; entre com sua senha para vinculá-la")
+		}
+		usuarioID = usuarioExistente.ID
+	case errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado):
+		usuarioID, erro = autenticadores.ProvisionarUsuario(ctx, repositorioUsuarios, identidade.Nome, identidade.Nick, identidade.Email)
+		if erro != nil {
+			return 0, erro
+		}
+	default:
+		return 0, erro
+	}
+
+	if erro = repositorioIdentidades.Vincular(ctx, usuarioID, identidade.Provedor, identidade.Sujeito, identidade.Email); erro != nil {
+		return 0, erro
+	}
+
+	return usuarioID, nil
+}
+
+// motivoDaSituacaoDaConta é o motivo, nas métricas de login, da conta recusada por verificarSituacaoDaConta
+func motivoDaSituacaoDaConta(erro error) string {
+	switch {
+	case errors.Is(erro, repositorios.ErrContaDesativada):
+		return metricas.MotivoContaDesativada
+	case errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado):
+		return metricas.MotivoCredenciaisInvalidas
+	default:
+		return metricas.MotivoErro
+	}
+}
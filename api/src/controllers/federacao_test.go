@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"api/src/metricas"
+	"api/src/repositorios"
+	"api/src/testes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// loginsContados lê api_logins_total do /metrics para o resultado e o motivo
+func loginsContados(t *testing.T, resultado, motivo string) float64 {
+	t.Helper()
+
+	resposta := httptest.NewRecorder()
+	metricas.Handler().ServeHTTP(resposta, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	corpo, _ := io.ReadAll(resposta.Body)
+
+	linha := regexp.MustCompile(`api_logins_total\{motivo="` + motivo + `",resultado="` + resultado + `"\} (\S+)`).FindSubmatch(corpo)
+	if linha == nil {
+		return 0
+	}
+	valor, erro := strconv.ParseFloat(string(linha[1]), 64)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	return valor
+}
+
+// TestFalhasDoLoginExternoEntramNasMetricas: as recusas do callback OIDC e do ACS SAML que não chegam ao banco
+// são contadas em api_logins_total, como as do POST /login
+func TestFalhasDoLoginExternoEntramNasMetricas(t *testing.T) {
+	testes.Configurar(t, map[string]string{
+		"OIDC_PROVEDORES":          "teste",
+		"OIDC_TESTE_ISSUER":        "https://idp.exemplo.com",
+		"OIDC_TESTE_CLIENT_ID":     "api",
+		"OIDC_TESTE_CLIENT_SECRET": "segredo",
+	})
+	controladores, _ := novosControladoresDeTeste()
+
+	oidc := func(provedor, consulta string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/login/"+provedor+"/callback?"+consulta, nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		r = mux.SetURLVars(r, map[string]string{"provedor": provedor})
+		w := httptest.NewRecorder()
+		controladores.ConcluirLoginExterno(w, r)
+		return w
+	}
+
+	casos := []struct {
+		nome     string
+		executar func() *httptest.ResponseRecorder
+		status   int
+		motivo   string
+	}{
+		{"provedor OIDC desconhecido", func() *httptest.ResponseRecorder { return oidc("outro", "code=x&state=y", nil) },
+			http.StatusNotFound, metricas.MotivoRequisicaoInvalida},
+		{"recusado pelo provedor OIDC", func() *httptest.ResponseRecorder { return oidc("teste", "error=access_denied", nil) },
+			http.StatusUnauthorized, metricas.MotivoRecusadoPeloProvedor},
+		{"state de outro navegador", func() *httptest.ResponseRecorder {
+			return oidc("teste", "code=x&state=y", &http.Cookie{Name: cookieEstadoOIDC, Value: "z"})
+		}, http.StatusBadRequest, metricas.MotivoRequisicaoInvalida},
+		{"state expirado", func() *httptest.ResponseRecorder {
+			return oidc("teste", "code=x&state=y", &http.Cookie{Name: cookieEstadoOIDC, Value: "y"})
+		}, http.StatusBadRequest, metricas.MotivoRequisicaoInvalida},
+		{"tenant SAML inválido", func() *httptest.ResponseRecorder {
+			r := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/saml/x/acs", nil), map[string]string{"tenant": "Tenant Inválido"})
+			w := httptest.NewRecorder()
+			controladores.ConsumirAssercaoSAML(w, r)
+			return w
+		}, http.StatusNotFound, metricas.MotivoRequisicaoInvalida},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			antes := loginsContados(t, metricas.LoginFalha, caso.motivo)
+			if w := caso.executar(); w.Code != caso.status {
+				t.Fatalf("status = %d, quer %d: %s", w.Code, caso.status, w.Body.String())
+			}
+			if depois := loginsContados(t, metricas.LoginFalha, caso.motivo); depois != antes+1 {
+				t.Errorf("api_logins_total{resultado=falha,motivo=%s} = %v, quer %v", caso.motivo, depois, antes+1)
+			}
+		})
+	}
+}
+
+func TestMotivoDaSituacaoDaConta(t *testing.T) {
+	casos := map[error]string{
+		repositorios.ErrContaDesativada:      metricas.MotivoContaDesativada,
+		repositorios.ErrUsuarioNaoEncontrado: metricas.MotivoCredenciaisInvalidas,
+		errors.New("conexão recusada"):       metricas.MotivoErro,
+	}
+	for erro, quer := range casos {
+		if motivo := motivoDaSituacaoDaConta(erro); motivo != quer {
+			t.Errorf("motivoDaSituacaoDaConta(%v) = %s, quer %s", erro, motivo, quer)
+		}
+	}
+}
@@ -2,186 +2,244 @@ package controllers
 
 import (
 	"api/src/autenticacao"
-	"api/src/banco"
+	"api/src/autenticadores"
+	"api/src/cache"
 	"api/src/config"
+	"api/src/metricas"
 	"api/src/modelos"
 	"api/src/repositorios"
 	"api/src/respostas"
-	"api/src/seguranca"
+	"api/src/tenancia"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"strconv"
-	"time"
-)
-
-var ctx = context.Background()
-
-const (
-	maxLoginAttempts = 5                // Número máximo de tentativas permitidas
-	timeWindow       = 15 * time.Minute // Janela de tempo para resetar tentativas
-	blockTime        = 1 * time.Minute  // Tempo de bloqueio após atingir o limite
 )
 
 // Login autentica um usuário com controle de tentativas e bloqueio
-func Login(w http.ResponseWriter, r *http.Request) {
+func (controladores *Controladores) Login(w http.ResponseWriter, r *http.Request) {
 	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
 	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
 		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
 		return
 	}
 
 	var usuario modelos.Usuario
 	if erro = json.Unmarshal(corpoRequisicao, &usuario); erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
 
-	// Conectar ao Redis
-	rdb := config.RedisClient
+	// Cache dos tokens (Redis, ou a reserva quando ele não está disponível)
+	rdb := cache.Atual()
+	organizacao := tenancia.DaRequisicao(r)
 
-	// Definir as chaves para tentativas de login, bloqueio e token
-	loginKey := "login_attempts:" + usuario.Email
-	blockKey := "login_blocked:" + usuario.Email
-	tokenKey := "auth_token:" + usuario.Email
-	userDataKey := "user_data:" + usuario.Email // Nova chave para armazenar os dados do usuário (id, nome, etc.)
+	// Os tokens ficam no cache pela janela de tentativas (LOGIN_JANELA), recarregável sem reiniciar a API
+	limites := config.Atual().Login
 
-	// Verificar se o token já está armazenado no Redis
-	tokenExistente, err := rdb.Get(ctx, tokenKey).Result()
-	if err == nil && tokenExistente != "" {
-		// Se o token já existir no Redis, podemos retornar o token sem precisar consultar o banco de dados.
-		// Agora, também precisamos verificar se o token está relacionado ao ID correto armazenado no Redis
-		userData, err := rdb.Get(ctx, userDataKey).Result()
-		if err != nil {
-			respostas.Erro(w, http.StatusInternalServerError, errors.New("erro ao recuperar dados do usuário do Redis"))
-			return
-		}
+	// Definir as chaves para o token e os dados do usuário.
+	// O mesmo e-mail pode existir em várias organizações, então as chaves incluem o ID da organização.
+	sufixo := sufixoDeLogin(organizacao.ID, usuario.Email)
+	tokenKey := "auth_token:" + sufixo
+	userDataKey := "user_data:" + sufixo // Nova chave para armazenar os dados do usuário (id, nome, etc.)
 
-		// Aqui, extraímos o ID e nome dos dados armazenados no Redis
-		var usuarioRedis modelos.Usuario
-		erro = json.Unmarshal([]byte(userData), &usuarioRedis)
-		if erro != nil {
-			respostas.Erro(w, http.StatusInternalServerError, erro)
-			return
-		}
-
-		// Log de debug indicando que o login foi feito com Redis
-		log.Println("Login realizado com sucesso usando Redis.")
-
-		// Retornar o token e ID diretamente se o usuário estiver no Redis
-		respostas.JSON(w, http.StatusOK, modelos.DadosAutenticacao{
-			ID:    strconv.FormatUint(usuarioRedis.ID, 10), // Retorna o ID do usuário
-			Token: tokenExistente,
-		})
+	// Verifica o bloqueio e o limite de tentativas antes de checar as credenciais
+	if motivo, erro := conferirTentativasDeSenha(r.Context(), organizacao.ID, usuario.Email); erro != nil {
+		metricas.Login(metricas.LoginFalha, motivo)
+		respostas.Erro(w, http.StatusTooManyRequests, erro)
 		return
 	}
 
-	// Se o token não existir ou houve erro ao obter do Redis, verifica se o usuário está bloqueado
-	blocked, _ := rdb.Get(ctx, blockKey).Result()
-	if blocked == "1" {
-		respostas.Erro(w, http.StatusTooManyRequests, errors.New("muitas tentativas, tente novamente mais tarde"))
+	// As credenciais são sempre verificadas pelos autenticadores configurados (banco, LDAP...)
+	autenticador, erro := autenticadores.Configurados(organizacao, controladores.usuarios(organizacao.ID))
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoErro)
+		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	// Verificar o número de tentativas de login no Redis
-	attempts, _ := rdb.Get(ctx, loginKey).Int()
-	if attempts >= maxLoginAttempts {
-		// Bloquear o usuário por 1 hora no Redis
-		rdb.Set(ctx, blockKey, "1", blockTime)
-		rdb.Del(ctx, loginKey) // Resetar as tentativas
-		respostas.Erro(w, http.StatusTooManyRequests, errors.New("muitas tentativas. Conta bloqueada por 1 minuto"))
+	resultado, erro := autenticador.Autenticar(r.Context(), usuario.Email, usuario.Senha)
+	if errors.Is(erro, autenticadores.ErrCredenciaisInvalidas) {
+		// Incrementar as tentativas de login no cache
+		contarSenhaErrada(r.Context(), organizacao.ID, usuario.Email)
+		metricas.Login(metricas.LoginFalha, metricas.MotivoCredenciaisInvalidas)
+		respostas.Erro(w, http.StatusUnauthorized, erro)
 		return
 	}
-
-	// Conectar ao banco de dados PostgreSQL
-	db, erro := banco.Conectar()
 	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoErro)
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
-	defer db.Close()
 
-	// Buscar o usuário no PostgreSQL, se o Redis não fornecer as informações
-	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	usuarioSalvoNoBanco, erro := repositorio.BuscarPorEmail(usuario.Email)
+	// Conta desativada tem erro próprio e só entra com ?reativar=true; excluída dentro da carência é restaurada
+	erro = controladores.verificarSituacaoDaConta(r.Context(), organizacao.ID, resultado.Usuario.ID, r.URL.Query().Get("reativar") == "true")
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoCredenciaisInvalidas)
+		respostas.Erro(w, http.StatusUnauthorized, autenticadores.ErrCredenciaisInvalidas)
+		return
+	}
+	if errors.Is(erro, repositorios.ErrContaDesativada) {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoContaDesativada)
+		respostas.Erro(w, statusDaSituacaoDaConta(erro), erro)
+		return
+	}
 	if erro != nil {
-		respostas.Erro(w, http.StatusInternalServerError, erro)
+		metricas.Login(metricas.LoginFalha, metricas.MotivoErro)
+		respostas.Erro(w, statusDaSituacaoDaConta(erro), erro)
 		return
 	}
 
-	// Verificar as credenciais do usuário
-	if erro = seguranca.VerificarSenha(usuarioSalvoNoBanco.Senha, usuario.Senha); erro != nil {
-		// Incrementar as tentativas de login no Redis
-		rdb.Incr(ctx, loginKey)
-		rdb.Expire(ctx, loginKey, timeWindow)
-		respostas.Erro(w, http.StatusUnauthorized, errors.New("credenciais inválidas"))
-		return
+	// Se o login for bem-sucedido, resetar tentativas e bloqueio no cache
+	zerarTentativasDeSenha(r.Context(), organizacao.ID, usuario.Email)
+
+	// Converter o ID do usuário para string
+	usuarioID := strconv.FormatUint(resultado.Usuario.ID, 10)
+
+	// Reaproveitar o token já armazenado no cache, se ainda for do mesmo usuário e ainda for válido: a organização
+	// pode ter trocado a chave de assinatura depois que ele foi guardado
+	tokenExistente, err := rdb.Buscar(r.Context(), tokenKey)
+	if err == nil && tokenExistente != "" {
+		var usuarioRedis modelos.Usuario
+		userData, err := rdb.Buscar(r.Context(), userDataKey)
+		_, invalido := autenticacao.ExtrairPermissoes(tokenExistente)
+		if err == nil && invalido == nil && json.Unmarshal([]byte(userData), &usuarioRedis) == nil && usuarioRedis.ID == resultado.Usuario.ID {
+			// Log de debug indicando que o token foi recuperado do cache
+			log.Println("Login realizado com sucesso usando o cache.")
+			controladores.registrarLogin(r, resultado.Usuario.ID, resultado.Origem)
+			metricas.Login(metricas.LoginSucesso, resultado.Origem)
+			respostas.JSON(w, http.StatusOK, modelos.DadosAutenticacao{ID: usuarioID, Token: tokenExistente})
+			return
+		}
 	}
 
-	// Log de debug indicando que o login foi feito com PostgreSQL
-	log.Println("Login realizado com sucesso usando PostgreSQL.")
+	// Log de debug indicando por qual autenticador o login foi feito
+	log.Printf("Login realizado com sucesso usando %s.", resultado.Origem)
 
 	// Gerar o token de autenticação
-	token, erro := autenticacao.CriarToken(usuarioSalvoNoBanco.ID)
+	token, erro := autenticacao.CriarToken(r.Context(), organizacao, resultado.Usuario.ID, resultado.Papeis)
 	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoErro)
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	// Armazenar o token no Redis (expira após algum tempo, por exemplo, 1 hora)
-	rdb.Set(ctx, tokenKey, token, timeWindow)
+	// Armazenar o token no cache (expira após algum tempo, por exemplo, 1 hora)
+	rdb.Salvar(r.Context(), tokenKey, token, limites.JanelaTentativas)
 
-	// Armazenar os dados do usuário no Redis (ID, nome, etc.)
+	// Armazenar os dados do usuário no cache (ID, nome, etc.)
 	usuarioRedis := modelos.Usuario{
-		ID:    usuarioSalvoNoBanco.ID,
-		Nome:  usuarioSalvoNoBanco.Nome,
-		Email: usuarioSalvoNoBanco.Email,
+		ID:    resultado.Usuario.ID,
+		Nome:  resultado.Usuario.Nome,
+		Email: resultado.Usuario.Email,
 	}
 
 	usuarioRedisData, err := json.Marshal(usuarioRedis)
 	if err != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoErro)
 		respostas.Erro(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	rdb.Set(ctx, userDataKey, usuarioRedisData, timeWindow)
+	rdb.Salvar(r.Context(), userDataKey, string(usuarioRedisData), limites.JanelaTentativas)
+	controladores.registrarLogin(r, resultado.Usuario.ID, resultado.Origem)
+	metricas.Login(metricas.LoginSucesso, resultado.Origem)
 
-	// Se o login for bem-sucedido, resetar tentativas e bloqueio no Redis
-	rdb.Del(ctx, loginKey, blockKey)
-
-	// Converter o ID do usuário para string e retornar o token
-	usuarioID := strconv.FormatUint(usuarioSalvoNoBanco.ID, 10)
 	respostas.JSON(w, http.StatusOK, modelos.DadosAutenticacao{ID: usuarioID, Token: token})
 }
 
+// conferirTentativasDeSenha recusa a verificação de uma senha quando o e-mail está bloqueado ou passou de
+// LOGIN_MAX_TENTATIVAS; nesse caso grava o bloqueio por LOGIN_BLOQUEIO. As tentativas são as mesmas no login e
+// no aceite de convites com a senha da conta. Retorna o motivo da recusa para as métricas do login.
+func conferirTentativasDeSenha(ctx context.Context, orgID uint64, email string) (string, error) {
+	rdb := cache.Atual()
+	limites := config.Atual().Login
+	sufixo := sufixoDeLogin(orgID, email)
+
+	if bloqueado, _ := rdb.Buscar(ctx, "login_blocked:"+sufixo); bloqueado == "1" {
+		return metricas.MotivoBloqueado, errors.New("muitas tentativas, tente novamente mais tarde")
+	}
+
+	valor, _ := rdb.Buscar(ctx, "login_attempts:"+sufixo)
+	if tentativas, _ := strconv.Atoi(valor); tentativas >= limites.MaxTentativas {
+		// Só a primeira requisição que passar do limite grava o bloqueio
+		if bloqueou, _ := rdb.CompararETrocar(ctx, "login_blocked:"+sufixo, "", "1", limites.TempoBloqueio); bloqueou {
+			metricas.Bloqueio()
+		}
+		rdb.Apagar(ctx, "login_attempts:"+sufixo) // Resetar as tentativas
+		return metricas.MotivoLimiteTentativas, fmt.Errorf("muitas tentativas. Conta bloqueada por %v", limites.TempoBloqueio)
+	}
+
+	return "", nil
+}
+
+// contarSenhaErrada soma uma tentativa errada na janela de LOGIN_JANELA
+func contarSenhaErrada(ctx context.Context, orgID uint64, email string) {
+	cache.Atual().Incrementar(ctx, "login_attempts:"+sufixoDeLogin(orgID, email), config.Atual().Login.JanelaTentativas)
+}
+
+// zerarTentativasDeSenha apaga as tentativas e o bloqueio depois de uma senha correta
+func zerarTentativasDeSenha(ctx context.Context, orgID uint64, email string) {
+	sufixo := sufixoDeLogin(orgID, email)
+	cache.Atual().Apagar(ctx, "login_attempts:"+sufixo, "login_blocked:"+sufixo)
+}
+
+// registrarLogin guarda o login aceito no histórico que o titular recebe na exportação dos dados.
+// O login já foi aceito: uma falha ao gravar só vai para o log. Sem banco (usuários em memória) não há histórico.
+func (controladores *Controladores) registrarLogin(r *http.Request, usuarioID uint64, origem string) {
+	if controladores.db == nil {
+		return
+	}
+
+	ip, _, erro := net.SplitHostPort(r.RemoteAddr)
+	if erro != nil {
+		ip = r.RemoteAddr
+	}
+
+	historico := repositorios.NovoRepositorioDeHistoricoDeLogins(controladores.db, tenancia.DaRequisicao(r).ID)
+	if erro = historico.Registrar(r.Context(), usuarioID, modelos.LoginRegistrado{Origem: origem, IP: ip}); erro != nil {
+		log.Printf("Erro ao registrar o login do usuário %d no histórico: %v", usuarioID, erro)
+	}
+}
+
+// sufixoDeLogin monta a parte final das chaves do cache usadas pelo login de um e-mail
+func sufixoDeLogin(orgID uint64, email string) string {
+	return fmt.Sprintf("%d:%s", orgID, email)
+}
+
 // LoginAnonimo gera um token para um usuário anônimo
-func LoginAnonimo(w http.ResponseWriter, r *http.Request) {
-	// Conectar ao Redis
-	rdb := config.RedisClient
+func (controladores *Controladores) LoginAnonimo(w http.ResponseWriter, r *http.Request) {
+	rdb := cache.Atual()
+
+	organizacao := tenancia.DaRequisicao(r)
 
 	// Chave única para o login anônimo, pode ser um ID único gerado para o usuário anônimo (UUID, por exemplo)
-	anonimoKey := "anonimo_token:" + r.RemoteAddr // Usando o IP ou algum identificador único
+	anonimoKey := fmt.Sprintf("anonimo_token:%d:%s", organizacao.ID, r.RemoteAddr) // Usando o IP ou algum identificador único
 
-	// Tenta buscar o token no Redis para o usuário anônimo
-	tokenExistente, err := rdb.Get(ctx, anonimoKey).Result()
+	// Tenta buscar o token no cache para o usuário anônimo
+	tokenExistente, err := rdb.Buscar(r.Context(), anonimoKey)
 	if err == nil && tokenExistente != "" {
-		// Se o token já existir no Redis, retorna ele diretamente
+		// Se o token já existir no cache, retorna ele diretamente
 		respostas.JSON(w, http.StatusOK, map[string]string{"token": tokenExistente})
 		return
 	}
 
 	// Caso não exista, cria um novo token anônimo
-	token, erro := autenticacao.CriarTokenAnonimo()
+	token, erro := autenticacao.CriarTokenAnonimo(organizacao)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	// Armazenar o token no Redis, com tempo de expiração, por exemplo, 15 minutos
-	rdb.Set(ctx, anonimoKey, token, timeWindow)
+	// Armazenar o token no cache, com tempo de expiração, por exemplo, 15 minutos
+	rdb.Salvar(r.Context(), anonimoKey, token, config.Atual().Login.JanelaTentativas)
 
 	// Retorna o token gerado
 	respostas.JSON(w, http.StatusOK, map[string]string{"token": token})
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"api/src/metricas"
+	"api/src/testes"
+	"context"
+	"testing"
+)
+
+// TestTentativasDeSenhaBloqueiam: o login e o aceite de convites contam as senhas erradas juntos; passar do limite
+// bloqueia o e-mail até uma senha correta ou o fim do bloqueio
+func TestTentativasDeSenhaBloqueiam(t *testing.T) {
+	testes.Configurar(t, map[string]string{"LOGIN_MAX_TENTATIVAS": "2", "LOGIN_BLOQUEIO": "1m"})
+	ctx := context.Background()
+	email := "tentativas@exemplo.com"
+	t.Cleanup(func() { zerarTentativasDeSenha(ctx, organizacaoDeTeste.ID, email) })
+
+	for i := 0; i < 2; i++ {
+		if _, erro := conferirTentativasDeSenha(ctx, organizacaoDeTeste.ID, email); erro != nil {
+			t.Fatalf("tentativa %d recusada: %v", i+1, erro)
+		}
+		contarSenhaErrada(ctx, organizacaoDeTeste.ID, email)
+	}
+
+	for _, quer := range []string{metricas.MotivoLimiteTentativas, metricas.MotivoBloqueado} {
+		if motivo, erro := conferirTentativasDeSenha(ctx, organizacaoDeTeste.ID, email); erro == nil || motivo != quer {
+			t.Errorf("motivo = %q, %v; quer %q", motivo, erro, quer)
+		}
+	}
+
+	// O mesmo e-mail em outra organização tem as suas próprias tentativas
+	if _, erro := conferirTentativasDeSenha(ctx, organizacaoDeTeste.ID+1, email); erro != nil {
+		t.Errorf("outra organização bloqueada: %v", erro)
+	}
+
+	zerarTentativasDeSenha(ctx, organizacaoDeTeste.ID, email)
+	if _, erro := conferirTentativasDeSenha(ctx, organizacaoDeTeste.ID, email); erro != nil {
+		t.Errorf("ainda bloqueado depois de zerar: %v", erro)
+	}
+}
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/tenancia"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// BuscarMembros lista a equipe da organização da requisição
+func (controladores *Controladores) BuscarMembros(w http.ResponseWriter, r *http.Request) {
+	db := controladores.db
+
+	// Qualquer membro pode ver a equipe
+	_, papel, erro := papelDoSolicitante(r, db)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if papel == "" {
+		if status, erro := exigirGestaoDeMembros(r, papel); erro != nil {
+			respostas.Erro(w, status, erro)
+			return
+		}
+	}
+
+	membros, erro := repositorios.NovoRepositorioDeMembros(db, tenancia.DaRequisicao(r).ID).Listar(r.Context())
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, membros)
+}
+
+// AlterarPapelDoMembro troca o papel (admin ou membro) de um membro da equipe
+func (controladores *Controladores) AlterarPapelDoMembro(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	corpoRequisicao, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var alteracao modelos.AtribuicaoDePapel
+	if erro = json.Unmarshal(corpoRequisicao, &alteracao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if !modelos.PapelDeMembroAtribuivel(alteracao.Papel) {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("papel inválido: use admin ou membro; para o dono, transfira a propriedade"))
+		return
+	}
+
+	db := controladores.db
+
+	_, papel, erro := papelDoSolicitante(r, db)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if status, erro := exigirGestaoDeMembros(r, papel); erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	repositorio := repositorios.NovoRepositorioDeMembros(db, tenancia.DaRequisicao(r).ID)
+	if erro = repositorio.AlterarPapel(r.Context(), usuarioID, alteracao.Papel); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// RemoverMembro tira um usuário da equipe. O próprio membro também pode sair.
+func (controladores *Controladores) RemoverMembro(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	db := controladores.db
+
+	solicitanteID, papel, erro := papelDoSolicitante(r, db)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if solicitanteID != usuarioID {
+		if status, erro := exigirGestaoDeMembros(r, papel); erro != nil {
+			respostas.Erro(w, status, erro)
+			return
+		}
+	}
+
+	repositorio := repositorios.NovoRepositorioDeMembros(db, tenancia.DaRequisicao(r).ID)
+	if erro = repositorio.Remover(r.Context(), usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// TransferirPropriedade passa a organização para outro membro; o dono atual vira admin
+func (controladores *Controladores) TransferirPropriedade(w http.ResponseWriter, r *http.Request) {
+	corpoRequisicao, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var transferencia modelos.TransferenciaDePropriedade
+	if erro = json.Unmarshal(corpoRequisicao, &transferencia); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if transferencia.UsuarioID == 0 {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("o usuarioId do novo dono é obrigatório"))
+		return
+	}
+
+	db := controladores.db
+
+	solicitanteID, papel, erro := papelDoSolicitante(r, db)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if papel != modelos.PapelDono {
+		respostas.Erro(w, http.StatusForbidden, errors.New("apenas o dono pode transferir a propriedade da organização"))
+		return
+	}
+
+	repositorio := repositorios.NovoRepositorioDeMembros(db, tenancia.DaRequisicao(r).ID)
+	if erro = repositorio.TransferirPropriedade(r.Context(), solicitanteID, transferencia.UsuarioID); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// papelDoSolicitante retorna o usuário do token e o papel dele na equipe ("" se não for membro)
+func papelDoSolicitante(r *http.Request, db *sql.DB) (uint64, string, error) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		return 0, "", erro
+	}
+	if usuarioID == 0 {
+		return 0, "", errors.New("login anônimo não pode gerenciar a organização")
+	}
+
+	papel, erro := repositorios.NovoRepositorioDeMembros(db, tenancia.DaRequisicao(r).ID).BuscarPapel(r.Context(), usuarioID)
+	if errors.Is(erro, repositorios.ErrMembroNaoEncontrado) {
+		return usuarioID, "", nil
+	}
+	if erro != nil {
+		return 0, "", erro
+	}
+
+	return usuarioID, papel, nil
+}
+
+// exigirGestaoDeMembros permite donos e admins da equipe ou quem tem a permissão de RBAC membros:gerenciar
+func exigirGestaoDeMembros(r *http.Request, papel string) (int, error) {
+	if papel == modelos.PapelDono || papel == modelos.PapelAdmin {
+		return 0, nil
+	}
+
+	possui, erro := autenticacao.PossuiPermissao(r, "membros:gerenciar")
+	if erro != nil {
+		return http.StatusUnauthorized, erro
+	}
+	if !possui {
+		return http.StatusForbidden, errors.New("apenas donos e admins da organização podem gerenciar a equipe")
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"api/src/config"
+	"api/src/metricas"
+	"api/src/respostas"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Metricas expõe as métricas no formato do Prometheus. Com METRICAS_TOKEN definido, só responde a quem
+// envia Authorization: Bearer com o mesmo valor.
+func Metricas(w http.ResponseWriter, r *http.Request) {
+	if config.Atual().Servidor.TokenMetricas != "" && !comTokenDeMetricas(r) {
+		respostas.Erro(w, http.StatusUnauthorized, errors.New("token de métricas inválido"))
+		return
+	}
+
+	metricas.Handler().ServeHTTP(w, r)
+}
+
+// comTokenDeMetricas diz se a requisição traz Authorization: Bearer com o METRICAS_TOKEN. Sem o token
+// configurado ninguém o apresenta.
+func comTokenDeMetricas(r *http.Request) bool {
+	token := config.Atual().Servidor.TokenMetricas
+	if token == "" {
+		return false
+	}
+	recebido := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(recebido), []byte(token)) == 1
+}
@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/seguranca"
+	"api/src/tenancia"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	grantTypeTrocaDeToken = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tipoTokenAcesso       = "urn:ietf:params:oauth:token-type:access_token"
+	tipoTokenJWT          = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// TrocarToken implementa a troca de tokens da RFC 8693 para delegação e personificação
+func (controladores *Controladores) TrocarToken(w http.ResponseWriter, r *http.Request) {
+	if erro := r.ParseForm(); erro != nil {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_request", "corpo da requisição inválido")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != grantTypeTrocaDeToken {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "unsupported_grant_type", "grant_type não suportado")
+		return
+	}
+
+	clientID, segredo, ok := r.BasicAuth()
+	if !ok {
+		clientID, segredo = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" {
+		respostas.ErroOAuth(w, http.StatusUnauthorized, "invalid_client", "autenticação do cliente é obrigatória")
+		return
+	}
+
+	db := controladores.db
+
+	repositorio := repositorios.NovoRepositorioDeClientesOAuth(db)
+	cliente, erro := repositorio.BuscarPorClientID(r.Context(), clientID)
+	if erro != nil || seguranca.VerificarSenha(cliente.Segredo, segredo) != nil {
+		respostas.ErroOAuth(w, http.StatusUnauthorized, "invalid_client", "credenciais do cliente inválidas")
+		return
+	}
+
+	if !tipoDeTokenSuportado(r.PostForm.Get("subject_token_type")) {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_request", "subject_token_type não suportado")
+		return
+	}
+
+	tipoSolicitado := r.PostForm.Get("requested_token_type")
+	if tipoSolicitado != "" && !tipoDeTokenSuportado(tipoSolicitado) {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_request", "requested_token_type não suportado")
+		return
+	}
+
+	permissoesDoSujeito, erro := autenticacao.ExtrairPermissoes(r.PostForm.Get("subject_token"))
+	if erro != nil {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_grant", "subject_token inválido")
+		return
+	}
+
+	usuarioID, erro := autenticacao.UsuarioIDDasPermissoes(permissoesDoSujeito)
+	if erro != nil {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_grant", erro.Error())
+		return
+	}
+
+	// O token emitido continua na organização do token original, que precisa ser a da requisição
+	organizacao := tenancia.DaRequisicao(r)
+	if autenticacao.OrganizacaoDasPermissoes(permissoesDoSujeito) != organizacao.ID {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_grant", "subject_token emitido para outra organização")
+		return
+	}
+
+	// Sem actor_token a troca é uma personificação; com ele, uma delegação registrada na claim "act"
+	var ator map[string]interface{}
+	if tokenDoAtor := r.PostForm.Get("actor_token"); tokenDoAtor != "" {
+		if !cliente.PermiteDelegacao {
+			respostas.ErroOAuth(w, http.StatusBadRequest, "unauthorized_client", "cliente não pode realizar delegação")
+			return
+		}
+
+		if !tipoDeTokenSuportado(r.PostForm.Get("actor_token_type")) {
+			respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_request", "actor_token_type não suportado")
+			return
+		}
+
+		permissoesDoAtor, erro := autenticacao.ExtrairPermissoes(tokenDoAtor)
+		if erro != nil {
+			respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_grant", "actor_token inválido")
+			return
+		}
+
+		atorID, erro := autenticacao.UsuarioIDDasPermissoes(permissoesDoAtor)
+		if erro != nil {
+			respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_grant", erro.Error())
+			return
+		}
+
+		if autenticacao.OrganizacaoDasPermissoes(permissoesDoAtor) != organizacao.ID {
+			respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_grant", "actor_token emitido para outra organização")
+			return
+		}
+
+		ator = autenticacao.NovoAtor(atorID, permissoesDoSujeito)
+	} else if !cliente.PermitePersonificacao {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "unauthorized_client", "cliente não pode realizar personificação")
+		return
+	}
+
+	var audiencias []string
+	audiencias = append(audiencias, r.PostForm["audience"]...)
+	audiencias = append(audiencias, r.PostForm["resource"]...)
+	if len(audiencias) == 0 {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_target", "audience é obrigatório")
+		return
+	}
+	for _, audiencia := range audiencias {
+		if !cliente.PermiteAudiencia(audiencia) {
+			respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_target", "audiência não permitida: "+audiencia)
+			return
+		}
+	}
+
+	escopos, erro := reduzirEscopos(cliente, autenticacao.EscoposDasPermissoes(permissoesDoSujeito), strings.Fields(r.PostForm.Get("scope")))
+	if erro != nil {
+		respostas.ErroOAuth(w, http.StatusBadRequest, "invalid_scope", erro.Error())
+		return
+	}
+
+	// O token emitido nunca vive mais que o token original nem que o limite do cliente
+	expiracao := time.Now().Add(cliente.DuracaoMaxima)
+	if expiracaoDoSujeito := autenticacao.ExpiracaoDasPermissoes(permissoesDoSujeito); !expiracaoDoSujeito.IsZero() && expiracaoDoSujeito.Before(expiracao) {
+		expiracao = expiracaoDoSujeito
+	}
+
+	token, erro := autenticacao.CriarTokenDelegado(autenticacao.Delegacao{
+		Organizacao:    organizacao,
+		UsuarioID:      usuarioID,
+		ClientID:       cliente.ClientID,
+		Audiencias:     audiencias,
+		Escopos:        escopos,
+		PapeisExternos: autenticacao.PapeisExternos(permissoesDoSujeito),
+		Ator:           ator,
+		Expiracao:      expiracao,
+	})
+	if erro != nil {
+		respostas.ErroOAuth(w, http.StatusInternalServerError, "server_error", "erro ao gerar o token")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respostas.JSON(w, http.StatusOK, modelos.RespostaTrocaToken{
+		AccessToken:     token,
+		IssuedTokenType: tipoTokenAcesso,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(time.Until(expiracao).Seconds()),
+		Scope:           strings.Join(escopos, " "),
+	})
+}
+
+func tipoDeTokenSuportado(tipo string) bool {
+	return tipo == tipoTokenAcesso || tipo == tipoTokenJWT
+}
+
+// reduzirEscopos garante que o token emitido tenha no máximo os escopos do token original e do cliente
+func reduzirEscopos(cliente modelos.ClienteOAuth, escoposDoSujeito, solicitados []string) ([]string, error) {
+	disponiveis := make(map[string]bool)
+	for _, escopo := range cliente.Escopos {
+		disponiveis[escopo] = escoposDoSujeito == nil
+	}
+	for _, escopo := range escoposDoSujeito {
+		if cliente.PermiteEscopo(escopo) {
+			disponiveis[escopo] = true
+		}
+	}
+
+	if len(solicitados) == 0 {
+		var concedidos []string
+		for _, escopo := range cliente.Escopos {
+			if disponiveis[escopo] {
+				concedidos = append(concedidos, escopo)
+			}
+		}
+		return concedidos, nil
+	}
+
+	for _, escopo := range solicitados {
+		if !disponiveis[escopo] {
+			return nil, errors.New("escopo não permitido: " + escopo)
+		}
+	}
+
+	return solicitados, nil
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/tenancia"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// CriarOrganizacao cria uma organização e a conta de quem a criou, que entra na equipe como dono.
+// A conta é da nova organização: o login é feito com X-Organizacao ou /o/<slug>.
+func (controladores *Controladores) CriarOrganizacao(w http.ResponseWriter, r *http.Request) {
+	corpoRequisicao, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var cadastro modelos.CadastroDeOrganizacao
+	if erro = json.Unmarshal(corpoRequisicao, &cadastro); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = cadastro.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	organizacao := modelos.Organizacao{Slug: cadastro.Slug, Nome: cadastro.Nome, PoliticaDeSenha: modelos.PoliticaDeSenhaPadrao}
+	repositorio := repositorios.NovoRepositorioDeOrganizacoes(controladores.db)
+
+	var donoID uint64
+	organizacao.ID, donoID, erro = repositorio.Criar(r.Context(), organizacao, cadastro.Dono)
+	if erro != nil {
+		respostas.Erro(w, statusDoErroDeBanco(erro), erro)
+		return
+	}
+
+	// Uma busca pelo slug antes da criação deixou a ausência no cache
+	tenancia.Invalidar(organizacao)
+
+	respostas.JSON(w, http.StatusCreated, modelos.OrganizacaoCriada{
+		Organizacao: organizacao,
+		Dono: modelos.Membro{
+			UsuarioID: donoID,
+			Nome:      cadastro.Dono.Nome,
+			Nick:      cadastro.Dono.Nick,
+			Email:     cadastro.Dono.Email,
+			Papel:     modelos.PapelDono,
+			CriadoEm:  time.Now(),
+		},
+	})
+}
+
+// AtualizarConfiguracoesDaOrganizacao troca a política de senha, a duração dos tokens e a chave de assinatura da
+// organização da requisição. Trocar a chave invalida os tokens já emitidos pela organização.
+func (controladores *Controladores) AtualizarConfiguracoesDaOrganizacao(w http.ResponseWriter, r *http.Request) {
+	corpoRequisicao, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var configuracoes modelos.ConfiguracoesDaOrganizacao
+	if erro = json.Unmarshal(corpoRequisicao, &configuracoes); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	organizacao := tenancia.DaRequisicao(r)
+	if erro = configuracoes.Aplicar(&organizacao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repositorio := repositorios.NovoRepositorioDeOrganizacoes(controladores.db)
+	if erro = repositorio.AtualizarConfiguracoes(r.Context(), organizacao); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	tenancia.Invalidar(organizacao)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
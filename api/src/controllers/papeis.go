@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/tenancia"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// BuscarPapeis lista os papéis do sistema e os da organização, com as suas permissões
+func (controladores *Controladores) BuscarPapeis(w http.ResponseWriter, r *http.Request) {
+	db := controladores.db
+
+	papeis, erro := repositorios.NovoRepositorioDePapeis(db, tenancia.DaRequisicao(r).ID).Listar(r.Context())
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, papeis)
+}
+
+// CriarPapel cadastra um papel próprio da organização com as permissões informadas
+func (controladores *Controladores) CriarPapel(w http.ResponseWriter, r *http.Request) {
+	corpoRequisicao, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var papel modelos.Papel
+	if erro = json.Unmarshal(corpoRequisicao, &papel); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	papel.Nome = strings.TrimSpace(papel.Nome)
+	if papel.Nome == "" {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("o nome do papel é obrigatório"))
+		return
+	}
+
+	papel.ID, erro = repositorios.NovoRepositorioDePapeis(controladores.db, tenancia.DaRequisicao(r).ID).Criar(r.Context(), papel)
+	if errors.Is(erro, repositorios.ErrPermissaoDesconhecida) {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, statusDoErroDeBanco(erro), erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusCreated, papel)
+}
+
+// ExcluirPapel apaga um papel da organização; os papéis do sistema não podem ser excluídos
+func (controladores *Controladores) ExcluirPapel(w http.ResponseWriter, r *http.Request) {
+	erro := repositorios.NovoRepositorioDePapeis(controladores.db, tenancia.DaRequisicao(r).ID).Excluir(r.Context(), mux.Vars(r)["papel"])
+	if errors.Is(erro, repositorios.ErrPapelNaoEncontrado) {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	// Quem tinha o papel perde as permissões dele na próxima requisição
+	autenticacao.InvalidarAcessos(r.Context(), tenancia.DaRequisicao(r).ID)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarPapeisDoUsuario lista os papéis atribuídos a um usuário
+func (controladores *Controladores) BuscarPapeisDoUsuario(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	db := controladores.db
+
+	if erro = controladores.verificarUsuarioDaOrganizacao(db, r, usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	papeis, erro := repositorios.NovoRepositorioDePapeis(db, tenancia.DaRequisicao(r).ID).BuscarDoUsuario(r.Context(), usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, papeis)
+}
+
+// AtribuirPapel concede um papel a um usuário
+func (controladores *Controladores) AtribuirPapel(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	corpoRequisicao, erro := io.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var atribuicao modelos.AtribuicaoDePapel
+	if erro = json.Unmarshal(corpoRequisicao, &atribuicao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if atribuicao.Papel == "" {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("o papel é obrigatório"))
+		return
+	}
+
+	db := controladores.db
+
+	if erro = controladores.verificarUsuarioDaOrganizacao(db, r, usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	if erro = repositorios.NovoRepositorioDePapeis(db, tenancia.DaRequisicao(r).ID).Atribuir(r.Context(), usuarioID, atribuicao.Papel); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	controladores.registrarAlteracaoDePapel(r, usuarioID, modelos.AuditoriaPapelAtribuido, atribuicao.Papel)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// RemoverPapel retira um papel de um usuário
+func (controladores *Controladores) RemoverPapel(w http.ResponseWriter, r *http.Request) {
+	parametros := mux.Vars(r)
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	// Evita que o último administrador tire o próprio acesso por engano
+	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if usuarioIDNoToken == usuarioID && parametros["papel"] == "admin" {
+		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível remover o próprio papel de admin"))
+		return
+	}
+
+	db := controladores.db
+
+	if erro = controladores.verificarUsuarioDaOrganizacao(db, r, usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	if erro = repositorios.NovoRepositorioDePapeis(db, tenancia.DaRequisicao(r).ID).Remover(r.Context(), usuarioID, parametros["papel"]); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	controladores.registrarAlteracaoDePapel(r, usuarioID, modelos.AuditoriaPapelRemovido, parametros["papel"])
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// registrarAlteracaoDePapel faz a alteração valer na próxima requisição do usuário e a grava na auditoria.
+// A alteração já foi feita: uma falha na auditoria só é registrada no log.
+func (controladores *Controladores) registrarAlteracaoDePapel(r *http.Request, usuarioID uint64, acao, papel string) {
+	organizacao := tenancia.DaRequisicao(r)
+	autenticacao.InvalidarAcessos(r.Context(), organizacao.ID)
+
+	evento := modelos.EventoDeAuditoria{UsuarioID: usuarioID, Acao: acao, Detalhes: map[string]string{"papel": papel}}
+	if atorID, erro := autenticacao.ExtrairUsuarioID(r); erro == nil && atorID != 0 {
+		evento.AtorID = &atorID
+	}
+	if erro := repositorios.NovoRepositorioDeAuditoria(controladores.db, organizacao.ID).Registrar(r.Context(), evento); erro != nil {
+		log.Printf("Erro ao registrar %s do papel %s do usuário %d na auditoria: %v", acao, papel, usuarioID, erro)
+	}
+}
+
+// verificarUsuarioDaOrganizacao impede que um administrador altere usuários de outra organização
+func (controladores *Controladores) verificarUsuarioDaOrganizacao(db *sql.DB, r *http.Request, usuarioID uint64) error {
+	_, erro := controladores.usuarios(tenancia.DaRequisicao(r).ID).BuscarPorID(r.Context(), usuarioID)
+	return erro
+}
+
+// verificarDonoOuPermissao permite a ação quando o token é do próprio usuário ou concede a permissão informada.
+// Com permissão vazia, apenas o próprio usuário é autorizado.
+func verificarDonoOuPermissao(r *http.Request, usuarioID uint64, permissao string) (int, error) {
+	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		return http.StatusUnauthorized, erro
+	}
+
+	if usuarioIDNoToken != 0 && usuarioIDNoToken == usuarioID {
+		return 0, nil
+	}
+
+	if permissao != "" {
+		possui, erro := autenticacao.PossuiPermissao(r, permissao)
+		if erro != nil {
+			return http.StatusUnauthorized, erro
+		}
+		if possui {
+			return 0, nil
+		}
+	}
+
+	return http.StatusForbidden, errors.New("você não tem permissão para alterar outro usuário")
+}
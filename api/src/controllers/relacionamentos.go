@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/tenancia"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// BloquearUsuario faz o usuário do token bloquear o usuário da URL, desfazendo os vínculos entre os dois
+func (controladores *Controladores) BloquearUsuario(w http.ResponseWriter, r *http.Request) {
+	controladores.alterarRelacionamento(w, r, repositorios.RepositorioUsuarios.Bloquear)
+}
+
+// DesbloquearUsuario remove o bloqueio feito pelo usuário do token
+func (controladores *Controladores) DesbloquearUsuario(w http.ResponseWriter, r *http.Request) {
+	controladores.alterarRelacionamento(w, r, repositorios.RepositorioUsuarios.Desbloquear)
+}
+
+// SilenciarUsuario esconde o usuário da URL das buscas do usuário do token
+func (controladores *Controladores) SilenciarUsuario(w http.ResponseWriter, r *http.Request) {
+	controladores.alterarRelacionamento(w, r, repositorios.RepositorioUsuarios.Silenciar)
+}
+
+// DessilenciarUsuario volta a mostrar o usuário silenciado
+func (controladores *Controladores) DessilenciarUsuario(w http.ResponseWriter, r *http.Request) {
+	controladores.alterarRelacionamento(w, r, repositorios.RepositorioUsuarios.Dessilenciar)
+}
+
+func (controladores *Controladores) alterarRelacionamento(w http.ResponseWriter, r *http.Request, operacao func(repositorios.RepositorioUsuarios, context.Context, uint64, uint64) error) {
+	outroID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if usuarioID == 0 {
+		respostas.Erro(w, http.StatusForbidden, errors.New("login anônimo não pode bloquear ou silenciar usuários"))
+		return
+	}
+
+	orgID := tenancia.DaRequisicao(r).ID
+	repositorio := controladores.usuarios(orgID)
+	erro = operacao(repositorio, r.Context(), usuarioID, outroID)
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	// Bloqueios desfazem vínculos nos dois sentidos
+	invalidarSugestoes(r.Context(), orgID, usuarioID, outroID)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarSolicitacoes lista os pedidos pendentes para seguir a conta privada do usuário
+func (controladores *Controladores) BuscarSolicitacoes(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, ""); status != 0 {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	repositorio := controladores.usuarios(tenancia.DaRequisicao(r).ID)
+	usuarios, erro := repositorio.BuscarSolicitacoes(r.Context(), usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	for i := range usuarios {
+		usuarios[i].Email = ""
+	}
+
+	respostas.JSON(w, http.StatusOK, usuarios)
+}
+
+// AprovarSolicitacao aceita o pedido de seguidorId para seguir a conta privada
+func (controladores *Controladores) AprovarSolicitacao(w http.ResponseWriter, r *http.Request) {
+	controladores.responderSolicitacao(w, r, repositorios.RepositorioUsuarios.AprovarSolicitacao)
+}
+
+// RejeitarSolicitacao descarta o pedido de seguidorId
+func (controladores *Controladores) RejeitarSolicitacao(w http.ResponseWriter, r *http.Request) {
+	controladores.responderSolicitacao(w, r, repositorios.RepositorioUsuarios.RejeitarSolicitacao)
+}
+
+func (controladores *Controladores) responderSolicitacao(w http.ResponseWriter, r *http.Request, operacao func(repositorios.RepositorioUsuarios, context.Context, uint64, uint64) error) {
+	parametros := mux.Vars(r)
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	seguidorID, erro := strconv.ParseUint(parametros["seguidorId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, ""); status != 0 {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	orgID := tenancia.DaRequisicao(r).ID
+	repositorio := controladores.usuarios(orgID)
+	if erro = operacao(repositorio, r.Context(), usuarioID, seguidorID); erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	invalidarSugestoes(r.Context(), orgID, seguidorID)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"api/src/modelos"
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestBloquearUsuario(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	ana, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Senha: "hash"})
+	bruno, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Bruno", Nick: "bruno", Email: "bruno@exemplo.com", Senha: "hash"})
+	deAna := map[string]string{"usuarioId": strconv.FormatUint(ana, 10)}
+	deBruno := map[string]string{"usuarioId": strconv.FormatUint(bruno, 10)}
+
+	if w := requisitarComo(t, bruno, controladores.SeguirUsuario, http.MethodPost, "/", nil, deAna); w.Code != http.StatusOK {
+		t.Fatalf("seguir: status %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := requisitarComo(t, ana, controladores.BloquearUsuario, http.MethodPost, "/", nil, deAna); w.Code != http.StatusBadRequest {
+		t.Errorf("bloquear a si mesmo: status %d, quer 400", w.Code)
+	}
+	if w := requisitarComo(t, 0, controladores.BloquearUsuario, http.MethodPost, "/", nil, deBruno); w.Code != http.StatusForbidden {
+		t.Errorf("login anônimo: status %d, quer 403", w.Code)
+	}
+	if w := requisitarComo(t, ana, controladores.BloquearUsuario, http.MethodPost, "/", nil, deBruno); w.Code != http.StatusNoContent {
+		t.Fatalf("bloquear: status %d: %s", w.Code, w.Body.String())
+	}
+
+	// O bloqueio desfaz o vínculo, impede um novo e esconde o perfil nos dois sentidos
+	if quantidade, _ := repositorio.QuantidadeSeguidores(ctx, ana); quantidade != 0 {
+		t.Errorf("seguidores depois do bloqueio = %d, quer 0", quantidade)
+	}
+	if w := requisitarComo(t, bruno, controladores.SeguirUsuario, http.MethodPost, "/", nil, deAna); w.Code != http.StatusForbidden {
+		t.Errorf("seguir quem bloqueou: status %d, quer 403", w.Code)
+	}
+	if w := requisitarComo(t, bruno, controladores.BuscarUsuario, http.MethodGet, "/", nil, deAna); w.Code != http.StatusNotFound {
+		t.Errorf("perfil de quem bloqueou: status %d, quer 404", w.Code)
+	}
+	if w := requisitarComo(t, ana, controladores.BuscarUsuario, http.MethodGet, "/", nil, deBruno); w.Code != http.StatusNotFound {
+		t.Errorf("perfil do bloqueado: status %d, quer 404", w.Code)
+	}
+
+	// Só quem bloqueou pode desbloquear, e os vínculos desfeitos não voltam
+	if w := requisitarComo(t, bruno, controladores.DesbloquearUsuario, http.MethodDelete, "/", nil, deAna); w.Code != http.StatusBadRequest {
+		t.Errorf("desbloquear sem ter bloqueado: status %d, quer 400", w.Code)
+	}
+	if w := requisitarComo(t, ana, controladores.DesbloquearUsuario, http.MethodDelete, "/", nil, deBruno); w.Code != http.StatusNoContent {
+		t.Fatalf("desbloquear: status %d: %s", w.Code, w.Body.String())
+	}
+	if quantidade, _ := repositorio.QuantidadeSeguidores(ctx, ana); quantidade != 0 {
+		t.Errorf("seguidores depois do desbloqueio = %d, quer 0", quantidade)
+	}
+	if w := requisitarComo(t, bruno, controladores.SeguirUsuario, http.MethodPost, "/", nil, deAna); w.Code != http.StatusOK {
+		t.Errorf("seguir depois do desbloqueio: status %d, quer 200", w.Code)
+	}
+}
+
+func TestSilenciarUsuario(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	ana, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Senha: "hash"})
+	bruno, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Bruno", Nick: "bruno", Email: "bruno@exemplo.com", Senha: "hash"})
+	deBruno := map[string]string{"usuarioId": strconv.FormatUint(bruno, 10)}
+
+	nicksNaBusca := func() []string {
+		t.Helper()
+		var resultados []modelos.ResultadoDeBusca
+		lerJSON(t, requisitarComo(t, ana, controladores.BuscarUsuarios, http.MethodGet, "/usuarios?ordem=nick", nil, nil), &resultados)
+		var nicks []string
+		for _, resultado := range resultados {
+			nicks = append(nicks, resultado.Nick)
+		}
+		return nicks
+	}
+
+	if w := requisitarComo(t, ana, controladores.SilenciarUsuario, http.MethodPost, "/", nil, deBruno); w.Code != http.StatusNoContent {
+		t.Fatalf("silenciar: status %d: %s", w.Code, w.Body.String())
+	}
+	for _, nick := range nicksNaBusca() {
+		if nick == "bruno" {
+			t.Error("o silenciado continua na busca de quem silenciou")
+		}
+	}
+
+	// Silenciar não bloqueia: o perfil e o seguir continuam disponíveis
+	if w := requisitarComo(t, ana, controladores.SeguirUsuario, http.MethodPost, "/", nil, deBruno); w.Code != http.StatusOK {
+		t.Errorf("seguir o silenciado: status %d, quer 200", w.Code)
+	}
+
+	if w := requisitarComo(t, ana, controladores.DessilenciarUsuario, http.MethodDelete, "/", nil, deBruno); w.Code != http.StatusNoContent {
+		t.Fatalf("dessilenciar: status %d: %s", w.Code, w.Body.String())
+	}
+	if nicks := nicksNaBusca(); len(nicks) != 2 {
+		t.Errorf("busca depois de dessilenciar = %v, quer ana e bruno", nicks)
+	}
+}
+
+func TestSolicitacoesDeContaPrivada(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	privada, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Carla", Nick: "carla", Email: "carla@exemplo.com", Senha: "hash"})
+	davi, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Davi", Nick: "davi", Email: "davi@exemplo.com", Senha: "hash"})
+	eva, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Eva", Nick: "eva", Email: "eva@exemplo.com", Senha: "hash"})
+	if erro := repositorio.Atualizar(ctx, privada, modelos.Usuario{Nome: "Carla", Nick: "carla", Email: "carla@exemplo.com", Privado: true}); erro != nil {
+		t.Fatal(erro)
+	}
+	daPrivada := map[string]string{"usuarioId": strconv.FormatUint(privada, 10)}
+
+	// Seguir uma conta privada gera um pedido pendente
+	for _, seguidor := range []uint64{davi, eva} {
+		w := requisitarComo(t, seguidor, controladores.SeguirUsuario, http.MethodPost, "/", nil, daPrivada)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("seguir conta privada: status %d, quer 202: %s", w.Code, w.Body.String())
+		}
+	}
+	if quantidade, _ := repositorio.QuantidadeSeguidores(ctx, privada); quantidade != 0 {
+		t.Errorf("seguidores antes da aprovação = %d", quantidade)
+	}
+
+	// Só a dona da conta vê e responde os pedidos
+	if w := requisitarComo(t, davi, controladores.BuscarSolicitacoes, http.MethodGet, "/", nil, daPrivada); w.Code != http.StatusForbidden {
+		t.Errorf("pedidos de outra conta: status %d, quer 403", w.Code)
+	}
+	w := requisitarComo(t, privada, controladores.BuscarSolicitacoes, http.MethodGet, "/", nil, daPrivada)
+	var pedidos []modelos.Usuario
+	lerJSON(t, w, &pedidos)
+	if w.Code != http.StatusOK || len(pedidos) != 2 || pedidos[0].Email != "" {
+		t.Fatalf("pedidos: status %d, %+v", w.Code, pedidos)
+	}
+
+	responder := func(usuarioID, seguidorID uint64, handler http.HandlerFunc) int {
+		variaveis := map[string]string{"usuarioId": strconv.FormatUint(privada, 10), "seguidorId": strconv.FormatUint(seguidorID, 10)}
+		return requisitarComo(t, usuarioID, handler, http.MethodPost, "/", nil, variaveis).Code
+	}
+	if status := responder(davi, davi, controladores.AprovarSolicitacao); status != http.StatusForbidden {
+		t.Errorf("o próprio seguidor aprovando: status %d, quer 403", status)
+	}
+	if status := responder(privada, davi, controladores.AprovarSolicitacao); status != http.StatusNoContent {
+		t.Errorf("aprovar: status %d, quer 204", status)
+	}
+	if status := responder(privada, eva, controladores.RejeitarSolicitacao); status != http.StatusNoContent {
+		t.Errorf("rejeitar: status %d, quer 204", status)
+	}
+	if status := responder(privada, eva, controladores.AprovarSolicitacao); status != http.StatusNotFound {
+		t.Errorf("aprovar pedido rejeitado: status %d, quer 404", status)
+	}
+
+	var seguidores []modelos.Usuario
+	lerJSON(t, requisitarComo(t, privada, controladores.BuscarSeguidores, http.MethodGet, "/", nil, daPrivada), &seguidores)
+	if len(seguidores) != 1 || seguidores[0].ID != davi {
+		t.Errorf("seguidores depois das respostas = %+v, quer só davi", seguidores)
+	}
+}
@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/cache"
+	"api/src/federacao"
+	"api/src/metricas"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/seguranca"
+	"api/src/tenancia"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/gorilla/mux"
+)
+
+const (
+	duracaoRequisicaoSAML = 10 * time.Minute
+	tamanhoMaximoMetadata = 1 << 20
+)
+
+// MetadataSAML publica o metadata do provedor de serviço (SP) do tenant
+func (controladores *Controladores) MetadataSAML(w http.ResponseWriter, r *http.Request) {
+	sp, _, _, erro := controladores.provedorDeServicoDoTenant(r.Context(), mux.Vars(r)["tenant"])
+	if erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	metadata, erro := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(metadata)
+}
+
+// IniciarLoginSAML cria o AuthnRequest e redireciona o navegador para o IdP do tenant
+func (controladores *Controladores) IniciarLoginSAML(w http.ResponseWriter, r *http.Request) {
+	sp, _, _, erro := controladores.provedorDeServicoDoTenant(r.Context(), mux.Vars(r)["tenant"])
+	if erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	requisicao, erro := sp.MakeAuthenticationRequest(
+		sp.GetSSOBindingLocation(saml.HTTPRedirectBinding),
+		saml.HTTPRedirectBinding,
+		saml.HTTPPostBinding,
+	)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	relayState, erro := seguranca.GerarTokenAleatorio(32)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	// O ID da requisição é conferido no InResponseTo da resposta do IdP
+	if erro = cache.Atual().Salvar(r.Context(), "saml_requisicao:"+relayState, requisicao.ID, duracaoRequisicaoSAML); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, errors.New("erro ao salvar a requisição SAML"))
+		return
+	}
+
+	urlDoIdP, erro := requisicao.Redirect(relayState, sp)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	http.Redirect(w, r, urlDoIdP.String(), http.StatusFound)
+}
+
+// ConsumirAssercaoSAML recebe a asserção pelo binding HTTP-POST e gera o token da API
+func (controladores *Controladores) ConsumirAssercaoSAML(w http.ResponseWriter, r *http.Request) {
+	sp, provedor, organizacao, erro := controladores.provedorDeServicoDoTenant(r.Context(), mux.Vars(r)["tenant"])
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	if erro = r.ParseForm(); erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	chaveRequisicao := "saml_requisicao:" + r.PostForm.Get("RelayState")
+	idRequisicao, erro := cache.Atual().Buscar(r.Context(), chaveRequisicao)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoRequisicaoInvalida)
+		respostas.Erro(w, http.StatusBadRequest, errors.New("login SAML expirado ou não iniciado por esta API"))
+		return
+	}
+	cache.Atual().Apagar(r.Context(), chaveRequisicao)
+
+	assercao, erro := federacao.ValidarAssercao(sp, r.PostForm.Get("SAMLResponse"), idRequisicao)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoCredenciaisInvalidas)
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	identidade, erro := federacao.IdentidadeDaAssercao(provedor, assercao)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoCredenciaisInvalidas)
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	db := controladores.db
+
+	usuarioID, erro := controladores.resolverUsuarioExterno(r.Context(), db, organizacao.ID, identidade)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoVinculoRecusado)
+		respostas.Erro(w, http.StatusConflict, erro)
+		return
+	}
+
+	if erro = controladores.verificarSituacaoDaConta(r.Context(), organizacao.ID, usuarioID, false); erro != nil {
+		metricas.Login(metricas.LoginFalha, motivoDaSituacaoDaConta(erro))
+		respostas.Erro(w, statusDaSituacaoDaConta(erro), erro)
+		return
+	}
+
+	token, erro := autenticacao.CriarToken(r.Context(), organizacao, usuarioID, nil)
+	if erro != nil {
+		metricas.Login(metricas.LoginFalha, metricas.MotivoErro)
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	log.Printf("Login realizado com sucesso usando SAML (tenant %s).", provedor.Tenant)
+	controladores.registrarLogin(r, usuarioID, metricas.LoginSAML)
+	metricas.Login(metricas.LoginSucesso, metricas.LoginSAML)
+
+	fragmento := url.Values{"id": {strconv.FormatUint(usuarioID, 10)}, "token": {token}}
+	http.Redirect(w, r, "/logado#"+fragmento.Encode(), http.StatusSeeOther)
+}
+
+// SalvarProvedorSAML recebe o metadata XML do IdP de um tenant e o mapeamento de atributos.
+// O tenant é o slug da organização, e cada organização só configura o seu próprio IdP.
+func (controladores *Controladores) SalvarProvedorSAML(w http.ResponseWriter, r *http.Request) {
+	tenant := mux.Vars(r)["tenant"]
+	if erro := federacao.ValidarTenant(tenant); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if tenancia.DaRequisicao(r).Slug != tenant {
+		respostas.Erro(w, http.StatusForbidden, errors.New("só é possível configurar o IdP da própria organização"))
+		return
+	}
+
+	metadata, erro := io.ReadAll(io.LimitReader(r.Body, tamanhoMaximoMetadata))
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	if erro = federacao.ValidarMetadataSAML(metadata); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	parametros := r.URL.Query()
+	provedor := modelos.ProvedorSAML{
+		Tenant:        tenant,
+		Metadata:      string(metadata),
+		AtributoEmail: valorOuPadrao(parametros.Get("atributoEmail"), "email"),
+		AtributoNome:  valorOuPadrao(parametros.Get("atributoNome"), "displayName"),
+		AtributoNick:  valorOuPadrao(parametros.Get("atributoNick"), "uid"),
+		ConfiarEmail:  parametros.Get("confiarEmail") == "true",
+	}
+
+	db := controladores.db
+
+	repositorio := repositorios.NovoRepositorioDeProvedoresSAML(db)
+	provedor.ID, erro = repositorio.Salvar(r.Context(), provedor)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, provedor)
+}
+
+// provedorDeServicoDoTenant carrega a organização e a configuração do tenant e monta o SP correspondente
+func (controladores *Controladores) provedorDeServicoDoTenant(ctx context.Context, tenant string) (*saml.ServiceProvider, modelos.ProvedorSAML, modelos.Organizacao, error) {
+	if erro := federacao.ValidarTenant(tenant); erro != nil {
+		return nil, modelos.ProvedorSAML{}, modelos.Organizacao{}, erro
+	}
+
+	organizacao, erro := tenancia.BuscarPorSlug(tenant)
+	if erro != nil {
+		return nil, modelos.ProvedorSAML{}, modelos.Organizacao{}, erro
+	}
+
+	db := controladores.db
+
+	provedor, erro := repositorios.NovoRepositorioDeProvedoresSAML(db).BuscarPorTenant(ctx, tenant)
+	if erro != nil {
+		return nil, modelos.ProvedorSAML{}, modelos.Organizacao{}, erro
+	}
+
+	sp, erro := federacao.ProvedorDeServico(provedor)
+	if erro != nil {
+		return nil, modelos.ProvedorSAML{}, modelos.Organizacao{}, erro
+	}
+
+	return sp, provedor, organizacao, nil
+}
+
+func valorOuPadrao(valor, padrao string) string {
+	if valor == "" {
+		return padrao
+	}
+	return valor
+}
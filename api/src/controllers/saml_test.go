@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"api/src/cache"
+	"api/src/federacao"
+	"api/src/metricas"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/testes"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TestACSSAML posta no ACS uma resposta assinada pelo IdP do tenant e a mesma resposta adulterada
+func TestACSSAML(t *testing.T) {
+	db := testes.BancoDeDados(t)
+	testes.Configurar(t, testes.ArquivosSAML(t))
+	ctx := context.Background()
+
+	idp := testes.NovoIdPSAML(t)
+	provedor := modelos.ProvedorSAML{
+		Tenant:        organizacaoDeTeste.Slug,
+		Metadata:      string(idp.Metadata(t)),
+		AtributoEmail: "email",
+		AtributoNome:  "displayName",
+		AtributoNick:  "uid",
+	}
+	if _, erro := repositorios.NovoRepositorioDeProvedoresSAML(db).Salvar(ctx, provedor); erro != nil {
+		t.Fatal(erro)
+	}
+	sp, erro := federacao.ProvedorDeServico(provedor)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	usuarios := repositorios.NovaFabricaDeUsuarios(db)
+	controladores := NovosControladores(Dependencias{Usuarios: usuarios, DB: db})
+
+	// postar faz o papel do navegador: o login foi iniciado pela API (RelayState no cache) e o IdP devolveu a resposta
+	postar := func(t *testing.T, resposta []byte) *httptest.ResponseRecorder {
+		t.Helper()
+
+		relayState := fmt.Sprintf("relay-%d", time.Now().UnixNano())
+		if erro := cache.Atual().Salvar(ctx, "saml_requisicao:"+relayState, "id-da-requisicao", time.Minute); erro != nil {
+			t.Fatal(erro)
+		}
+
+		formulario := url.Values{"SAMLResponse": {base64.StdEncoding.EncodeToString(resposta)}, "RelayState": {relayState}}
+		r := httptest.NewRequest(http.MethodPost, "/saml/"+provedor.Tenant+"/acs", strings.NewReader(formulario.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = mux.SetURLVars(r, map[string]string{"tenant": provedor.Tenant})
+
+		w := httptest.NewRecorder()
+		controladores.ConsumirAssercaoSAML(w, r)
+		return w
+	}
+
+	sufixo := time.Now().UnixNano()
+	email, outroEmail := fmt.Sprintf("saml-%d@empresa.com", sufixo), fmt.Sprintf("alvo-%d@empresa.com", sufixo)
+	resposta := idp.Resposta(t, sp, "id-da-requisicao", fmt.Sprintf("sujeito-%d", sufixo), email)
+
+	t.Run("adulterada", func(t *testing.T) {
+		antes := loginsContados(t, metricas.LoginFalha, metricas.MotivoCredenciaisInvalidas)
+		w := postar(t, bytes.ReplaceAll(resposta, []byte(email), []byte(outroEmail)))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, quer 401: %s", w.Code, w.Body.String())
+		}
+		if depois := loginsContados(t, metricas.LoginFalha, metricas.MotivoCredenciaisInvalidas); depois != antes+1 {
+			t.Errorf("falhas por credenciais inválidas = %v, quer %v", depois, antes+1)
+		}
+		if _, erro := usuarios(organizacaoDeTeste.ID).BuscarPorEmail(ctx, outroEmail); !errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+			t.Errorf("a asserção adulterada criou a conta: %v", erro)
+		}
+	})
+
+	t.Run("assinada", func(t *testing.T) {
+		antes := loginsContados(t, metricas.LoginSucesso, metricas.LoginSAML)
+		w := postar(t, resposta)
+		if w.Code != http.StatusSeeOther {
+			t.Fatalf("status = %d, quer 303: %s", w.Code, w.Body.String())
+		}
+		if depois := loginsContados(t, metricas.LoginSucesso, metricas.LoginSAML); depois != antes+1 {
+			t.Errorf("logins SAML = %v, quer %v", depois, antes+1)
+		}
+
+		destino, erro := url.Parse(w.Header().Get("Location"))
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		fragmento, _ := url.ParseQuery(destino.Fragment)
+		if destino.Path != "/logado" || fragmento.Get("token") == "" {
+			t.Errorf("redirecionamento = %s, quer /logado com o token", destino)
+		}
+
+		usuario, erro := usuarios(organizacaoDeTeste.ID).BuscarPorEmail(ctx, email)
+		if erro != nil || fragmento.Get("id") != fmt.Sprint(usuario.ID) {
+			t.Errorf("conta provisionada = %+v, %v; id no redirecionamento = %s", usuario, erro, fragmento.Get("id"))
+		}
+	})
+
+	t.Run("reenviada", func(t *testing.T) {
+		// Sem o RelayState guardado no início do login, a resposta não é aceita de novo
+		formulario := url.Values{"SAMLResponse": {base64.StdEncoding.EncodeToString(resposta)}, "RelayState": {"desconhecido"}}
+		r := httptest.NewRequest(http.MethodPost, "/saml/"+provedor.Tenant+"/acs", strings.NewReader(formulario.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = mux.SetURLVars(r, map[string]string{"tenant": provedor.Tenant})
+
+		w := httptest.NewRecorder()
+		controladores.ConsumirAssercaoSAML(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, quer 400", w.Code)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"api/src/ciclo"
+	"api/src/respostas"
+	"api/src/saude"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// inicio é quando o processo subiu, mostrado no /healthz
+var inicio = time.Now()
+
+// Vivacidade responde 200 enquanto o processo atende requisições, sem consultar as dependências:
+// um banco fora do ar não se resolve reiniciando a API.
+func Vivacidade(w http.ResponseWriter, r *http.Request) {
+	if !querDetalhes(r) {
+		responderTexto(w, http.StatusOK, saude.SituacaoOK)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, struct {
+		Situacao string    `json:"situacao"`
+		InicioEm time.Time `json:"inicioEm"`
+		AtivaHa  string    `json:"ativaHa"`
+	}{saude.SituacaoOK, inicio, time.Since(inicio).Truncate(time.Second).String()})
+}
+
+// Prontidao responde 503 quando uma dependência obrigatória (PostgreSQL, chaves de assinatura) falha ou quando a API
+// está encerrando, e 200 nos outros casos, inclusive com dependências opcionais fora do ar (situação degradado).
+// Para o balanceador o corpo é só a situação; com Accept: application/json ou ?formato=json vem cada verificação,
+// e o detalhe dos erros só com o METRICAS_TOKEN (sem ele, o erro fica no log).
+func Prontidao(w http.ResponseWriter, r *http.Request) {
+	// No encerramento o balanceador precisa tirar a instância mesmo com as dependências no ar
+	if !ciclo.Pronto() {
+		if querDetalhes(r) {
+			respostas.JSON(w, http.StatusServiceUnavailable, saude.Relatorio{Situacao: "encerrando"})
+		} else {
+			responderTexto(w, http.StatusServiceUnavailable, "encerrando")
+		}
+		return
+	}
+
+	relatorio := saude.Verificar()
+	status := http.StatusOK
+	if relatorio.Situacao == saude.SituacaoIndisponivel {
+		status = http.StatusServiceUnavailable
+	}
+
+	if querDetalhes(r) {
+		if !comTokenDeMetricas(r) {
+			relatorio = relatorio.SemDetalhes()
+		}
+		respostas.JSON(w, status, relatorio)
+		return
+	}
+	responderTexto(w, status, relatorio.Situacao)
+}
+
+func querDetalhes(r *http.Request) bool {
+	return r.URL.Query().Get("formato") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+func responderTexto(w http.ResponseWriter, status int, texto string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write([]byte(texto + "\n"))
+}
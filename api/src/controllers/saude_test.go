@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"api/src/testes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestDetalhesDaSaudeExigemOTokenDeMetricas: o relatório do /readyz só traz o erro das dependências para quem
+// apresenta o METRICAS_TOKEN; sem o token configurado, ninguém recebe
+func TestDetalhesDaSaudeExigemOTokenDeMetricas(t *testing.T) {
+	casos := []struct {
+		nome, configurado, enviado string
+		quer                       bool
+	}{
+		{"sem METRICAS_TOKEN", "", "", false},
+		{"sem METRICAS_TOKEN e com Authorization", "", "Bearer ", false},
+		{"anônimo", "token-de-metricas", "", false},
+		{"token errado", "token-de-metricas", "Bearer outro", false},
+		{"token certo", "token-de-metricas", "Bearer token-de-metricas", true},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			testes.Configurar(t, map[string]string{"METRICAS_TOKEN": caso.configurado})
+
+			r := httptest.NewRequest(http.MethodGet, "/readyz?formato=json", nil)
+			if caso.enviado != "" {
+				r.Header.Set("Authorization", caso.enviado)
+			}
+			if recebe := comTokenDeMetricas(r); recebe != caso.quer {
+				t.Errorf("comTokenDeMetricas = %v, quer %v", recebe, caso.quer)
+			}
+		})
+	}
+}
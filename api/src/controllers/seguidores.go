@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/paginacao"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/tenancia"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// SeguirUsuario faz o usuário do token seguir o usuário da URL
+func (controladores *Controladores) SeguirUsuario(w http.ResponseWriter, r *http.Request) {
+	controladores.alterarSeguimento(w, r, true)
+}
+
+// PararDeSeguirUsuario faz o usuário do token deixar de seguir o usuário da URL
+func (controladores *Controladores) PararDeSeguirUsuario(w http.ResponseWriter, r *http.Request) {
+	controladores.alterarSeguimento(w, r, false)
+}
+
+func (controladores *Controladores) alterarSeguimento(w http.ResponseWriter, r *http.Request, seguir bool) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	seguidorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+	if seguidorID == 0 {
+		respostas.Erro(w, http.StatusForbidden, errors.New("login anônimo não pode seguir usuários"))
+		return
+	}
+
+	orgID := tenancia.DaRequisicao(r).ID
+	repositorio := controladores.usuarios(orgID)
+	operacao := repositorio.PararDeSeguir
+	if seguir {
+		operacao = repositorio.Seguir
+	}
+
+	usuarios, erro := operacao(r.Context(), usuarioID, seguidorID)
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if errors.Is(erro, repositorios.ErrUsuarioBloqueado) {
+		respostas.Erro(w, http.StatusForbidden, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	invalidarSugestoes(r.Context(), orgID, seguidorID)
+
+	// Conta privada: o pedido fica pendente até o dono aprovar
+	if usuarios[0].Conexao == repositorios.ConexaoSolicitada {
+		respostas.JSON(w, http.StatusAccepted, usuarios[0])
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, usuarios[0])
+}
+
+// BuscarSeguidores lista, paginado por cursor, quem segue o usuário
+func (controladores *Controladores) BuscarSeguidores(w http.ResponseWriter, r *http.Request) {
+	controladores.listarGrafo(w, r, repositorios.RepositorioUsuarios.BuscarSeguidores)
+}
+
+// BuscarSeguindo lista, paginado por cursor, quem o usuário segue
+func (controladores *Controladores) BuscarSeguindo(w http.ResponseWriter, r *http.Request) {
+	controladores.listarGrafo(w, r, repositorios.RepositorioUsuarios.BuscarSeguindo)
+}
+
+func (controladores *Controladores) listarGrafo(w http.ResponseWriter, r *http.Request, buscar func(repositorios.RepositorioUsuarios, context.Context, uint64, paginacao.Pagina) ([]modelos.Usuario, *paginacao.Cursor, error)) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	// Sem ?ordem=, os vínculos mais recentes vêm primeiro
+	pagina, erro := paginacao.DaRequisicao(r, "-criadoEm", "criadoEm", "nome", "nick")
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	visitanteID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repositorio := controladores.usuarios(tenancia.DaRequisicao(r).ID)
+	usuario, erro := repositorio.BuscarPorID(r.Context(), usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+
+	podeVer, erro := repositorio.PodeVerConexoes(r.Context(), usuario, visitanteID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	// Administradores com usuarios:ler também veem as conexões de contas privadas
+	if !podeVer {
+		if podeVer, _ = autenticacao.PossuiPermissao(r, "usuarios:ler"); !podeVer {
+			respostas.Erro(w, http.StatusForbidden, errors.New("esta conta é privada"))
+			return
+		}
+	}
+
+	usuarios, proximo, erro := buscar(repositorio, r.Context(), usuarioID, pagina)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	// Listas públicas não expõem o e-mail dos usuários
+	for i := range usuarios {
+		usuarios[i].Email = ""
+	}
+
+	paginacao.Responder(w, r, usuarios, proximo)
+}
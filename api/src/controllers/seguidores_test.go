@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"api/src/modelos"
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestConexoesDeContaPrivadaComUsuariosLer(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	privada, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Carla", Nick: "carla", Email: "carla@exemplo.com", Senha: "hash"})
+	visitante, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Davi", Nick: "davi", Email: "davi@exemplo.com", Senha: "hash"})
+	moderador, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Eva", Nick: "eva", Email: "eva@exemplo.com", Senha: "hash"})
+	if erro := repositorio.Atualizar(ctx, privada, modelos.Usuario{Nome: "Carla", Nick: "carla", Email: "carla@exemplo.com", Privado: true}); erro != nil {
+		t.Fatal(erro)
+	}
+
+	permissoesDeTeste[moderador] = []string{"usuarios:ler"}
+	t.Cleanup(func() { delete(permissoesDeTeste, moderador) })
+
+	variaveis := map[string]string{"usuarioId": strconv.FormatUint(privada, 10)}
+	if w := requisitarComo(t, visitante, controladores.BuscarSeguidores, http.MethodGet, "/", nil, variaveis); w.Code != http.StatusForbidden {
+		t.Errorf("visitante sem permissão: status %d, quer 403", w.Code)
+	}
+	if w := requisitarComo(t, moderador, controladores.BuscarSeguidores, http.MethodGet, "/", nil, variaveis); w.Code != http.StatusOK {
+		t.Errorf("com usuarios:ler: status %d, quer 200: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSeguirEDeixarDeSeguir(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	ana, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Senha: "hash"})
+	bruno, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Bruno", Nick: "bruno", Email: "bruno@exemplo.com", Senha: "hash"})
+	deAna := map[string]string{"usuarioId": strconv.FormatUint(ana, 10)}
+	deBruno := map[string]string{"usuarioId": strconv.FormatUint(bruno, 10)}
+
+	listar := func(handler http.HandlerFunc, variaveis map[string]string) []modelos.Usuario {
+		t.Helper()
+		w := requisitarComo(t, ana, handler, http.MethodGet, "/", nil, variaveis)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body.String())
+		}
+		var lista []modelos.Usuario
+		lerJSON(t, w, &lista)
+		return lista
+	}
+
+	if w := requisitarComo(t, ana, controladores.SeguirUsuario, http.MethodPost, "/", nil, deAna); w.Code != http.StatusBadRequest {
+		t.Errorf("seguir a si mesmo: status %d, quer 400", w.Code)
+	}
+	if w := requisitarComo(t, ana, controladores.SeguirUsuario, http.MethodPost, "/", nil, map[string]string{"usuarioId": "9999"}); w.Code != http.StatusNotFound {
+		t.Errorf("seguir usuário inexistente: status %d, quer 404", w.Code)
+	}
+	if w := requisitarComo(t, 0, controladores.SeguirUsuario, http.MethodPost, "/", nil, deBruno); w.Code != http.StatusForbidden {
+		t.Errorf("login anônimo: status %d, quer 403", w.Code)
+	}
+
+	w := requisitarComo(t, ana, controladores.SeguirUsuario, http.MethodPost, "/", nil, deBruno)
+	if w.Code != http.StatusOK {
+		t.Fatalf("seguir: status %d: %s", w.Code, w.Body.String())
+	}
+	var seguido modelos.Usuario
+	lerJSON(t, w, &seguido)
+	if seguido.ID != bruno || seguido.Conexao != "seguindo" {
+		t.Errorf("resposta do seguir = %+v", seguido)
+	}
+	if w = requisitarComo(t, ana, controladores.SeguirUsuario, http.MethodPost, "/", nil, deBruno); w.Code != http.StatusBadRequest {
+		t.Errorf("seguir de novo: status %d, quer 400", w.Code)
+	}
+
+	// As listas mostram o vínculo nos dois sentidos, sem o e-mail
+	if seguidores := listar(controladores.BuscarSeguidores, deBruno); len(seguidores) != 1 || seguidores[0].ID != ana || seguidores[0].Email != "" {
+		t.Errorf("seguidores de bruno = %+v", seguidores)
+	}
+	if seguindo := listar(controladores.BuscarSeguindo, deAna); len(seguindo) != 1 || seguindo[0].ID != bruno {
+		t.Errorf("ana segue = %+v", seguindo)
+	}
+
+	if w = requisitarComo(t, ana, controladores.PararDeSeguirUsuario, http.MethodDelete, "/", nil, deBruno); w.Code != http.StatusOK {
+		t.Fatalf("parar de seguir: status %d: %s", w.Code, w.Body.String())
+	}
+	if seguidores := listar(controladores.BuscarSeguidores, deBruno); len(seguidores) != 0 {
+		t.Errorf("seguidores depois de parar de seguir = %+v", seguidores)
+	}
+	if w = requisitarComo(t, ana, controladores.PararDeSeguirUsuario, http.MethodDelete, "/", nil, deBruno); w.Code != http.StatusBadRequest {
+		t.Errorf("parar de seguir quem não segue: status %d, quer 400", w.Code)
+	}
+}
+
+func TestListasDoGrafoComOrdemInvalida(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	ana, _ := usuarios(organizacaoDeTeste.ID).Criar(context.Background(), modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Senha: "hash"})
+
+	variaveis := map[string]string{"usuarioId": strconv.FormatUint(ana, 10)}
+	if w := requisitarComo(t, ana, controladores.BuscarSeguidores, http.MethodGet, "/?ordem=email", nil, variaveis); w.Code != http.StatusBadRequest {
+		t.Errorf("ordem por e-mail: status %d, quer 400", w.Code)
+	}
+	if w := requisitarComo(t, ana, controladores.BuscarSeguindo, http.MethodGet, "/", nil, map[string]string{"usuarioId": "9999"}); w.Code != http.StatusNotFound {
+		t.Errorf("usuário inexistente: status %d, quer 404", w.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"api/src/cache"
+	"api/src/modelos"
+	"api/src/paginacao"
+	"api/src/respostas"
+	"api/src/tenancia"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// quantidadeSugestoes é quantas sugestões são calculadas e guardadas no cache; ?limite e ?pagina recortam essa lista
+	quantidadeSugestoes = paginacao.LimiteMaximo
+	duracaoSugestoes    = 10 * time.Minute
+)
+
+// BuscarSugestoes lista quem o usuário talvez conheça, a partir de quem ele já segue
+func (controladores *Controladores) BuscarSugestoes(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, ""); status != 0 {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	limite, deslocamento, erro := paginacaoDaRequisicao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	orgID := tenancia.DaRequisicao(r).ID
+	chave := chaveDeSugestoes(orgID, usuarioID)
+
+	var sugestoes []modelos.Sugestao
+	if dados, erro := cache.Atual().Buscar(r.Context(), chave); erro != nil || json.Unmarshal([]byte(dados), &sugestoes) != nil {
+		repositorio := controladores.usuarios(orgID)
+		if sugestoes, erro = repositorio.BuscarSugestoes(r.Context(), usuarioID, quantidadeSugestoes); erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+
+		if dados, erro := json.Marshal(sugestoes); erro == nil {
+			cache.Atual().Salvar(r.Context(), chave, string(dados), duracaoSugestoes)
+		}
+	}
+
+	if deslocamento > len(sugestoes) {
+		deslocamento = len(sugestoes)
+	}
+	fim := deslocamento + limite
+	if fim > len(sugestoes) {
+		fim = len(sugestoes)
+	}
+
+	respostas.JSON(w, http.StatusOK, sugestoes[deslocamento:fim])
+}
+
+// invalidarSugestoes descarta as sugestões guardadas no cache para os usuários informados.
+// Mudanças no grafo de quem o usuário segue só aparecem quando o cache expira.
+func invalidarSugestoes(ctx context.Context, orgID uint64, usuariosID ...uint64) {
+	chaves := make([]string, 0, len(usuariosID))
+	for _, usuarioID := range usuariosID {
+		chaves = append(chaves, chaveDeSugestoes(orgID, usuarioID))
+	}
+	cache.Atual().Apagar(ctx, chaves...)
+}
+
+func chaveDeSugestoes(orgID, usuarioID uint64) string {
+	return fmt.Sprintf("sugestoes:%d:%d", orgID, usuarioID)
+}
+
+// paginacaoDaRequisicao lê ?pagina= (a partir de 1) e ?limite=; as sugestões ficam inteiras no cache e são recortadas por deslocamento
+func paginacaoDaRequisicao(r *http.Request) (int, int, error) {
+	limite, pagina := paginacao.LimitePadrao, 1
+
+	if valor := r.URL.Query().Get("limite"); valor != "" {
+		numero, erro := strconv.Atoi(valor)
+		if erro != nil || numero < 1 || numero > paginacao.LimiteMaximo {
+			return 0, 0, fmt.Errorf("limite deve ser um número entre 1 e %d", paginacao.LimiteMaximo)
+		}
+		limite = numero
+	}
+
+	if valor := r.URL.Query().Get("pagina"); valor != "" {
+		numero, erro := strconv.Atoi(valor)
+		if erro != nil || numero < 1 {
+			return 0, 0, errors.New("pagina deve ser um número a partir de 1")
+		}
+		pagina = numero
+	}
+
+	return limite, (pagina - 1) * limite, nil
+}
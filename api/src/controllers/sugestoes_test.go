@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"api/src/modelos"
+	"api/src/paginacao"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestPaginacaoDaRequisicao(t *testing.T) {
+	casos := []struct {
+		consulta     string
+		limite       int
+		deslocamento int
+		valido       bool
+	}{
+		{"", paginacao.LimitePadrao, 0, true},
+		{"?limite=5", 5, 0, true},
+		{"?limite=5&pagina=3", 5, 10, true},
+		{"?pagina=2", paginacao.LimitePadrao, paginacao.LimitePadrao, true},
+		{"?limite=0", 0, 0, false},
+		{"?limite=" + strconv.Itoa(paginacao.LimiteMaximo+1), 0, 0, false},
+		{"?limite=dez", 0, 0, false},
+		{"?pagina=0", 0, 0, false},
+		{"?pagina=-1", 0, 0, false},
+	}
+	for _, caso := range casos {
+		limite, deslocamento, erro := paginacaoDaRequisicao(httptest.NewRequest(http.MethodGet, "/"+caso.consulta, nil))
+		if (erro == nil) != caso.valido {
+			t.Errorf("%q: erro = %v, válido = %v", caso.consulta, erro, caso.valido)
+			continue
+		}
+		if caso.valido && (limite != caso.limite || deslocamento != caso.deslocamento) {
+			t.Errorf("%q: limite %d e deslocamento %d, quer %d e %d", caso.consulta, limite, deslocamento, caso.limite, caso.deslocamento)
+		}
+	}
+}
+
+func TestBuscarSugestoes(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	IDs := map[string]uint64{}
+	for _, nick := range []string{"ana", "bia", "caio", "dudu"} {
+		ID, erro := repositorio.Criar(ctx, modelos.Usuario{Nome: nick, Nick: nick, Email: nick + "@exemplo.com", Senha: "hash"})
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		IDs[nick] = ID
+	}
+	// Os IDs em memória se repetem entre os testes: nada do cache de outro teste pode ser aproveitado
+	invalidarSugestoes(ctx, organizacaoDeTeste.ID, IDs["ana"])
+
+	// Ana segue Bia, que segue Caio e Dudu
+	for _, par := range [][2]string{{"bia", "ana"}, {"caio", "bia"}, {"dudu", "bia"}} {
+		if _, erro := repositorio.Seguir(ctx, IDs[par[0]], IDs[par[1]]); erro != nil {
+			t.Fatal(erro)
+		}
+	}
+	deAna := map[string]string{"usuarioId": strconv.FormatUint(IDs["ana"], 10)}
+
+	sugeridos := func(caminho string) []string {
+		t.Helper()
+		w := requisitarComo(t, IDs["ana"], controladores.BuscarSugestoes, http.MethodGet, caminho, nil, deAna)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", caminho, w.Code, w.Body.String())
+		}
+		var sugestoes []modelos.Sugestao
+		lerJSON(t, w, &sugestoes)
+		nicks := make([]string, 0, len(sugestoes))
+		for _, sugestao := range sugestoes {
+			nicks = append(nicks, sugestao.Nick)
+		}
+		return nicks
+	}
+
+	if nicks := sugeridos("/"); len(nicks) != 2 {
+		t.Fatalf("sugestões = %v, quer caio e dudu", nicks)
+	}
+	primeira, segunda := sugeridos("/?limite=1"), sugeridos("/?limite=1&pagina=2")
+	if len(primeira) != 1 || len(segunda) != 1 || primeira[0] == segunda[0] {
+		t.Errorf("páginas = %v e %v, quer uma sugestão diferente em cada", primeira, segunda)
+	}
+	if nicks := sugeridos("/?limite=1&pagina=5"); len(nicks) != 0 {
+		t.Errorf("página além do fim = %v, quer vazia", nicks)
+	}
+
+	// Seguir pela API descarta o cache: quem passou a ser seguido sai das sugestões
+	if w := requisitarComo(t, IDs["ana"], controladores.SeguirUsuario, http.MethodPost, "/", nil, map[string]string{"usuarioId": strconv.FormatUint(IDs["caio"], 10)}); w.Code != http.StatusOK {
+		t.Fatalf("seguir: status %d: %s", w.Code, w.Body.String())
+	}
+	if nicks := sugeridos("/"); len(nicks) != 1 || nicks[0] != "dudu" {
+		t.Errorf("sugestões depois de seguir caio = %v, quer só dudu", nicks)
+	}
+
+	if w := requisitarComo(t, IDs["bia"], controladores.BuscarSugestoes, http.MethodGet, "/", nil, deAna); w.Code != http.StatusForbidden {
+		t.Errorf("sugestões de outro usuário: status %d, quer 403", w.Code)
+	}
+	if w := requisitarComo(t, IDs["ana"], controladores.BuscarSugestoes, http.MethodGet, "/?pagina=0", nil, deAna); w.Code != http.StatusBadRequest {
+		t.Errorf("página 0: status %d, quer 400", w.Code)
+	}
+}
@@ -1,12 +1,26 @@
 package controllers
 
 import (
+	"api/src/config"
+	"api/src/federacao"
 	"html/template"
 	"log"
 	"net/http"
+	"sync"
 )
 
-var templates = template.Must(template.ParseGlob("static/*.html"))
+// As páginas são lidas no primeiro uso, e não ao carregar o pacote, para que os testes dos handlers
+// rodem fora da pasta onde ficam os arquivos estáticos
+var (
+	templates       *template.Template
+	erroTemplates   error
+	carregarPaginas sync.Once
+)
+
+// dadosPaginaDeAcesso alimenta as páginas de login e registro com os botões dos provedores externos
+type dadosPaginaDeAcesso struct {
+	Provedores []config.ProvedorOIDC
+}
 
 func HomeHandler(w http.ResponseWriter, r *http.Request) {
 	err := renderTemplate(w, "index.html", nil)
@@ -17,7 +31,7 @@ func HomeHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
-	err := renderTemplate(w, "login.html", nil)
+	err := renderTemplate(w, "login.html", dadosPaginaDeAcesso{Provedores: federacao.Provedores()})
 	if err != nil {
 		log.Printf("Erro ao renderizar template login.html: %v", err) // Log detalhado do erro
 		http.Error(w, "Erro interno ao carregar a página de login.", http.StatusInternalServerError)
@@ -25,7 +39,7 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func RegisterHandler(w http.ResponseWriter, r *http.Request) {
-	err := renderTemplate(w, "register.html", nil)
+	err := renderTemplate(w, "register.html", dadosPaginaDeAcesso{Provedores: federacao.Provedores()})
 	if err != nil {
 		log.Printf("Erro ao renderizar template register.html: %v", err) // Log detalhado do erro
 		http.Error(w, "Erro interno ao carregar a página de registro.", http.StatusInternalServerError)
@@ -41,6 +55,12 @@ func LogadoHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func renderTemplate(w http.ResponseWriter, tmpl string, data interface{}) error {
+	carregarPaginas.Do(func() { templates, erroTemplates = template.ParseGlob("static/*.html") })
+	if erroTemplates != nil {
+		log.Printf("Erro ao carregar os templates: %v", erroTemplates)
+		return erroTemplates
+	}
+
 	// Verifica se o template existe
 	if err := templates.ExecuteTemplate(w, tmpl, data); err != nil {
 		log.Printf("Erro ao executar o template '%s': %v", tmpl, err) // Log detalhado
@@ -2,23 +2,30 @@ package controllers
 
 import (
 	"api/src/autenticacao"
-	"api/src/banco"
+	"api/src/cache"
 	"api/src/modelos"
+	"api/src/paginacao"
 	"api/src/repositorios"
 	"api/src/respostas"
 	"api/src/seguranca"
+	"api/src/tenancia"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // CriarUsuario insere um usuário no banco de dados
-func CriarUsuario(w http.ResponseWriter, r *http.Request) {
+func (controladores *Controladores) CriarUsuario(w http.ResponseWriter, r *http.Request) {
 	corpoRequest, erro := ioutil.ReadAll(r.Body)
 	if erro != nil {
 		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
@@ -31,56 +38,304 @@ func CriarUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	organizacao := tenancia.DaRequisicao(r)
+	if erro = organizacao.PoliticaDeSenha.Validar(usuario.Senha); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
 	if erro = usuario.Preparar("cadastro"); erro != nil {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
 
-	db, erro := banco.Conectar()
+	repositorio := controladores.usuarios(organizacao.ID)
+	usuario.ID, erro = repositorio.Criar(r.Context(), usuario)
 	if erro != nil {
-		respostas.Erro(w, http.StatusInternalServerError, erro)
+		respostas.Erro(w, statusDoErroDeBanco(erro), erro)
 		return
 	}
-	defer db.Close()
 
-	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	usuario.ID, erro = repositorio.Criar(usuario)
+	respostas.JSON(w, http.StatusCreated, usuario)
+}
+
+// BuscarUsuarios busca, paginado por cursor, os usuários pelo nome ou nick, escondendo os bloqueados e silenciados pelo visitante
+func (controladores *Controladores) BuscarUsuarios(w http.ResponseWriter, r *http.Request) {
+	filtro, erro := filtroDaRequisicao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	// Com termo, o padrão é ordenar pela similaridade com o nome ou o nick
+	ordemPadrao, campos := "nome", []string{"criadoEm", "nome", "nick"}
+	if filtro.Termo != "" {
+		ordemPadrao, campos = "-relevancia", append(campos, "relevancia")
+	}
+
+	pagina, erro := paginacao.DaRequisicao(r, ordemPadrao, campos...)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	// Login anônimo retorna 0 e vê todos os usuários
+	visitanteID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repositorio := controladores.usuarios(tenancia.DaRequisicao(r).ID)
+	usuarios, proximo, erro := repositorio.Buscar(r.Context(), filtro, visitanteID, pagina)
+	if errors.Is(erro, repositorios.ErrTermoMuitoLongo) {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	respostas.JSON(w, http.StatusCreated, usuario)
+	paginacao.Responder(w, r, usuarios, proximo)
+}
+
+// filtroDaRequisicao lê ?usuario= (nome ou nick), ?criadoApos=, ?criadoAntes= (data ou RFC 3339) e ?privado=
+func filtroDaRequisicao(r *http.Request) (modelos.FiltroDeUsuarios, error) {
+	parametros := r.URL.Query()
+	filtro := modelos.FiltroDeUsuarios{Termo: strings.ToLower(parametros.Get("usuario"))}
+
+	for nome, destino := range map[string]**time.Time{"criadoApos": &filtro.CriadoApos, "criadoAntes": &filtro.CriadoAntes} {
+		valor := parametros.Get(nome)
+		if valor == "" {
+			continue
+		}
+
+		data, erro := time.Parse(time.RFC3339, valor)
+		if erro != nil {
+			if data, erro = time.Parse("2006-01-02", valor); erro != nil {
+				return modelos.FiltroDeUsuarios{}, fmt.Errorf("%s deve ser uma data (2006-01-02) ou data e hora RFC 3339", nome)
+			}
+		}
+		*destino = &data
+	}
+
+	if valor := parametros.Get("privado"); valor != "" {
+		privado, erro := strconv.ParseBool(valor)
+		if erro != nil {
+			return modelos.FiltroDeUsuarios{}, errors.New("privado deve ser true ou false")
+		}
+		filtro.Privado = &privado
+	}
+
+	return filtro, nil
 }
 
-// BuscarUsuarios busca todos os usuários salvos no banco
-func BuscarUsuarios(w http.ResponseWriter, r *http.Request) {
-	nomeOuNick := strings.ToLower(r.URL.Query().Get("usuario"))
-	db, erro := banco.Conectar()
+// BuscarUsuario traz o perfil de um usuário pelo ID, com as contagens de seguidores e seguindo. O e-mail só aparece para o próprio usuário ou para quem tem usuarios:ler.
+func (controladores *Controladores) BuscarUsuario(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repositorio := controladores.usuarios(tenancia.DaRequisicao(r).ID)
+	usuario, erro := repositorio.BuscarPorID(r.Context(), usuarioID)
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
-	defer db.Close()
 
-	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	usuarios, erro := repositorio.Buscar(nomeOuNick)
+	// Quem bloqueou ou foi bloqueado não vê o perfil do outro
+	if visitanteID, erro := autenticacao.ExtrairUsuarioID(r); erro == nil && visitanteID != 0 {
+		if bloqueado, erro := repositorio.Bloqueado(r.Context(), usuarioID, visitanteID); erro != nil || bloqueado {
+			respostas.Erro(w, http.StatusNotFound, fmt.Errorf("usuário com ID %d %w", usuarioID, repositorios.ErrUsuarioNaoEncontrado))
+			return
+		}
+	}
+
+	if status, _ := verificarDonoOuPermissao(r, usuarioID, "usuarios:ler"); status != 0 {
+		usuario.Email = ""
+	}
+
+	perfil := modelos.PerfilDeUsuario{Usuario: usuario}
+	if perfil.Seguidores, erro = repositorio.QuantidadeSeguidores(r.Context(), usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if perfil.Seguindo, erro = repositorio.QuantidadeSeguindo(r.Context(), usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, perfil)
+}
+
+// AtualizarUsuario substitui nome, nick e e-mail de um usuário
+func (controladores *Controladores) AtualizarUsuario(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, "usuarios:editar"); erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var usuario modelos.Usuario
+	if erro = json.Unmarshal(corpoRequisicao, &usuario); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	controladores.salvarAlteracaoDeUsuario(w, r, usuarioID, func(*modelos.Usuario) modelos.Usuario { return usuario })
+}
+
+// AtualizarUsuarioParcialmente altera apenas os campos enviados no corpo
+func (controladores *Controladores) AtualizarUsuarioParcialmente(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, "usuarios:editar"); erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var alteracao modelos.AlteracaoDeUsuario
+	if erro = json.Unmarshal(corpoRequisicao, &alteracao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	controladores.salvarAlteracaoDeUsuario(w, r, usuarioID, func(atual *modelos.Usuario) modelos.Usuario {
+		alterado := *atual
+		alteracao.Aplicar(&alterado)
+		return alterado
+	})
+}
+
+// salvarAlteracaoDeUsuario carrega o usuário, monta a nova versão, valida com Preparar("edicao") e salva.
+// O cache do login no Redis é descartado quando os dados guardados nele mudam.
+func (controladores *Controladores) salvarAlteracaoDeUsuario(w http.ResponseWriter, r *http.Request, usuarioID uint64, alterar func(*modelos.Usuario) modelos.Usuario) {
+	organizacao := tenancia.DaRequisicao(r)
+	repositorio := controladores.usuarios(organizacao.ID)
+	atual, erro := repositorio.BuscarPorID(r.Context(), usuarioID)
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	respostas.JSON(w, http.StatusOK, usuarios)
+	usuario := alterar(&atual)
+	if erro = usuario.Preparar("edicao"); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = repositorio.Atualizar(r.Context(), usuarioID, usuario); erro != nil {
+		respostas.Erro(w, statusDoErroDeBanco(erro), erro)
+		return
+	}
+
+	if usuario.Email != atual.Email || usuario.Nick != atual.Nick || usuario.Nome != atual.Nome {
+		invalidarCacheDeLogin(r.Context(), organizacao.ID, atual.Email)
+	}
+
+	usuario.ID = usuarioID
+	usuario.CriadoEm = atual.CriadoEm
+	usuario.Senha = ""
+	respostas.JSON(w, http.StatusOK, usuario)
 }
 
-// AtualizarSenha permite alterar a senha de um usuário
-func AtualizarSenha(w http.ResponseWriter, r *http.Request) {
-	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+// DeletarUsuario exclui a conta, que ainda pode ser restaurada com um login durante a carência (CARENCIA_EXCLUSAO_DIAS).
+// O dono da organização precisa transferir a propriedade antes.
+func (controladores *Controladores) DeletarUsuario(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
 	if erro != nil {
-		respostas.Erro(w, http.StatusUnauthorized, erro)
+		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
 
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, "usuarios:excluir"); erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	db := controladores.db
+
+	organizacao := tenancia.DaRequisicao(r)
+	repositorio := controladores.usuarios(organizacao.ID)
+	usuario, erro := repositorio.BuscarPorID(r.Context(), usuarioID)
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	papel, erro := repositorios.NovoRepositorioDeMembros(db, organizacao.ID).BuscarPapel(r.Context(), usuarioID)
+	if erro == nil && papel == modelos.PapelDono {
+		respostas.Erro(w, http.StatusConflict, errors.New("transfira a propriedade da organização antes de excluir a conta"))
+		return
+	}
+
+	if erro = repositorio.Deletar(r.Context(), usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	invalidarCacheDeLogin(r.Context(), organizacao.ID, usuario.Email)
+	autenticacao.InvalidarAcessos(r.Context(), organizacao.ID)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// invalidarCacheDeLogin remove os dados e o token guardados no cache pelo login do e-mail
+func invalidarCacheDeLogin(ctx context.Context, orgID uint64, email string) {
+	sufixo := sufixoDeLogin(orgID, email)
+	cache.Atual().Apagar(ctx, "user_data:"+sufixo, "auth_token:"+sufixo)
+}
+
+// statusDoErroDeBanco traduz violações de unicidade (nick ou e-mail em uso) para 409
+func statusDoErroDeBanco(erro error) int {
+	var erroPostgres *pgconn.PgError
+	if errors.As(erro, &erroPostgres) && erroPostgres.Code == "23505" || errors.Is(erro, repositorios.ErrRegistroDuplicado) {
+		return http.StatusConflict
+	}
+	if errors.Is(erro, sql.ErrNoRows) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// AtualizarSenha permite alterar a senha de um usuário
+func (controladores *Controladores) AtualizarSenha(w http.ResponseWriter, r *http.Request) {
 	parametros := mux.Vars(r)
 	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
 	if erro != nil {
@@ -88,8 +343,9 @@ func AtualizarSenha(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if usuarioIDNoToken != usuarioID {
-		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível atualizar a senha de um usuário que não seja o seu"))
+	// Exige a senha atual, então só faz sentido para o próprio usuário
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, ""); erro != nil {
+		respostas.Erro(w, status, erro)
 		return
 	}
 
@@ -100,15 +356,20 @@ func AtualizarSenha(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, erro := banco.Conectar()
+	organizacao := tenancia.DaRequisicao(r)
+	if erro = organizacao.PoliticaDeSenha.Validar(senha.Nova); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repositorio := controladores.usuarios(organizacao.ID)
+	usuario, erro := repositorio.BuscarPorID(r.Context(), usuarioID)
 	if erro != nil {
-		respostas.Erro(w, http.StatusInternalServerError, erro)
+		respostas.Erro(w, http.StatusNotFound, erro)
 		return
 	}
-	defer db.Close()
 
-	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
-	senhaSalvaNoBanco, erro := repositorio.BuscarSenha(usuarioID)
+	senhaSalvaNoBanco, erro := repositorio.BuscarSenha(r.Context(), usuarioID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
@@ -125,76 +386,136 @@ func AtualizarSenha(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if erro = repositorio.AtualizarSenha(usuarioID, string(senhaComHash)); erro != nil {
+	if erro = repositorio.AtualizarSenha(r.Context(), usuarioID, string(senhaComHash)); erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
+	// O token guardado pelo login com a senha antiga não é mais reaproveitado
+	invalidarCacheDeLogin(r.Context(), organizacao.ID, usuario.Email)
+
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
 
-// NovaSenha permite alterar a senha de um usuário
-func NovaSenha(w http.ResponseWriter, r *http.Request) {
-	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+// NovaSenha define uma nova senha para o próprio usuário, sem pedir a atual. A senha de outro usuário
+// só é definida por um administrador em RedefinirSenha, que fica registrada na auditoria.
+func (controladores *Controladores) NovaSenha(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
 	if erro != nil {
-		respostas.Erro(w, http.StatusUnauthorized, erro)
+		respostas.Erro(w, http.StatusBadRequest, errors.New("ID do usuário inválido"))
 		return
 	}
 
-	parametros := mux.Vars(r)
-	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
-	if erro != nil {
-		respostas.Erro(w, http.StatusBadRequest, errors.New("ID do usuário inválido"))
+	if status, erro := verificarDonoOuPermissao(r, usuarioID, ""); erro != nil {
+		respostas.Erro(w, status, erro)
 		return
 	}
 
-	if usuarioIDNoToken != usuarioID {
-		respostas.Erro(w, http.StatusForbidden, errors.New("não é possível atualizar a senha de um usuário que não seja o seu"))
+	organizacao := tenancia.DaRequisicao(r)
+	senhaComHash, status, erro := lerNovaSenha(r, organizacao)
+	if erro != nil {
+		respostas.Erro(w, status, erro)
 		return
 	}
 
-	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	repositorio := controladores.usuarios(organizacao.ID)
+	usuario, erro := repositorio.BuscarPorID(r.Context(), usuarioID)
 	if erro != nil {
-		respostas.Erro(w, http.StatusBadRequest, errors.New("erro ao ler o corpo da requisição"))
+		respostas.Erro(w, http.StatusNotFound, erro)
 		return
 	}
-	defer r.Body.Close()
 
-	var senha modelos.ResetarSenha
-	if erro = json.Unmarshal(corpoRequisicao, &senha); erro != nil {
-		respostas.Erro(w, http.StatusBadRequest, errors.New("formato de JSON inválido"))
+	if erro = repositorio.NovaSenha(r.Context(), usuarioID, senhaComHash); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, errors.New("erro ao atualizar a senha"))
 		return
 	}
 
-	if senha.NovaSenha == "" || senha.ConfirmarSenha == "" {
-		respostas.Erro(w, http.StatusBadRequest, errors.New("os campos novaSenha e confirmarSenha são obrigatórios"))
+	// O token guardado pelo login com a senha antiga não é mais reaproveitado
+	invalidarCacheDeLogin(r.Context(), organizacao.ID, usuario.Email)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// RedefinirSenha permite que um administrador com usuarios:editar defina a senha de outro usuário.
+// O evento é gravado na auditoria antes da troca: sem o registro, a senha não muda.
+func (controladores *Controladores) RedefinirSenha(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("ID do usuário inválido"))
 		return
 	}
 
-	if senha.NovaSenha != senha.ConfirmarSenha {
-		respostas.Erro(w, http.StatusBadRequest, errors.New("as senhas não coincidem"))
+	atorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
 		return
 	}
 
-	db, erro := banco.Conectar()
+	organizacao := tenancia.DaRequisicao(r)
+	senhaComHash, status, erro := lerNovaSenha(r, organizacao)
 	if erro != nil {
-		respostas.Erro(w, http.StatusInternalServerError, errors.New("erro ao conectar ao banco de dados"))
+		respostas.Erro(w, status, erro)
 		return
 	}
-	defer db.Close()
 
-	repositorio := repositorios.NovoRepositorioDeUsuarios(db)
+	repositorio := controladores.usuarios(organizacao.ID)
+	usuario, erro := repositorio.BuscarPorID(r.Context(), usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusNotFound, erro)
+		return
+	}
 
-	senhaComHash, erro := seguranca.Hash(senha.NovaSenha)
+	erro = repositorios.NovoRepositorioDeAuditoria(controladores.db, organizacao.ID).Registrar(r.Context(), modelos.EventoDeAuditoria{
+		AtorID:    &atorID,
+		UsuarioID: usuarioID,
+		Acao:      modelos.AuditoriaSenhaRedefinida,
+	})
 	if erro != nil {
-		respostas.Erro(w, http.StatusInternalServerError, errors.New("erro ao gerar hash da senha"))
+		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	if erro = repositorio.NovaSenha(usuarioID, string(senhaComHash)); erro != nil {
+	if erro = repositorio.NovaSenha(r.Context(), usuarioID, senhaComHash); erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, errors.New("erro ao atualizar a senha"))
 		return
 	}
 
+	// O token guardado pelo login com a senha antiga não é mais reaproveitado
+	invalidarCacheDeLogin(r.Context(), organizacao.ID, usuario.Email)
+
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
+
+// lerNovaSenha lê novaSenha e confirmarSenha do corpo, valida com a política da organização e retorna o hash.
+// No erro, retorna também o status da resposta.
+func lerNovaSenha(r *http.Request, organizacao modelos.Organizacao) (string, int, error) {
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		return "", http.StatusBadRequest, errors.New("erro ao ler o corpo da requisição")
+	}
+	defer r.Body.Close()
+
+	var senha modelos.ResetarSenha
+	if erro = json.Unmarshal(corpoRequisicao, &senha); erro != nil {
+		return "", http.StatusBadRequest, errors.New("formato de JSON inválido")
+	}
+
+	if senha.NovaSenha == "" || senha.ConfirmarSenha == "" {
+		return "", http.StatusBadRequest, errors.New("os campos novaSenha e confirmarSenha são obrigatórios")
+	}
+
+	if senha.NovaSenha != senha.ConfirmarSenha {
+		return "", http.StatusBadRequest, errors.New("as senhas não coincidem")
+	}
+
+	if erro = organizacao.PoliticaDeSenha.Validar(senha.NovaSenha); erro != nil {
+		return "", http.StatusBadRequest, erro
+	}
+
+	senhaComHash, erro := seguranca.Hash(senha.NovaSenha)
+	if erro != nil {
+		return "", http.StatusInternalServerError, errors.New("erro ao gerar hash da senha")
+	}
+
+	return string(senhaComHash), 0, nil
+}
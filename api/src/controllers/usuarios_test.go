@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"api/src/cache"
+	"api/src/modelos"
+	"api/src/paginacao"
+	"api/src/seguranca"
+	"api/src/testes"
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCriarUsuarioUsaORepositorioInjetado(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	corpo := map[string]string{"nome": "Ana Souza", "nick": "ana", "email": "ana@exemplo.com", "senha": "segredo123"}
+
+	w := requisitar(controladores.CriarUsuario, http.MethodPost, "/usuarios", corpo, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+
+	var criado struct {
+		ID uint64 `json:"id"`
+	}
+	lerJSON(t, w, &criado)
+
+	usuario, erro := usuarios(organizacaoDeTeste.ID).BuscarPorID(context.Background(), criado.ID)
+	if erro != nil || usuario.Nick != "ana" {
+		t.Fatalf("usuário não chegou ao repositório injetado: %+v, %v", usuario, erro)
+	}
+
+	// Nick e e-mail repetidos na organização são conflito, e não erro interno
+	if w = requisitar(controladores.CriarUsuario, http.MethodPost, "/usuarios", corpo, nil); w.Code != http.StatusConflict {
+		t.Fatalf("cadastro repetido: status %d, esperado 409", w.Code)
+	}
+
+	corpo["email"] = "invalido"
+	corpo["nick"] = "outra"
+	if w = requisitar(controladores.CriarUsuario, http.MethodPost, "/usuarios", corpo, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("e-mail inválido: status %d, esperado 400", w.Code)
+	}
+}
+
+func TestNovaSenhaSoParaOProprioUsuario(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ana, _ := repositorio.Criar(context.Background(), modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Senha: "hash-ana"})
+	bruno, _ := repositorio.Criar(context.Background(), modelos.Usuario{Nome: "Bruno", Nick: "bruno", Email: "bruno@exemplo.com", Senha: "hash-bruno"})
+
+	// Nem com usuarios:editar a rota troca a senha de outro usuário: para isso existe a redefinição com auditoria
+	permissoesDeTeste[ana] = []string{"usuarios:editar"}
+	t.Cleanup(func() { delete(permissoesDeTeste, ana) })
+
+	corpo := map[string]string{"novaSenha": "nova-senha-123", "confirmarSenha": "nova-senha-123"}
+	variaveis := map[string]string{"usuarioId": strconv.FormatUint(bruno, 10)}
+	if w := requisitarComo(t, ana, controladores.NovaSenha, http.MethodPost, "/", corpo, variaveis); w.Code != http.StatusForbidden {
+		t.Errorf("senha de outro usuário: status %d, quer 403", w.Code)
+	}
+	if senha, _ := repositorio.BuscarSenha(context.Background(), bruno); senha != "hash-bruno" {
+		t.Error("a senha do outro usuário foi alterada")
+	}
+
+	variaveis["usuarioId"] = strconv.FormatUint(ana, 10)
+	if w := requisitarComo(t, ana, controladores.NovaSenha, http.MethodPost, "/", corpo, variaveis); w.Code != http.StatusNoContent {
+		t.Fatalf("própria senha: status %d: %s", w.Code, w.Body.String())
+	}
+	if senha, _ := repositorio.BuscarSenha(context.Background(), ana); senha == "hash-ana" {
+		t.Error("a própria senha não foi alterada")
+	}
+}
+
+// TestTrocaDeSenhaDescartaOLoginGuardado: depois de trocar a senha, o login com a senha antiga não pode continuar
+// devolvendo o token guardado no cache
+func TestTrocaDeSenhaDescartaOLoginGuardado(t *testing.T) {
+	testes.Configurar(t, nil)
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	hash, _ := seguranca.Hash("senha-atual-1")
+	ana, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana.senha@exemplo.com", Senha: string(hash)})
+	variaveis := map[string]string{"usuarioId": strconv.FormatUint(ana, 10)}
+	sufixo := sufixoDeLogin(organizacaoDeTeste.ID, "ana.senha@exemplo.com")
+
+	casos := []struct {
+		nome    string
+		handler http.HandlerFunc
+		corpo   map[string]string
+	}{
+		{"AtualizarSenha", controladores.AtualizarSenha, map[string]string{"atual": "senha-atual-1", "nova": "senha-nova-22"}},
+		{"NovaSenha", controladores.NovaSenha, map[string]string{"novaSenha": "senha-nova-333", "confirmarSenha": "senha-nova-333"}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			cache.Atual().Salvar(ctx, "auth_token:"+sufixo, "token-antigo", time.Minute)
+			cache.Atual().Salvar(ctx, "user_data:"+sufixo, `{"id": 1}`, time.Minute)
+
+			if w := requisitarComo(t, ana, caso.handler, http.MethodPost, "/", caso.corpo, variaveis); w.Code != http.StatusNoContent {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+			for _, chave := range []string{"auth_token:" + sufixo, "user_data:" + sufixo} {
+				if valor, _ := cache.Atual().Buscar(ctx, chave); valor != "" {
+					t.Errorf("%s continua no cache: %q", chave, valor)
+				}
+			}
+		})
+	}
+}
+
+// TestBuscaDeUsuariosEmPaginas: o corpo continua sendo o array de usuários e a próxima página vem nos cabeçalhos
+// Link e X-Next-Cursor
+func TestBuscaDeUsuariosEmPaginas(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	IDs := map[string]uint64{}
+	for _, nick := range []string{"ana", "bia", "caio"} {
+		ID, erro := repositorio.Criar(ctx, modelos.Usuario{Nome: nick, Nick: nick, Email: nick + "@exemplo.com", Senha: "hash"})
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		IDs[nick] = ID
+	}
+	visitante := IDs["caio"]
+
+	proximaPagina := regexp.MustCompile(`^<(\?[^>]+)>; rel="next"$`)
+
+	w := requisitarComo(t, visitante, controladores.BuscarUsuarios, http.MethodGet, "/usuarios?ordem=nick&limite=2", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var primeira []modelos.ResultadoDeBusca
+	lerJSON(t, w, &primeira)
+	if len(primeira) != 2 || primeira[0].Nick != "ana" || primeira[1].Nick != "bia" {
+		t.Fatalf("primeira página = %+v", primeira)
+	}
+
+	link := proximaPagina.FindStringSubmatch(w.Header().Get("Link"))
+	if link == nil {
+		t.Fatalf("Link = %q, quer rel=\"next\"", w.Header().Get("Link"))
+	}
+	if cursor := w.Header().Get(paginacao.CabecalhoProximoCursor); cursor == "" || !strings.Contains(link[1], "cursor="+cursor) {
+		t.Errorf("%s = %q, quer o cursor do Link %q", paginacao.CabecalhoProximoCursor, cursor, link[1])
+	}
+
+	w = requisitarComo(t, visitante, controladores.BuscarUsuarios, http.MethodGet, "/usuarios"+link[1], nil, nil)
+	var segunda []modelos.ResultadoDeBusca
+	lerJSON(t, w, &segunda)
+	if len(segunda) != 1 || segunda[0].Nick != "caio" {
+		t.Errorf("segunda página = %+v", segunda)
+	}
+	if ultimo := w.Header().Get("Link"); ultimo != "" {
+		t.Errorf("Link na última página: %s", ultimo)
+	}
+
+	// ?privado= separa as contas privadas das públicas
+	bia, _ := repositorio.BuscarPorID(ctx, IDs["bia"])
+	bia.Privado = true
+	if erro := repositorio.Atualizar(ctx, bia.ID, bia); erro != nil {
+		t.Fatal(erro)
+	}
+	for valor, quer := range map[string][]string{"true": {"bia"}, "false": {"ana", "caio"}} {
+		w = requisitarComo(t, visitante, controladores.BuscarUsuarios, http.MethodGet, "/usuarios?ordem=nick&privado="+valor, nil, nil)
+		var encontrados []modelos.ResultadoDeBusca
+		lerJSON(t, w, &encontrados)
+		var nicks []string
+		for _, usuario := range encontrados {
+			nicks = append(nicks, usuario.Nick)
+		}
+		if strings.Join(nicks, ",") != strings.Join(quer, ",") {
+			t.Errorf("?privado=%s trouxe %v, quer %v", valor, nicks, quer)
+		}
+	}
+	if w = requisitarComo(t, visitante, controladores.BuscarUsuarios, http.MethodGet, "/usuarios?privado=talvez", nil, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("?privado=talvez: status %d, quer 400", w.Code)
+	}
+
+	// Termo longo demais é erro de quem pediu, e não do servidor
+	if w = requisitarComo(t, visitante, controladores.BuscarUsuarios, http.MethodGet, "/usuarios?usuario="+strings.Repeat("a", 101), nil, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("termo com 101 caracteres: status %d, quer 400", w.Code)
+	}
+}
+
+func TestPerfilDeUsuario(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	ana, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Senha: "hash"})
+	bruno, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Bruno", Nick: "bruno", Email: "bruno@exemplo.com", Senha: "hash"})
+	if _, erro := repositorio.Seguir(ctx, bruno, ana); erro != nil {
+		t.Fatal(erro)
+	}
+
+	buscar := func(visitante, usuarioID uint64) modelos.PerfilDeUsuario {
+		t.Helper()
+		w := requisitarComo(t, visitante, controladores.BuscarUsuario, http.MethodGet, "/", nil, map[string]string{"usuarioId": strconv.FormatUint(usuarioID, 10)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body.String())
+		}
+		var perfil modelos.PerfilDeUsuario
+		lerJSON(t, w, &perfil)
+		return perfil
+	}
+
+	// O e-mail só aparece para o próprio usuário; as contagens do grafo aparecem para todos
+	if perfil := buscar(bruno, bruno); perfil.Email != "bruno@exemplo.com" || perfil.Seguidores != 1 || perfil.Seguindo != 0 {
+		t.Errorf("próprio perfil = %+v", perfil)
+	}
+	if perfil := buscar(ana, bruno); perfil.Email != "" || perfil.Nick != "bruno" || perfil.Seguidores != 1 {
+		t.Errorf("perfil visto por outro = %+v", perfil)
+	}
+	if perfil := buscar(bruno, ana); perfil.Seguindo != 1 {
+		t.Errorf("seguindo = %d, quer 1", perfil.Seguindo)
+	}
+
+	if w := requisitarComo(t, ana, controladores.BuscarUsuario, http.MethodGet, "/", nil, map[string]string{"usuarioId": "9999"}); w.Code != http.StatusNotFound {
+		t.Errorf("usuário inexistente: status %d, quer 404", w.Code)
+	}
+	if w := requisitarComo(t, ana, controladores.BuscarUsuario, http.MethodGet, "/", nil, map[string]string{"usuarioId": "abc"}); w.Code != http.StatusBadRequest {
+		t.Errorf("ID inválido: status %d, quer 400", w.Code)
+	}
+}
+
+func TestAtualizarUsuarioParcialmente(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	ana, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Senha: "hash"})
+	bruno, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Bruno", Nick: "bruno", Email: "bruno@exemplo.com", Senha: "hash"})
+	variaveis := map[string]string{"usuarioId": strconv.FormatUint(ana, 10)}
+
+	// O PATCH com só o nome mantém nick, e-mail e a conta pública
+	w := requisitarComo(t, ana, controladores.AtualizarUsuarioParcialmente, http.MethodPatch, "/", map[string]string{"nome": "Ana Souza"}, variaveis)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	usuario, _ := repositorio.BuscarPorID(ctx, ana)
+	if usuario.Nome != "Ana Souza" || usuario.Nick != "ana" || usuario.Email != "ana@exemplo.com" || usuario.Privado {
+		t.Errorf("depois do PATCH = %+v", usuario)
+	}
+
+	w = requisitarComo(t, ana, controladores.AtualizarUsuarioParcialmente, http.MethodPatch, "/", map[string]bool{"privado": true}, variaveis)
+	if usuario, _ = repositorio.BuscarPorID(ctx, ana); w.Code != http.StatusOK || !usuario.Privado || usuario.Nome != "Ana Souza" {
+		t.Errorf("PATCH privado: status %d, usuário %+v", w.Code, usuario)
+	}
+
+	// Os campos enviados passam pela mesma validação do PUT
+	if w = requisitarComo(t, ana, controladores.AtualizarUsuarioParcialmente, http.MethodPatch, "/", map[string]string{"email": "invalido"}, variaveis); w.Code != http.StatusBadRequest {
+		t.Errorf("e-mail inválido: status %d, quer 400", w.Code)
+	}
+	if w = requisitarComo(t, ana, controladores.AtualizarUsuarioParcialmente, http.MethodPatch, "/", map[string]string{"nick": "bruno"}, variaveis); w.Code != http.StatusConflict {
+		t.Errorf("nick de outro usuário: status %d, quer 409", w.Code)
+	}
+
+	// Sem usuarios:editar, ninguém altera o perfil alheio, nem com PUT nem com PATCH
+	corpo := map[string]string{"nome": "Invasor", "nick": "ana", "email": "ana@exemplo.com"}
+	if w = requisitarComo(t, bruno, controladores.AtualizarUsuario, http.MethodPut, "/", corpo, variaveis); w.Code != http.StatusForbidden {
+		t.Errorf("PUT de outro usuário: status %d, quer 403", w.Code)
+	}
+	if w = requisitarComo(t, bruno, controladores.AtualizarUsuarioParcialmente, http.MethodPatch, "/", corpo, variaveis); w.Code != http.StatusForbidden {
+		t.Errorf("PATCH de outro usuário: status %d, quer 403", w.Code)
+	}
+	if usuario, _ = repositorio.BuscarPorID(ctx, ana); usuario.Nome != "Ana Souza" {
+		t.Errorf("perfil alterado por outro usuário: %+v", usuario)
+	}
+
+	// O PUT substitui todos os campos editáveis
+	corpo["nome"] = "Ana S."
+	if w = requisitarComo(t, ana, controladores.AtualizarUsuario, http.MethodPut, "/", corpo, variaveis); w.Code != http.StatusOK {
+		t.Fatalf("PUT: status %d: %s", w.Code, w.Body.String())
+	}
+	if usuario, _ = repositorio.BuscarPorID(ctx, ana); usuario.Nome != "Ana S." || usuario.Privado {
+		t.Errorf("depois do PUT = %+v", usuario)
+	}
+}
+
+func TestDeletarUsuarioDeOutro(t *testing.T) {
+	controladores, usuarios := novosControladoresDeTeste()
+	repositorio := usuarios(organizacaoDeTeste.ID)
+	ctx := context.Background()
+
+	ana, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Senha: "hash"})
+	bruno, _ := repositorio.Criar(ctx, modelos.Usuario{Nome: "Bruno", Nick: "bruno", Email: "bruno@exemplo.com", Senha: "hash"})
+
+	variaveis := map[string]string{"usuarioId": strconv.FormatUint(ana, 10)}
+	if w := requisitarComo(t, bruno, controladores.DeletarUsuario, http.MethodDelete, "/", nil, variaveis); w.Code != http.StatusForbidden {
+		t.Errorf("exclusão de outro usuário: status %d, quer 403", w.Code)
+	}
+	if _, erro := repositorio.BuscarPorID(ctx, ana); erro != nil {
+		t.Errorf("a conta foi excluída: %v", erro)
+	}
+}
@@ -0,0 +1,80 @@
+package email
+
+import (
+	"api/src/config"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// ErrNaoConfigurado indica que SMTP_HOST não foi definido e o e-mail não foi enviado
+var ErrNaoConfigurado = errors.New("envio de e-mail não configurado")
+
+// Enviar manda um e-mail de texto simples pelo servidor SMTP configurado
+func Enviar(destinatario, assunto, corpo string) error {
+	configuracaoSMTP := config.Atual().SMTP
+	if configuracaoSMTP.Host == "" {
+		return ErrNaoConfigurado
+	}
+
+	// Quebras de linha no destinatário ou no assunto permitiriam injetar cabeçalhos
+	if strings.ContainsAny(destinatario, "\r\n") || strings.ContainsAny(assunto, "\r\n") {
+		return errors.New("destinatário ou assunto inválido")
+	}
+
+	mensagem := strings.Join([]string{
+		"From: " + configuracaoSMTP.Remetente,
+		"To: " + destinatario,
+		"Subject: " + mime.QEncoding.Encode("utf-8", assunto),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		corpo,
+	}, "\r\n")
+
+	var autenticacao smtp.Auth
+	if configuracaoSMTP.Usuario != "" {
+		autenticacao = smtp.PlainAuth("", configuracaoSMTP.Usuario, string(configuracaoSMTP.Senha), configuracaoSMTP.Host)
+	}
+
+	endereco := fmt.Sprintf("%s:%d", configuracaoSMTP.Host, configuracaoSMTP.Porta)
+	if erro := smtp.SendMail(endereco, autenticacao, configuracaoSMTP.Remetente, []string{destinatario}, []byte(mensagem)); erro != nil {
+		return fmt.Errorf("erro ao enviar e-mail: %v", erro)
+	}
+
+	return nil
+}
+
+// Verificar confere se o servidor SMTP atende, sem enviar nada; ErrNaoConfigurado quando SMTP_HOST não foi definido
+func Verificar(ctx context.Context) error {
+	configuracaoSMTP := config.Atual().SMTP
+	if configuracaoSMTP.Host == "" {
+		return ErrNaoConfigurado
+	}
+
+	var discador net.Dialer
+	conexao, erro := discador.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", configuracaoSMTP.Host, configuracaoSMTP.Porta))
+	if erro != nil {
+		return fmt.Errorf("servidor SMTP inacessível: %v", erro)
+	}
+	if prazo, ok := ctx.Deadline(); ok {
+		conexao.SetDeadline(prazo)
+	}
+
+	// NewClient lê a saudação do servidor; NOOP confirma que ele responde a comandos
+	cliente, erro := smtp.NewClient(conexao, configuracaoSMTP.Host)
+	if erro != nil {
+		conexao.Close()
+		return fmt.Errorf("servidor SMTP não respondeu: %v", erro)
+	}
+	defer cliente.Close()
+
+	if erro = cliente.Noop(); erro != nil {
+		return fmt.Errorf("servidor SMTP não respondeu: %v", erro)
+	}
+	return cliente.Quit()
+}
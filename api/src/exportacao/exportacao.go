@@ -0,0 +1,275 @@
+package exportacao
+
+import (
+	"api/src/banco"
+	"api/src/cache"
+	"api/src/config"
+	"api/src/email"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/seguranca"
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	// Validade é por quanto tempo o arquivo gerado e o link de download ficam disponíveis
+	Validade = 48 * time.Hour
+	// TempoMaximoDeGeracao é quanto uma exportação pode ficar pendente; passado esse tempo a geração foi
+	// interrompida (a instância caiu, por exemplo) e a exportação é dada como falha
+	TempoMaximoDeGeracao = 30 * time.Minute
+)
+
+// prazoParaMarcarFalha limita a gravação da falha, feita mesmo depois de o ctx da geração ser cancelado
+const prazoParaMarcarFalha = 5 * time.Second
+
+// finalidadeDoDownload separa a chave dos links de download da SECRET_KEY que assina os tokens
+const finalidadeDoDownload = "exportacao:download"
+
+// leiaMe acompanha o ZIP e explica o conteúdo de cada arquivo
+const leiaMe = `Exportação de dados pessoais (LGPD, art. 18)
+
+perfil.json                  dados da conta, papel na organização e papéis de acesso
+sessoes.json                 sessão de login guardada em cache e tentativas recentes de login
+historico_de_logins.json     logins aceitos: quando, por qual meio (senha, LDAP, OIDC, SAML) e de qual IP
+seguidores.json              quem segue você
+seguindo.json                quem você segue
+bloqueados.json              usuários bloqueados por você
+silenciados.json             usuários silenciados por você
+solicitacoes_recebidas.json  pedidos pendentes para seguir a sua conta privada
+solicitacoes_enviadas.json   seus pedidos pendentes para seguir contas privadas
+identidades_externas.json    vínculos com provedores de login externo (OIDC e SAML)
+auditoria.json               ações de administradores sobre a sua conta (papéis e redefinição de senha)
+consentimentos.json          consentimentos concedidos e revogados, do mais antigo para o mais recente
+`
+
+// Gerar monta o ZIP com os dados pessoais do titular, guarda no banco e avisa por e-mail com o link assinado.
+// Roda em segundo plano com o ctx do ciclo de vida da API, não o da requisição que a pediu. Em caso de erro, ou se
+// o encerramento da API cancelar o ctx, a exportação é marcada como falha e o usuário pode pedir outra.
+func Gerar(ctx context.Context, organizacao modelos.Organizacao, exportacao modelos.Exportacao) {
+	db := banco.Conexao()
+
+	repositorio := repositorios.NovoRepositorioDeExportacoes(db, organizacao.ID)
+	usuario, expiraEm, erro := gerar(ctx, db, repositorio, organizacao.ID, exportacao.UsuarioID, exportacao.ID)
+	if erro != nil {
+		log.Printf("Erro na exportação %d: %v", exportacao.ID, erro)
+		falhar(ctx, repositorio, exportacao.ID, motivoDaFalha(ctx))
+		return
+	}
+
+	notificar(organizacao, usuario, exportacao.ID, expiraEm)
+}
+
+// motivoDaFalha diferencia a geração interrompida pelo encerramento da API de um erro ao gerar
+func motivoDaFalha(ctx context.Context) string {
+	if ctx.Err() != nil {
+		return "a geração foi interrompida pelo encerramento da API"
+	}
+	return "não foi possível gerar a exportação"
+}
+
+// falhar marca a exportação como falha. O ctx recebido pode já estar cancelado pelo encerramento; a marcação usa um
+// prazo próprio para não deixar a exportação pendente até TempoMaximoDeGeracao.
+func falhar(ctx context.Context, repositorio *repositorios.Exportacoes, exportacaoID uint64, motivo string) {
+	ctx, cancelar := context.WithTimeout(context.WithoutCancel(ctx), prazoParaMarcarFalha)
+	defer cancelar()
+
+	if erro := repositorio.Falhar(ctx, exportacaoID, motivo); erro != nil {
+		log.Printf("Erro ao marcar a exportação %d como falha: %v", exportacaoID, erro)
+	}
+}
+
+// gerar coleta os dados, compacta e salva o arquivo, retornando o titular e quando o arquivo expira
+func gerar(ctx context.Context, db *sql.DB, repositorio *repositorios.Exportacoes, orgID, usuarioID, exportacaoID uint64) (modelos.Usuario, time.Time, error) {
+	usuario, arquivos, erro := coletar(ctx, db, repositorio, orgID, usuarioID)
+	if erro != nil {
+		return modelos.Usuario{}, time.Time{}, erro
+	}
+
+	arquivo, erro := compactar(arquivos)
+	if erro != nil {
+		return modelos.Usuario{}, time.Time{}, erro
+	}
+
+	expiraEm := time.Now().Add(Validade)
+	if erro = repositorio.Concluir(ctx, exportacaoID, arquivo, expiraEm); erro != nil {
+		return modelos.Usuario{}, time.Time{}, erro
+	}
+
+	return usuario, expiraEm, nil
+}
+
+// URLDeDownload monta o link assinado que dá acesso ao arquivo até expiraEm, sem precisar do token
+func URLDeDownload(organizacao modelos.Organizacao, exportacaoID uint64, expiraEm time.Time) string {
+	expira := strconv.FormatInt(expiraEm.Unix(), 10)
+	assinatura := seguranca.Assinar(chaveDoDownload(), mensagemAssinada(organizacao.ID, exportacaoID, expira))
+	return fmt.Sprintf("%s/o/%s/exportacoes/%d/download?expira=%s&assinatura=%s",
+		config.Atual().URLBase, organizacao.Slug, exportacaoID, expira, assinatura)
+}
+
+// VerificarDownload confere a assinatura e a validade dos parâmetros do link de download
+func VerificarDownload(orgID, exportacaoID uint64, expira, assinatura string) error {
+	segundos, erro := strconv.ParseInt(expira, 10, 64)
+	if erro != nil || !seguranca.AssinaturaValida(chaveDoDownload(), mensagemAssinada(orgID, exportacaoID, expira), assinatura) {
+		return errors.New("link de download inválido")
+	}
+
+	if time.Now().After(time.Unix(segundos, 0)) {
+		return errors.New("link de download expirado")
+	}
+
+	return nil
+}
+
+func chaveDoDownload() []byte {
+	return seguranca.DerivarChave([]byte(config.Atual().SecretKey), finalidadeDoDownload)
+}
+
+func mensagemAssinada(orgID, exportacaoID uint64, expira string) string {
+	return fmt.Sprintf("exportacao:%d:%d:%s", orgID, exportacaoID, expira)
+}
+
+// coletar reúne os dados do titular, um item por arquivo JSON
+func coletar(ctx context.Context, db *sql.DB, repositorio *repositorios.Exportacoes, orgID, usuarioID uint64) (modelos.Usuario, map[string]interface{}, error) {
+	usuario, erro := repositorios.NovoRepositorioDeUsuarios(db, orgID).BuscarPorID(ctx, usuarioID)
+	if erro != nil {
+		return modelos.Usuario{}, nil, erro
+	}
+
+	papeis, erro := repositorios.NovoRepositorioDePapeis(db, orgID).BuscarDoUsuario(ctx, usuarioID)
+	if erro != nil {
+		return modelos.Usuario{}, nil, erro
+	}
+
+	papelNaOrganizacao, erro := repositorios.NovoRepositorioDeMembros(db, orgID).BuscarPapel(ctx, usuarioID)
+	if erro != nil && !errors.Is(erro, repositorios.ErrMembroNaoEncontrado) {
+		return modelos.Usuario{}, nil, erro
+	}
+
+	vinculos, erro := repositorio.BuscarVinculos(ctx, usuarioID)
+	if erro != nil {
+		return modelos.Usuario{}, nil, erro
+	}
+
+	identidades, erro := repositorio.BuscarIdentidades(ctx, usuarioID)
+	if erro != nil {
+		return modelos.Usuario{}, nil, erro
+	}
+
+	eventos, erro := repositorios.NovoRepositorioDeAuditoria(db, orgID).BuscarDoUsuario(ctx, usuarioID)
+	if erro != nil {
+		return modelos.Usuario{}, nil, erro
+	}
+
+	logins, erro := repositorios.NovoRepositorioDeHistoricoDeLogins(db, orgID).BuscarDoUsuario(ctx, usuarioID)
+	if erro != nil {
+		return modelos.Usuario{}, nil, erro
+	}
+
+	consentimentos, erro := repositorios.NovoRepositorioDeConsentimentos(db, orgID).BuscarDoUsuario(ctx, usuarioID)
+	if erro != nil {
+		return modelos.Usuario{}, nil, erro
+	}
+
+	arquivos := map[string]interface{}{
+		"perfil": struct {
+			modelos.Usuario
+			PapelNaOrganizacao string   `json:"papelNaOrganizacao,omitempty"`
+			Papeis             []string `json:"papeis"`
+		}{usuario, papelNaOrganizacao, papeis},
+		"sessoes":              sessoes(ctx, orgID, usuario.Email),
+		"identidades_externas": identidades,
+		"auditoria":            eventos,
+		"historico_de_logins":  logins,
+		"consentimentos":       consentimentos,
+	}
+	for nome, lista := range vinculos {
+		arquivos[nome] = lista
+	}
+
+	return usuario, arquivos, nil
+}
+
+// sessoes descreve o que o login guarda no cache para o e-mail, sem expor o token.
+// As chaves seguem o formato usado pelo login: prefixo + "{orgId}:{email}".
+func sessoes(ctx context.Context, orgID uint64, emailDoUsuario string) interface{} {
+	sufixo := fmt.Sprintf("%d:%s", orgID, emailDoUsuario)
+
+	var sessao struct {
+		LoginEmCache           bool       `json:"loginEmCache"`
+		CacheExpiraEm          *time.Time `json:"cacheExpiraEm,omitempty"`
+		TentativasRecentes     int        `json:"tentativasDeLoginRecentes"`
+		BloqueadoPorTentativas bool       `json:"bloqueadoPorTentativas"`
+	}
+
+	armazenamento := cache.Atual()
+	if duracao, erro := armazenamento.Validade(ctx, "auth_token:"+sufixo); erro == nil {
+		sessao.LoginEmCache = true
+		if duracao > 0 {
+			expiraEm := time.Now().Add(duracao)
+			sessao.CacheExpiraEm = &expiraEm
+		}
+	}
+	if tentativas, erro := armazenamento.Buscar(ctx, "login_attempts:"+sufixo); erro == nil {
+		sessao.TentativasRecentes, _ = strconv.Atoi(tentativas)
+	}
+	_, erro := armazenamento.Buscar(ctx, "login_blocked:"+sufixo)
+	sessao.BloqueadoPorTentativas = erro == nil
+
+	return sessao
+}
+
+// compactar grava cada item como um arquivo JSON dentro do ZIP, junto com o LEIA-ME
+func compactar(arquivos map[string]interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	escritor := zip.NewWriter(&buffer)
+
+	leia, erro := escritor.Create("LEIA-ME.txt")
+	if erro != nil {
+		return nil, erro
+	}
+	if _, erro = leia.Write([]byte(leiaMe)); erro != nil {
+		return nil, erro
+	}
+
+	for nome, conteudo := range arquivos {
+		dados, erro := json.MarshalIndent(conteudo, "", "  ")
+		if erro != nil {
+			return nil, erro
+		}
+
+		arquivo, erro := escritor.Create(nome + ".json")
+		if erro != nil {
+			return nil, erro
+		}
+		if _, erro = arquivo.Write(dados); erro != nil {
+			return nil, erro
+		}
+	}
+
+	if erro = escritor.Close(); erro != nil {
+		return nil, erro
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// notificar avisa o titular por e-mail; sem SMTP o link continua disponível na consulta da exportação
+func notificar(organizacao modelos.Organizacao, usuario modelos.Usuario, exportacaoID uint64, expiraEm time.Time) {
+	corpo := fmt.Sprintf(
+		"Olá, %s.\n\nA exportação dos seus dados em %s está pronta. Para baixar, acesse:\n%s\n\nO link expira em %s.",
+		usuario.Nome, organizacao.Nome, URLDeDownload(organizacao, exportacaoID, expiraEm), expiraEm.Format("02/01/2006 15:04"),
+	)
+
+	if erro := email.Enviar(usuario.Email, "Seus dados estão prontos", corpo); erro != nil {
+		log.Printf("Aviso da exportação %d não enviado por e-mail: %v", exportacaoID, erro)
+	}
+}
@@ -0,0 +1,106 @@
+package exportacao
+
+import (
+	"api/src/config"
+	"api/src/modelos"
+	"api/src/seguranca"
+	"api/src/testes"
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLinkDeDownload(t *testing.T) {
+	testes.Configurar(t, map[string]string{"APP_URL": "https://api.exemplo.com"})
+	organizacao := modelos.Organizacao{ID: 3, Slug: "empresa"}
+	expiraEm := time.Now().Add(time.Hour)
+
+	link, erro := url.Parse(URLDeDownload(organizacao, 42, expiraEm))
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if link.Path != "/o/empresa/exportacoes/42/download" {
+		t.Errorf("caminho = %s", link.Path)
+	}
+	expira, assinatura := link.Query().Get("expira"), link.Query().Get("assinatura")
+
+	if erro = VerificarDownload(3, 42, expira, assinatura); erro != nil {
+		t.Fatalf("link recém-gerado: %v", erro)
+	}
+
+	// Um HMAC feito direto com a SECRET_KEY, a chave dos tokens, não vale como link de download
+	comASecretKey := seguranca.Assinar([]byte(config.Atual().SecretKey), mensagemAssinada(3, 42, expira))
+	if VerificarDownload(3, 42, expira, comASecretKey) == nil {
+		t.Error("assinatura com a SECRET_KEY aceita")
+	}
+
+	casos := []struct {
+		nome                string
+		orgID, exportacaoID uint64
+		expira              string
+	}{
+		{"outra exportação", 3, 43, expira},
+		{"outra organização", 4, 42, expira},
+		{"validade estendida", 3, 42, strconv.FormatInt(expiraEm.Add(time.Hour).Unix(), 10)},
+		{"validade ilegível", 3, 42, "amanha"},
+	}
+	for _, caso := range casos {
+		if VerificarDownload(caso.orgID, caso.exportacaoID, caso.expira, assinatura) == nil {
+			t.Errorf("%s: link aceito", caso.nome)
+		}
+	}
+
+	vencido := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	if erro = VerificarDownload(3, 42, vencido, seguranca.Assinar(chaveDoDownload(), mensagemAssinada(3, 42, vencido))); erro == nil || !strings.Contains(erro.Error(), "expirado") {
+		t.Errorf("link vencido: erro = %v", erro)
+	}
+}
+
+func TestCompactar(t *testing.T) {
+	arquivo, erro := compactar(map[string]interface{}{
+		"perfil":    map[string]string{"nick": "ana"},
+		"auditoria": []modelos.EventoDeAuditoria{{Acao: modelos.AuditoriaPapelAtribuido}},
+	})
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	leitor, erro := zip.NewReader(bytes.NewReader(arquivo), int64(len(arquivo)))
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	nomes := map[string]bool{}
+	for _, item := range leitor.File {
+		nomes[item.Name] = true
+	}
+	for _, nome := range []string{"LEIA-ME.txt", "perfil.json", "auditoria.json"} {
+		if !nomes[nome] {
+			t.Errorf("%s ausente no ZIP: %v", nome, nomes)
+		}
+	}
+
+	// O LEIA-ME descreve cada arquivo, inclusive o histórico de logins e os consentimentos
+	for _, trecho := range []string{"auditoria.json", "historico_de_logins.json", "consentimentos.json"} {
+		if !strings.Contains(leiaMe, trecho) {
+			t.Errorf("LEIA-ME sem %q", trecho)
+		}
+	}
+}
+
+// TestMotivoDaFalha: a exportação cancelada pelo encerramento da API não é descrita como erro na geração
+func TestMotivoDaFalha(t *testing.T) {
+	ctx, cancelar := context.WithCancel(context.Background())
+	if motivo := motivoDaFalha(ctx); strings.Contains(motivo, "encerramento") {
+		t.Errorf("ctx ativo: motivo = %q", motivo)
+	}
+
+	cancelar()
+	if motivo := motivoDaFalha(ctx); !strings.Contains(motivo, "encerramento") {
+		t.Errorf("ctx cancelado: motivo = %q", motivo)
+	}
+}
@@ -0,0 +1,154 @@
+package federacao
+
+import (
+	"api/src/config"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Identidade representa o usuário autenticado por um provedor externo
+type Identidade struct {
+	Provedor        string
+	Sujeito         string
+	Email           string
+	EmailVerificado bool
+	Nome            string
+	Nick            string
+}
+
+// Provedor é um relying party OpenID Connect configurado a partir de config.ProvedorOIDC
+type Provedor struct {
+	config.ProvedorOIDC
+
+	mutex        sync.Mutex
+	verificador  *oidc.IDTokenVerifier
+	configOAuth2 *oauth2.Config
+}
+
+var (
+	provedores     map[string]*Provedor
+	provedoresOnce sync.Once
+)
+
+// Provedores retorna os provedores habilitados, na ordem em que foram configurados
+func Provedores() []config.ProvedorOIDC {
+	return config.Atual().ProvedoresOIDC
+}
+
+// Buscar retorna o provedor com o nome informado
+func Buscar(nome string) (*Provedor, error) {
+	provedoresOnce.Do(func() {
+		provedores = make(map[string]*Provedor)
+		for _, provedor := range config.Atual().ProvedoresOIDC {
+			provedores[provedor.Nome] = &Provedor{ProvedorOIDC: provedor}
+		}
+	})
+
+	provedor, ok := provedores[nome]
+	if !ok {
+		return nil, fmt.Errorf("provedor %s não configurado", nome)
+	}
+	return provedor, nil
+}
+
+// descobrir busca o documento de discovery do issuer na primeira utilização do provedor
+func (provedor *Provedor) descobrir(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	provedor.mutex.Lock()
+	defer provedor.mutex.Unlock()
+
+	if provedor.configOAuth2 != nil {
+		return provedor.configOAuth2, provedor.verificador, nil
+	}
+
+	descoberto, erro := oidc.NewProvider(ctx, provedor.Issuer)
+	if erro != nil {
+		return nil, nil, fmt.Errorf("erro ao consultar o provedor %s: %v", provedor.Nome, erro)
+	}
+
+	provedor.configOAuth2 = &oauth2.Config{
+		ClientID:     provedor.ClientID,
+		ClientSecret: string(provedor.ClientSecret),
+		Endpoint:     descoberto.Endpoint(),
+		RedirectURL:  provedor.URLDeRetorno(),
+		Scopes:       provedor.Escopos,
+	}
+	provedor.verificador = descoberto.Verifier(&oidc.Config{ClientID: provedor.ClientID})
+
+	return provedor.configOAuth2, provedor.verificador, nil
+}
+
+// URLDeRetorno é o endereço de callback registrado no provedor
+func (provedor *Provedor) URLDeRetorno() string {
+	return config.Atual().URLBase + "/login/" + provedor.Nome + "/callback"
+}
+
+// URLDeAutorizacao monta a URL para onde o navegador é redirecionado, com state, nonce e PKCE
+func (provedor *Provedor) URLDeAutorizacao(ctx context.Context, estado, nonce, verificadorPKCE string) (string, error) {
+	configOAuth2, _, erro := provedor.descobrir(ctx)
+	if erro != nil {
+		return "", erro
+	}
+
+	return configOAuth2.AuthCodeURL(estado, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verificadorPKCE)), nil
+}
+
+// Concluir troca o código de autorização, valida o id_token e mapeia as claims para uma Identidade
+func (provedor *Provedor) Concluir(ctx context.Context, codigo, nonce, verificadorPKCE string) (Identidade, error) {
+	configOAuth2, verificador, erro := provedor.descobrir(ctx)
+	if erro != nil {
+		return Identidade{}, erro
+	}
+
+	tokenOAuth2, erro := configOAuth2.Exchange(ctx, codigo, oauth2.VerifierOption(verificadorPKCE))
+	if erro != nil {
+		return Identidade{}, fmt.Errorf("erro ao trocar o código de autorização: %v", erro)
+	}
+
+	idTokenBruto, ok := tokenOAuth2.Extra("id_token").(string)
+	if !ok {
+		return Identidade{}, errors.New("o provedor não retornou um id_token")
+	}
+
+	idToken, erro := verificador.Verify(ctx, idTokenBruto)
+	if erro != nil {
+		return Identidade{}, fmt.Errorf("id_token inválido: %v", erro)
+	}
+
+	if idToken.Nonce != nonce {
+		return Identidade{}, errors.New("nonce do id_token não confere")
+	}
+
+	var claims map[string]interface{}
+	if erro = idToken.Claims(&claims); erro != nil {
+		return Identidade{}, erro
+	}
+
+	identidade := Identidade{
+		Provedor: provedor.Nome,
+		Sujeito:  idToken.Subject,
+		Email:    strings.ToLower(strings.TrimSpace(claimTexto(claims, provedor.ClaimEmail))),
+		Nome:     claimTexto(claims, provedor.ClaimNome),
+		Nick:     claimTexto(claims, provedor.ClaimNick),
+	}
+
+	// Alguns provedores enviam email_verified como texto ("true") em vez de booleano
+	switch verificado := claims[provedor.ClaimEmailVerificado].(type) {
+	case bool:
+		identidade.EmailVerificado = verificado
+	case string:
+		identidade.EmailVerificado = verificado == "true"
+	}
+
+	return identidade, nil
+}
+
+func claimTexto(claims map[string]interface{}, nome string) string {
+	valor, _ := claims[nome].(string)
+	return valor
+}
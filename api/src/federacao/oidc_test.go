@@ -0,0 +1,238 @@
+package federacao
+
+import (
+	"api/src/config"
+	"api/src/testes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// provedorDeTeste é um IdP OpenID Connect em processo: publica o discovery e as chaves, e troca o código de
+// autorização por um id_token com as claims que o teste definir, conferindo o PKCE
+type provedorDeTeste struct {
+	servidor *httptest.Server
+	chave    *rsa.PrivateKey
+
+	mutex       sync.Mutex
+	desafio     string
+	claims      jwt.MapClaims
+	chaveDoSign *rsa.PrivateKey
+}
+
+func novoProvedorDeTeste(t *testing.T) *provedorDeTeste {
+	t.Helper()
+
+	chave, erro := rsa.GenerateKey(rand.Reader, 2048)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	provedor := &provedorDeTeste{chave: chave}
+
+	rotas := http.NewServeMux()
+	rotas.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                provedor.servidor.URL,
+			"authorization_endpoint":                provedor.servidor.URL + "/autorizar",
+			"token_endpoint":                        provedor.servidor.URL + "/token",
+			"jwks_uri":                              provedor.servidor.URL + "/chaves",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	rotas.HandleFunc("/chaves", func(w http.ResponseWriter, r *http.Request) {
+		codificar := base64.RawURLEncoding.EncodeToString
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "chave-de-teste",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   codificar(chave.N.Bytes()),
+				"e":   codificar(big.NewInt(int64(chave.E)).Bytes()),
+			}},
+		})
+	})
+	rotas.HandleFunc("/token", provedor.emitir)
+
+	provedor.servidor = httptest.NewServer(rotas)
+	t.Cleanup(provedor.servidor.Close)
+	return provedor
+}
+
+// emitir responde ao token endpoint; o code_verifier precisa bater com o code_challenge da URL de autorização
+func (provedor *provedorDeTeste) emitir(w http.ResponseWriter, r *http.Request) {
+	provedor.mutex.Lock()
+	defer provedor.mutex.Unlock()
+
+	r.ParseForm()
+	resumo := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != "codigo-valido" || base64.RawURLEncoding.EncodeToString(resumo[:]) != provedor.desafio {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, provedor.claims)
+	token.Header["kid"] = "chave-de-teste"
+	idToken, _ := token.SignedString(provedor.chaveDoSign)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "acesso",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// autorizar simula o navegador passando pelo IdP: guarda o code_challenge, as claims do próximo id_token e a
+// chave que vai assiná-lo (nil para a chave publicada)
+func (provedor *provedorDeTeste) autorizar(t *testing.T, urlDeAutorizacao string, claims jwt.MapClaims, chave *rsa.PrivateKey) {
+	t.Helper()
+
+	endereco, erro := url.Parse(urlDeAutorizacao)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if metodo := endereco.Query().Get("code_challenge_method"); metodo != "S256" {
+		t.Fatalf("code_challenge_method = %q, quer S256", metodo)
+	}
+
+	provedor.mutex.Lock()
+	defer provedor.mutex.Unlock()
+	provedor.desafio = endereco.Query().Get("code_challenge")
+	provedor.claims = claims
+	provedor.chaveDoSign = provedor.chave
+	if chave != nil {
+		provedor.chaveDoSign = chave
+	}
+}
+
+func (provedor *provedorDeTeste) claimsValidas(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":                provedor.servidor.URL,
+		"aud":                "api-de-teste",
+		"sub":                "sujeito-123",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              nonce,
+		"email":              " Ana@Empresa.com ",
+		"email_verified":     "true",
+		"name":               "Ana",
+		"preferred_username": "ana",
+	}
+}
+
+func (provedor *provedorDeTeste) configurado() *Provedor {
+	return &Provedor{ProvedorOIDC: config.ProvedorOIDC{
+		Nome:                 "teste",
+		Issuer:               provedor.servidor.URL,
+		ClientID:             "api-de-teste",
+		ClientSecret:         "segredo",
+		Escopos:              []string{"openid", "email", "profile"},
+		ClaimEmail:           "email",
+		ClaimEmailVerificado: "email_verified",
+		ClaimNome:            "name",
+		ClaimNick:            "preferred_username",
+	}}
+}
+
+func TestLoginOIDC(t *testing.T) {
+	testes.Configurar(t, map[string]string{"APP_URL": "https://api.exemplo.com"})
+	idp := novoProvedorDeTeste(t)
+	ctx := context.Background()
+
+	const nonce, verificador = "nonce-de-teste", "verificador-pkce-com-pelo-menos-43-caracteres-0123"
+
+	casos := []struct {
+		nome    string
+		ajustar func(claims jwt.MapClaims)
+		chave   *rsa.PrivateKey
+		nonce   string
+		falha   string
+	}{
+		{nome: "válido"},
+		{nome: "nonce de outro login", nonce: "outro-nonce", falha: "nonce"},
+		{nome: "outra audiência", ajustar: func(claims jwt.MapClaims) { claims["aud"] = "outro-cliente" }, falha: "id_token inválido"},
+		{nome: "expirado", ajustar: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() }, falha: "id_token inválido"},
+		{nome: "outro emissor", ajustar: func(claims jwt.MapClaims) { claims["iss"] = "https://falso.exemplo.com" }, falha: "id_token inválido"},
+		{nome: "assinado com outra chave", chave: outraChave(t), falha: "id_token inválido"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			provedor := idp.configurado()
+
+			urlDeAutorizacao, erro := provedor.URLDeAutorizacao(ctx, "estado", nonce, verificador)
+			if erro != nil {
+				t.Fatal(erro)
+			}
+			if !strings.HasPrefix(urlDeAutorizacao, idp.servidor.URL+"/autorizar?") || !strings.Contains(urlDeAutorizacao, "nonce="+nonce) {
+				t.Fatalf("URL de autorização = %s", urlDeAutorizacao)
+			}
+			if retorno := provedor.URLDeRetorno(); retorno != "https://api.exemplo.com/login/teste/callback" {
+				t.Errorf("URL de retorno = %s", retorno)
+			}
+
+			claims := idp.claimsValidas(nonce)
+			if caso.nonce != "" {
+				claims["nonce"] = caso.nonce
+			}
+			if caso.ajustar != nil {
+				caso.ajustar(claims)
+			}
+			idp.autorizar(t, urlDeAutorizacao, claims, caso.chave)
+
+			identidade, erro := provedor.Concluir(ctx, "codigo-valido", nonce, verificador)
+			if caso.falha != "" {
+				if erro == nil || !strings.Contains(erro.Error(), caso.falha) {
+					t.Fatalf("erro = %v, quer %q", erro, caso.falha)
+				}
+				return
+			}
+			if erro != nil {
+				t.Fatalf("Concluir: %v", erro)
+			}
+
+			quer := Identidade{Provedor: "teste", Sujeito: "sujeito-123", Email: "ana@empresa.com", EmailVerificado: true, Nome: "Ana", Nick: "ana"}
+			if identidade != quer {
+				t.Errorf("identidade = %+v, quer %+v", identidade, quer)
+			}
+		})
+	}
+
+	t.Run("verificador PKCE trocado", func(t *testing.T) {
+		provedor := idp.configurado()
+		urlDeAutorizacao, erro := provedor.URLDeAutorizacao(ctx, "estado", nonce, verificador)
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		idp.autorizar(t, urlDeAutorizacao, idp.claimsValidas(nonce), nil)
+
+		if _, erro = provedor.Concluir(ctx, "codigo-valido", nonce, "outro-verificador-pkce-com-pelo-menos-43-caracteres"); erro == nil {
+			t.Error("o código foi trocado sem o verificador PKCE certo")
+		}
+	})
+}
+
+func outraChave(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	chave, erro := rsa.GenerateKey(rand.Reader, 2048)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	return chave
+}
@@ -0,0 +1,137 @@
+package federacao
+
+import (
+	"api/src/config"
+	"api/src/modelos"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+)
+
+var tenantValido = regexp.MustCompile(`^[a-z0-9-]{1,50}$`)
+
+// ValidarTenant garante que o identificador do tenant pode ser usado nas URLs do SSO
+func ValidarTenant(tenant string) error {
+	if !tenantValido.MatchString(tenant) {
+		return errors.New("tenant inválido: use apenas letras minúsculas, números e hífens")
+	}
+	return nil
+}
+
+// ValidarMetadataSAML confere se o XML enviado descreve um IdP com endpoint de SSO e certificado
+func ValidarMetadataSAML(metadata []byte) error {
+	idp, erro := samlsp.ParseMetadata(metadata)
+	if erro != nil {
+		return fmt.Errorf("metadata SAML inválido: %v", erro)
+	}
+
+	if len(idp.IDPSSODescriptors) == 0 {
+		return errors.New("o metadata não descreve um IdP")
+	}
+
+	possuiCertificado := false
+	for _, descritor := range idp.IDPSSODescriptors {
+		for _, chave := range descritor.KeyDescriptors {
+			if len(chave.KeyInfo.X509Data.X509Certificates) > 0 {
+				possuiCertificado = true
+			}
+		}
+	}
+	if !possuiCertificado {
+		return errors.New("o metadata do IdP não possui certificado de assinatura")
+	}
+
+	return nil
+}
+
+// ProvedorDeServico monta o SP SAML do tenant a partir do metadata do IdP salvo no banco
+func ProvedorDeServico(provedor modelos.ProvedorSAML) (*saml.ServiceProvider, error) {
+	idp, erro := samlsp.ParseMetadata([]byte(provedor.Metadata))
+	if erro != nil {
+		return nil, fmt.Errorf("metadata SAML do tenant %s inválido: %v", provedor.Tenant, erro)
+	}
+
+	base := config.Atual().URLBase + "/saml/" + provedor.Tenant
+	urlMetadata, erro := url.Parse(base + "/metadata")
+	if erro != nil {
+		return nil, erro
+	}
+	urlACS, erro := url.Parse(base + "/acs")
+	if erro != nil {
+		return nil, erro
+	}
+
+	return &saml.ServiceProvider{
+		EntityID:          urlMetadata.String(),
+		Key:               config.Atual().SAML.Chave,
+		Certificate:       config.Atual().SAML.Certificado,
+		MetadataURL:       *urlMetadata,
+		AcsURL:            *urlACS,
+		IDPMetadata:       idp,
+		AuthnNameIDFormat: saml.PersistentNameIDFormat,
+		AllowIDPInitiated: false,
+	}, nil
+}
+
+// ValidarAssercao valida a resposta recebida pelo binding HTTP-POST: assinatura, destino (recipient),
+// audiência, validade e o ID da requisição que originou o login
+func ValidarAssercao(sp *saml.ServiceProvider, respostaSAML string, idRequisicao string) (*saml.Assertion, error) {
+	respostaXML, erro := base64.StdEncoding.DecodeString(respostaSAML)
+	if erro != nil {
+		return nil, errors.New("SAMLResponse inválido")
+	}
+
+	assercao, erro := sp.ParseXMLResponse(respostaXML, []string{idRequisicao})
+	if erro != nil {
+		// O erro público é genérico de propósito; o motivo real fica só no log
+		var erroResposta *saml.InvalidResponseError
+		if errors.As(erro, &erroResposta) {
+			log.Printf("Asserção SAML rejeitada: %v", erroResposta.PrivateErr)
+		}
+		return nil, errors.New("asserção SAML inválida")
+	}
+
+	return assercao, nil
+}
+
+// IdentidadeDaAssercao mapeia os atributos da asserção para uma Identidade, conforme a configuração do tenant
+func IdentidadeDaAssercao(provedor modelos.ProvedorSAML, assercao *saml.Assertion) (Identidade, error) {
+	if assercao.Subject == nil || assercao.Subject.NameID == nil || assercao.Subject.NameID.Value == "" {
+		return Identidade{}, errors.New("asserção SAML sem NameID")
+	}
+
+	atributos := make(map[string]string)
+	for _, declaracao := range assercao.AttributeStatements {
+		for _, atributo := range declaracao.Attributes {
+			if len(atributo.Values) == 0 {
+				continue
+			}
+			atributos[atributo.Name] = atributo.Values[0].Value
+			if atributo.FriendlyName != "" {
+				atributos[atributo.FriendlyName] = atributo.Values[0].Value
+			}
+		}
+	}
+
+	nameID := assercao.Subject.NameID
+	email := atributos[provedor.AtributoEmail]
+	if email == "" && nameID.Format == string(saml.EmailAddressNameIDFormat) {
+		email = nameID.Value
+	}
+
+	return Identidade{
+		Provedor:        "saml:" + provedor.Tenant,
+		Sujeito:         nameID.Value,
+		Email:           strings.ToLower(strings.TrimSpace(email)),
+		EmailVerificado: provedor.ConfiarEmail,
+		Nome:            atributos[provedor.AtributoNome],
+		Nick:            atributos[provedor.AtributoNick],
+	}, nil
+}
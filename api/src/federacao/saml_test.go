@@ -0,0 +1,69 @@
+package federacao
+
+import (
+	"api/src/modelos"
+	"api/src/testes"
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestAssercaoSAML(t *testing.T) {
+	testes.Configurar(t, testes.ArquivosSAML(t))
+	idp := testes.NovoIdPSAML(t)
+
+	provedor := modelos.ProvedorSAML{
+		Tenant:        "empresa-x",
+		Metadata:      string(idp.Metadata(t)),
+		AtributoEmail: "email",
+		ConfiarEmail:  true,
+	}
+	if erro := ValidarMetadataSAML([]byte(provedor.Metadata)); erro != nil {
+		t.Fatalf("ValidarMetadataSAML: %v", erro)
+	}
+	sp, erro := ProvedorDeServico(provedor)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	const idRequisicao = "id-da-requisicao"
+	resposta := idp.Resposta(t, sp, idRequisicao, "sujeito-ana", "ana@empresa.com")
+
+	t.Run("assinada", func(t *testing.T) {
+		assercao, erro := ValidarAssercao(sp, base64.StdEncoding.EncodeToString(resposta), idRequisicao)
+		if erro != nil {
+			t.Fatalf("ValidarAssercao: %v", erro)
+		}
+
+		identidade, erro := IdentidadeDaAssercao(provedor, assercao)
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		quer := Identidade{Provedor: "saml:empresa-x", Sujeito: "sujeito-ana", Email: "ana@empresa.com", EmailVerificado: true}
+		if identidade != quer {
+			t.Errorf("identidade = %+v, quer %+v", identidade, quer)
+		}
+	})
+
+	casos := []struct {
+		nome         string
+		resposta     []byte
+		idRequisicao string
+	}{
+		// Trocar o e-mail depois da assinatura é o ataque de quem quer entrar na conta de outra pessoa
+		{"e-mail adulterado", bytes.ReplaceAll(resposta, []byte("ana@empresa.com"), []byte("chefe@empresa.com")), idRequisicao},
+		{"NameID adulterado", bytes.ReplaceAll(resposta, []byte("sujeito-ana"), []byte("sujeito-chefe")), idRequisicao},
+		{"de outro login", resposta, "outra-requisicao"},
+		{"assinada por outro IdP", testes.NovoIdPSAML(t).Resposta(t, sp, idRequisicao, "sujeito-ana", "ana@empresa.com"), idRequisicao},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if caso.idRequisicao == idRequisicao && bytes.Equal(caso.resposta, resposta) {
+				t.Fatal("a adulteração não mudou a resposta")
+			}
+			if _, erro := ValidarAssercao(sp, base64.StdEncoding.EncodeToString(caso.resposta), caso.idRequisicao); erro == nil {
+				t.Error("a asserção foi aceita")
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package metricas
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Os rótulos só recebem valores de conjuntos fechados (modelo da rota, método, código HTTP, constantes deste pacote),
+// nunca o caminho bruto, o e-mail ou a organização: cada combinação nova é uma série a mais no Prometheus.
+
+// Resultados e motivos do login, no rótulo das métricas de login
+const (
+	LoginSucesso = "sucesso"
+	LoginFalha   = "falha"
+
+	MotivoRequisicaoInvalida   = "requisicao_invalida"
+	MotivoCredenciaisInvalidas = "credenciais_invalidas"
+	MotivoBloqueado            = "bloqueado"
+	MotivoLimiteTentativas     = "limite_tentativas"
+	MotivoContaDesativada      = "conta_desativada"
+	MotivoErro                 = "erro"
+	// MotivoRecusadoPeloProvedor é o login externo negado pelo provedor de identidade (?error= no callback)
+	MotivoRecusadoPeloProvedor = "recusado_pelo_provedor"
+	// MotivoVinculoRecusado é a identidade externa que não pôde ser ligada a uma conta (e-mail ausente ou não verificado)
+	MotivoVinculoRecusado = "vinculo_recusado"
+
+	// Autenticadores do login externo, no motivo do sucesso
+	LoginOIDC = "oidc"
+	LoginSAML = "saml"
+)
+
+// Tipos de token, no rótulo de api_tokens_emitidos_total
+const (
+	TokenUsuario = "usuario"
+	TokenAnonimo = "anonimo"
+	// TokenDelegado é o emitido pela troca de tokens (POST /oauth/token)
+	TokenDelegado = "delegado"
+)
+
+// Operações de hash, no rótulo de api_hash_duracao_segundos
+const (
+	HashGerar     = "gerar"
+	HashVerificar = "verificar"
+)
+
+var registro = prometheus.NewRegistry()
+
+var (
+	requisicoes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "api_http_requisicoes_total",
+		Help: "Requisições atendidas, por rota (modelo da URI), método e código HTTP.",
+	}, []string{"rota", "method", "code"})
+
+	duracaoRequisicoes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "api_http_requisicao_duracao_segundos",
+		Help:    "Tempo de resposta das requisições, por rota (modelo da URI), método e código HTTP.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"rota", "method", "code"})
+
+	requisicoesEmAndamento = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "api_http_requisicoes_em_andamento",
+		Help: "Requisições sendo atendidas agora, por rota (modelo da URI).",
+	}, []string{"rota"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "api_logins_total",
+		Help: "Tentativas de login por resultado; o motivo é o autenticador no sucesso e a causa na falha.",
+	}, []string{"resultado", "motivo"})
+
+	bloqueios = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "api_login_bloqueios_total",
+		Help: "Bloqueios de login aplicados por excesso de tentativas.",
+	})
+
+	tokensEmitidos = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "api_tokens_emitidos_total",
+		Help: "Tokens de acesso emitidos, por tipo (usuario, anonimo ou delegado).",
+	}, []string{"tipo"})
+
+	duracaoHash = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "api_hash_duracao_segundos",
+		Help:    "Tempo do bcrypt ao gerar e ao verificar senhas e códigos.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operacao"})
+)
+
+func init() {
+	registro.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requisicoes, duracaoRequisicoes, requisicoesEmAndamento,
+		logins, bloqueios, tokensEmitidos, duracaoHash,
+	)
+
+	// Os motivos começam zerados para que rate() e alertas funcionem antes da primeira ocorrência
+	for _, motivo := range []string{
+		MotivoRequisicaoInvalida, MotivoCredenciaisInvalidas, MotivoBloqueado,
+		MotivoLimiteTentativas, MotivoContaDesativada, MotivoErro,
+		MotivoRecusadoPeloProvedor, MotivoVinculoRecusado,
+	} {
+		logins.WithLabelValues(LoginFalha, motivo)
+	}
+	for _, tipo := range []string{TokenUsuario, TokenAnonimo, TokenDelegado} {
+		tokensEmitidos.WithLabelValues(tipo)
+	}
+}
+
+// Handler retorna o handler do /metrics, no formato de exposição do Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registro, promhttp.HandlerOpts{})
+}
+
+// Instrumentar mede as requisições de uma rota. A rota é o modelo da URI (/usuarios/{usuarioId}), não o caminho
+// recebido; método e código são normalizados pelo promhttp para não criar séries com valores arbitrários.
+func Instrumentar(rota string, proximaFuncao http.HandlerFunc) http.HandlerFunc {
+	rotulo := prometheus.Labels{"rota": rota}
+
+	return promhttp.InstrumentHandlerInFlight(requisicoesEmAndamento.With(rotulo),
+		promhttp.InstrumentHandlerDuration(duracaoRequisicoes.MustCurryWith(rotulo),
+			promhttp.InstrumentHandlerCounter(requisicoes.MustCurryWith(rotulo), proximaFuncao),
+		),
+	).ServeHTTP
+}
+
+// Login conta uma tentativa de login. No sucesso o motivo é o autenticador (banco, ldap, oidc, saml); na falha,
+// um dos Motivo*.
+func Login(resultado, motivo string) {
+	logins.WithLabelValues(resultado, motivo).Inc()
+}
+
+// Bloqueio conta um bloqueio de login por excesso de tentativas
+func Bloqueio() {
+	bloqueios.Inc()
+}
+
+// TokenEmitido conta um token emitido do tipo informado (TokenUsuario, TokenAnonimo ou TokenDelegado)
+func TokenEmitido(tipo string) {
+	tokensEmitidos.WithLabelValues(tipo).Inc()
+}
+
+// ObservarHash registra a duração de uma operação do bcrypt iniciada em inicio
+func ObservarHash(operacao string, inicio time.Time) {
+	duracaoHash.WithLabelValues(operacao).Observe(time.Since(inicio).Seconds())
+}
@@ -0,0 +1,75 @@
+package metricas
+
+import (
+	"api/src/banco"
+	"api/src/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// coletorDePools lê as estatísticas dos pools do PostgreSQL e do Redis a cada coleta, em vez de copiá-las
+// periodicamente; um pool que ainda não foi aberto (ou Redis desativado) simplesmente não aparece
+type coletorDePools struct {
+	bancoConexoes   *prometheus.Desc
+	bancoMaximo     *prometheus.Desc
+	bancoAquisicoes *prometheus.Desc
+	bancoEsperas    *prometheus.Desc
+	bancoEspera     *prometheus.Desc
+
+	redisConexoes *prometheus.Desc
+	redisPool     *prometheus.Desc
+}
+
+func init() {
+	registro.MustRegister(&coletorDePools{
+		bancoConexoes: prometheus.NewDesc("api_banco_conexoes",
+			"Conexões do pool do PostgreSQL, por estado (em_uso, ociosas, abrindo).", []string{"estado"}, nil),
+		bancoMaximo: prometheus.NewDesc("api_banco_conexoes_maximo",
+			"Máximo de conexões do pool do PostgreSQL (DB_MAX_CONEXOES).", nil, nil),
+		bancoAquisicoes: prometheus.NewDesc("api_banco_aquisicoes_total",
+			"Conexões pedidas ao pool do PostgreSQL.", nil, nil),
+		bancoEsperas: prometheus.NewDesc("api_banco_aquisicoes_com_espera_total",
+			"Pedidos ao pool do PostgreSQL que esperaram uma conexão ficar livre ou ser aberta.", nil, nil),
+		bancoEspera: prometheus.NewDesc("api_banco_aquisicao_espera_segundos_total",
+			"Tempo total esperando conexões do pool do PostgreSQL.", nil, nil),
+
+		redisConexoes: prometheus.NewDesc("api_redis_conexoes",
+			"Conexões do pool do Redis, por estado (total, ociosas).", []string{"estado"}, nil),
+		redisPool: prometheus.NewDesc("api_redis_pool_total",
+			"Pedidos ao pool do Redis, por resultado (acerto, falta, timeout) e conexões obsoletas fechadas (obsoleta).",
+			[]string{"evento"}, nil),
+	})
+}
+
+func (coletor *coletorDePools) Describe(descricoes chan<- *prometheus.Desc) {
+	descricoes <- coletor.bancoConexoes
+	descricoes <- coletor.bancoMaximo
+	descricoes <- coletor.bancoAquisicoes
+	descricoes <- coletor.bancoEsperas
+	descricoes <- coletor.bancoEspera
+	descricoes <- coletor.redisConexoes
+	descricoes <- coletor.redisPool
+}
+
+func (coletor *coletorDePools) Collect(metricas chan<- prometheus.Metric) {
+	if pool := banco.Pool(); pool != nil {
+		estatisticas := pool.Stat()
+		metricas <- prometheus.MustNewConstMetric(coletor.bancoConexoes, prometheus.GaugeValue, float64(estatisticas.AcquiredConns()), "em_uso")
+		metricas <- prometheus.MustNewConstMetric(coletor.bancoConexoes, prometheus.GaugeValue, float64(estatisticas.IdleConns()), "ociosas")
+		metricas <- prometheus.MustNewConstMetric(coletor.bancoConexoes, prometheus.GaugeValue, float64(estatisticas.ConstructingConns()), "abrindo")
+		metricas <- prometheus.MustNewConstMetric(coletor.bancoMaximo, prometheus.GaugeValue, float64(estatisticas.MaxConns()))
+		metricas <- prometheus.MustNewConstMetric(coletor.bancoAquisicoes, prometheus.CounterValue, float64(estatisticas.AcquireCount()))
+		metricas <- prometheus.MustNewConstMetric(coletor.bancoEsperas, prometheus.CounterValue, float64(estatisticas.EmptyAcquireCount()))
+		metricas <- prometheus.MustNewConstMetric(coletor.bancoEspera, prometheus.CounterValue, estatisticas.AcquireDuration().Seconds())
+	}
+
+	if cliente := config.RedisClient; cliente != nil {
+		estatisticas := cliente.PoolStats()
+		metricas <- prometheus.MustNewConstMetric(coletor.redisConexoes, prometheus.GaugeValue, float64(estatisticas.TotalConns), "total")
+		metricas <- prometheus.MustNewConstMetric(coletor.redisConexoes, prometheus.GaugeValue, float64(estatisticas.IdleConns), "ociosas")
+		metricas <- prometheus.MustNewConstMetric(coletor.redisPool, prometheus.CounterValue, float64(estatisticas.Hits), "acerto")
+		metricas <- prometheus.MustNewConstMetric(coletor.redisPool, prometheus.CounterValue, float64(estatisticas.Misses), "falta")
+		metricas <- prometheus.MustNewConstMetric(coletor.redisPool, prometheus.CounterValue, float64(estatisticas.Timeouts), "timeout")
+		metricas <- prometheus.MustNewConstMetric(coletor.redisPool, prometheus.CounterValue, float64(estatisticas.StaleConns), "obsoleta")
+	}
+}
@@ -2,7 +2,9 @@ package middlewares
 
 import (
 	"api/src/autenticacao"
+	"api/src/metricas"
 	"api/src/respostas"
+	"errors"
 	"log"
 	"net/http"
 )
@@ -15,15 +17,58 @@ func Logger(proximaFuncao http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// Autenticar permite usuários autenticados e anônimos
-func Autenticar(proximaFuncao http.HandlerFunc) http.HandlerFunc {
+// Metricas conta e mede as requisições da rota, identificada pelo modelo da URI (e não pelo caminho recebido,
+// que tem IDs e criaria uma série por usuário)
+func Metricas(rota string, proximaFuncao http.HandlerFunc) http.HandlerFunc {
+	return metricas.Instrumentar(rota, proximaFuncao)
+}
+
+// Autenticar permite usuários autenticados e anônimos e guarda no contexto o acesso atual do usuário. Tokens restritos por escopo (os da troca de tokens)
+// só passam se a rota declarar um escopo e ele estiver no token.
+func Autenticar(escopo string, proximaFuncao http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r, erro := autenticacao.CarregarAcesso(r)
+		if erro != nil {
+			respostas.Erro(w, http.StatusUnauthorized, erro)
+			return
+		}
+
+		permite, erro := autenticacao.PermiteEscopo(r, escopo)
+		if erro != nil {
+			respostas.Erro(w, http.StatusUnauthorized, erro)
+			return
+		}
+
+		if !permite {
+			respostas.Erro(w, http.StatusForbidden, errors.New("o escopo do token não permite esta rota"))
+			return
+		}
+
+		proximaFuncao(w, r)
+	}
+}
+
+// Autorizar permite apenas tokens que concedem a permissão informada; num token restrito,
+// a permissão também precisa estar no escopo
+func Autorizar(permissao string, proximaFuncao http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		_, erro := autenticacao.ValidarToken(r)
+		r, erro := autenticacao.CarregarAcesso(r)
+		if erro != nil {
+			respostas.Erro(w, http.StatusUnauthorized, erro)
+			return
+		}
+
+		possui, erro := autenticacao.PossuiPermissao(r, permissao)
 		if erro != nil {
 			respostas.Erro(w, http.StatusUnauthorized, erro)
 			return
 		}
 
+		if !possui {
+			respostas.Erro(w, http.StatusForbidden, errors.New("permissão necessária: "+permissao))
+			return
+		}
+
 		proximaFuncao(w, r)
 	}
 }
@@ -0,0 +1,97 @@
+package middlewares
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/tenancia"
+	"api/src/testes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var organizacaoDeTeste = modelos.Organizacao{ID: tenancia.IDPadrao, Slug: tenancia.SlugPadrao}
+
+type fonteFixa struct{}
+
+func (fonteFixa) BuscarPorID(_ context.Context, ID uint64) (modelos.Organizacao, error) {
+	if ID == organizacaoDeTeste.ID {
+		return organizacaoDeTeste, nil
+	}
+	return modelos.Organizacao{}, repositorios.ErrOrganizacaoNaoEncontrada
+}
+
+func (fonte fonteFixa) BuscarPorSlug(ctx context.Context, _ string) (modelos.Organizacao, error) {
+	return fonte.BuscarPorID(ctx, organizacaoDeTeste.ID)
+}
+
+func (fonte fonteFixa) BuscarPorDominio(ctx context.Context, _ string) (modelos.Organizacao, error) {
+	return fonte.BuscarPorID(ctx, 0)
+}
+
+// acessoFixo dá a todo usuário as mesmas permissões
+type acessoFixo []string
+
+func (permissoes acessoFixo) Acesso(context.Context, uint64, uint64, []string) (modelos.Acesso, error) {
+	return modelos.Acesso{Permissoes: permissoes}, nil
+}
+
+func TestMain(m *testing.M) {
+	tenancia.Configurar(fonteFixa{})
+	autenticacao.ConfigurarAcesso(acessoFixo{"usuarios:ler", "papeis:gerenciar"})
+	m.Run()
+}
+
+func status(handler http.HandlerFunc, token string) int {
+	requisicao := httptest.NewRequest(http.MethodGet, "/usuarios", nil)
+	requisicao.Header.Set("Authorization", "Bearer "+token)
+	requisicao = requisicao.WithContext(tenancia.NoContexto(requisicao.Context(), organizacaoDeTeste))
+
+	resposta := httptest.NewRecorder()
+	handler(resposta, requisicao)
+	return resposta.Code
+}
+
+func TestTokenDelegadoSoUsaRotasDoSeuEscopo(t *testing.T) {
+	configuracao := testes.Configurar(t, nil)
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	delegado, erro := autenticacao.CriarTokenDelegado(autenticacao.Delegacao{
+		Organizacao: organizacaoDeTeste,
+		UsuarioID:   7,
+		Audiencias:  []string{configuracao.AudienciaTokens},
+		Escopos:     []string{"usuarios:ler"},
+		Expiracao:   time.Now().Add(time.Minute),
+	})
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	doLogin, erro := autenticacao.CriarToken(context.Background(), organizacaoDeTeste, 7, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	casos := []struct {
+		nome    string
+		handler http.HandlerFunc
+		token   string
+		quer    int
+	}{
+		{"rota com o escopo do token", Autenticar("usuarios:ler", ok), delegado, http.StatusOK},
+		{"rota sem escopo", Autenticar("", ok), delegado, http.StatusForbidden},
+		{"permissão fora do escopo", Autorizar("papeis:gerenciar", ok), delegado, http.StatusForbidden},
+		{"token do login em rota sem escopo", Autenticar("", ok), doLogin, http.StatusOK},
+		{"token do login com a permissão", Autorizar("papeis:gerenciar", ok), doLogin, http.StatusOK},
+		{"sem token", Autenticar("", ok), "", http.StatusUnauthorized},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if codigo := status(caso.handler, caso.token); codigo != caso.quer {
+				t.Errorf("status = %d, quer %d", codigo, caso.quer)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package migracoes_test
+
+import (
+	"api/src/migracoes"
+	"api/src/testes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// bancoVazio cria, no servidor de testes.VariavelBanco, um banco só para o teste: as migrações descem até o fim
+// e não podem apagar as tabelas que os testes dos outros pacotes estão usando
+func bancoVazio(t *testing.T) *sql.DB {
+	t.Helper()
+
+	servidor := testes.BancoDeDados(t)
+	nome := fmt.Sprintf("migracoes_%d", time.Now().UnixNano())
+	if _, erro := servidor.Exec("CREATE DATABASE " + nome); erro != nil {
+		t.Skipf("sem permissão para criar um banco de testes: %v", erro)
+	}
+	t.Cleanup(func() { servidor.Exec("DROP DATABASE IF EXISTS " + nome) })
+
+	configuracao, erro := pgx.ParseConfig(os.Getenv(testes.VariavelBanco))
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	configuracao.Database = nome
+
+	db, erro := sql.Open("pgx", stdlib.RegisterConnConfig(configuracao))
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func situacoes(t *testing.T, db *sql.DB) map[string]int {
+	t.Helper()
+
+	estados, erro := migracoes.Situacao(db)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	contagem := map[string]int{}
+	for _, estado := range estados {
+		contagem[estado.Situacao]++
+	}
+	return contagem
+}
+
+// TestSubirEDescer aplica todas as migrações, desfaz uma por uma e aplica de novo: cada .down.sql precisa
+// desfazer o seu .up.sql por inteiro, ou a segunda subida falha
+func TestSubirEDescer(t *testing.T) {
+	db := bancoVazio(t)
+
+	if erro := migracoes.Preparar(db, false); !errors.Is(erro, migracoes.ErrEsquemaDesatualizado) {
+		t.Fatalf("Preparar num banco vazio = %v, quer ErrEsquemaDesatualizado", erro)
+	}
+
+	aplicadas, erro := migracoes.Subir(db, 0)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	total := len(aplicadas)
+	if contagem := situacoes(t, db); contagem[migracoes.Aplicada] != total || len(contagem) != 1 {
+		t.Fatalf("depois de subir: %v", contagem)
+	}
+	if erro = migracoes.Preparar(db, false); erro != nil {
+		t.Fatalf("Preparar com tudo aplicado: %v", erro)
+	}
+
+	for i := total; i > 0; i-- {
+		desfeitas, erro := migracoes.Descer(db, 1)
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		if len(desfeitas) != 1 || desfeitas[0].Versao != aplicadas[i-1].Versao {
+			t.Fatalf("Descer desfez %+v, quer a versão %d", desfeitas, aplicadas[i-1].Versao)
+		}
+	}
+	if contagem := situacoes(t, db); contagem[migracoes.Pendente] != total {
+		t.Fatalf("depois de descer: %v", contagem)
+	}
+
+	var tabelas int
+	if erro = db.QueryRowContext(context.Background(),
+		"SELECT count(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name <> 'schema_migrations'",
+	).Scan(&tabelas); erro != nil {
+		t.Fatal(erro)
+	}
+	if tabelas != 0 {
+		t.Errorf("%d tabela(s) sobraram depois de descer tudo", tabelas)
+	}
+
+	// Só a próxima, e depois o resto
+	if aplicadas, erro = migracoes.Subir(db, 1); erro != nil || len(aplicadas) != 1 {
+		t.Fatalf("Subir(1) = %d, %v", len(aplicadas), erro)
+	}
+	if aplicadas, erro = migracoes.Subir(db, 0); erro != nil || len(aplicadas) != total-1 {
+		t.Fatalf("segunda subida = %d, %v", len(aplicadas), erro)
+	}
+}
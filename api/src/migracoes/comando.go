@@ -0,0 +1,80 @@
+package migracoes
+
+import (
+	"api/src/banco"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// uso descreve o subcomando migrate
+const uso = `uso: main migrate <comando>
+
+  up [n]     aplica as migrações pendentes (ou só as n próximas)
+  down [n]   desfaz a última migração aplicada (ou as n últimas)
+  status     lista as migrações e a situação de cada uma no banco`
+
+// Executar roda o subcomando migrate com os argumentos que vêm depois dele na linha de comando
+func Executar(argumentos []string) error {
+	if len(argumentos) == 0 {
+		return errors.New(uso)
+	}
+
+	quantidade := 0
+	if len(argumentos) > 1 {
+		numero, erro := strconv.Atoi(argumentos[1])
+		if erro != nil || numero < 1 {
+			return fmt.Errorf("quantidade inválida: %q\n\n%s", argumentos[1], uso)
+		}
+		quantidade = numero
+	}
+
+	db := banco.Conexao()
+
+	switch argumentos[0] {
+	case "up":
+		aplicadas, erro := Subir(db, quantidade)
+		for _, migracao := range aplicadas {
+			fmt.Printf("aplicada  %04d_%s\n", migracao.Versao, migracao.Nome)
+		}
+		if erro == nil && len(aplicadas) == 0 {
+			fmt.Println("nenhuma migração pendente")
+		}
+		return erro
+	case "down":
+		if quantidade == 0 {
+			quantidade = 1
+		}
+		desfeitas, erro := Descer(db, quantidade)
+		for _, migracao := range desfeitas {
+			fmt.Printf("desfeita  %04d_%s\n", migracao.Versao, migracao.Nome)
+		}
+		if erro == nil && len(desfeitas) == 0 {
+			fmt.Println("nenhuma migração aplicada")
+		}
+		return erro
+	case "status":
+		estados, erro := Situacao(db)
+		if erro != nil {
+			return erro
+		}
+		return imprimirSituacao(estados)
+	}
+
+	return fmt.Errorf("comando desconhecido: %q\n\n%s", argumentos[0], uso)
+}
+
+func imprimirSituacao(estados []Estado) error {
+	tabela := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tabela, "VERSÃO\tNOME\tSITUAÇÃO\tAPLICADA EM")
+	for _, estado := range estados {
+		aplicadaEm := "-"
+		if estado.AplicadaEm != nil {
+			aplicadaEm = estado.AplicadaEm.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(tabela, "%04d\t%s\t%s\t%s\n", estado.Versao, estado.Nome, estado.Situacao, aplicadaEm)
+	}
+	return tabela.Flush()
+}
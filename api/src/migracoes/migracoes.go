@@ -0,0 +1,362 @@
+package migracoes
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// arquivos guarda as migrações no binário: NNNN_nome.up.sql aplica e NNNN_nome.down.sql desfaz
+//
+//go:embed sql/*.sql
+var arquivos embed.FS
+
+var (
+	// ErrEsquemaDesconhecido é retornado quando o banco tem migrações que esta versão da API não conhece
+	// (criadas por uma versão mais nova) ou que foram alteradas depois de aplicadas
+	ErrEsquemaDesconhecido = errors.New("esquema do banco desconhecido por esta versão da API")
+
+	// ErrEsquemaDesatualizado é retornado na inicialização quando há migrações pendentes e elas não podem ser aplicadas
+	ErrEsquemaDesatualizado = errors.New("esquema do banco desatualizado")
+)
+
+// Situações de uma migração no comando migrate status
+const (
+	Aplicada     = "aplicada"
+	Pendente     = "pendente"
+	Desconhecida = "desconhecida"
+	Alterada     = "alterada"
+)
+
+// chaveDoLock identifica o advisory lock que impede duas instâncias de migrarem ao mesmo tempo
+const chaveDoLock = "schema_migrations"
+
+var padraoDoArquivo = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migracao é um par de arquivos SQL com a mesma versão
+type Migracao struct {
+	Versao   int64
+	Nome     string
+	Subida   string
+	Descida  string
+	Checksum string
+}
+
+// Estado é uma linha do migrate status
+type Estado struct {
+	Versao     int64
+	Nome       string
+	Situacao   string
+	AplicadaEm *time.Time
+}
+
+// registro é uma linha de schema_migrations
+type registro struct {
+	versao     int64
+	nome       string
+	checksum   string
+	aplicadaEm time.Time
+}
+
+// Subir aplica, em ordem, até limite migrações pendentes (0 aplica todas) e retorna as que foram aplicadas
+func Subir(db *sql.DB, limite int) ([]Migracao, error) {
+	var aplicadas []Migracao
+	erro := comLock(db, func(conexao *sql.Conn, conhecidas []Migracao, registros map[int64]registro) error {
+		for _, migracao := range pendentes(conhecidas, registros) {
+			if limite > 0 && len(aplicadas) == limite {
+				break
+			}
+
+			if erro := aplicar(conexao, migracao); erro != nil {
+				return erro
+			}
+			aplicadas = append(aplicadas, migracao)
+		}
+		return nil
+	})
+
+	return aplicadas, erro
+}
+
+// Descer desfaz as últimas limite migrações aplicadas, da mais nova para a mais antiga
+func Descer(db *sql.DB, limite int) ([]Migracao, error) {
+	var desfeitas []Migracao
+	erro := comLock(db, func(conexao *sql.Conn, conhecidas []Migracao, registros map[int64]registro) error {
+		for i := len(conhecidas) - 1; i >= 0 && len(desfeitas) < limite; i-- {
+			migracao := conhecidas[i]
+			if _, aplicada := registros[migracao.Versao]; !aplicada {
+				continue
+			}
+
+			if erro := desfazer(conexao, migracao); erro != nil {
+				return erro
+			}
+			desfeitas = append(desfeitas, migracao)
+		}
+		return nil
+	})
+
+	return desfeitas, erro
+}
+
+// Situacao lista as migrações conhecidas e as registradas no banco, sem alterar nada
+func Situacao(db *sql.DB) ([]Estado, error) {
+	conhecidas, erro := carregar()
+	if erro != nil {
+		return nil, erro
+	}
+
+	registros, erro := lerRegistros(context.Background(), db)
+	if erro != nil {
+		return nil, erro
+	}
+
+	var estados []Estado
+	for _, migracao := range conhecidas {
+		estado := Estado{Versao: migracao.Versao, Nome: migracao.Nome, Situacao: Pendente}
+		if registro, aplicada := registros[migracao.Versao]; aplicada {
+			estado.Situacao = Aplicada
+			if registro.checksum != migracao.Checksum {
+				estado.Situacao = Alterada
+			}
+			estado.AplicadaEm = &registro.aplicadaEm
+			delete(registros, migracao.Versao)
+		}
+		estados = append(estados, estado)
+	}
+
+	for _, registro := range registros {
+		aplicadaEm := registro.aplicadaEm
+		estados = append(estados, Estado{Versao: registro.versao, Nome: registro.nome, Situacao: Desconhecida, AplicadaEm: &aplicadaEm})
+	}
+	sort.Slice(estados, func(i, j int) bool { return estados[i].Versao < estados[j].Versao })
+
+	return estados, nil
+}
+
+// Preparar é chamado na inicialização: recusa um esquema desconhecido e, com migrações pendentes,
+// aplica todas quando aplicarPendentes é verdadeiro ou retorna ErrEsquemaDesatualizado
+func Preparar(db *sql.DB, aplicarPendentes bool) error {
+	return comLock(db, func(conexao *sql.Conn, conhecidas []Migracao, registros map[int64]registro) error {
+		faltando := pendentes(conhecidas, registros)
+		if len(faltando) == 0 {
+			return nil
+		}
+
+		if !aplicarPendentes {
+			return fmt.Errorf("%w: %d migração(ões) pendente(s), rode \"migrate up\"", ErrEsquemaDesatualizado, len(faltando))
+		}
+
+		for _, migracao := range faltando {
+			if erro := aplicar(conexao, migracao); erro != nil {
+				return erro
+			}
+		}
+		return nil
+	})
+}
+
+// comLock segura o advisory lock numa conexão dedicada, garante a tabela schema_migrations
+// e confere se todas as migrações registradas no banco são conhecidas antes de chamar funcao
+func comLock(db *sql.DB, funcao func(*sql.Conn, []Migracao, map[int64]registro) error) error {
+	conhecidas, erro := carregar()
+	if erro != nil {
+		return erro
+	}
+
+	ctx := context.Background()
+	conexao, erro := db.Conn(ctx)
+	if erro != nil {
+		return erro
+	}
+	defer conexao.Close()
+
+	// O lock é da sessão: outras instâncias esperam aqui até esta terminar
+	if _, erro = conexao.ExecContext(ctx, `SELECT pg_advisory_lock(hashtext($1))`, chaveDoLock); erro != nil {
+		return fmt.Errorf("erro ao obter o lock das migrações: %v", erro)
+	}
+	defer conexao.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, chaveDoLock)
+
+	if _, erro = conexao.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		versao bigint PRIMARY KEY,
+		nome varchar(255) NOT NULL,
+		checksum char(64) NOT NULL,
+		aplicadaEm timestamptz NOT NULL DEFAULT now()
+	)`); erro != nil {
+		return erro
+	}
+
+	registros, erro := lerRegistros(ctx, conexao)
+	if erro != nil {
+		return erro
+	}
+
+	if erro = verificar(conhecidas, registros); erro != nil {
+		return erro
+	}
+
+	return funcao(conexao, conhecidas, registros)
+}
+
+// verificar recusa migrações registradas que não existem no binário ou cujo SQL mudou depois de aplicado
+func verificar(conhecidas []Migracao, registros map[int64]registro) error {
+	porVersao := make(map[int64]Migracao, len(conhecidas))
+	for _, migracao := range conhecidas {
+		porVersao[migracao.Versao] = migracao
+	}
+
+	for _, registro := range registros {
+		migracao, conhecida := porVersao[registro.versao]
+		if !conhecida {
+			return fmt.Errorf("%w: migração %04d_%s aplicada por outra versão", ErrEsquemaDesconhecido, registro.versao, registro.nome)
+		}
+		if migracao.Checksum != registro.checksum {
+			return fmt.Errorf("%w: migração %04d_%s foi alterada depois de aplicada", ErrEsquemaDesconhecido, registro.versao, registro.nome)
+		}
+	}
+
+	return nil
+}
+
+func pendentes(conhecidas []Migracao, registros map[int64]registro) []Migracao {
+	var faltando []Migracao
+	for _, migracao := range conhecidas {
+		if _, aplicada := registros[migracao.Versao]; !aplicada {
+			faltando = append(faltando, migracao)
+		}
+	}
+	return faltando
+}
+
+func aplicar(conexao *sql.Conn, migracao Migracao) error {
+	erro := executar(conexao, migracao.Subida,
+		`INSERT INTO schema_migrations (versao, nome, checksum) VALUES ($1, $2, $3)`,
+		migracao.Versao, migracao.Nome, migracao.Checksum,
+	)
+	if erro != nil {
+		return fmt.Errorf("erro ao aplicar a migração %04d_%s: %v", migracao.Versao, migracao.Nome, erro)
+	}
+	return nil
+}
+
+func desfazer(conexao *sql.Conn, migracao Migracao) error {
+	erro := executar(conexao, migracao.Descida, `DELETE FROM schema_migrations WHERE versao = $1`, migracao.Versao)
+	if erro != nil {
+		return fmt.Errorf("erro ao desfazer a migração %04d_%s: %v", migracao.Versao, migracao.Nome, erro)
+	}
+	return nil
+}
+
+// executar roda o SQL da migração e atualiza schema_migrations na mesma transação
+func executar(conexao *sql.Conn, comandos, controle string, argumentos ...interface{}) error {
+	ctx := context.Background()
+	transacao, erro := conexao.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	// Sem argumentos o driver usa o protocolo simples, que aceita vários comandos de uma vez
+	if _, erro = transacao.ExecContext(ctx, comandos); erro != nil {
+		return erro
+	}
+
+	if _, erro = transacao.ExecContext(ctx, controle, argumentos...); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+type consultor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// lerRegistros lê schema_migrations; um banco sem a tabela ainda não tem nenhuma migração registrada
+func lerRegistros(ctx context.Context, db consultor) (map[int64]registro, error) {
+	registros := make(map[int64]registro)
+
+	var tabela sql.NullString
+	if erro := db.QueryRowContext(ctx, `SELECT to_regclass('public.schema_migrations')::text`).Scan(&tabela); erro != nil {
+		return nil, erro
+	}
+	if !tabela.Valid {
+		return registros, nil
+	}
+
+	linhas, erro := db.QueryContext(ctx, `SELECT versao, nome, checksum, aplicadaEm FROM schema_migrations`)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	for linhas.Next() {
+		var registro registro
+		if erro = linhas.Scan(&registro.versao, &registro.nome, &registro.checksum, &registro.aplicadaEm); erro != nil {
+			return nil, erro
+		}
+		registros[registro.versao] = registro
+	}
+
+	return registros, linhas.Err()
+}
+
+// carregar lê os arquivos embutidos e monta as migrações em ordem de versão
+func carregar() ([]Migracao, error) {
+	entradas, erro := fs.ReadDir(arquivos, "sql")
+	if erro != nil {
+		return nil, erro
+	}
+
+	porVersao := make(map[int64]*Migracao)
+	for _, entrada := range entradas {
+		partes := padraoDoArquivo.FindStringSubmatch(entrada.Name())
+		if partes == nil {
+			return nil, fmt.Errorf("nome de migração inválido: %s", entrada.Name())
+		}
+
+		versao, _ := strconv.ParseInt(partes[1], 10, 64)
+		conteudo, erro := arquivos.ReadFile(path.Join("sql", entrada.Name()))
+		if erro != nil {
+			return nil, erro
+		}
+
+		migracao, existe := porVersao[versao]
+		if !existe {
+			migracao = &Migracao{Versao: versao, Nome: partes[2]}
+			porVersao[versao] = migracao
+		}
+		if migracao.Nome != partes[2] {
+			return nil, fmt.Errorf("versão %d usada por %s e %s", versao, migracao.Nome, partes[2])
+		}
+
+		if partes[3] == "up" {
+			soma := sha256.Sum256(conteudo)
+			migracao.Subida, migracao.Checksum = string(conteudo), hex.EncodeToString(soma[:])
+		} else {
+			migracao.Descida = string(conteudo)
+		}
+	}
+
+	migracoes := make([]Migracao, 0, len(porVersao))
+	for _, migracao := range porVersao {
+		if migracao.Subida == "" || migracao.Descida == "" {
+			return nil, fmt.Errorf("migração %04d_%s precisa dos arquivos .up.sql e .down.sql", migracao.Versao, migracao.Nome)
+		}
+		migracoes = append(migracoes, *migracao)
+	}
+	sort.Slice(migracoes, func(i, j int) bool { return migracoes[i].Versao < migracoes[j].Versao })
+
+	return migracoes, nil
+}
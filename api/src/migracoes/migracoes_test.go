@@ -0,0 +1,75 @@
+package migracoes
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestCadaSubidaTemDescida(t *testing.T) {
+	entradas, erro := fs.ReadDir(arquivos, "sql")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	nomes := map[string]bool{}
+	for _, entrada := range entradas {
+		nomes[entrada.Name()] = true
+	}
+	for nome := range nomes {
+		if strings.HasSuffix(nome, ".up.sql") && !nomes[strings.TrimSuffix(nome, ".up.sql")+".down.sql"] {
+			t.Errorf("%s sem o .down.sql", nome)
+		}
+		if strings.HasSuffix(nome, ".down.sql") && !nomes[strings.TrimSuffix(nome, ".down.sql")+".up.sql"] {
+			t.Errorf("%s sem o .up.sql", nome)
+		}
+	}
+
+	migracoes, erro := carregar()
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if len(migracoes)*2 != len(nomes) {
+		t.Errorf("%d migrações para %d arquivos", len(migracoes), len(nomes))
+	}
+
+	// As versões são sequenciais: um buraco costuma ser um arquivo esquecido no commit
+	for i, migracao := range migracoes {
+		if migracao.Versao != int64(i+1) {
+			t.Errorf("migração %04d_%s na posição %d", migracao.Versao, migracao.Nome, i+1)
+		}
+		if strings.TrimSpace(migracao.Subida) == "" || strings.TrimSpace(migracao.Descida) == "" || len(migracao.Checksum) != 64 {
+			t.Errorf("migração %04d_%s incompleta", migracao.Versao, migracao.Nome)
+		}
+	}
+}
+
+func TestVerificarRegistros(t *testing.T) {
+	conhecidas := []Migracao{{Versao: 1, Nome: "inicial", Checksum: "a"}, {Versao: 2, Nome: "segunda", Checksum: "b"}}
+
+	casos := []struct {
+		nome      string
+		registros map[int64]registro
+		valido    bool
+	}{
+		{"nenhuma aplicada", map[int64]registro{}, true},
+		{"parte aplicada", map[int64]registro{1: {versao: 1, nome: "inicial", checksum: "a"}}, true},
+		{"versão mais nova", map[int64]registro{3: {versao: 3, nome: "futura", checksum: "c"}}, false},
+		{"SQL alterado", map[int64]registro{2: {versao: 2, nome: "segunda", checksum: "outro"}}, false},
+	}
+	for _, caso := range casos {
+		erro := verificar(conhecidas, caso.registros)
+		if caso.valido && erro != nil {
+			t.Errorf("%s: %v", caso.nome, erro)
+		}
+		if !caso.valido && !errors.Is(erro, ErrEsquemaDesconhecido) {
+			t.Errorf("%s: erro = %v, quer ErrEsquemaDesconhecido", caso.nome, erro)
+		}
+	}
+
+	faltando := pendentes(conhecidas, map[int64]registro{1: {versao: 1}})
+	if len(faltando) != 1 || faltando[0].Versao != 2 {
+		t.Errorf("pendentes = %+v, quer só a versão 2", faltando)
+	}
+}
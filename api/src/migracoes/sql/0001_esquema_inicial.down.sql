@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS convites;
+DROP TABLE IF EXISTS membros;
+DROP TABLE IF EXISTS usuario_papeis;
+DROP TABLE IF EXISTS papel_permissoes;
+DROP TABLE IF EXISTS permissoes;
+DROP TABLE IF EXISTS papeis;
+DROP TABLE IF EXISTS provedores_saml;
+DROP TABLE IF EXISTS identidades_externas;
+DROP TABLE IF EXISTS clientes_oauth;
+DROP TABLE IF EXISTS seguindo;
+DROP TABLE IF EXISTS usuarios;
+DROP TABLE IF EXISTS organizacoes;
@@ -0,0 +1,155 @@
+-- Esquema criado antes das migrações versionadas. Todos os comandos são idempotentes para que
+-- bancos criados pela verificação antiga (verificarBanco) sejam adotados sem recriar nada.
+
+CREATE TABLE IF NOT EXISTS organizacoes (
+	id serial PRIMARY KEY,
+	slug varchar(50) NOT NULL UNIQUE,
+	nome varchar(100) NOT NULL,
+	dominio varchar(255) UNIQUE,
+	senha_tamanho_minimo integer NOT NULL default 8,
+	senha_exige_maiuscula boolean NOT NULL default false,
+	senha_exige_numero boolean NOT NULL default false,
+	senha_exige_simbolo boolean NOT NULL default false,
+	duracao_token_segundos integer NOT NULL default 21600,
+	duracao_token_anonimo_segundos integer NOT NULL default 86400,
+	chave_assinatura text,
+	criadoEm timestamp default current_timestamp
+);
+
+-- Usuários cadastrados antes da multi-tenancy pertencem à organização padrão
+INSERT INTO organizacoes (id, slug, nome) VALUES (1, 'padrao', 'Organização padrão') ON CONFLICT (id) DO NOTHING;
+SELECT setval('organizacoes_id_seq', (SELECT MAX(id) FROM organizacoes));
+
+CREATE TABLE IF NOT EXISTS usuarios (
+	id serial PRIMARY KEY,
+	org_id integer NOT NULL DEFAULT 1 REFERENCES organizacoes(id) ON DELETE CASCADE,
+	nome varchar(50) NOT NULL,
+	nick varchar(50) NOT NULL,
+	email varchar(50) NOT NULL,
+	senha varchar(100) NOT NULL,
+	criadoEm timestamp default current_timestamp
+);
+
+ALTER TABLE usuarios ADD COLUMN IF NOT EXISTS org_id integer NOT NULL DEFAULT 1 REFERENCES organizacoes(id) ON DELETE CASCADE;
+
+-- nick e email são únicos dentro da organização, e não na instalação inteira
+ALTER TABLE usuarios DROP CONSTRAINT IF EXISTS usuarios_nick_key;
+ALTER TABLE usuarios DROP CONSTRAINT IF EXISTS usuarios_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS usuarios_org_nick_idx ON usuarios (org_id, nick);
+CREATE UNIQUE INDEX IF NOT EXISTS usuarios_org_email_idx ON usuarios (org_id, email);
+
+-- usuario_id é quem é seguido e seguindo_id é o seguidor
+CREATE TABLE IF NOT EXISTS seguindo (
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	seguindo_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	criadoEm timestamp default current_timestamp,
+	PRIMARY KEY (usuario_id, seguindo_id),
+	CHECK (usuario_id <> seguindo_id)
+);
+CREATE INDEX IF NOT EXISTS seguindo_seguidor_idx ON seguindo (seguindo_id);
+
+CREATE TABLE IF NOT EXISTS clientes_oauth (
+	id serial PRIMARY KEY,
+	client_id varchar(100) NOT NULL UNIQUE,
+	segredo varchar(100) NOT NULL,
+	audiencias text[] NOT NULL default '{}',
+	escopos text[] NOT NULL default '{}',
+	permite_delegacao boolean NOT NULL default true,
+	permite_personificacao boolean NOT NULL default false,
+	duracao_maxima_segundos integer NOT NULL default 900,
+	criadoEm timestamp default current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS identidades_externas (
+	id serial PRIMARY KEY,
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	provedor varchar(50) NOT NULL,
+	sujeito varchar(255) NOT NULL,
+	email varchar(50),
+	criadoEm timestamp default current_timestamp
+);
+CREATE INDEX IF NOT EXISTS identidades_externas_usuario_idx ON identidades_externas (usuario_id);
+
+-- o mesmo sujeito de um IdP pode ter uma conta em cada organização
+ALTER TABLE identidades_externas DROP CONSTRAINT IF EXISTS identidades_externas_provedor_sujeito_key;
+CREATE UNIQUE INDEX IF NOT EXISTS identidades_externas_provedor_sujeito_usuario_idx ON identidades_externas (provedor, sujeito, usuario_id);
+
+CREATE TABLE IF NOT EXISTS provedores_saml (
+	id serial PRIMARY KEY,
+	tenant varchar(50) NOT NULL UNIQUE,
+	metadata text NOT NULL,
+	atributo_email varchar(255) NOT NULL default 'email',
+	atributo_nome varchar(255) NOT NULL default 'displayName',
+	atributo_nick varchar(255) NOT NULL default 'uid',
+	confiar_email boolean NOT NULL default false,
+	criadoEm timestamp default current_timestamp,
+	atualizadoEm timestamp default current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS papeis (
+	id serial PRIMARY KEY,
+	nome varchar(50) NOT NULL UNIQUE,
+	descricao varchar(255) NOT NULL default ''
+);
+
+CREATE TABLE IF NOT EXISTS permissoes (
+	id serial PRIMARY KEY,
+	nome varchar(100) NOT NULL UNIQUE,
+	descricao varchar(255) NOT NULL default ''
+);
+
+CREATE TABLE IF NOT EXISTS papel_permissoes (
+	papel_id integer NOT NULL REFERENCES papeis(id) ON DELETE CASCADE,
+	permissao_id integer NOT NULL REFERENCES permissoes(id) ON DELETE CASCADE,
+	PRIMARY KEY (papel_id, permissao_id)
+);
+
+CREATE TABLE IF NOT EXISTS usuario_papeis (
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	papel_id integer NOT NULL REFERENCES papeis(id) ON DELETE CASCADE,
+	criadoEm timestamp default current_timestamp,
+	PRIMARY KEY (usuario_id, papel_id)
+);
+
+INSERT INTO papeis (nome, descricao) VALUES
+	('admin', 'Administra usuários, papéis e integrações'),
+	('usuario', 'Concedido a todo usuário autenticado')
+ON CONFLICT (nome) DO NOTHING;
+
+INSERT INTO permissoes (nome, descricao) VALUES
+	('usuarios:ler', 'Consultar dados de qualquer usuário'),
+	('usuarios:editar', 'Alterar dados de qualquer usuário'),
+	('usuarios:excluir', 'Excluir qualquer usuário'),
+	('papeis:gerenciar', 'Atribuir e remover papéis'),
+	('saml:gerenciar', 'Configurar o IdP SAML dos tenants'),
+	('membros:gerenciar', 'Gerenciar membros e convites da organização')
+ON CONFLICT (nome) DO NOTHING;
+
+-- o papel admin recebe todas as permissões
+INSERT INTO papel_permissoes (papel_id, permissao_id)
+	SELECT p.id, pe.id FROM papeis p CROSS JOIN permissoes pe WHERE p.nome = 'admin'
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS membros (
+	org_id integer NOT NULL REFERENCES organizacoes(id) ON DELETE CASCADE,
+	usuario_id integer PRIMARY KEY REFERENCES usuarios(id) ON DELETE CASCADE,
+	papel varchar(20) NOT NULL CHECK (papel IN ('dono', 'admin', 'membro')),
+	criadoEm timestamp default current_timestamp
+);
+
+-- Cada organização tem no máximo um dono
+CREATE UNIQUE INDEX IF NOT EXISTS membros_dono_idx ON membros (org_id) WHERE papel = 'dono';
+
+CREATE TABLE IF NOT EXISTS convites (
+	id serial PRIMARY KEY,
+	org_id integer NOT NULL REFERENCES organizacoes(id) ON DELETE CASCADE,
+	email varchar(50) NOT NULL,
+	papel varchar(20) NOT NULL,
+	convidado_por integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	token_hash char(64) NOT NULL UNIQUE,
+	expira_em timestamptz NOT NULL,
+	aceito_em timestamptz,
+	revogado_em timestamptz,
+	criadoEm timestamp default current_timestamp
+);
+CREATE INDEX IF NOT EXISTS convites_org_email_idx ON convites (org_id, email);
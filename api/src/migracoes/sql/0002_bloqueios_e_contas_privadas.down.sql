@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS solicitacoes_seguir;
+DROP TABLE IF EXISTS silenciados;
+DROP TABLE IF EXISTS bloqueios;
+ALTER TABLE usuarios DROP COLUMN IF EXISTS privado;
@@ -0,0 +1,29 @@
+-- contas privadas exigem aprovação para serem seguidas
+ALTER TABLE usuarios ADD COLUMN IF NOT EXISTS privado boolean NOT NULL DEFAULT false;
+
+-- usuario_id é quem bloqueou e bloqueado_id é o usuário bloqueado
+CREATE TABLE IF NOT EXISTS bloqueios (
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	bloqueado_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	criadoEm timestamp default current_timestamp,
+	PRIMARY KEY (usuario_id, bloqueado_id),
+	CHECK (usuario_id <> bloqueado_id)
+);
+CREATE INDEX IF NOT EXISTS bloqueios_bloqueado_idx ON bloqueios (bloqueado_id);
+
+CREATE TABLE IF NOT EXISTS silenciados (
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	silenciado_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	criadoEm timestamp default current_timestamp,
+	PRIMARY KEY (usuario_id, silenciado_id),
+	CHECK (usuario_id <> silenciado_id)
+);
+
+-- usuario_id é a conta privada e seguidor_id é quem pediu para segui-la
+CREATE TABLE IF NOT EXISTS solicitacoes_seguir (
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	seguidor_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	criadoEm timestamp default current_timestamp,
+	PRIMARY KEY (usuario_id, seguidor_id),
+	CHECK (usuario_id <> seguidor_id)
+);
@@ -0,0 +1,4 @@
+-- As extensões ficam instaladas: podem ser usadas por outros objetos do banco
+DROP INDEX IF EXISTS usuarios_nick_trgm_idx;
+DROP INDEX IF EXISTS usuarios_nome_trgm_idx;
+DROP FUNCTION IF EXISTS sem_acento(text);
@@ -0,0 +1,10 @@
+-- busca sem acento e por similaridade: unaccent não é IMMUTABLE, por isso o índice usa a função sem_acento
+CREATE EXTENSION IF NOT EXISTS pg_trgm SCHEMA public;
+CREATE EXTENSION IF NOT EXISTS unaccent SCHEMA public;
+
+CREATE OR REPLACE FUNCTION sem_acento(text) RETURNS text AS
+	$$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$
+LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+CREATE INDEX IF NOT EXISTS usuarios_nome_trgm_idx ON usuarios USING gin (lower(sem_acento(nome)) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS usuarios_nick_trgm_idx ON usuarios USING gin (lower(sem_acento(nick)) gin_trgm_ops);
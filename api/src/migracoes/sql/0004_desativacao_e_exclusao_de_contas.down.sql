@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS usuarios_excluidos_idx;
+ALTER TABLE usuarios DROP COLUMN IF EXISTS excluidoEm;
+ALTER TABLE usuarios DROP COLUMN IF EXISTS desativadoEm;
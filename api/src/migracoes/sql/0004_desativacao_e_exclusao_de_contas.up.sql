@@ -0,0 +1,4 @@
+-- desativação pelo usuário e exclusão com carência antes do expurgo
+ALTER TABLE usuarios ADD COLUMN IF NOT EXISTS desativadoEm timestamptz;
+ALTER TABLE usuarios ADD COLUMN IF NOT EXISTS excluidoEm timestamptz;
+CREATE INDEX IF NOT EXISTS usuarios_excluidos_idx ON usuarios (excluidoEm) WHERE excluidoEm IS NOT NULL;
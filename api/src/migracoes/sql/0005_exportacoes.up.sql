@@ -0,0 +1,15 @@
+-- exportação dos dados pessoais (LGPD); o arquivo é apagado pelo expurgo quando o link expira
+CREATE TABLE IF NOT EXISTS exportacoes (
+	id serial PRIMARY KEY,
+	org_id integer NOT NULL REFERENCES organizacoes(id) ON DELETE CASCADE,
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	status varchar(20) NOT NULL CHECK (status IN ('pendente', 'concluida', 'falhou')),
+	arquivo bytea,
+	erro text,
+	criadoEm timestamptz NOT NULL DEFAULT now(),
+	concluidoEm timestamptz,
+	expiraEm timestamptz
+);
+
+-- Um pedido pendente por usuário
+CREATE UNIQUE INDEX IF NOT EXISTS exportacoes_pendente_idx ON exportacoes (usuario_id) WHERE status = 'pendente';
@@ -0,0 +1,10 @@
+-- cache chave-valor usado quando o Redis não está disponível (tentativas de login, bloqueios, sessões).
+-- UNLOGGED não grava no WAL: é mais rápido e o conteúdo pode se perder numa queda do banco, como num cache.
+CREATE UNLOGGED TABLE IF NOT EXISTS cache (
+	chave text PRIMARY KEY,
+	valor text NOT NULL,
+	expiraEm timestamptz
+);
+
+-- O expurgo apaga as chaves vencidas pela data de expiração
+CREATE INDEX IF NOT EXISTS cache_expiraem_idx ON cache (expiraEm) WHERE expiraEm IS NOT NULL;
@@ -0,0 +1,5 @@
+DELETE FROM papeis WHERE org_id IS NOT NULL;
+DROP INDEX IF EXISTS papeis_org_nome_idx;
+DROP INDEX IF EXISTS papeis_sistema_nome_idx;
+ALTER TABLE papeis DROP COLUMN IF EXISTS org_id;
+ALTER TABLE papeis ADD CONSTRAINT papeis_nome_key UNIQUE (nome);
@@ -0,0 +1,6 @@
+-- papéis próprios de cada organização. Os de org_id nulo (admin, usuario) são do sistema e valem em todas;
+-- o nome é único entre os do sistema e dentro de cada organização
+ALTER TABLE papeis ADD COLUMN IF NOT EXISTS org_id integer REFERENCES organizacoes(id) ON DELETE CASCADE;
+ALTER TABLE papeis DROP CONSTRAINT IF EXISTS papeis_nome_key;
+CREATE UNIQUE INDEX IF NOT EXISTS papeis_sistema_nome_idx ON papeis (nome) WHERE org_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS papeis_org_nome_idx ON papeis (org_id, nome) WHERE org_id IS NOT NULL;
@@ -0,0 +1,12 @@
+-- eventos de auditoria: ações de administradores sobre a conta de outro usuário.
+-- ator_id fica nulo quando a conta de quem agiu é expurgada; o evento sai junto com a conta do usuário afetado.
+CREATE TABLE IF NOT EXISTS auditoria (
+	id serial PRIMARY KEY,
+	org_id integer NOT NULL REFERENCES organizacoes(id) ON DELETE CASCADE,
+	ator_id integer REFERENCES usuarios(id) ON DELETE SET NULL,
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	acao varchar(50) NOT NULL,
+	detalhes jsonb NOT NULL DEFAULT '{}',
+	criadoEm timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS auditoria_usuario_idx ON auditoria (usuario_id, criadoEm);
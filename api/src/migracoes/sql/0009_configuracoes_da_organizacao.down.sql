@@ -0,0 +1,2 @@
+-- as atribuições a papéis saem junto, por ON DELETE CASCADE
+DELETE FROM permissoes WHERE nome = 'organizacao:configurar';
@@ -0,0 +1,9 @@
+-- permissão de PUT /organizacao/configuracoes; o papel admin do sistema recebe todas as permissões
+INSERT INTO permissoes (nome, descricao) VALUES
+	('organizacao:configurar', 'Alterar a política de senha, os tokens e a chave de assinatura da organização')
+ON CONFLICT (nome) DO NOTHING;
+
+INSERT INTO papel_permissoes (papel_id, permissao_id)
+	SELECT p.id, pe.id FROM papeis p CROSS JOIN permissoes pe
+	WHERE p.nome = 'admin' AND p.org_id IS NULL AND pe.nome = 'organizacao:configurar'
+ON CONFLICT DO NOTHING;
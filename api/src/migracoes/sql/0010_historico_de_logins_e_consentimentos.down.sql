@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS consentimentos;
+DROP TABLE IF EXISTS historico_de_logins;
@@ -0,0 +1,21 @@
+-- logins aceitos, exportados ao titular junto com os demais dados pessoais (LGPD)
+CREATE TABLE IF NOT EXISTS historico_de_logins (
+	id serial PRIMARY KEY,
+	org_id integer NOT NULL REFERENCES organizacoes(id) ON DELETE CASCADE,
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	origem varchar(20) NOT NULL,
+	ip varchar(45) NOT NULL DEFAULT '',
+	criadoEm timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS historico_de_logins_usuario_idx ON historico_de_logins (usuario_id, criadoEm);
+
+-- consentimentos dados e revogados pelo titular; cada mudança é uma linha, a mais recente vale
+CREATE TABLE IF NOT EXISTS consentimentos (
+	id serial PRIMARY KEY,
+	org_id integer NOT NULL REFERENCES organizacoes(id) ON DELETE CASCADE,
+	usuario_id integer NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+	finalidade varchar(50) NOT NULL,
+	concedido boolean NOT NULL,
+	criadoEm timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS consentimentos_usuario_idx ON consentimentos (usuario_id, finalidade, criadoEm);
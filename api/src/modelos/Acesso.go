@@ -0,0 +1,9 @@
+package modelos
+
+// Acesso reúne os papéis e as permissões de um usuário no momento da requisição, e não no do login
+type Acesso struct {
+	Papeis     []string `json:"papeis"`
+	Permissoes []string `json:"permissoes"`
+	// Inativa indica uma conta desativada, excluída ou que não existe mais: os tokens dela são recusados
+	Inativa bool `json:"inativa,omitempty"`
+}
@@ -0,0 +1,43 @@
+package modelos
+
+import "time"
+
+// ClienteOAuth representa um serviço autorizado a trocar tokens em nome de usuários
+type ClienteOAuth struct {
+	ID                    uint64
+	ClientID              string
+	Segredo               string
+	Audiencias            []string
+	Escopos               []string
+	PermiteDelegacao      bool
+	PermitePersonificacao bool
+	DuracaoMaxima         time.Duration
+}
+
+// PermiteAudiencia informa se o cliente pode emitir tokens para a audiência informada
+func (cliente ClienteOAuth) PermiteAudiencia(audiencia string) bool {
+	return contem(cliente.Audiencias, audiencia)
+}
+
+// PermiteEscopo informa se o escopo está entre os liberados para o cliente
+func (cliente ClienteOAuth) PermiteEscopo(escopo string) bool {
+	return contem(cliente.Escopos, escopo)
+}
+
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if item == valor {
+			return true
+		}
+	}
+	return false
+}
+
+// RespostaTrocaToken representa a resposta da troca de tokens (RFC 8693)
+type RespostaTrocaToken struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Scope           string `json:"scope,omitempty"`
+}
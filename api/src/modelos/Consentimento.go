@@ -0,0 +1,21 @@
+package modelos
+
+import (
+	"regexp"
+	"time"
+)
+
+// finalidadeValida limita a finalidade a um identificador curto, como "newsletter" ou "pesquisa-de-satisfacao"
+var finalidadeValida = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// Consentimento registra o titular concedendo ou revogando o uso dos dados para uma finalidade (LGPD, art. 8º)
+type Consentimento struct {
+	Finalidade string    `json:"finalidade"`
+	Concedido  bool      `json:"concedido"`
+	CriadoEm   time.Time `json:"criadoEm"`
+}
+
+// FinalidadeValida informa se a finalidade tem só letras minúsculas, dígitos, "_" e "-", até 50 caracteres
+func FinalidadeValida(finalidade string) bool {
+	return finalidadeValida.MatchString(finalidade)
+}
@@ -0,0 +1,59 @@
+package modelos
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/badoux/checkmail"
+)
+
+// Convite representa o convite para um e-mail entrar na organização com um papel
+type Convite struct {
+	ID           uint64     `json:"id"`
+	OrgID        uint64     `json:"orgId"`
+	Email        string     `json:"email"`
+	Papel        string     `json:"papel"`
+	ConvidadoPor uint64     `json:"convidadoPor"`
+	ExpiraEm     time.Time  `json:"expiraEm"`
+	AceitoEm     *time.Time `json:"aceitoEm,omitempty"`
+	RevogadoEm   *time.Time `json:"revogadoEm,omitempty"`
+	CriadoEm     time.Time  `json:"criadoEm"`
+	// Link só é devolvido quando o e-mail não pôde ser enviado, para ser repassado manualmente
+	Link string `json:"link,omitempty"`
+}
+
+// Preparar valida e formata o convite recebido
+func (convite *Convite) Preparar() error {
+	convite.Email = strings.ToLower(strings.TrimSpace(convite.Email))
+	if convite.Email == "" {
+		return errors.New("o e-mail é obrigatório e não pode estar em branco")
+	}
+
+	if erro := checkmail.ValidateFormat(convite.Email); erro != nil {
+		return errors.New("o e-mail inserido é inválido")
+	}
+
+	if convite.Papel == "" {
+		convite.Papel = PapelMembro
+	}
+
+	if !PapelDeMembroAtribuivel(convite.Papel) {
+		return errors.New("papel inválido: use admin ou membro")
+	}
+
+	return nil
+}
+
+// Pendente informa se o convite ainda pode ser aceito
+func (convite Convite) Pendente() bool {
+	return convite.AceitoEm == nil && convite.RevogadoEm == nil && time.Now().Before(convite.ExpiraEm)
+}
+
+// AceiteDeConvite é o corpo da requisição de aceite. Nome, nick e senha criam a conta quando ela não existe;
+// se a conta já existe e a requisição não tem token, a senha confirma que o convidado é o dono dela.
+type AceiteDeConvite struct {
+	Nome  string `json:"nome"`
+	Nick  string `json:"nick"`
+	Senha string `json:"senha"`
+}
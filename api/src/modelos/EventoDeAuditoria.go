@@ -0,0 +1,22 @@
+package modelos
+
+import "time"
+
+const (
+	// AuditoriaSenhaRedefinida registra a senha de um usuário definida por um administrador
+	AuditoriaSenhaRedefinida = "senha_redefinida"
+	// AuditoriaPapelAtribuido registra um papel concedido a um usuário
+	AuditoriaPapelAtribuido = "papel_atribuido"
+	// AuditoriaPapelRemovido registra um papel retirado de um usuário
+	AuditoriaPapelRemovido = "papel_removido"
+)
+
+// EventoDeAuditoria representa uma ação de um administrador (Ator) sobre a conta de um usuário
+type EventoDeAuditoria struct {
+	ID        uint64            `json:"id,omitempty"`
+	AtorID    *uint64           `json:"atorId,omitempty"`
+	UsuarioID uint64            `json:"usuarioId"`
+	Acao      string            `json:"acao"`
+	Detalhes  map[string]string `json:"detalhes,omitempty"`
+	CriadoEm  time.Time         `json:"criadoEm"`
+}
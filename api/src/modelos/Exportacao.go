@@ -0,0 +1,38 @@
+package modelos
+
+import "time"
+
+// Situações de uma exportação de dados pessoais
+const (
+	ExportacaoPendente  = "pendente"
+	ExportacaoConcluida = "concluida"
+	ExportacaoFalhou    = "falhou"
+)
+
+// Exportacao é um pedido do titular pelos próprios dados (LGPD), gerado em segundo plano
+type Exportacao struct {
+	ID          uint64     `json:"id"`
+	UsuarioID   uint64     `json:"usuarioId"`
+	Status      string     `json:"status"`
+	Erro        string     `json:"erro,omitempty"`
+	CriadoEm    time.Time  `json:"criadoEm"`
+	ConcluidoEm *time.Time `json:"concluidoEm,omitempty"`
+	ExpiraEm    *time.Time `json:"expiraEm,omitempty"`
+	// URL é o link assinado de download, devolvido enquanto o arquivo não expira
+	URL string `json:"url,omitempty"`
+}
+
+// VinculoExportado é um usuário ligado ao titular dos dados (seguidor, seguido, bloqueado...)
+type VinculoExportado struct {
+	ID    uint64    `json:"id"`
+	Nick  string    `json:"nick"`
+	Desde time.Time `json:"desde"`
+}
+
+// IdentidadeExportada é um vínculo do titular com um provedor de login externo (OIDC ou SAML)
+type IdentidadeExportada struct {
+	Provedor string    `json:"provedor"`
+	Sujeito  string    `json:"sujeito"`
+	Email    string    `json:"email,omitempty"`
+	CriadoEm time.Time `json:"criadoEm"`
+}
@@ -0,0 +1,11 @@
+package modelos
+
+import "time"
+
+// LoginRegistrado é uma entrada do histórico de logins aceitos do usuário
+type LoginRegistrado struct {
+	// Origem é quem conferiu as credenciais: banco, ldap, oidc ou saml
+	Origem   string    `json:"origem"`
+	IP       string    `json:"ip,omitempty"`
+	CriadoEm time.Time `json:"criadoEm"`
+}
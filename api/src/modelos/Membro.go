@@ -0,0 +1,31 @@
+package modelos
+
+import "time"
+
+// Papéis de um membro dentro da organização
+const (
+	PapelDono   = "dono"
+	PapelAdmin  = "admin"
+	PapelMembro = "membro"
+)
+
+// Membro representa um usuário que faz parte da equipe de uma organização
+type Membro struct {
+	UsuarioID uint64    `json:"usuarioId"`
+	Nome      string    `json:"nome"`
+	Nick      string    `json:"nick"`
+	Email     string    `json:"email"`
+	Papel     string    `json:"papel"`
+	CriadoEm  time.Time `json:"criadoEm"`
+}
+
+// PapelDeMembroAtribuivel informa se o papel pode ser concedido por convite ou alteração.
+// O papel de dono só muda pela transferência de propriedade.
+func PapelDeMembroAtribuivel(papel string) bool {
+	return papel == PapelAdmin || papel == PapelMembro
+}
+
+// TransferenciaDePropriedade representa o corpo da requisição que passa a organização para outro membro
+type TransferenciaDePropriedade struct {
+	UsuarioID uint64 `json:"usuarioId"`
+}
@@ -0,0 +1,160 @@
+package modelos
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Organizacao representa um tenant: cada produto tem os seus próprios usuários, políticas e chave de assinatura
+type Organizacao struct {
+	ID                  uint64        `json:"id"`
+	Slug                string        `json:"slug"`
+	Nome                string        `json:"nome"`
+	Dominio             string        `json:"dominio,omitempty"`
+	PoliticaDeSenha     PoliticaSenha `json:"politicaDeSenha"`
+	DuracaoToken        time.Duration `json:"-"`
+	DuracaoTokenAnonimo time.Duration `json:"-"`
+	ChaveAssinatura     []byte        `json:"-"`
+}
+
+// PoliticaDeSenhaPadrao é a política de uma organização recém-criada, a dos valores padrão da tabela organizacoes
+var PoliticaDeSenhaPadrao = PoliticaSenha{TamanhoMinimo: 8}
+
+// PoliticaSenha define as regras que as senhas dos usuários de uma organização precisam seguir
+type PoliticaSenha struct {
+	TamanhoMinimo  int  `json:"tamanhoMinimo"`
+	ExigeMaiuscula bool `json:"exigeMaiuscula"`
+	ExigeNumero    bool `json:"exigeNumero"`
+	ExigeSimbolo   bool `json:"exigeSimbolo"`
+}
+
+// Validar verifica se a senha atende à política
+func (politica PoliticaSenha) Validar(senha string) error {
+	if len([]rune(senha)) < politica.TamanhoMinimo {
+		return fmt.Errorf("a senha deve ter pelo menos %d caracteres", politica.TamanhoMinimo)
+	}
+
+	var maiuscula, numero, simbolo bool
+	for _, caractere := range senha {
+		switch {
+		case unicode.IsUpper(caractere):
+			maiuscula = true
+		case unicode.IsDigit(caractere):
+			numero = true
+		case !unicode.IsLetter(caractere) && !unicode.IsSpace(caractere):
+			simbolo = true
+		}
+	}
+
+	var faltando []string
+	if politica.ExigeMaiuscula && !maiuscula {
+		faltando = append(faltando, "uma letra maiúscula")
+	}
+	if politica.ExigeNumero && !numero {
+		faltando = append(faltando, "um número")
+	}
+	if politica.ExigeSimbolo && !simbolo {
+		faltando = append(faltando, "um símbolo")
+	}
+	if len(faltando) > 0 {
+		return errors.New("a senha deve conter " + strings.Join(faltando, ", "))
+	}
+
+	return nil
+}
+
+// formatoDoSlug aceita letras minúsculas, números e hífens entre eles, como em /o/empresa-x
+var formatoDoSlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// CadastroDeOrganizacao é o corpo da requisição que cria uma organização com a conta de quem vai ser o dono
+type CadastroDeOrganizacao struct {
+	Slug string  `json:"slug"`
+	Nome string  `json:"nome"`
+	Dono Usuario `json:"dono"`
+}
+
+// Preparar valida o slug, o nome e a conta do dono, que recebe o hash da senha
+func (cadastro *CadastroDeOrganizacao) Preparar() error {
+	cadastro.Slug = strings.ToLower(strings.TrimSpace(cadastro.Slug))
+	if len(cadastro.Slug) > 50 || !formatoDoSlug.MatchString(cadastro.Slug) {
+		return errors.New("slug inválido: use até 50 letras minúsculas, números e hífens")
+	}
+
+	cadastro.Nome = strings.TrimSpace(cadastro.Nome)
+	if cadastro.Nome == "" || len([]rune(cadastro.Nome)) > 100 {
+		return errors.New("o nome da organização é obrigatório e tem no máximo 100 caracteres")
+	}
+
+	if erro := PoliticaDeSenhaPadrao.Validar(cadastro.Dono.Senha); erro != nil {
+		return erro
+	}
+
+	return cadastro.Dono.Preparar("cadastro")
+}
+
+// OrganizacaoCriada é a resposta da criação: a organização e o dono dela
+type OrganizacaoCriada struct {
+	Organizacao Organizacao `json:"organizacao"`
+	Dono        Membro      `json:"dono"`
+}
+
+// tamanhoMinimoDaChave é o mínimo de bytes da chave de assinatura própria de uma organização (HS256 usa 256 bits)
+const tamanhoMinimoDaChave = 32
+
+// ConfiguracoesDaOrganizacao é o corpo de PUT /organizacao/configuracoes. As durações usam o formato de TOKEN_DURACAO
+// ("6h", "30m"); vazias, voltam ao padrão da configuração. A chave de assinatura só muda quando vem no corpo, e a
+// string vazia volta à SECRET_KEY global.
+type ConfiguracoesDaOrganizacao struct {
+	PoliticaDeSenha     PoliticaSenha `json:"politicaDeSenha"`
+	DuracaoToken        string        `json:"duracaoToken"`
+	DuracaoTokenAnonimo string        `json:"duracaoTokenAnonimo"`
+	ChaveAssinatura     *string       `json:"chaveAssinatura"`
+}
+
+// Aplicar valida as configurações e as copia para a organização
+func (configuracoes ConfiguracoesDaOrganizacao) Aplicar(organizacao *Organizacao) error {
+	if tamanho := configuracoes.PoliticaDeSenha.TamanhoMinimo; tamanho < PoliticaDeSenhaPadrao.TamanhoMinimo || tamanho > 72 {
+		return fmt.Errorf("o tamanho mínimo da senha vai de %d a 72 caracteres", PoliticaDeSenhaPadrao.TamanhoMinimo)
+	}
+
+	duracaoToken, erro := duracaoDoToken("duracaoToken", configuracoes.DuracaoToken)
+	if erro != nil {
+		return erro
+	}
+	duracaoTokenAnonimo, erro := duracaoDoToken("duracaoTokenAnonimo", configuracoes.DuracaoTokenAnonimo)
+	if erro != nil {
+		return erro
+	}
+
+	switch chave := configuracoes.ChaveAssinatura; {
+	case chave == nil:
+	case *chave == "":
+		organizacao.ChaveAssinatura = nil
+	case len(*chave) < tamanhoMinimoDaChave:
+		return fmt.Errorf("a chave de assinatura deve ter pelo menos %d bytes", tamanhoMinimoDaChave)
+	default:
+		organizacao.ChaveAssinatura = []byte(*chave)
+	}
+
+	organizacao.PoliticaDeSenha = configuracoes.PoliticaDeSenha
+	organizacao.DuracaoToken = duracaoToken
+	organizacao.DuracaoTokenAnonimo = duracaoTokenAnonimo
+	return nil
+}
+
+// duracaoDoToken lê uma duração em segundos inteiros de pelo menos um minuto; vazia é zero, o padrão da configuração
+func duracaoDoToken(campo, valor string) (time.Duration, error) {
+	if valor == "" {
+		return 0, nil
+	}
+
+	duracao, erro := time.ParseDuration(valor)
+	if erro != nil || duracao < time.Minute || duracao%time.Second != 0 {
+		return 0, fmt.Errorf("%s inválida: %q (use segundos inteiros e pelo menos 1m, como 30m ou 6h)", campo, valor)
+	}
+	return duracao, nil
+}
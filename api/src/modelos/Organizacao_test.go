@@ -0,0 +1,80 @@
+package modelos
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCadastroDeOrganizacao(t *testing.T) {
+	dono := Usuario{Nome: "Dona", Nick: "dona", Email: "dona@exemplo.com", Senha: "senha-forte"}
+
+	casos := []struct {
+		nome        string
+		slug        string
+		organizacao string
+		senha       string
+		valido      bool
+	}{
+		{"válido", " Empresa-X ", "Empresa X", dono.Senha, true},
+		{"slug com espaço", "empresa x", "Empresa X", dono.Senha, false},
+		{"slug com hífen no fim", "empresa-", "Empresa X", dono.Senha, false},
+		{"slug longo", strings.Repeat("a", 51), "Empresa X", dono.Senha, false},
+		{"sem nome", "empresa-x", "  ", dono.Senha, false},
+		{"senha curta", "empresa-x", "Empresa X", "curta", false},
+	}
+	for _, caso := range casos {
+		cadastro := CadastroDeOrganizacao{Slug: caso.slug, Nome: caso.organizacao, Dono: dono}
+		cadastro.Dono.Senha = caso.senha
+
+		erro := cadastro.Preparar()
+		if (erro == nil) != caso.valido {
+			t.Errorf("%s: erro = %v, válido = %v", caso.nome, erro, caso.valido)
+			continue
+		}
+		if caso.valido {
+			if cadastro.Slug != "empresa-x" {
+				t.Errorf("%s: slug = %q, quer empresa-x", caso.nome, cadastro.Slug)
+			}
+			if cadastro.Dono.Senha == caso.senha {
+				t.Errorf("%s: a senha do dono não recebeu o hash", caso.nome)
+			}
+		}
+	}
+}
+
+func TestConfiguracoesDaOrganizacao(t *testing.T) {
+	chaveLonga, chaveCurta, semChave := strings.Repeat("k", 32), "curta", ""
+	politica := PoliticaSenha{TamanhoMinimo: 12, ExigeNumero: true}
+
+	casos := []struct {
+		nome          string
+		configuracoes ConfiguracoesDaOrganizacao
+		valido        bool
+		querChave     string
+	}{
+		{"sem chave mantém a atual", ConfiguracoesDaOrganizacao{PoliticaDeSenha: politica, DuracaoToken: "2h"}, true, "atual"},
+		{"chave nova", ConfiguracoesDaOrganizacao{PoliticaDeSenha: politica, ChaveAssinatura: &chaveLonga}, true, chaveLonga},
+		{"chave vazia volta à global", ConfiguracoesDaOrganizacao{PoliticaDeSenha: politica, ChaveAssinatura: &semChave}, true, ""},
+		{"chave curta", ConfiguracoesDaOrganizacao{PoliticaDeSenha: politica, ChaveAssinatura: &chaveCurta}, false, ""},
+		{"senha mínima abaixo do padrão", ConfiguracoesDaOrganizacao{PoliticaDeSenha: PoliticaSenha{TamanhoMinimo: 4}}, false, ""},
+		{"duração inválida", ConfiguracoesDaOrganizacao{PoliticaDeSenha: politica, DuracaoToken: "seis horas"}, false, ""},
+		{"duração curta", ConfiguracoesDaOrganizacao{PoliticaDeSenha: politica, DuracaoTokenAnonimo: "30s"}, false, ""},
+	}
+	for _, caso := range casos {
+		organizacao := Organizacao{ID: 2, ChaveAssinatura: []byte("atual")}
+		erro := caso.configuracoes.Aplicar(&organizacao)
+		if (erro == nil) != caso.valido {
+			t.Errorf("%s: erro = %v, válido = %v", caso.nome, erro, caso.valido)
+			continue
+		}
+		if !caso.valido {
+			continue
+		}
+		if string(organizacao.ChaveAssinatura) != caso.querChave {
+			t.Errorf("%s: chave = %q, quer %q", caso.nome, organizacao.ChaveAssinatura, caso.querChave)
+		}
+		if organizacao.PoliticaDeSenha != politica {
+			t.Errorf("%s: política = %+v, quer %+v", caso.nome, organizacao.PoliticaDeSenha, politica)
+		}
+	}
+}
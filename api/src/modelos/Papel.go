@@ -0,0 +1,16 @@
+package modelos
+
+// Papel representa um papel (role) e as permissões concedidas por ele.
+// Os papéis do sistema valem em todas as organizações; os demais pertencem a uma só.
+type Papel struct {
+	ID         uint64   `json:"id,omitempty"`
+	Nome       string   `json:"nome"`
+	Descricao  string   `json:"descricao,omitempty"`
+	Sistema    bool     `json:"sistema"`
+	Permissoes []string `json:"permissoes,omitempty"`
+}
+
+// AtribuicaoDePapel representa o corpo da requisição para atribuir um papel a um usuário
+type AtribuicaoDePapel struct {
+	Papel string `json:"papel"`
+}
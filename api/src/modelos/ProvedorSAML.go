@@ -0,0 +1,13 @@
+package modelos
+
+// ProvedorSAML representa o IdP SAML configurado para um tenant
+type ProvedorSAML struct {
+	ID            uint64 `json:"id,omitempty"`
+	Tenant        string `json:"tenant"`
+	Metadata      string `json:"-"`
+	AtributoEmail string `json:"atributoEmail"`
+	AtributoNome  string `json:"atributoNome"`
+	AtributoNick  string `json:"atributoNick"`
+	// ConfiarEmail permite vincular contas existentes pelo e-mail informado pelo IdP
+	ConfiarEmail bool `json:"confiarEmail"`
+}
@@ -18,6 +18,8 @@ type Usuario struct {
 	Senha    string    `json:"senha,omitempty"`
 	CriadoEm time.Time `json:"CriadoEm,omitempty"`
 	Conexao  string    `json:"conexao,omitempty"`
+	// Privado faz com que novos seguidores precisem de aprovação
+	Privado bool `json:"privado,omitempty"`
 }
 
 // Preparar vai chamar os métodos para validar e formatar o usuário recebido
@@ -73,3 +75,67 @@ func (usuario *Usuario) formatar(etapa string) error {
 
 	return nil
 }
+
+// AlteracaoDeUsuario representa o corpo de um PATCH: apenas os campos enviados são alterados
+type AlteracaoDeUsuario struct {
+	Nome    *string `json:"nome"`
+	Nick    *string `json:"nick"`
+	Email   *string `json:"email"`
+	Privado *bool   `json:"privado"`
+}
+
+// Aplicar copia para o usuário os campos presentes na alteração
+func (alteracao AlteracaoDeUsuario) Aplicar(usuario *Usuario) {
+	if alteracao.Nome != nil {
+		usuario.Nome = *alteracao.Nome
+	}
+	if alteracao.Nick != nil {
+		usuario.Nick = *alteracao.Nick
+	}
+	if alteracao.Email != nil {
+		usuario.Email = *alteracao.Email
+	}
+	if alteracao.Privado != nil {
+		usuario.Privado = *alteracao.Privado
+	}
+}
+
+// PerfilDeUsuario é o usuário com as contagens do grafo social
+type PerfilDeUsuario struct {
+	Usuario
+	Seguidores int `json:"seguidores"`
+	Seguindo   int `json:"seguindo"`
+}
+
+// Sugestao é um usuário sugerido para seguir, com a quantidade de seguidos em comum que também o seguem
+type Sugestao struct {
+	Usuario
+	EmComum int `json:"emComum"`
+}
+
+// FiltroDeUsuarios reúne os filtros opcionais da busca de usuários
+type FiltroDeUsuarios struct {
+	Termo       string
+	CriadoApos  *time.Time
+	CriadoAntes *time.Time
+	Privado     *bool
+}
+
+// ResultadoDeBusca é um usuário encontrado pela busca, com a relevância e os trechos que casaram com o termo
+type ResultadoDeBusca struct {
+	Usuario
+	Relevancia float64    `json:"relevancia,omitempty"`
+	Destaques  *Destaques `json:"destaques,omitempty"`
+}
+
+// Destaques traz nome e nick escapados para HTML, com as ocorrências do termo entre <mark> e </mark>
+type Destaques struct {
+	Nome string `json:"nome"`
+	Nick string `json:"nick"`
+}
+
+// SituacaoDaConta informa quando a conta foi desativada ou excluída; nil quando não foi
+type SituacaoDaConta struct {
+	DesativadoEm *time.Time
+	ExcluidoEm   *time.Time
+}
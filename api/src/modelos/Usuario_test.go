@@ -0,0 +1,21 @@
+package modelos
+
+import "testing"
+
+func TestAplicarAlteracaoDeUsuario(t *testing.T) {
+	nome, privado := "Ana Souza", true
+	usuario := Usuario{ID: 1, Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com"}
+
+	AlteracaoDeUsuario{Nome: &nome, Privado: &privado}.Aplicar(&usuario)
+
+	esperado := Usuario{ID: 1, Nome: "Ana Souza", Nick: "ana", Email: "ana@exemplo.com", Privado: true}
+	if usuario != esperado {
+		t.Errorf("usuário = %+v, quer %+v", usuario, esperado)
+	}
+
+	// Um PATCH vazio não muda nada, nem desfaz a conta privada
+	AlteracaoDeUsuario{}.Aplicar(&usuario)
+	if usuario != esperado {
+		t.Errorf("PATCH vazio alterou o usuário: %+v", usuario)
+	}
+}
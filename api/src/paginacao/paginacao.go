@@ -0,0 +1,167 @@
+package paginacao
+
+import (
+	"api/src/respostas"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// LimitePadrao é o tamanho da página quando ?limite= não é informado
+	LimitePadrao = 20
+
+	// LimiteMaximo é o maior tamanho de página aceito
+	LimiteMaximo = 100
+
+	// CabecalhoProximoCursor traz o cursor da próxima página, o mesmo do Link, para quem não quer interpretar o Link
+	CabecalhoProximoCursor = "X-Next-Cursor"
+)
+
+// ErrCursorInvalido é retornado quando o cursor não foi gerado por esta API ou é de outra ordenação
+var ErrCursorInvalido = errors.New("cursor inválido")
+
+// Coluna descreve uma ordenação possível: a expressão SQL e o tipo usado para comparar o valor do cursor
+type Coluna struct {
+	Expressao string
+	Tipo      string
+}
+
+// Cursor marca a última linha entregue; a próxima página começa logo depois dela.
+// Vai para o cliente codificado em base64 e deve ser tratado como opaco.
+type Cursor struct {
+	Ordem string `json:"o"`
+	Valor string `json:"v"`
+	ID    uint64 `json:"i"`
+}
+
+// Pagina é a paginação por chave (keyset) pedida na requisição
+type Pagina struct {
+	Limite      int
+	Campo       string
+	Descendente bool
+	Cursor      *Cursor
+}
+
+// DaRequisicao lê ?limite=, ?ordem= (campo, ou -campo para ordem decrescente) e ?cursor=.
+// campos são as ordenações aceitas pelo endpoint e padrao é a ordem usada quando ?ordem= não é informado.
+func DaRequisicao(r *http.Request, padrao string, campos ...string) (Pagina, error) {
+	parametros := r.URL.Query()
+	pagina := Pagina{Limite: LimitePadrao}
+
+	if valor := parametros.Get("limite"); valor != "" {
+		numero, erro := strconv.Atoi(valor)
+		if erro != nil || numero < 1 || numero > LimiteMaximo {
+			return Pagina{}, fmt.Errorf("limite deve ser um número entre 1 e %d", LimiteMaximo)
+		}
+		pagina.Limite = numero
+	}
+
+	ordem := parametros.Get("ordem")
+	if ordem == "" {
+		ordem = padrao
+	}
+	pagina.Campo = strings.TrimPrefix(ordem, "-")
+	pagina.Descendente = strings.HasPrefix(ordem, "-")
+
+	valida := false
+	for _, campo := range campos {
+		if campo == pagina.Campo {
+			valida = true
+		}
+	}
+	if !valida {
+		return Pagina{}, fmt.Errorf("ordem deve ser um de: %s (use - na frente para ordem decrescente)", strings.Join(campos, ", "))
+	}
+
+	if valor := parametros.Get("cursor"); valor != "" {
+		cursor, erro := decodificar(valor)
+		if erro != nil || cursor.Ordem != pagina.ordem() {
+			return Pagina{}, ErrCursorInvalido
+		}
+		pagina.Cursor = &cursor
+	}
+
+	return pagina, nil
+}
+
+// SQL monta a condição do cursor, a coluna da chave e o ORDER BY ... LIMIT da consulta.
+// colunaID desempata linhas com o mesmo valor e parametro é o próximo $n livre na consulta.
+// A chave é selecionada como texto para que o repositório consiga gerar o próximo cursor.
+func (pagina Pagina) SQL(colunas map[string]Coluna, colunaID string, parametro int) (condicao, chave, ordenacao string, argumentos []interface{}) {
+	coluna := colunas[pagina.Campo]
+	direcao, comparacao := "ASC", ">"
+	if pagina.Descendente {
+		direcao, comparacao = "DESC", "<"
+	}
+
+	condicao = "TRUE"
+	if pagina.Cursor != nil {
+		condicao = fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d)", coluna.Expressao, colunaID, comparacao, parametro, coluna.Tipo, parametro+1)
+		argumentos = []interface{}{pagina.Cursor.Valor, pagina.Cursor.ID}
+		parametro += 2
+	}
+
+	chave = coluna.Expressao + "::text"
+	ordenacao = fmt.Sprintf("ORDER BY %[1]s %[3]s, %[2]s %[3]s LIMIT $%[4]d", coluna.Expressao, colunaID, direcao, parametro)
+	argumentos = append(argumentos, pagina.Limite+1)
+	return condicao, chave, ordenacao, argumentos
+}
+
+// Proximo recebe a chave e o ID de cada linha lida (até Limite+1) e diz quantas entregar e qual o próximo cursor.
+// Sem uma linha além do limite não há próxima página e o cursor é nil.
+func (pagina Pagina) Proximo(chaves []string, IDs []uint64) (int, *Cursor) {
+	if len(IDs) <= pagina.Limite {
+		return len(IDs), nil
+	}
+
+	ultima := pagina.Limite - 1
+	return pagina.Limite, &Cursor{Ordem: pagina.ordem(), Valor: chaves[ultima], ID: IDs[ultima]}
+}
+
+// Responder escreve os itens como o array JSON de sempre, para os clientes que já liam a lista continuarem
+// funcionando, e o próximo cursor nos cabeçalhos X-Next-Cursor e Link com rel="next". O link é uma referência
+// relativa só com a query, para preservar o prefixo /o/{slug} usado pelo cliente.
+func Responder(w http.ResponseWriter, r *http.Request, itens interface{}, proximo *Cursor) {
+	if proximo != nil {
+		cursor := codificar(*proximo)
+		w.Header().Set(CabecalhoProximoCursor, cursor)
+
+		parametros := r.URL.Query()
+		parametros.Set("cursor", cursor)
+		w.Header().Set("Link", fmt.Sprintf(`<?%s>; rel="next"`, parametros.Encode()))
+	}
+
+	respostas.JSON(w, http.StatusOK, itens)
+}
+
+func (pagina Pagina) ordem() string {
+	if pagina.Descendente {
+		return "-" + pagina.Campo
+	}
+	return pagina.Campo
+}
+
+func codificar(cursor Cursor) string {
+	dados, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(dados)
+}
+
+func decodificar(valor string) (Cursor, error) {
+	var cursor Cursor
+
+	dados, erro := base64.RawURLEncoding.DecodeString(valor)
+	if erro != nil {
+		return Cursor{}, erro
+	}
+
+	if erro = json.Unmarshal(dados, &cursor); erro != nil {
+		return Cursor{}, erro
+	}
+
+	return cursor, nil
+}
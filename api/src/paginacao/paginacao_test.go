@@ -0,0 +1,63 @@
+package paginacao
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestResponder(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/o/empresa/usuarios?usuario=ana&ordem=-criadoEm&limite=2", nil)
+	pagina, erro := DaRequisicao(r, "nome", "criadoEm", "nome")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	_, proximo := pagina.Proximo([]string{"2024-03-01", "2024-02-01", "2024-01-01"}, []uint64{9, 8, 7})
+
+	w := httptest.NewRecorder()
+	Responder(w, r, []string{"ana", "anabela"}, proximo)
+
+	if corpo := strings.TrimSpace(w.Body.String()); corpo != `["ana","anabela"]` {
+		t.Errorf("corpo = %s, quer o array sem envelope", corpo)
+	}
+
+	link := w.Header().Get("Link")
+	if !strings.HasPrefix(link, "<?") || !strings.HasSuffix(link, `>; rel="next"`) {
+		t.Fatalf("Link = %q", link)
+	}
+	parametros, erro := url.ParseQuery(strings.TrimSuffix(strings.TrimPrefix(link, "<?"), `>; rel="next"`))
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if parametros.Get("usuario") != "ana" || parametros.Get("ordem") != "-criadoEm" || parametros.Get("limite") != "2" {
+		t.Errorf("o Link não preserva a query: %v", parametros)
+	}
+
+	if cursor := w.Header().Get(CabecalhoProximoCursor); cursor == "" || cursor != parametros.Get("cursor") {
+		t.Errorf("%s = %q, quer o mesmo cursor do Link (%q)", CabecalhoProximoCursor, cursor, parametros.Get("cursor"))
+	}
+
+	// O cursor do Link continua a partir da última linha entregue
+	seguinte, erro := DaRequisicao(httptest.NewRequest(http.MethodGet, "/usuarios?"+parametros.Encode(), nil), "nome", "criadoEm", "nome")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if seguinte.Cursor == nil || seguinte.Cursor.ID != 8 || seguinte.Cursor.Valor != "2024-02-01" {
+		t.Errorf("cursor = %+v, quer o da linha 8", seguinte.Cursor)
+	}
+
+	// O cursor é de -criadoEm: com outra ordem ele não vale
+	parametros.Set("ordem", "nome")
+	if _, erro = DaRequisicao(httptest.NewRequest(http.MethodGet, "/usuarios?"+parametros.Encode(), nil), "nome", "criadoEm", "nome"); erro != ErrCursorInvalido {
+		t.Errorf("erro = %v, quer ErrCursorInvalido", erro)
+	}
+
+	w = httptest.NewRecorder()
+	Responder(w, r, []string{}, nil)
+	if w.Header().Get("Link") != "" || w.Header().Get(CabecalhoProximoCursor) != "" || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("última página: Link = %q, %s = %q, corpo = %s", w.Header().Get("Link"), CabecalhoProximoCursor,
+			w.Header().Get(CabecalhoProximoCursor), w.Body.String())
+	}
+}
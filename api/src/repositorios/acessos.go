@@ -0,0 +1,39 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+)
+
+// Acessos resolve os papéis e as permissões de usuários de qualquer organização, a cada requisição autenticada
+type Acessos struct {
+	db *sql.DB
+}
+
+// NovoRepositorioDeAcessos cria um repositório de acessos
+func NovoRepositorioDeAcessos(db *sql.DB) *Acessos {
+	return &Acessos{db}
+}
+
+// Acesso traz os papéis e as permissões efetivos do usuário na organização (ver Papeis.Acesso).
+// Conta desativada, excluída ou expurgada volta com Inativa e sem papéis.
+func (repositorio Acessos) Acesso(ctx context.Context, orgID, usuarioID uint64, papeisExternos []string) (modelos.Acesso, error) {
+	var ativa bool
+	erro := repositorio.db.QueryRowContext(ctx,
+		"SELECT "+contaAtiva("usuarios")+" FROM usuarios WHERE id = $1 AND org_id = $2", usuarioID, orgID,
+	).Scan(&ativa)
+	if erro == sql.ErrNoRows || erro == nil && !ativa {
+		return modelos.Acesso{Inativa: true}, nil
+	}
+	if erro != nil {
+		return modelos.Acesso{}, erro
+	}
+
+	papeis, permissoes, erro := NovoRepositorioDePapeis(repositorio.db, orgID).Acesso(ctx, usuarioID, papeisExternos)
+	if erro != nil {
+		return modelos.Acesso{}, erro
+	}
+
+	return modelos.Acesso{Papeis: papeis, Permissoes: permissoes}, nil
+}
@@ -0,0 +1,48 @@
+package repositorios
+
+import (
+	"api/src/testes"
+	"testing"
+)
+
+func TestAcessoDeContaInativa(t *testing.T) {
+	db := testes.BancoDeDados(t)
+
+	orgID := testes.NovaOrganizacao(t, db)
+	usuarios := NovoRepositorioDeUsuarios(db, orgID)
+	acessos := NovoRepositorioDeAcessos(db)
+
+	desativada := criar(t, usuarios, "desativada_acesso", "Desativada")
+	excluida := criar(t, usuarios, "excluida_acesso", "Excluída")
+	ativa := criar(t, usuarios, "ativa_acesso", "Ativa")
+	if erro := usuarios.Desativar(ctx, desativada); erro != nil {
+		t.Fatal(erro)
+	}
+	if erro := usuarios.Deletar(ctx, excluida); erro != nil {
+		t.Fatal(erro)
+	}
+
+	casos := []struct {
+		nome      string
+		orgID     uint64
+		usuarioID uint64
+		inativa   bool
+	}{
+		{"ativa", orgID, ativa, false},
+		{"desativada", orgID, desativada, true},
+		{"excluída", orgID, excluida, true},
+		{"de outra organização", testes.NovaOrganizacao(t, db), ativa, true},
+	}
+	for _, caso := range casos {
+		acesso, erro := acessos.Acesso(ctx, caso.orgID, caso.usuarioID, nil)
+		if erro != nil {
+			t.Fatalf("%s: %v", caso.nome, erro)
+		}
+		if acesso.Inativa != caso.inativa {
+			t.Errorf("%s: Inativa = %v, quer %v", caso.nome, acesso.Inativa, caso.inativa)
+		}
+		if !caso.inativa && !contem(acesso.Papeis, PapelPadrao) {
+			t.Errorf("%s: papéis = %v, quer o papel padrão", caso.nome, acesso.Papeis)
+		}
+	}
+}
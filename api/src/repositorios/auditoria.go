@@ -0,0 +1,71 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// Auditoria representa o registro de eventos de auditoria da organização
+type Auditoria struct {
+	db    *sql.DB
+	orgID uint64
+}
+
+// NovoRepositorioDeAuditoria cria um repositório de eventos de auditoria da organização
+func NovoRepositorioDeAuditoria(db *sql.DB, orgID uint64) *Auditoria {
+	return &Auditoria{db, orgID}
+}
+
+// Registrar grava um evento
+func (repositorio Auditoria) Registrar(ctx context.Context, evento modelos.EventoDeAuditoria) error {
+	detalhes, erro := json.Marshal(evento.Detalhes)
+	if erro != nil {
+		return erro
+	}
+	if evento.Detalhes == nil {
+		detalhes = []byte("{}")
+	}
+
+	_, erro = repositorio.db.ExecContext(ctx,
+		"INSERT INTO auditoria (org_id, ator_id, usuario_id, acao, detalhes) VALUES ($1, $2, $3, $4, $5)",
+		repositorio.orgID, evento.AtorID, evento.UsuarioID, evento.Acao, detalhes,
+	)
+	return erro
+}
+
+// BuscarDoUsuario traz os eventos sobre a conta do usuário, do mais antigo para o mais recente
+func (repositorio Auditoria) BuscarDoUsuario(ctx context.Context, usuarioID uint64) ([]modelos.EventoDeAuditoria, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		SELECT id, ator_id, usuario_id, acao, detalhes, criadoEm
+		FROM auditoria WHERE org_id = $1 AND usuario_id = $2
+		ORDER BY criadoEm, id`, repositorio.orgID, usuarioID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	eventos := []modelos.EventoDeAuditoria{}
+	for linhas.Next() {
+		var (
+			evento   modelos.EventoDeAuditoria
+			atorID   sql.NullInt64
+			detalhes []byte
+		)
+		if erro = linhas.Scan(&evento.ID, &atorID, &evento.UsuarioID, &evento.Acao, &detalhes, &evento.CriadoEm); erro != nil {
+			return nil, erro
+		}
+		if atorID.Valid {
+			ID := uint64(atorID.Int64)
+			evento.AtorID = &ID
+		}
+		if erro = json.Unmarshal(detalhes, &evento.Detalhes); erro != nil {
+			return nil, erro
+		}
+		eventos = append(eventos, evento)
+	}
+
+	return eventos, linhas.Err()
+}
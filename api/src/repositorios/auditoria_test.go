@@ -0,0 +1,43 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/testes"
+	"testing"
+)
+
+func TestAuditoria(t *testing.T) {
+	db := testes.BancoDeDados(t)
+
+	orgID := testes.NovaOrganizacao(t, db)
+	usuarios := NovoRepositorioDeUsuarios(db, orgID)
+	admin := criar(t, usuarios, "admin_auditoria", "Admin")
+	usuario := criar(t, usuarios, "alvo_auditoria", "Alvo")
+
+	auditoria := NovoRepositorioDeAuditoria(db, orgID)
+	eventos := []modelos.EventoDeAuditoria{
+		{AtorID: &admin, UsuarioID: usuario, Acao: modelos.AuditoriaSenhaRedefinida},
+		{AtorID: &admin, UsuarioID: usuario, Acao: modelos.AuditoriaPapelAtribuido, Detalhes: map[string]string{"papel": "admin"}},
+	}
+	for _, evento := range eventos {
+		if erro := auditoria.Registrar(ctx, evento); erro != nil {
+			t.Fatal(erro)
+		}
+	}
+
+	registrados, erro := auditoria.BuscarDoUsuario(ctx, usuario)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if len(registrados) != 2 || registrados[0].Acao != modelos.AuditoriaSenhaRedefinida || registrados[1].Detalhes["papel"] != "admin" {
+		t.Fatalf("eventos = %+v", registrados)
+	}
+	if registrados[0].AtorID == nil || *registrados[0].AtorID != admin {
+		t.Errorf("ator = %v, quer %d", registrados[0].AtorID, admin)
+	}
+
+	// Outra organização não enxerga os eventos
+	if outros, _ := NovoRepositorioDeAuditoria(db, testes.NovaOrganizacao(t, db)).BuscarDoUsuario(ctx, usuario); len(outros) != 0 {
+		t.Errorf("eventos vistos por outra organização: %+v", outros)
+	}
+}
@@ -0,0 +1,113 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Marcadores usados nos fragmentos destacados da busca
+const (
+	inicioDoDestaque = "<mark>"
+	fimDoDestaque    = "</mark>"
+)
+
+// escaparLike faz com que %, _ e \ do termo sejam comparados literalmente no LIKE (ESCAPE '\')
+func escaparLike(termo string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(termo)
+}
+
+// normalizarBusca aplica a mesma normalização da coluna indexada: sem acento e em minúsculas
+func normalizarBusca(coluna string) string {
+	return fmt.Sprintf("lower(sem_acento(%s))", coluna)
+}
+
+// condicaoDeBusca casa o nome ou o nick que contém o termo ou que se parece com ele (similaridade de trigramas).
+// parametroLike é a posição ($n) do termo escapado com escaparLike e parametroTermo, a do termo original.
+func condicaoDeBusca(parametroLike, parametroTermo int) string {
+	padrao := fmt.Sprintf("'%%' || lower(sem_acento($%d)) || '%%'", parametroLike)
+	termo := fmt.Sprintf("lower(sem_acento($%d))", parametroTermo)
+	return fmt.Sprintf(
+		`(%[1]s LIKE %[3]s ESCAPE '\' OR %[2]s LIKE %[3]s ESCAPE '\' OR %[1]s %% %[4]s OR %[2]s %% %[4]s)`,
+		normalizarBusca("u.nome"), normalizarBusca("u.nick"), padrao, termo,
+	)
+}
+
+// relevanciaDaBusca é a maior similaridade entre o termo e o nome ou o nick
+func relevanciaDaBusca(parametro int) string {
+	termo := fmt.Sprintf("lower(sem_acento($%d))", parametro)
+	return fmt.Sprintf("GREATEST(similarity(%[1]s, %[3]s), similarity(%[2]s, %[3]s))",
+		normalizarBusca("u.nome"), normalizarBusca("u.nick"), termo,
+	)
+}
+
+// destaques marca no nome e no nick os trechos que contêm o termo, ignorando acentos e maiúsculas.
+// Retorna nil quando o usuário só casou por similaridade e não há trecho a destacar.
+func destaques(usuario modelos.Usuario, termo string) *modelos.Destaques {
+	nome, destacouNome := destacar(usuario.Nome, termo)
+	nick, destacouNick := destacar(usuario.Nick, termo)
+	if !destacouNome && !destacouNick {
+		return nil
+	}
+
+	return &modelos.Destaques{Nome: nome, Nick: nick}
+}
+
+// destacar escapa o texto para HTML e envolve cada ocorrência do termo com os marcadores
+func destacar(texto, termo string) (string, bool) {
+	termoNormalizado, _ := semAcento(termo)
+	if termoNormalizado == "" {
+		return html.EscapeString(texto), false
+	}
+
+	normalizado, origem := semAcento(texto)
+
+	var resultado strings.Builder
+	destacou := false
+	copiado := 0
+	for inicio := 0; inicio <= len(normalizado)-len(termoNormalizado); {
+		if !strings.HasPrefix(normalizado[inicio:], termoNormalizado) {
+			inicio++
+			continue
+		}
+
+		fim := inicio + len(termoNormalizado)
+		inicioOriginal, fimOriginal := origem[inicio], origem[fim]
+		resultado.WriteString(html.EscapeString(texto[copiado:inicioOriginal]))
+		resultado.WriteString(inicioDoDestaque + html.EscapeString(texto[inicioOriginal:fimOriginal]) + fimDoDestaque)
+		copiado = fimOriginal
+		destacou = true
+		inicio = fim
+	}
+	resultado.WriteString(html.EscapeString(texto[copiado:]))
+
+	return resultado.String(), destacou
+}
+
+// semAcento remove os acentos e passa para minúsculas. origem[i] é a posição, no texto original, do caractere que
+// gerou o byte i do resultado; origem[len(resultado)] é o fim do texto, para que qualquer trecho possa ser mapeado de volta.
+func semAcento(texto string) (string, []int) {
+	var resultado strings.Builder
+	origem := make([]int, 0, len(texto)+1)
+
+	for posicao, caractere := range texto {
+		for _, decomposto := range norm.NFD.String(string(caractere)) {
+			if unicode.Is(unicode.Mn, decomposto) {
+				continue
+			}
+
+			minusculo := string(unicode.ToLower(decomposto))
+			for i := 0; i < len(minusculo); i++ {
+				origem = append(origem, posicao)
+			}
+			resultado.WriteString(minusculo)
+		}
+	}
+	origem = append(origem, len(texto))
+
+	return resultado.String(), origem
+}
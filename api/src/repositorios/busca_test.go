@@ -0,0 +1,65 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"testing"
+)
+
+func TestDestacar(t *testing.T) {
+	casos := []struct {
+		texto, termo string
+		esperado     string
+		destacou     bool
+	}{
+		{"José Antônio", "jose", "<mark>José</mark> Antônio", true},
+		{"José Antônio", "ANTONIO", "José <mark>Antônio</mark>", true},
+		// As ocorrências não se sobrepõem e o resto menor que o termo não é marcado
+		{"ana_banana", "ana", "<mark>ana</mark>_b<mark>ana</mark>na", true},
+		{"Maria", "joão", "Maria", false},
+		{"Maria", "", "Maria", false},
+		{"<b>Ana</b>", "ana", "&lt;b&gt;<mark>Ana</mark>&lt;/b&gt;", true},
+		{"<script>", "script", "&lt;<mark>script</mark>&gt;", true},
+		{"Çeçé", "cece", "<mark>Çeçé</mark>", true},
+	}
+	for _, caso := range casos {
+		resultado, destacou := destacar(caso.texto, caso.termo)
+		if resultado != caso.esperado || destacou != caso.destacou {
+			t.Errorf("destacar(%q, %q) = %q, %v; quer %q, %v", caso.texto, caso.termo, resultado, destacou, caso.esperado, caso.destacou)
+		}
+	}
+}
+
+func TestDestaquesSoComTrechoEncontrado(t *testing.T) {
+	usuario := modelos.Usuario{Nome: "Zezinho", Nick: "ze<3"}
+
+	// Casou só por similaridade: não há o que destacar
+	if resultado := destaques(usuario, "zezinhu"); resultado != nil {
+		t.Errorf("destaques por similaridade = %+v, quer nil", resultado)
+	}
+
+	resultado := destaques(usuario, "ze")
+	if resultado == nil || resultado.Nome != "<mark>Ze</mark>zinho" || resultado.Nick != "<mark>ze</mark>&lt;3" {
+		t.Errorf("destaques = %+v", resultado)
+	}
+}
+
+func TestSemAcentoMapeiaAsPosicoes(t *testing.T) {
+	texto := "Ação"
+	normalizado, origem := semAcento(texto)
+	if normalizado != "acao" {
+		t.Fatalf("semAcento(%q) = %q", texto, normalizado)
+	}
+	if len(origem) != len(normalizado)+1 || origem[len(normalizado)] != len(texto) {
+		t.Fatalf("origem = %v", origem)
+	}
+	// "ç" e "ã" ocupam dois bytes no original
+	if trecho := texto[origem[1]:origem[3]]; trecho != "çã" {
+		t.Errorf("trecho original de %q = %q, quer çã", normalizado[1:3], trecho)
+	}
+}
+
+func TestEscaparLike(t *testing.T) {
+	if escapado := escaparLike(`100%_a\b`); escapado != `100\%\_a\\b` {
+		t.Errorf("escaparLike = %s", escapado)
+	}
+}
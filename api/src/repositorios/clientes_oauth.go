@@ -0,0 +1,59 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ClientesOAuth representa um repositório de clientes OAuth
+type ClientesOAuth struct {
+	db *sql.DB
+}
+
+// NovoRepositorioDeClientesOAuth cria um repositório de clientes OAuth
+func NovoRepositorioDeClientesOAuth(db *sql.DB) *ClientesOAuth {
+	return &ClientesOAuth{db}
+}
+
+// BuscarPorClientID traz um cliente e a sua política de troca de tokens
+func (repositorio ClientesOAuth) BuscarPorClientID(ctx context.Context, clientID string) (modelos.ClienteOAuth, error) {
+	var (
+		cliente       modelos.ClienteOAuth
+		duracaoMaxima int64
+		audiencias    pq.StringArray
+		escopos       pq.StringArray
+	)
+
+	linha := repositorio.db.QueryRowContext(ctx, `
+		SELECT id, client_id, segredo, audiencias, escopos,
+		       permite_delegacao, permite_personificacao, duracao_maxima_segundos
+		FROM clientes_oauth WHERE client_id = $1`, clientID,
+	)
+
+	if erro := linha.Scan(
+		&cliente.ID,
+		&cliente.ClientID,
+		&cliente.Segredo,
+		&audiencias,
+		&escopos,
+		&cliente.PermiteDelegacao,
+		&cliente.PermitePersonificacao,
+		&duracaoMaxima,
+	); erro != nil {
+		if erro == sql.ErrNoRows {
+			return modelos.ClienteOAuth{}, fmt.Errorf("cliente %s não encontrado", clientID)
+		}
+		return modelos.ClienteOAuth{}, erro
+	}
+
+	cliente.Audiencias = audiencias
+	cliente.Escopos = escopos
+	cliente.DuracaoMaxima = time.Duration(duracaoMaxima) * time.Second
+
+	return cliente, nil
+}
@@ -0,0 +1,64 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+)
+
+// Consentimentos representa os consentimentos dados e revogados pelos usuários da organização
+type Consentimentos struct {
+	db    *sql.DB
+	orgID uint64
+}
+
+// NovoRepositorioDeConsentimentos cria um repositório de consentimentos da organização
+func NovoRepositorioDeConsentimentos(db *sql.DB, orgID uint64) *Consentimentos {
+	return &Consentimentos{db, orgID}
+}
+
+// Registrar grava a concessão ou a revogação do consentimento; as anteriores ficam no histórico
+func (repositorio Consentimentos) Registrar(ctx context.Context, usuarioID uint64, finalidade string, concedido bool) error {
+	_, erro := repositorio.db.ExecContext(ctx,
+		"INSERT INTO consentimentos (org_id, usuario_id, finalidade, concedido) VALUES ($1, $2, $3, $4)",
+		repositorio.orgID, usuarioID, finalidade, concedido,
+	)
+	return erro
+}
+
+// BuscarAtuais traz a situação atual de cada finalidade, a mudança mais recente de cada uma
+func (repositorio Consentimentos) BuscarAtuais(ctx context.Context, usuarioID uint64) ([]modelos.Consentimento, error) {
+	return repositorio.buscar(ctx, `
+		SELECT DISTINCT ON (finalidade) finalidade, concedido, criadoEm
+		FROM consentimentos WHERE org_id = $1 AND usuario_id = $2
+		ORDER BY finalidade, criadoEm DESC, id DESC`, usuarioID,
+	)
+}
+
+// BuscarDoUsuario traz todas as concessões e revogações do usuário, da mais antiga para a mais recente
+func (repositorio Consentimentos) BuscarDoUsuario(ctx context.Context, usuarioID uint64) ([]modelos.Consentimento, error) {
+	return repositorio.buscar(ctx, `
+		SELECT finalidade, concedido, criadoEm
+		FROM consentimentos WHERE org_id = $1 AND usuario_id = $2
+		ORDER BY criadoEm, id`, usuarioID,
+	)
+}
+
+func (repositorio Consentimentos) buscar(ctx context.Context, consulta string, usuarioID uint64) ([]modelos.Consentimento, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, repositorio.orgID, usuarioID)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	consentimentos := []modelos.Consentimento{}
+	for linhas.Next() {
+		var consentimento modelos.Consentimento
+		if erro = linhas.Scan(&consentimento.Finalidade, &consentimento.Concedido, &consentimento.CriadoEm); erro != nil {
+			return nil, erro
+		}
+		consentimentos = append(consentimentos, consentimento)
+	}
+
+	return consentimentos, linhas.Err()
+}
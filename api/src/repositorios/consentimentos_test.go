@@ -0,0 +1,75 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/testes"
+	"testing"
+)
+
+func TestConsentimentos(t *testing.T) {
+	db := testes.BancoDeDados(t)
+
+	orgID := testes.NovaOrganizacao(t, db)
+	usuario := criar(t, NovoRepositorioDeUsuarios(db, orgID), "titular_consentimentos", "Titular")
+
+	consentimentos := NovoRepositorioDeConsentimentos(db, orgID)
+	mudancas := []struct {
+		finalidade string
+		concedido  bool
+	}{{"newsletter", true}, {"pesquisa", true}, {"newsletter", false}}
+	for _, mudanca := range mudancas {
+		if erro := consentimentos.Registrar(ctx, usuario, mudanca.finalidade, mudanca.concedido); erro != nil {
+			t.Fatal(erro)
+		}
+	}
+
+	historico, erro := consentimentos.BuscarDoUsuario(ctx, usuario)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if len(historico) != 3 || historico[0].Finalidade != "newsletter" || !historico[0].Concedido || historico[2].Concedido {
+		t.Fatalf("histórico = %+v", historico)
+	}
+
+	// A revogação mais recente vale sobre a concessão anterior
+	atuais, erro := consentimentos.BuscarAtuais(ctx, usuario)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	quer := []modelos.Consentimento{{Finalidade: "newsletter", Concedido: false}, {Finalidade: "pesquisa", Concedido: true}}
+	if len(atuais) != len(quer) {
+		t.Fatalf("atuais = %+v", atuais)
+	}
+	for i := range quer {
+		if atuais[i].Finalidade != quer[i].Finalidade || atuais[i].Concedido != quer[i].Concedido {
+			t.Errorf("atuais[%d] = %+v, quer %+v", i, atuais[i], quer[i])
+		}
+	}
+
+	// Outra organização não enxerga os consentimentos
+	if outros, _ := NovoRepositorioDeConsentimentos(db, testes.NovaOrganizacao(t, db)).BuscarDoUsuario(ctx, usuario); len(outros) != 0 {
+		t.Errorf("consentimentos vistos por outra organização: %+v", outros)
+	}
+}
+
+func TestHistoricoDeLogins(t *testing.T) {
+	db := testes.BancoDeDados(t)
+
+	orgID := testes.NovaOrganizacao(t, db)
+	usuario := criar(t, NovoRepositorioDeUsuarios(db, orgID), "titular_logins", "Titular")
+
+	historico := NovoRepositorioDeHistoricoDeLogins(db, orgID)
+	for _, login := range []modelos.LoginRegistrado{{Origem: "banco", IP: "192.0.2.10"}, {Origem: "saml"}} {
+		if erro := historico.Registrar(ctx, usuario, login); erro != nil {
+			t.Fatal(erro)
+		}
+	}
+
+	logins, erro := historico.BuscarDoUsuario(ctx, usuario)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if len(logins) != 2 || logins[0].Origem != "banco" || logins[0].IP != "192.0.2.10" || logins[1].Origem != "saml" {
+		t.Fatalf("logins = %+v", logins)
+	}
+}
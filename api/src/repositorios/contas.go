@@ -0,0 +1,130 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrContaDesativada é retornado no login de uma conta desativada pelo próprio usuário
+var ErrContaDesativada = errors.New("conta desativada: entre com ?reativar=true para reativá-la")
+
+// chaveExpurgo identifica o advisory lock do expurgo, para que só uma instância da API apague contas por vez
+const chaveExpurgo = 7039
+
+// contaAtiva é a condição SQL que esconde as contas desativadas ou excluídas; tabela é o nome ou o alias de usuarios
+func contaAtiva(tabela string) string {
+	return fmt.Sprintf("%[1]s.desativadoEm IS NULL AND %[1]s.excluidoEm IS NULL", tabela)
+}
+
+// BuscarSituacao traz as datas de desativação e exclusão da conta, inclusive de contas escondidas das buscas
+func (repositorio Usuarios) BuscarSituacao(ctx context.Context, ID uint64) (modelos.SituacaoDaConta, error) {
+	var situacao modelos.SituacaoDaConta
+
+	erro := repositorio.db.QueryRowContext(ctx,
+		"SELECT desativadoEm, excluidoEm FROM usuarios WHERE id = $1 AND org_id = $2", ID, repositorio.orgID,
+	).Scan(&situacao.DesativadoEm, &situacao.ExcluidoEm)
+	if erro == sql.ErrNoRows {
+		return modelos.SituacaoDaConta{}, fmt.Errorf("usuário com ID %d %w", ID, ErrUsuarioNaoEncontrado)
+	}
+
+	return situacao, erro
+}
+
+// Desativar esconde a conta e impede o login até que o próprio usuário a reative
+func (repositorio Usuarios) Desativar(ctx context.Context, ID uint64) error {
+	return repositorio.alterarSituacao(ctx,
+		"UPDATE usuarios SET desativadoEm = now() WHERE id = $1 AND org_id = $2 AND "+contaAtiva("usuarios"), ID,
+	)
+}
+
+// Reativar desfaz a desativação e a exclusão ainda dentro da carência
+func (repositorio Usuarios) Reativar(ctx context.Context, ID uint64) error {
+	return repositorio.alterarSituacao(ctx,
+		"UPDATE usuarios SET desativadoEm = NULL, excluidoEm = NULL WHERE id = $1 AND org_id = $2", ID,
+	)
+}
+
+func (repositorio Usuarios) alterarSituacao(ctx context.Context, comando string, ID uint64) error {
+	resultado, erro := repositorio.db.ExecContext(ctx, comando, ID, repositorio.orgID)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("usuário com ID %d %w", ID, ErrUsuarioNaoEncontrado)
+	}
+
+	return nil
+}
+
+// Contas reúne as operações sobre contas de todas as organizações, usadas pelas tarefas em segundo plano
+type Contas struct {
+	db *sql.DB
+}
+
+// NovoRepositorioDeContas cria um repositório de contas
+func NovoRepositorioDeContas(db *sql.DB) *Contas {
+	return &Contas{db}
+}
+
+// ExpurgarExcluidas apaga de vez as contas excluídas há mais tempo que a carência.
+// Vínculos, bloqueios, pedidos, papéis, identidades externas e participação em organizações
+// saem junto pelo ON DELETE CASCADE. Quando outra instância já está expurgando, não faz nada.
+func (repositorio Contas) ExpurgarExcluidas(ctx context.Context, carencia time.Duration) (int64, error) {
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %v", erro)
+	}
+	defer tx.Rollback()
+
+	var obteve bool
+	if erro = tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", chaveExpurgo).Scan(&obteve); erro != nil {
+		return 0, erro
+	}
+	if !obteve {
+		return 0, nil
+	}
+
+	resultado, erro := tx.ExecContext(ctx,
+		"DELETE FROM usuarios WHERE excluidoEm IS NOT NULL AND excluidoEm < now() - make_interval(secs => $1)",
+		carencia.Seconds(),
+	)
+	if erro != nil {
+		return 0, erro
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação: %v", erro)
+	}
+
+	return resultado.RowsAffected()
+}
+
+// ApagarExportacoesExpiradas remove os arquivos de exportação de dados cujo link já expirou
+func (repositorio Contas) ApagarExportacoesExpiradas(ctx context.Context) (int64, error) {
+	resultado, erro := repositorio.db.ExecContext(ctx, "DELETE FROM exportacoes WHERE expiraEm < now()")
+	if erro != nil {
+		return 0, erro
+	}
+
+	return resultado.RowsAffected()
+}
+
+// InterromperExportacoes marca como falha as exportações pendentes há mais de tempoMaximo, cuja geração
+// não terminou (a instância que gerava caiu, por exemplo). O usuário fica livre para pedir outra.
+func (repositorio Contas) InterromperExportacoes(ctx context.Context, tempoMaximo time.Duration) (int64, error) {
+	resultado, erro := repositorio.db.ExecContext(ctx, `
+		UPDATE exportacoes SET status = $1, erro = $2, concluidoEm = now()
+		WHERE status = $3 AND criadoEm < now() - make_interval(secs => $4)`,
+		modelos.ExportacaoFalhou, MotivoInterrompida, modelos.ExportacaoPendente, tempoMaximo.Seconds(),
+	)
+	if erro != nil {
+		return 0, erro
+	}
+
+	return resultado.RowsAffected()
+}
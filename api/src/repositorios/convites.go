@@ -0,0 +1,192 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrConviteNaoEncontrado é retornado quando o convite não existe na organização
+	ErrConviteNaoEncontrado = errors.New("convite não encontrado")
+	// ErrConviteIndisponivel é retornado quando o convite não pode mais ser aceito
+	ErrConviteIndisponivel = errors.New("o convite expirou, foi revogado ou já foi aceito")
+)
+
+// Convites representa um repositório de convites de uma organização
+type Convites struct {
+	db    *sql.DB
+	orgID uint64
+}
+
+// NovoRepositorioDeConvites cria um repositório de convites da organização
+func NovoRepositorioDeConvites(db *sql.DB, orgID uint64) *Convites {
+	return &Convites{db, orgID}
+}
+
+const colunasConvite = "id, org_id, email, papel, convidado_por, expira_em, aceito_em, revogado_em, criadoEm"
+
+// Criar salva o convite com o hash do token enviado por e-mail. Convites pendentes anteriores
+// para o mesmo e-mail são revogados, para que só o último link funcione.
+func (repositorio Convites) Criar(ctx context.Context, convite modelos.Convite, hashToken string) (uint64, error) {
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %v", erro)
+	}
+	defer tx.Rollback()
+
+	if _, erro = tx.ExecContext(ctx, `
+		UPDATE convites SET revogado_em = now()
+		WHERE org_id = $1 AND email = $2 AND aceito_em IS NULL AND revogado_em IS NULL`,
+		repositorio.orgID, convite.Email,
+	); erro != nil {
+		return 0, erro
+	}
+
+	var ID uint64
+	if erro = tx.QueryRowContext(ctx, `
+		INSERT INTO convites (org_id, email, papel, convidado_por, token_hash, expira_em)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		repositorio.orgID, convite.Email, convite.Papel, convite.ConvidadoPor, hashToken, convite.ExpiraEm,
+	).Scan(&ID); erro != nil {
+		return 0, erro
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação: %v", erro)
+	}
+
+	return ID, nil
+}
+
+// ListarPendentes traz os convites que ainda podem ser aceitos
+func (repositorio Convites) ListarPendentes(ctx context.Context) ([]modelos.Convite, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx,
+		"SELECT "+colunasConvite+` FROM convites
+		 WHERE org_id = $1 AND aceito_em IS NULL AND revogado_em IS NULL AND expira_em > now()
+		 ORDER BY criadoEm DESC`,
+		repositorio.orgID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var convites []modelos.Convite
+	for linhas.Next() {
+		convite, erro := escanearConvite(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+		convites = append(convites, convite)
+	}
+
+	return convites, nil
+}
+
+// BuscarPorToken traz o convite da organização pelo hash do token
+func (repositorio Convites) BuscarPorToken(ctx context.Context, hashToken string) (modelos.Convite, error) {
+	linha := repositorio.db.QueryRowContext(ctx,
+		"SELECT "+colunasConvite+" FROM convites WHERE org_id = $1 AND token_hash = $2",
+		repositorio.orgID, hashToken,
+	)
+
+	convite, erro := escanearConvite(linha)
+	if erro == sql.ErrNoRows {
+		return modelos.Convite{}, ErrConviteNaoEncontrado
+	}
+	return convite, erro
+}
+
+// Revogar invalida um convite pendente
+func (repositorio Convites) Revogar(ctx context.Context, ID uint64) error {
+	resultado, erro := repositorio.db.ExecContext(ctx, `
+		UPDATE convites SET revogado_em = now()
+		WHERE id = $1 AND org_id = $2 AND aceito_em IS NULL AND revogado_em IS NULL`,
+		ID, repositorio.orgID,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return ErrConviteNaoEncontrado
+	}
+
+	return nil
+}
+
+// Aceitar registra o aceite e coloca o convidado na equipe com o papel do convite. Quando novo não é nil, a conta
+// do convidado é criada na mesma transação e o ID dela é retornado no lugar de usuarioID: uma falha no meio não deixa
+// conta órfã nem convite consumido. A condição no UPDATE garante que o convite seja usado uma única vez.
+func (repositorio Convites) Aceitar(ctx context.Context, convite modelos.Convite, usuarioID uint64, novo *modelos.Usuario) (uint64, error) {
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %v", erro)
+	}
+	defer tx.Rollback()
+
+	resultado, erro := tx.ExecContext(ctx, `
+		UPDATE convites SET aceito_em = now()
+		WHERE id = $1 AND org_id = $2 AND aceito_em IS NULL AND revogado_em IS NULL AND expira_em > now()`,
+		convite.ID, repositorio.orgID,
+	)
+	if erro != nil {
+		return 0, erro
+	}
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return 0, ErrConviteIndisponivel
+	}
+
+	if novo != nil {
+		if usuarioID, erro = inserirUsuario(ctx, tx, repositorio.orgID, *novo); erro != nil {
+			return 0, erro
+		}
+	}
+
+	if erro = adicionarMembro(ctx, tx, repositorio.orgID, usuarioID, convite.Papel); erro != nil {
+		return 0, erro
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação: %v", erro)
+	}
+
+	return usuarioID, nil
+}
+
+type linhaEscaneavel interface {
+	Scan(destino ...interface{}) error
+}
+
+func escanearConvite(linha linhaEscaneavel) (modelos.Convite, error) {
+	var (
+		convite              modelos.Convite
+		aceitoEm, revogadoEm sql.NullTime
+	)
+
+	if erro := linha.Scan(
+		&convite.ID,
+		&convite.OrgID,
+		&convite.Email,
+		&convite.Papel,
+		&convite.ConvidadoPor,
+		&convite.ExpiraEm,
+		&aceitoEm,
+		&revogadoEm,
+		&convite.CriadoEm,
+	); erro != nil {
+		return modelos.Convite{}, erro
+	}
+
+	if aceitoEm.Valid {
+		convite.AceitoEm = &aceitoEm.Time
+	}
+	if revogadoEm.Valid {
+		convite.RevogadoEm = &revogadoEm.Time
+	}
+
+	return convite, nil
+}
@@ -0,0 +1,81 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/testes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAceitarConviteNumaTransacao(t *testing.T) {
+	db := testes.BancoDeDados(t)
+
+	orgID := testes.NovaOrganizacao(t, db)
+	convites := NovoRepositorioDeConvites(db, orgID)
+	usuarios := NovoRepositorioDeUsuarios(db, orgID)
+	membros := NovoRepositorioDeMembros(db, orgID)
+	anfitriao := criar(t, usuarios, "anfitriao", "Anfitrião")
+
+	convidar := func(email string) modelos.Convite {
+		t.Helper()
+		convite := modelos.Convite{Email: email, Papel: modelos.PapelAdmin, ConvidadoPor: anfitriao, ExpiraEm: time.Now().Add(time.Hour)}
+		ID, erro := convites.Criar(ctx, convite, "hash-"+email)
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		convite.ID = ID
+		return convite
+	}
+
+	t.Run("conta nova", func(t *testing.T) {
+		convite := convidar("nova@exemplo.com")
+		novo := &modelos.Usuario{Nome: "Nova", Nick: "nova", Email: convite.Email, Senha: "hash"}
+
+		usuarioID, erro := convites.Aceitar(ctx, convite, 0, novo)
+		if erro != nil {
+			t.Fatalf("Aceitar: %v", erro)
+		}
+		if papel, erro := membros.BuscarPapel(ctx, usuarioID); erro != nil || papel != modelos.PapelAdmin {
+			t.Errorf("papel = %q, %v; quer admin", papel, erro)
+		}
+
+		if _, erro = convites.Aceitar(ctx, convite, usuarioID, nil); !errors.Is(erro, ErrConviteIndisponivel) {
+			t.Errorf("segundo aceite: erro = %v, quer ErrConviteIndisponivel", erro)
+		}
+	})
+
+	t.Run("falha ao criar a conta", func(t *testing.T) {
+		convite := convidar("falha@exemplo.com")
+		novo := &modelos.Usuario{Nome: "Falha", Nick: strings.Repeat("f", 60), Email: convite.Email, Senha: "hash"}
+
+		if _, erro := convites.Aceitar(ctx, convite, 0, novo); erro == nil {
+			t.Fatal("um nick maior que a coluna deveria falhar")
+		}
+
+		// O convite continua pendente e nenhuma conta ficou para trás
+		pendente, erro := convites.BuscarPorToken(ctx, "hash-falha@exemplo.com")
+		if erro != nil || !pendente.Pendente() {
+			t.Errorf("o convite foi consumido: %+v, %v", pendente, erro)
+		}
+		if _, erro = usuarios.BuscarPorEmail(ctx, convite.Email); !errors.Is(erro, ErrUsuarioNaoEncontrado) {
+			t.Errorf("conta órfã: %v", erro)
+		}
+	})
+
+	t.Run("já é membro", func(t *testing.T) {
+		convite := convidar("membro@exemplo.com")
+		membro := criar(t, usuarios, "membro", "Membro")
+		if erro := membros.Adicionar(ctx, membro, modelos.PapelMembro); erro != nil {
+			t.Fatal(erro)
+		}
+
+		if _, erro := convites.Aceitar(ctx, convite, membro, nil); !errors.Is(erro, ErrMembroExistente) {
+			t.Fatalf("erro = %v, quer ErrMembroExistente", erro)
+		}
+		if pendente, _ := convites.BuscarPorToken(ctx, "hash-membro@exemplo.com"); !pendente.Pendente() {
+			t.Error("o convite foi consumido sem o convidado entrar na equipe")
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrExportacaoNaoEncontrada é retornado (envolvido) quando a exportação não existe, não está pronta ou expirou
+var ErrExportacaoNaoEncontrada = errors.New("exportação não encontrada")
+
+// Exportacoes representa um repositório de exportações de dados pessoais da organização
+type Exportacoes struct {
+	db    *sql.DB
+	orgID uint64
+}
+
+// NovoRepositorioDeExportacoes cria um repositório de exportações da organização
+func NovoRepositorioDeExportacoes(db *sql.DB, orgID uint64) *Exportacoes {
+	return &Exportacoes{db, orgID}
+}
+
+// MotivoInterrompida é o erro gravado na exportação que ficou pendente além do tempo máximo de geração
+const MotivoInterrompida = "a geração foi interrompida; peça uma nova exportação"
+
+// Criar registra um pedido pendente. O índice único parcial impede dois pedidos pendentes do mesmo usuário;
+// um pedido pendente há mais de tempoMaximo foi interrompido e é marcado como falha antes, para não travar o usuário.
+func (repositorio Exportacoes) Criar(ctx context.Context, usuarioID uint64, tempoMaximo time.Duration) (modelos.Exportacao, error) {
+	exportacao := modelos.Exportacao{UsuarioID: usuarioID, Status: modelos.ExportacaoPendente}
+
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return modelos.Exportacao{}, fmt.Errorf("erro ao iniciar transação: %v", erro)
+	}
+	defer tx.Rollback()
+
+	if _, erro = tx.ExecContext(ctx, `
+		UPDATE exportacoes SET status = $1, erro = $2, concluidoEm = now()
+		WHERE org_id = $3 AND usuario_id = $4 AND status = $5 AND criadoEm < now() - make_interval(secs => $6)`,
+		modelos.ExportacaoFalhou, MotivoInterrompida, repositorio.orgID, usuarioID, modelos.ExportacaoPendente, tempoMaximo.Seconds(),
+	); erro != nil {
+		return modelos.Exportacao{}, erro
+	}
+
+	erro = tx.QueryRowContext(ctx,
+		"INSERT INTO exportacoes (org_id, usuario_id, status) VALUES ($1, $2, $3) RETURNING id, criadoEm",
+		repositorio.orgID, usuarioID, exportacao.Status,
+	).Scan(&exportacao.ID, &exportacao.CriadoEm)
+	if erro != nil {
+		return modelos.Exportacao{}, erro
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return modelos.Exportacao{}, fmt.Errorf("erro ao confirmar transação: %v", erro)
+	}
+
+	return exportacao, nil
+}
+
+// BuscarPorID traz a exportação, sem o arquivo
+func (repositorio Exportacoes) BuscarPorID(ctx context.Context, ID uint64) (modelos.Exportacao, error) {
+	var (
+		exportacao modelos.Exportacao
+		falha      sql.NullString
+	)
+
+	erro := repositorio.db.QueryRowContext(ctx, `
+		SELECT id, usuario_id, status, erro, criadoEm, concluidoEm, expiraEm
+		FROM exportacoes WHERE id = $1 AND org_id = $2`, ID, repositorio.orgID,
+	).Scan(
+		&exportacao.ID,
+		&exportacao.UsuarioID,
+		&exportacao.Status,
+		&falha,
+		&exportacao.CriadoEm,
+		&exportacao.ConcluidoEm,
+		&exportacao.ExpiraEm,
+	)
+	if erro == sql.ErrNoRows {
+		return modelos.Exportacao{}, fmt.Errorf("exportação %d: %w", ID, ErrExportacaoNaoEncontrada)
+	}
+	if erro != nil {
+		return modelos.Exportacao{}, erro
+	}
+
+	exportacao.Erro = falha.String
+	return exportacao, nil
+}
+
+// Concluir guarda o arquivo gerado até expiraEm
+func (repositorio Exportacoes) Concluir(ctx context.Context, ID uint64, arquivo []byte, expiraEm time.Time) error {
+	_, erro := repositorio.db.ExecContext(ctx, `
+		UPDATE exportacoes SET status = $1, arquivo = $2, concluidoEm = now(), expiraEm = $3
+		WHERE id = $4 AND org_id = $5`,
+		modelos.ExportacaoConcluida, arquivo, expiraEm, ID, repositorio.orgID,
+	)
+	return erro
+}
+
+// Falhar marca a exportação como falha, liberando o usuário para pedir outra
+func (repositorio Exportacoes) Falhar(ctx context.Context, ID uint64, motivo string) error {
+	_, erro := repositorio.db.ExecContext(ctx,
+		"UPDATE exportacoes SET status = $1, erro = $2, concluidoEm = now() WHERE id = $3 AND org_id = $4",
+		modelos.ExportacaoFalhou, motivo, ID, repositorio.orgID,
+	)
+	return erro
+}
+
+// BuscarArquivo traz o ZIP de uma exportação concluída e ainda não expirada
+func (repositorio Exportacoes) BuscarArquivo(ctx context.Context, ID uint64) ([]byte, error) {
+	var arquivo []byte
+
+	erro := repositorio.db.QueryRowContext(ctx, `
+		SELECT arquivo FROM exportacoes
+		WHERE id = $1 AND org_id = $2 AND status = $3 AND expiraEm > now()`,
+		ID, repositorio.orgID, modelos.ExportacaoConcluida,
+	).Scan(&arquivo)
+	if erro == sql.ErrNoRows {
+		return nil, fmt.Errorf("exportação %d: %w", ID, ErrExportacaoNaoEncontrada)
+	}
+
+	return arquivo, erro
+}
+
+// BuscarVinculos traz as listas do grafo social do titular, indexadas pelo nome do arquivo exportado
+func (repositorio Exportacoes) BuscarVinculos(ctx context.Context, usuarioID uint64) (map[string][]modelos.VinculoExportado, error) {
+	consultas := map[string]string{
+		// Em "seguindo", usuario_id é quem é seguido e seguindo_id é o seguidor
+		"seguidores":             "SELECT u.id, u.nick, v.criadoEm FROM seguindo v INNER JOIN usuarios u ON u.id = v.seguindo_id WHERE v.usuario_id = $1",
+		"seguindo":               "SELECT u.id, u.nick, v.criadoEm FROM seguindo v INNER JOIN usuarios u ON u.id = v.usuario_id WHERE v.seguindo_id = $1",
+		"bloqueados":             "SELECT u.id, u.nick, v.criadoEm FROM bloqueios v INNER JOIN usuarios u ON u.id = v.bloqueado_id WHERE v.usuario_id = $1",
+		"silenciados":            "SELECT u.id, u.nick, v.criadoEm FROM silenciados v INNER JOIN usuarios u ON u.id = v.silenciado_id WHERE v.usuario_id = $1",
+		"solicitacoes_recebidas": "SELECT u.id, u.nick, v.criadoEm FROM solicitacoes_seguir v INNER JOIN usuarios u ON u.id = v.seguidor_id WHERE v.usuario_id = $1",
+		"solicitacoes_enviadas":  "SELECT u.id, u.nick, v.criadoEm FROM solicitacoes_seguir v INNER JOIN usuarios u ON u.id = v.usuario_id WHERE v.seguidor_id = $1",
+	}
+
+	vinculos := make(map[string][]modelos.VinculoExportado, len(consultas))
+	for nome, consulta := range consultas {
+		linhas, erro := repositorio.db.QueryContext(ctx, consulta+" ORDER BY v.criadoEm", usuarioID)
+		if erro != nil {
+			return nil, fmt.Errorf("erro ao exportar %s: %v", nome, erro)
+		}
+
+		lista := []modelos.VinculoExportado{}
+		for linhas.Next() {
+			var vinculo modelos.VinculoExportado
+			if erro = linhas.Scan(&vinculo.ID, &vinculo.Nick, &vinculo.Desde); erro != nil {
+				linhas.Close()
+				return nil, erro
+			}
+			lista = append(lista, vinculo)
+		}
+		erro = linhas.Err()
+		linhas.Close()
+		if erro != nil {
+			return nil, erro
+		}
+
+		vinculos[nome] = lista
+	}
+
+	return vinculos, nil
+}
+
+// BuscarIdentidades traz os vínculos do titular com provedores de login externo
+func (repositorio Exportacoes) BuscarIdentidades(ctx context.Context, usuarioID uint64) ([]modelos.IdentidadeExportada, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx,
+		"SELECT provedor, sujeito, COALESCE(email, ''), criadoEm FROM identidades_externas WHERE usuario_id = $1 ORDER BY criadoEm",
+		usuarioID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	identidades := []modelos.IdentidadeExportada{}
+	for linhas.Next() {
+		var identidade modelos.IdentidadeExportada
+		if erro = linhas.Scan(&identidade.Provedor, &identidade.Sujeito, &identidade.Email, &identidade.CriadoEm); erro != nil {
+			return nil, erro
+		}
+		identidades = append(identidades, identidade)
+	}
+
+	return identidades, linhas.Err()
+}
@@ -0,0 +1,51 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/testes"
+	"testing"
+	"time"
+)
+
+func TestExportacaoPendenteInterrompida(t *testing.T) {
+	db := testes.BancoDeDados(t)
+
+	orgID := testes.NovaOrganizacao(t, db)
+	exportacoes := NovoRepositorioDeExportacoes(db, orgID)
+	usuarioID := criar(t, NovoRepositorioDeUsuarios(db, orgID), "exportador", "Exportador")
+
+	primeira, erro := exportacoes.Criar(ctx, usuarioID, time.Hour)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	// A geração ainda pode estar em andamento: o segundo pedido é recusado pelo índice único
+	if _, erro = exportacoes.Criar(ctx, usuarioID, time.Hour); erro == nil {
+		t.Fatal("dois pedidos pendentes do mesmo usuário")
+	}
+
+	// A instância que gerava caiu há mais tempo que o máximo: o novo pedido encerra o antigo
+	if _, erro = db.ExecContext(ctx, "UPDATE exportacoes SET criadoEm = now() - interval '2 hours' WHERE id = $1", primeira.ID); erro != nil {
+		t.Fatal(erro)
+	}
+	segunda, erro := exportacoes.Criar(ctx, usuarioID, time.Hour)
+	if erro != nil {
+		t.Fatalf("pedido depois da interrupção: %v", erro)
+	}
+
+	antiga, erro := exportacoes.BuscarPorID(ctx, primeira.ID)
+	if erro != nil || antiga.Status != modelos.ExportacaoFalhou || antiga.Erro != MotivoInterrompida {
+		t.Errorf("exportação interrompida = %+v, %v; quer falhou", antiga, erro)
+	}
+
+	// O expurgo faz o mesmo em todas as organizações, sem esperar um novo pedido
+	if _, erro = db.ExecContext(ctx, "UPDATE exportacoes SET criadoEm = now() - interval '2 hours' WHERE id = $1", segunda.ID); erro != nil {
+		t.Fatal(erro)
+	}
+	if _, erro = NovoRepositorioDeContas(db).InterromperExportacoes(ctx, time.Hour); erro != nil {
+		t.Fatal(erro)
+	}
+	if atual, erro := exportacoes.BuscarPorID(ctx, segunda.ID); erro != nil || atual.Status != modelos.ExportacaoFalhou {
+		t.Errorf("depois do expurgo = %+v, %v; quer falhou", atual, erro)
+	}
+}
@@ -0,0 +1,51 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+)
+
+// HistoricoDeLogins representa o registro dos logins aceitos na organização
+type HistoricoDeLogins struct {
+	db    *sql.DB
+	orgID uint64
+}
+
+// NovoRepositorioDeHistoricoDeLogins cria um repositório do histórico de logins da organização
+func NovoRepositorioDeHistoricoDeLogins(db *sql.DB, orgID uint64) *HistoricoDeLogins {
+	return &HistoricoDeLogins{db, orgID}
+}
+
+// Registrar grava um login aceito do usuário
+func (repositorio HistoricoDeLogins) Registrar(ctx context.Context, usuarioID uint64, login modelos.LoginRegistrado) error {
+	_, erro := repositorio.db.ExecContext(ctx,
+		"INSERT INTO historico_de_logins (org_id, usuario_id, origem, ip) VALUES ($1, $2, $3, $4)",
+		repositorio.orgID, usuarioID, login.Origem, login.IP,
+	)
+	return erro
+}
+
+// BuscarDoUsuario traz os logins do usuário, do mais antigo para o mais recente
+func (repositorio HistoricoDeLogins) BuscarDoUsuario(ctx context.Context, usuarioID uint64) ([]modelos.LoginRegistrado, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		SELECT origem, ip, criadoEm
+		FROM historico_de_logins WHERE org_id = $1 AND usuario_id = $2
+		ORDER BY criadoEm, id`, repositorio.orgID, usuarioID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	logins := []modelos.LoginRegistrado{}
+	for linhas.Next() {
+		var login modelos.LoginRegistrado
+		if erro = linhas.Scan(&login.Origem, &login.IP, &login.CriadoEm); erro != nil {
+			return nil, erro
+		}
+		logins = append(logins, login)
+	}
+
+	return logins, linhas.Err()
+}
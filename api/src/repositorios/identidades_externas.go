@@ -0,0 +1,45 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+)
+
+// IdentidadesExternas representa um repositório de vínculos com provedores de login externo
+type IdentidadesExternas struct {
+	db *sql.DB
+}
+
+// NovoRepositorioDeIdentidadesExternas cria um repositório de identidades externas
+func NovoRepositorioDeIdentidadesExternas(db *sql.DB) *IdentidadesExternas {
+	return &IdentidadesExternas{db}
+}
+
+// BuscarUsuarioID retorna o usuário da organização vinculado ao sujeito do provedor ou 0 se não houver vínculo
+func (repositorio IdentidadesExternas) BuscarUsuarioID(ctx context.Context, orgID uint64, provedor, sujeito string) (uint64, error) {
+	var usuarioID uint64
+	erro := repositorio.db.QueryRowContext(ctx,
+		`SELECT i.usuario_id FROM identidades_externas i
+		 INNER JOIN usuarios u ON u.id = i.usuario_id
+		 WHERE i.provedor = $1 AND i.sujeito = $2 AND u.org_id = $3`,
+		provedor, sujeito, orgID,
+	).Scan(&usuarioID)
+	if erro == sql.ErrNoRows {
+		return 0, nil
+	}
+	if erro != nil {
+		return 0, erro
+	}
+
+	return usuarioID, nil
+}
+
+// Vincular associa o sujeito do provedor a um usuário
+func (repositorio IdentidadesExternas) Vincular(ctx context.Context, usuarioID uint64, provedor, sujeito, email string) error {
+	_, erro := repositorio.db.ExecContext(ctx,
+		`INSERT INTO identidades_externas (usuario_id, provedor, sujeito, email) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (provedor, sujeito, usuario_id) DO NOTHING`,
+		usuarioID, provedor, sujeito, email,
+	)
+	return erro
+}
@@ -0,0 +1,220 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMembroNaoEncontrado é retornado (envolvido) quando o usuário não faz parte da equipe da organização
+	ErrMembroNaoEncontrado = errors.New("não é membro da organização")
+	// ErrMembroExistente é retornado (envolvido) quando o usuário já está na equipe ou é de outra organização
+	ErrMembroExistente = errors.New("já é membro ou não pertence à organização")
+)
+
+// executor é o que as operações compartilhadas entre repositórios usam: o banco ou uma transação aberta
+type executor interface {
+	ExecContext(ctx context.Context, query string, argumentos ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, argumentos ...interface{}) *sql.Row
+}
+
+// Membros representa um repositório dos membros de uma organização
+type Membros struct {
+	db    *sql.DB
+	orgID uint64
+}
+
+// NovoRepositorioDeMembros cria um repositório de membros da organização
+func NovoRepositorioDeMembros(db *sql.DB, orgID uint64) *Membros {
+	return &Membros{db, orgID}
+}
+
+// Listar traz os membros da organização, começando pelo dono
+func (repositorio Membros) Listar(ctx context.Context) ([]modelos.Membro, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		SELECT u.id, u.nome, u.nick, u.email, m.papel, m.criadoEm
+		FROM membros m
+		INNER JOIN usuarios u ON u.id = m.usuario_id
+		WHERE m.org_id = $1
+		ORDER BY CASE m.papel WHEN 'dono' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, u.nome`,
+		repositorio.orgID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var membros []modelos.Membro
+	for linhas.Next() {
+		var membro modelos.Membro
+		if erro = linhas.Scan(
+			&membro.UsuarioID,
+			&membro.Nome,
+			&membro.Nick,
+			&membro.Email,
+			&membro.Papel,
+			&membro.CriadoEm,
+		); erro != nil {
+			return nil, erro
+		}
+
+		membros = append(membros, membro)
+	}
+
+	return membros, nil
+}
+
+// BuscarPapel retorna o papel do usuário na organização
+func (repositorio Membros) BuscarPapel(ctx context.Context, usuarioID uint64) (string, error) {
+	var papel string
+	erro := repositorio.db.QueryRowContext(ctx,
+		"SELECT papel FROM membros WHERE org_id = $1 AND usuario_id = $2",
+		repositorio.orgID, usuarioID,
+	).Scan(&papel)
+	if erro == sql.ErrNoRows {
+		return "", fmt.Errorf("usuário %d %w", usuarioID, ErrMembroNaoEncontrado)
+	}
+	if erro != nil {
+		return "", erro
+	}
+
+	return papel, nil
+}
+
+// Adicionar coloca um usuário da organização na equipe com o papel informado
+func (repositorio Membros) Adicionar(ctx context.Context, usuarioID uint64, papel string) error {
+	return adicionarMembro(ctx, repositorio.db, repositorio.orgID, usuarioID, papel)
+}
+
+// adicionarMembro é o INSERT de Adicionar, também usado dentro das transações de criação da organização e de aceite
+func adicionarMembro(ctx context.Context, executor executor, orgID, usuarioID uint64, papel string) error {
+	resultado, erro := executor.ExecContext(ctx, `
+		INSERT INTO membros (org_id, usuario_id, papel)
+		SELECT org_id, id, $3 FROM usuarios WHERE id = $2 AND org_id = $1
+		ON CONFLICT (usuario_id) DO NOTHING`,
+		orgID, usuarioID, papel,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("o usuário %d %w", usuarioID, ErrMembroExistente)
+	}
+
+	return nil
+}
+
+// AlterarPapel troca o papel de um membro; o dono não é alterado por aqui
+func (repositorio Membros) AlterarPapel(ctx context.Context, usuarioID uint64, papel string) error {
+	resultado, erro := repositorio.db.ExecContext(ctx,
+		"UPDATE membros SET papel = $3 WHERE org_id = $1 AND usuario_id = $2 AND papel <> 'dono'",
+		repositorio.orgID, usuarioID, papel,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("usuário %d %w ou é o dono", usuarioID, ErrMembroNaoEncontrado)
+	}
+
+	return nil
+}
+
+// Remover tira um membro da equipe; o dono precisa transferir a propriedade antes de sair
+func (repositorio Membros) Remover(ctx context.Context, usuarioID uint64) error {
+	resultado, erro := repositorio.db.ExecContext(ctx,
+		"DELETE FROM membros WHERE org_id = $1 AND usuario_id = $2 AND papel <> 'dono'",
+		repositorio.orgID, usuarioID,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("usuário %d %w ou é o dono", usuarioID, ErrMembroNaoEncontrado)
+	}
+
+	return nil
+}
+
+// TransferirPropriedade torna outro membro o dono; o dono atual passa a ser admin
+func (repositorio Membros) TransferirPropriedade(ctx context.Context, donoAtualID, novoDonoID uint64) error {
+	if donoAtualID == novoDonoID {
+		return errors.New("o usuário já é o dono da organização")
+	}
+
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", erro)
+	}
+	defer tx.Rollback()
+
+	resultado, erro := tx.ExecContext(ctx,
+		"UPDATE membros SET papel = 'admin' WHERE org_id = $1 AND usuario_id = $2 AND papel = 'dono'",
+		repositorio.orgID, donoAtualID,
+	)
+	if erro != nil {
+		return erro
+	}
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return errors.New("apenas o dono pode transferir a propriedade da organização")
+	}
+
+	resultado, erro = tx.ExecContext(ctx,
+		"UPDATE membros SET papel = 'dono' WHERE org_id = $1 AND usuario_id = $2",
+		repositorio.orgID, novoDonoID,
+	)
+	if erro != nil {
+		return erro
+	}
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("usuário %d %w", novoDonoID, ErrMembroNaoEncontrado)
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return fmt.Errorf("erro ao confirmar transação: %v", erro)
+	}
+
+	return nil
+}
+
+// DefinirDono torna o usuário da organização o dono dela, entrando na equipe se ainda não fizer parte; o dono
+// anterior, se houver, passa a ser admin. É o caminho do operador para organizações sem dono ou com o dono perdido.
+func (repositorio Membros) DefinirDono(ctx context.Context, usuarioID uint64) error {
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", erro)
+	}
+	defer tx.Rollback()
+
+	if _, erro = tx.ExecContext(ctx,
+		"UPDATE membros SET papel = 'admin' WHERE org_id = $1 AND papel = 'dono' AND usuario_id <> $2",
+		repositorio.orgID, usuarioID,
+	); erro != nil {
+		return erro
+	}
+
+	resultado, erro := tx.ExecContext(ctx, `
+		INSERT INTO membros (org_id, usuario_id, papel)
+		SELECT org_id, id, 'dono' FROM usuarios WHERE id = $2 AND org_id = $1
+		ON CONFLICT (usuario_id) DO UPDATE SET papel = 'dono'`,
+		repositorio.orgID, usuarioID,
+	)
+	if erro != nil {
+		return erro
+	}
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("usuário com ID %d %w", usuarioID, ErrUsuarioNaoEncontrado)
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return fmt.Errorf("erro ao confirmar transação: %v", erro)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOrganizacaoNaoEncontrada é retornado quando o tenant informado não existe
+var ErrOrganizacaoNaoEncontrada = errors.New("organização não encontrada")
+
+// Organizacoes representa um repositório de organizações (tenants)
+type Organizacoes struct {
+	db *sql.DB
+}
+
+// NovoRepositorioDeOrganizacoes cria um repositório de organizações
+func NovoRepositorioDeOrganizacoes(db *sql.DB) *Organizacoes {
+	return &Organizacoes{db}
+}
+
+const colunasOrganizacao = `id, slug, nome, COALESCE(dominio, ''),
+	senha_tamanho_minimo, senha_exige_maiuscula, senha_exige_numero, senha_exige_simbolo,
+	duracao_token_segundos, duracao_token_anonimo_segundos, COALESCE(chave_assinatura, '')`
+
+// BuscarPorID traz uma organização pelo ID
+func (repositorio Organizacoes) BuscarPorID(ctx context.Context, ID uint64) (modelos.Organizacao, error) {
+	return repositorio.buscar(ctx, "id = $1", ID)
+}
+
+// BuscarPorSlug traz uma organização pelo identificador usado em cabeçalhos e URLs
+func (repositorio Organizacoes) BuscarPorSlug(ctx context.Context, slug string) (modelos.Organizacao, error) {
+	return repositorio.buscar(ctx, "slug = $1", slug)
+}
+
+// BuscarPorDominio traz a organização associada ao host da requisição
+func (repositorio Organizacoes) BuscarPorDominio(ctx context.Context, dominio string) (modelos.Organizacao, error) {
+	return repositorio.buscar(ctx, "dominio = $1", dominio)
+}
+
+// Criar salva a organização com a conta de quem a criou, que entra na equipe como dono. Tudo acontece numa
+// transação, para que nenhuma organização fique sem dono. Retorna os IDs da organização e do dono.
+func (repositorio Organizacoes) Criar(ctx context.Context, organizacao modelos.Organizacao, dono modelos.Usuario) (uint64, uint64, error) {
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return 0, 0, fmt.Errorf("erro ao iniciar transação: %v", erro)
+	}
+	defer tx.Rollback()
+
+	var orgID uint64
+	if erro = tx.QueryRowContext(ctx,
+		"INSERT INTO organizacoes (slug, nome) VALUES ($1, $2) RETURNING id",
+		organizacao.Slug, organizacao.Nome,
+	).Scan(&orgID); erro != nil {
+		return 0, 0, erro
+	}
+
+	donoID, erro := inserirUsuario(ctx, tx, orgID, dono)
+	if erro != nil {
+		return 0, 0, erro
+	}
+
+	if erro = adicionarMembro(ctx, tx, orgID, donoID, modelos.PapelDono); erro != nil {
+		return 0, 0, erro
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return 0, 0, fmt.Errorf("erro ao confirmar transação: %v", erro)
+	}
+
+	return orgID, donoID, nil
+}
+
+// AtualizarConfiguracoes grava a política de senha, as durações dos tokens e a chave de assinatura da organização.
+// Duração zero e chave vazia ficam como os padrões da configuração.
+func (repositorio Organizacoes) AtualizarConfiguracoes(ctx context.Context, organizacao modelos.Organizacao) error {
+	resultado, erro := repositorio.db.ExecContext(ctx,
+		`UPDATE organizacoes SET senha_tamanho_minimo = $1, senha_exige_maiuscula = $2, senha_exige_numero = $3,
+			senha_exige_simbolo = $4, duracao_token_segundos = $5, duracao_token_anonimo_segundos = $6,
+			chave_assinatura = NULLIF($7, '')
+		WHERE id = $8`,
+		organizacao.PoliticaDeSenha.TamanhoMinimo,
+		organizacao.PoliticaDeSenha.ExigeMaiuscula,
+		organizacao.PoliticaDeSenha.ExigeNumero,
+		organizacao.PoliticaDeSenha.ExigeSimbolo,
+		int64(organizacao.DuracaoToken/time.Second),
+		int64(organizacao.DuracaoTokenAnonimo/time.Second),
+		string(organizacao.ChaveAssinatura),
+		organizacao.ID,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("%w: %d", ErrOrganizacaoNaoEncontrada, organizacao.ID)
+	}
+
+	return nil
+}
+
+func (repositorio Organizacoes) buscar(ctx context.Context, condicao string, valor interface{}) (modelos.Organizacao, error) {
+	var (
+		organizacao               modelos.Organizacao
+		duracaoToken, duracaoAnon int64
+		chave                     string
+	)
+
+	erro := repositorio.db.QueryRowContext(ctx, "SELECT "+colunasOrganizacao+" FROM organizacoes WHERE "+condicao, valor).Scan(
+		&organizacao.ID,
+		&organizacao.Slug,
+		&organizacao.Nome,
+		&organizacao.Dominio,
+		&organizacao.PoliticaDeSenha.TamanhoMinimo,
+		&organizacao.PoliticaDeSenha.ExigeMaiuscula,
+		&organizacao.PoliticaDeSenha.ExigeNumero,
+		&organizacao.PoliticaDeSenha.ExigeSimbolo,
+		&duracaoToken,
+		&duracaoAnon,
+		&chave,
+	)
+	if erro == sql.ErrNoRows {
+		return modelos.Organizacao{}, fmt.Errorf("%w: %v", ErrOrganizacaoNaoEncontrada, valor)
+	}
+	if erro != nil {
+		return modelos.Organizacao{}, erro
+	}
+
+	organizacao.DuracaoToken = time.Duration(duracaoToken) * time.Second
+	organizacao.DuracaoTokenAnonimo = time.Duration(duracaoAnon) * time.Second
+	if chave != "" {
+		organizacao.ChaveAssinatura = []byte(chave)
+	}
+
+	return organizacao, nil
+}
@@ -0,0 +1,136 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/testes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCriarOrganizacaoComDono(t *testing.T) {
+	db := testes.BancoDeDados(t)
+	repositorio := NovoRepositorioDeOrganizacoes(db)
+
+	slug := fmt.Sprintf("criada-%d", time.Now().UnixNano())
+	dono := modelos.Usuario{Nome: "Dona", Nick: "dona", Email: "dona@exemplo.com", Senha: "hash"}
+	orgID, donoID, erro := repositorio.Criar(ctx, modelos.Organizacao{Slug: slug, Nome: "Criada"}, dono)
+	if erro != nil {
+		t.Fatalf("Criar: %v", erro)
+	}
+
+	membros := NovoRepositorioDeMembros(db, orgID)
+	if papel, erro := membros.BuscarPapel(ctx, donoID); erro != nil || papel != modelos.PapelDono {
+		t.Fatalf("papel do criador = %q, %v; quer dono", papel, erro)
+	}
+
+	// Com um dono, a transferência de propriedade funciona
+	outro := criar(t, NovoRepositorioDeUsuarios(db, orgID), "outro_membro", "Outro")
+	if erro = membros.Adicionar(ctx, outro, modelos.PapelMembro); erro != nil {
+		t.Fatal(erro)
+	}
+	if erro = membros.TransferirPropriedade(ctx, donoID, outro); erro != nil {
+		t.Fatalf("TransferirPropriedade: %v", erro)
+	}
+	if papel, _ := membros.BuscarPapel(ctx, outro); papel != modelos.PapelDono {
+		t.Errorf("papel do novo dono = %q", papel)
+	}
+
+	// Slug repetido
+	repetida := modelos.Usuario{Nome: "Repetida", Nick: "repetida", Email: "repetida@exemplo.com", Senha: "hash"}
+	if _, _, erro = repositorio.Criar(ctx, modelos.Organizacao{Slug: slug, Nome: "Outra"}, repetida); !duplicado(erro) {
+		t.Errorf("slug repetido: erro = %v, quer violação de unicidade", erro)
+	}
+
+	// A conta do dono não cabe na tabela: a organização também não é gravada
+	invalido := modelos.Usuario{Nome: "Longo", Nick: strings.Repeat("n", 60), Email: "longo@exemplo.com", Senha: "hash"}
+	if _, _, erro = repositorio.Criar(ctx, modelos.Organizacao{Slug: slug + "-sem-dono", Nome: "Sem dono"}, invalido); erro == nil {
+		t.Fatal("um nick maior que a coluna deveria falhar")
+	}
+	if _, erro = repositorio.BuscarPorSlug(ctx, slug+"-sem-dono"); !errors.Is(erro, ErrOrganizacaoNaoEncontrada) {
+		t.Errorf("a organização ficou gravada sem dono: %v", erro)
+	}
+}
+
+func TestDefinirDono(t *testing.T) {
+	db := testes.BancoDeDados(t)
+	orgID, outraOrg := testes.NovaOrganizacao(t, db), testes.NovaOrganizacao(t, db)
+	usuarios := NovoRepositorioDeUsuarios(db, orgID)
+	membros := NovoRepositorioDeMembros(db, orgID)
+
+	// Organização sem dono: um usuário que nem está na equipe assume
+	primeiro := criar(t, usuarios, "primeiro_dono", "Primeiro")
+	if erro := membros.DefinirDono(ctx, primeiro); erro != nil {
+		t.Fatalf("DefinirDono: %v", erro)
+	}
+	if papel, erro := membros.BuscarPapel(ctx, primeiro); erro != nil || papel != modelos.PapelDono {
+		t.Fatalf("papel = %q, %v; quer dono", papel, erro)
+	}
+
+	// Outro dono: o anterior vira admin
+	segundo := criar(t, usuarios, "segundo_dono", "Segundo")
+	if erro := membros.Adicionar(ctx, segundo, modelos.PapelMembro); erro != nil {
+		t.Fatal(erro)
+	}
+	if erro := membros.DefinirDono(ctx, segundo); erro != nil {
+		t.Fatalf("DefinirDono: %v", erro)
+	}
+	if papel, _ := membros.BuscarPapel(ctx, segundo); papel != modelos.PapelDono {
+		t.Errorf("papel do novo dono = %q", papel)
+	}
+	if papel, _ := membros.BuscarPapel(ctx, primeiro); papel != modelos.PapelAdmin {
+		t.Errorf("papel do dono anterior = %q, quer admin", papel)
+	}
+
+	// Usuário de outra organização
+	deFora := criar(t, NovoRepositorioDeUsuarios(db, outraOrg), "de_fora", "De fora")
+	if erro := membros.DefinirDono(ctx, deFora); !errors.Is(erro, ErrUsuarioNaoEncontrado) {
+		t.Errorf("usuário de outra organização: erro = %v, quer ErrUsuarioNaoEncontrado", erro)
+	}
+	if papel, _ := membros.BuscarPapel(ctx, segundo); papel != modelos.PapelDono {
+		t.Errorf("a falha trocou o dono: papel = %q", papel)
+	}
+}
+
+func TestAtualizarConfiguracoesDaOrganizacao(t *testing.T) {
+	db := testes.BancoDeDados(t)
+	repositorio := NovoRepositorioDeOrganizacoes(db)
+
+	organizacao, erro := repositorio.BuscarPorID(ctx, testes.NovaOrganizacao(t, db))
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	organizacao.PoliticaDeSenha = modelos.PoliticaSenha{TamanhoMinimo: 12, ExigeSimbolo: true}
+	organizacao.DuracaoToken = 2 * time.Hour
+	organizacao.DuracaoTokenAnonimo = 0
+	organizacao.ChaveAssinatura = []byte(strings.Repeat("k", 32))
+	if erro = repositorio.AtualizarConfiguracoes(ctx, organizacao); erro != nil {
+		t.Fatalf("AtualizarConfiguracoes: %v", erro)
+	}
+
+	gravada, erro := repositorio.BuscarPorID(ctx, organizacao.ID)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if gravada.PoliticaDeSenha != organizacao.PoliticaDeSenha || gravada.DuracaoToken != 2*time.Hour ||
+		gravada.DuracaoTokenAnonimo != 0 || string(gravada.ChaveAssinatura) != string(organizacao.ChaveAssinatura) {
+		t.Errorf("organização gravada = %+v, quer %+v", gravada, organizacao)
+	}
+
+	// Sem chave, a organização volta à SECRET_KEY global
+	organizacao.ChaveAssinatura = nil
+	if erro = repositorio.AtualizarConfiguracoes(ctx, organizacao); erro != nil {
+		t.Fatal(erro)
+	}
+	if gravada, _ = repositorio.BuscarPorID(ctx, organizacao.ID); gravada.ChaveAssinatura != nil {
+		t.Errorf("chave = %q, quer nenhuma", gravada.ChaveAssinatura)
+	}
+
+	organizacao.ID = 0
+	if erro = repositorio.AtualizarConfiguracoes(ctx, organizacao); !errors.Is(erro, ErrOrganizacaoNaoEncontrada) {
+		t.Errorf("organização inexistente: erro = %v, quer ErrOrganizacaoNaoEncontrada", erro)
+	}
+}
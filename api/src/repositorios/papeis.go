@@ -0,0 +1,220 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// PapelPadrao é concedido implicitamente a todo usuário autenticado
+const PapelPadrao = "usuario"
+
+// ErrPapelNaoEncontrado indica um papel que não existe na organização (nem entre os do sistema)
+var ErrPapelNaoEncontrado = errors.New("papel não encontrado")
+
+// ErrPermissaoDesconhecida indica, na criação de um papel, uma permissão que não existe ou aparece repetida
+var ErrPermissaoDesconhecida = errors.New("permissão desconhecida ou repetida")
+
+// papeisVisiveis filtra os papéis do sistema e os da organização do repositório, sempre no parâmetro $1
+const papeisVisiveis = "(p.org_id IS NULL OR p.org_id = $1)"
+
+// Papeis representa um repositório de papéis e permissões de uma organização.
+// Os papéis do sistema (org_id nulo) valem em todas; os demais só na organização que os criou.
+type Papeis struct {
+	db    *sql.DB
+	orgID uint64
+}
+
+// NovoRepositorioDePapeis cria um repositório de papéis restrito a uma organização
+func NovoRepositorioDePapeis(db *sql.DB, orgID uint64) *Papeis {
+	return &Papeis{db, orgID}
+}
+
+// Listar traz os papéis do sistema e os da organização com as suas permissões
+func (repositorio Papeis) Listar(ctx context.Context) ([]modelos.Papel, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		SELECT p.id, p.nome, p.descricao, p.org_id IS NULL,
+			COALESCE(array_agg(pe.nome ORDER BY pe.nome) FILTER (WHERE pe.nome IS NOT NULL), '{}')
+		FROM papeis p
+		LEFT JOIN papel_permissoes pp ON pp.papel_id = p.id
+		LEFT JOIN permissoes pe ON pe.id = pp.permissao_id
+		WHERE `+papeisVisiveis+`
+		GROUP BY p.id
+		ORDER BY p.nome`, repositorio.orgID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var papeis []modelos.Papel
+	for linhas.Next() {
+		var (
+			papel      modelos.Papel
+			permissoes pq.StringArray
+		)
+
+		if erro = linhas.Scan(&papel.ID, &papel.Nome, &papel.Descricao, &papel.Sistema, &permissoes); erro != nil {
+			return nil, erro
+		}
+
+		papel.Permissoes = permissoes
+		papeis = append(papeis, papel)
+	}
+
+	return papeis, nil
+}
+
+// BuscarDoUsuario traz os papéis atribuídos diretamente a um usuário
+func (repositorio Papeis) BuscarDoUsuario(ctx context.Context, usuarioID uint64) ([]string, error) {
+	var papeis pq.StringArray
+	erro := repositorio.db.QueryRowContext(ctx, `
+		SELECT COALESCE(array_agg(p.nome ORDER BY p.nome), '{}')
+		FROM usuario_papeis up
+		INNER JOIN papeis p ON p.id = up.papel_id
+		WHERE up.usuario_id = $2 AND `+papeisVisiveis, repositorio.orgID, usuarioID,
+	).Scan(&papeis)
+	if erro != nil {
+		return nil, erro
+	}
+
+	return papeis, nil
+}
+
+// Acesso retorna os papéis e as permissões efetivas de um usuário: os papéis atribuídos no banco,
+// o papel padrão e os papéis vindos do autenticador (grupos do LDAP, por exemplo). Os externos só casam com papéis
+// da própria organização: um grupo do diretório chamado admin não concede o papel admin do sistema.
+func (repositorio Papeis) Acesso(ctx context.Context, usuarioID uint64, papeisExternos []string) ([]string, []string, error) {
+	var papeis, permissoes pq.StringArray
+	erro := repositorio.db.QueryRowContext(ctx, `
+		WITH papeis_efetivos AS (
+			SELECT p.id, p.nome FROM papeis p
+			WHERE `+papeisVisiveis+` AND (
+				p.nome = $3
+				OR (p.org_id = $1 AND p.nome = ANY($4))
+				OR p.id IN (SELECT papel_id FROM usuario_papeis WHERE usuario_id = $2)
+			)
+		)
+		SELECT
+			COALESCE((SELECT array_agg(nome ORDER BY nome) FROM papeis_efetivos), '{}'),
+			COALESCE((
+				SELECT array_agg(DISTINCT pe.nome)
+				FROM papel_permissoes pp
+				INNER JOIN permissoes pe ON pe.id = pp.permissao_id
+				WHERE pp.papel_id IN (SELECT id FROM papeis_efetivos)
+			), '{}')`,
+		repositorio.orgID, usuarioID, PapelPadrao, pq.Array(papeisExternos),
+	).Scan(&papeis, &permissoes)
+	if erro != nil {
+		return nil, nil, erro
+	}
+
+	return papeis, permissoes, nil
+}
+
+// Criar cadastra um papel próprio da organização com as permissões informadas.
+// O nome não pode repetir o de um papel do sistema, para a atribuição por nome não ficar ambígua.
+func (repositorio Papeis) Criar(ctx context.Context, papel modelos.Papel) (uint64, error) {
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return 0, erro
+	}
+	defer tx.Rollback()
+
+	var doSistema bool
+	if erro = tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM papeis WHERE nome = $1 AND org_id IS NULL)", papel.Nome,
+	).Scan(&doSistema); erro != nil {
+		return 0, erro
+	}
+	if doSistema {
+		return 0, fmt.Errorf("papel %s: %w", papel.Nome, ErrRegistroDuplicado)
+	}
+
+	var ID uint64
+	if erro = tx.QueryRowContext(ctx,
+		"INSERT INTO papeis (nome, descricao, org_id) VALUES ($1, $2, $3) RETURNING id",
+		papel.Nome, papel.Descricao, repositorio.orgID,
+	).Scan(&ID); erro != nil {
+		return 0, erro
+	}
+
+	resultado, erro := tx.ExecContext(ctx, `
+		INSERT INTO papel_permissoes (papel_id, permissao_id)
+		SELECT $1, id FROM permissoes WHERE nome = ANY($2)`, ID, pq.Array(papel.Permissoes),
+	)
+	if erro != nil {
+		return 0, erro
+	}
+	if linhas, _ := resultado.RowsAffected(); linhas != int64(len(papel.Permissoes)) {
+		return 0, fmt.Errorf("papel %s: %w", papel.Nome, ErrPermissaoDesconhecida)
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return 0, erro
+	}
+	return ID, nil
+}
+
+// Excluir apaga um papel da organização, junto com as atribuições dele. Papéis do sistema não são excluídos.
+func (repositorio Papeis) Excluir(ctx context.Context, papel string) error {
+	resultado, erro := repositorio.db.ExecContext(ctx,
+		"DELETE FROM papeis WHERE org_id = $1 AND nome = $2", repositorio.orgID, papel,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("papel %s: %w", papel, ErrPapelNaoEncontrado)
+	}
+	return nil
+}
+
+// Atribuir concede um papel a um usuário
+func (repositorio Papeis) Atribuir(ctx context.Context, usuarioID uint64, papel string) error {
+	resultado, erro := repositorio.db.ExecContext(ctx, `
+		INSERT INTO usuario_papeis (usuario_id, papel_id)
+		SELECT $2, p.id FROM papeis p WHERE p.nome = $3 AND `+papeisVisiveis+`
+		ON CONFLICT DO NOTHING`, repositorio.orgID, usuarioID, papel,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		var existe bool
+		if erro = repositorio.db.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM papeis p WHERE p.nome = $2 AND "+papeisVisiveis+")", repositorio.orgID, papel,
+		).Scan(&existe); erro != nil {
+			return erro
+		}
+		if !existe {
+			return fmt.Errorf("papel %s: %w", papel, ErrPapelNaoEncontrado)
+		}
+	}
+
+	return nil
+}
+
+// Remover retira um papel de um usuário
+func (repositorio Papeis) Remover(ctx context.Context, usuarioID uint64, papel string) error {
+	resultado, erro := repositorio.db.ExecContext(ctx, `
+		DELETE FROM usuario_papeis
+		WHERE usuario_id = $2 AND papel_id IN (SELECT p.id FROM papeis p WHERE p.nome = $3 AND `+papeisVisiveis+`)`,
+		repositorio.orgID, usuarioID, papel,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("o usuário %d não possui o papel %s", usuarioID, papel)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/testes"
+	"errors"
+	"testing"
+)
+
+func TestPapeisPorOrganizacao(t *testing.T) {
+	db := testes.BancoDeDados(t)
+
+	orgA, orgB := testes.NovaOrganizacao(t, db), testes.NovaOrganizacao(t, db)
+	papeisA, papeisB := NovoRepositorioDePapeis(db, orgA), NovoRepositorioDePapeis(db, orgB)
+	usuarioA := criar(t, NovoRepositorioDeUsuarios(db, orgA), "moderador_a", "Moderador A")
+	usuarioB := criar(t, NovoRepositorioDeUsuarios(db, orgB), "moderador_b", "Moderador B")
+
+	if _, erro := papeisA.Criar(ctx, modelos.Papel{Nome: "moderador", Permissoes: []string{"usuarios:editar"}}); erro != nil {
+		t.Fatalf("Criar: %v", erro)
+	}
+
+	t.Run("nome de papel do sistema", func(t *testing.T) {
+		if _, erro := papeisA.Criar(ctx, modelos.Papel{Nome: "admin"}); !errors.Is(erro, ErrRegistroDuplicado) {
+			t.Errorf("erro = %v, quer ErrRegistroDuplicado", erro)
+		}
+	})
+
+	t.Run("permissão desconhecida", func(t *testing.T) {
+		if _, erro := papeisA.Criar(ctx, modelos.Papel{Nome: "outro", Permissoes: []string{"nao:existe"}}); !errors.Is(erro, ErrPermissaoDesconhecida) {
+			t.Errorf("erro = %v, quer ErrPermissaoDesconhecida", erro)
+		}
+	})
+
+	t.Run("listagem", func(t *testing.T) {
+		for _, caso := range []struct {
+			repositorio *Papeis
+			quer        bool
+		}{{papeisA, true}, {papeisB, false}} {
+			papeis, erro := caso.repositorio.Listar(ctx)
+			if erro != nil {
+				t.Fatal(erro)
+			}
+			encontrou := false
+			for _, papel := range papeis {
+				if papel.Nome == "moderador" {
+					encontrou = true
+				}
+			}
+			if encontrou != caso.quer {
+				t.Errorf("org %d enxerga o papel moderador = %v, quer %v", caso.repositorio.orgID, encontrou, caso.quer)
+			}
+		}
+	})
+
+	t.Run("atribuição", func(t *testing.T) {
+		if erro := papeisB.Atribuir(ctx, usuarioB, "moderador"); !errors.Is(erro, ErrPapelNaoEncontrado) {
+			t.Errorf("papel de outra organização: erro = %v, quer ErrPapelNaoEncontrado", erro)
+		}
+
+		if erro := papeisA.Atribuir(ctx, usuarioA, "moderador"); erro != nil {
+			t.Fatal(erro)
+		}
+		_, permissoes, erro := papeisA.Acesso(ctx, usuarioA, nil)
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		if !contem(permissoes, "usuarios:editar") {
+			t.Errorf("permissões = %v, quer usuarios:editar", permissoes)
+		}
+
+		// Grupos externos com o nome do papel não valem em outra organização
+		_, permissoes, erro = papeisB.Acesso(ctx, usuarioB, []string{"moderador"})
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		if contem(permissoes, "usuarios:editar") {
+			t.Errorf("papel da organização A concedeu permissões na B: %v", permissoes)
+		}
+
+		// Nem um grupo externo com o nome de um papel do sistema
+		papeis, permissoes, erro := papeisB.Acesso(ctx, usuarioB, []string{"admin"})
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		if contem(papeis, "admin") || contem(permissoes, "papeis:gerenciar") {
+			t.Errorf("grupo externo admin concedeu o papel do sistema: %v, %v", papeis, permissoes)
+		}
+
+		// Um papel da própria organização com o nome do grupo vale, mesmo sem atribuição
+		doGrupo := criar(t, NovoRepositorioDeUsuarios(db, orgA), "grupo_a", "Grupo A")
+		if papeis, _, erro = papeisA.Acesso(ctx, doGrupo, []string{"moderador"}); erro != nil || !contem(papeis, "moderador") {
+			t.Errorf("papéis com o grupo moderador na organização A = %v, %v", papeis, erro)
+		}
+	})
+
+	t.Run("exclusão", func(t *testing.T) {
+		if erro := papeisA.Excluir(ctx, "admin"); !errors.Is(erro, ErrPapelNaoEncontrado) {
+			t.Errorf("excluir papel do sistema: erro = %v, quer ErrPapelNaoEncontrado", erro)
+		}
+		if erro := papeisB.Excluir(ctx, "moderador"); !errors.Is(erro, ErrPapelNaoEncontrado) {
+			t.Errorf("excluir papel de outra organização: erro = %v, quer ErrPapelNaoEncontrado", erro)
+		}
+		if erro := papeisA.Excluir(ctx, "moderador"); erro != nil {
+			t.Fatal(erro)
+		}
+		papeis, erro := papeisA.BuscarDoUsuario(ctx, usuarioA)
+		if erro != nil {
+			t.Fatal(erro)
+		}
+		if contem(papeis, "moderador") {
+			t.Errorf("a atribuição sobreviveu à exclusão do papel: %v", papeis)
+		}
+	})
+}
+
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if item == valor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ProvedoresSAML representa um repositório de IdPs SAML por tenant
+type ProvedoresSAML struct {
+	db *sql.DB
+}
+
+// NovoRepositorioDeProvedoresSAML cria um repositório de provedores SAML
+func NovoRepositorioDeProvedoresSAML(db *sql.DB) *ProvedoresSAML {
+	return &ProvedoresSAML{db}
+}
+
+// BuscarPorTenant traz a configuração do IdP de um tenant
+func (repositorio ProvedoresSAML) BuscarPorTenant(ctx context.Context, tenant string) (modelos.ProvedorSAML, error) {
+	var provedor modelos.ProvedorSAML
+	erro := repositorio.db.QueryRowContext(ctx, `
+		SELECT id, tenant, metadata, atributo_email, atributo_nome, atributo_nick, confiar_email
+		FROM provedores_saml WHERE tenant = $1`, tenant,
+	).Scan(
+		&provedor.ID,
+		&provedor.Tenant,
+		&provedor.Metadata,
+		&provedor.AtributoEmail,
+		&provedor.AtributoNome,
+		&provedor.AtributoNick,
+		&provedor.ConfiarEmail,
+	)
+	if erro == sql.ErrNoRows {
+		return modelos.ProvedorSAML{}, fmt.Errorf("provedor SAML do tenant %s não encontrado", tenant)
+	}
+	if erro != nil {
+		return modelos.ProvedorSAML{}, erro
+	}
+
+	return provedor, nil
+}
+
+// Salvar cria ou substitui a configuração do IdP de um tenant
+func (repositorio ProvedoresSAML) Salvar(ctx context.Context, provedor modelos.ProvedorSAML) (uint64, error) {
+	var id uint64
+	erro := repositorio.db.QueryRowContext(ctx, `
+		INSERT INTO provedores_saml (tenant, metadata, atributo_email, atributo_nome, atributo_nick, confiar_email)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant) DO UPDATE SET
+			metadata = EXCLUDED.metadata,
+			atributo_email = EXCLUDED.atributo_email,
+			atributo_nome = EXCLUDED.atributo_nome,
+			atributo_nick = EXCLUDED.atributo_nick,
+			confiar_email = EXCLUDED.confiar_email,
+			atualizadoEm = current_timestamp
+		RETURNING id`,
+		provedor.Tenant, provedor.Metadata, provedor.AtributoEmail, provedor.AtributoNome, provedor.AtributoNick, provedor.ConfiarEmail,
+	).Scan(&id)
+	if erro != nil {
+		return 0, erro
+	}
+
+	return id, nil
+}
@@ -0,0 +1,215 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUsuarioBloqueado é retornado quando um dos usuários bloqueou o outro
+var ErrUsuarioBloqueado = errors.New("não é possível interagir com este usuário")
+
+// ConexaoSolicitada é a conexão retornada por Seguir quando a conta é privada e o pedido fica pendente
+const ConexaoSolicitada = "solicitado"
+
+// filtroDeVisibilidade esconde da consulta os usuários bloqueados (nos dois sentidos) e os silenciados pelo visitante.
+// coluna é o ID do usuário listado e parametro é a posição ($n) do ID do visitante.
+func filtroDeVisibilidade(coluna string, parametro int) string {
+	return fmt.Sprintf(`
+		NOT EXISTS (
+			SELECT 1 FROM bloqueios b
+			WHERE (b.usuario_id = $%[2]d AND b.bloqueado_id = %[1]s) OR (b.usuario_id = %[1]s AND b.bloqueado_id = $%[2]d)
+		)
+		AND NOT EXISTS (SELECT 1 FROM silenciados s WHERE s.usuario_id = $%[2]d AND s.silenciado_id = %[1]s)`,
+		coluna, parametro,
+	)
+}
+
+// Bloqueado informa se algum dos dois usuários bloqueou o outro
+func (repositorio Usuarios) Bloqueado(ctx context.Context, usuarioID, outroID uint64) (bool, error) {
+	var bloqueado bool
+	erro := repositorio.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM bloqueios
+			WHERE (usuario_id = $1 AND bloqueado_id = $2) OR (usuario_id = $2 AND bloqueado_id = $1)
+		)`, usuarioID, outroID,
+	).Scan(&bloqueado)
+	return bloqueado, erro
+}
+
+// Bloquear impede qualquer interação entre os dois usuários e desfaz os vínculos e pedidos existentes
+func (repositorio Usuarios) Bloquear(ctx context.Context, usuarioID, bloqueadoID uint64) error {
+	if usuarioID == bloqueadoID {
+		return errors.New("um usuário não pode bloquear a si mesmo")
+	}
+
+	if erro := repositorio.verificarMesmaOrganizacao(ctx, usuarioID, bloqueadoID); erro != nil {
+		return erro
+	}
+
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", erro)
+	}
+	defer tx.Rollback()
+
+	if _, erro = tx.ExecContext(ctx,
+		"INSERT INTO bloqueios (usuario_id, bloqueado_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		usuarioID, bloqueadoID,
+	); erro != nil {
+		return erro
+	}
+
+	for _, tabela := range []string{"seguindo", "solicitacoes_seguir"} {
+		coluna := "seguindo_id"
+		if tabela == "solicitacoes_seguir" {
+			coluna = "seguidor_id"
+		}
+
+		if _, erro = tx.ExecContext(ctx, fmt.Sprintf(
+			"DELETE FROM %[1]s WHERE (usuario_id = $1 AND %[2]s = $2) OR (usuario_id = $2 AND %[2]s = $1)", tabela, coluna,
+		), usuarioID, bloqueadoID); erro != nil {
+			return erro
+		}
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return fmt.Errorf("erro ao confirmar transação: %v", erro)
+	}
+
+	return nil
+}
+
+// Desbloquear remove o bloqueio; os vínculos desfeitos não são restaurados
+func (repositorio Usuarios) Desbloquear(ctx context.Context, usuarioID, bloqueadoID uint64) error {
+	resultado, erro := repositorio.db.ExecContext(ctx,
+		"DELETE FROM bloqueios WHERE usuario_id = $1 AND bloqueado_id = $2",
+		usuarioID, bloqueadoID,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("o usuário %d não está bloqueado", bloqueadoID)
+	}
+
+	return nil
+}
+
+// Silenciar esconde o usuário das buscas e sugestões de quem silenciou, sem desfazer o vínculo
+func (repositorio Usuarios) Silenciar(ctx context.Context, usuarioID, silenciadoID uint64) error {
+	if usuarioID == silenciadoID {
+		return errors.New("um usuário não pode silenciar a si mesmo")
+	}
+
+	if erro := repositorio.verificarMesmaOrganizacao(ctx, usuarioID, silenciadoID); erro != nil {
+		return erro
+	}
+
+	_, erro := repositorio.db.ExecContext(ctx,
+		"INSERT INTO silenciados (usuario_id, silenciado_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		usuarioID, silenciadoID,
+	)
+	return erro
+}
+
+// Dessilenciar volta a mostrar o usuário silenciado
+func (repositorio Usuarios) Dessilenciar(ctx context.Context, usuarioID, silenciadoID uint64) error {
+	resultado, erro := repositorio.db.ExecContext(ctx,
+		"DELETE FROM silenciados WHERE usuario_id = $1 AND silenciado_id = $2",
+		usuarioID, silenciadoID,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("o usuário %d não está silenciado", silenciadoID)
+	}
+
+	return nil
+}
+
+// BuscarSolicitacoes traz os pedidos pendentes para seguir a conta privada do usuário
+func (repositorio Usuarios) BuscarSolicitacoes(ctx context.Context, usuarioID uint64) ([]modelos.Usuario, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		SELECT u.id, u.nome, u.nick, u.email, u.criadoEm
+		FROM usuarios u
+		INNER JOIN solicitacoes_seguir s ON u.id = s.seguidor_id
+		WHERE s.usuario_id = $1 AND u.org_id = $2 AND `+contaAtiva("u")+`
+		ORDER BY s.criadoEm`, usuarioID, repositorio.orgID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	return escanearUsuarios(linhas)
+}
+
+// AprovarSolicitacao transforma o pedido pendente em um vínculo em "seguindo"
+func (repositorio Usuarios) AprovarSolicitacao(ctx context.Context, usuarioID, seguidorID uint64) error {
+	tx, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", erro)
+	}
+	defer tx.Rollback()
+
+	resultado, erro := tx.ExecContext(ctx,
+		"DELETE FROM solicitacoes_seguir WHERE usuario_id = $1 AND seguidor_id = $2",
+		usuarioID, seguidorID,
+	)
+	if erro != nil {
+		return erro
+	}
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("não há pedido do usuário %d para seguir o usuário %d", seguidorID, usuarioID)
+	}
+
+	if _, erro = tx.ExecContext(ctx,
+		"INSERT INTO seguindo (usuario_id, seguindo_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		usuarioID, seguidorID,
+	); erro != nil {
+		return erro
+	}
+
+	if erro = tx.Commit(); erro != nil {
+		return fmt.Errorf("erro ao confirmar transação: %v", erro)
+	}
+
+	return nil
+}
+
+// RejeitarSolicitacao descarta o pedido pendente
+func (repositorio Usuarios) RejeitarSolicitacao(ctx context.Context, usuarioID, seguidorID uint64) error {
+	resultado, erro := repositorio.db.ExecContext(ctx,
+		"DELETE FROM solicitacoes_seguir WHERE usuario_id = $1 AND seguidor_id = $2",
+		usuarioID, seguidorID,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return fmt.Errorf("não há pedido do usuário %d para seguir o usuário %d", seguidorID, usuarioID)
+	}
+
+	return nil
+}
+
+// PodeVerConexoes informa se o visitante pode ver seguidores e seguindo do usuário:
+// contas públicas são visíveis para todos; privadas, só para o dono e os seguidores aprovados
+func (repositorio Usuarios) PodeVerConexoes(ctx context.Context, usuario modelos.Usuario, visitanteID uint64) (bool, error) {
+	if !usuario.Privado || usuario.ID == visitanteID {
+		return true, nil
+	}
+
+	var segue bool
+	erro := repositorio.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM seguindo WHERE usuario_id = $1 AND seguindo_id = $2)",
+		usuario.ID, visitanteID,
+	).Scan(&segue)
+	return segue, erro
+}
@@ -0,0 +1,72 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/paginacao"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrRegistroDuplicado é retornado pelas implementações fora do PostgreSQL quando nick ou e-mail já existem na
+// organização; no PostgreSQL o mesmo caso chega como violação de unicidade (23505)
+var ErrRegistroDuplicado = errors.New("já existe um registro com estes dados")
+
+// RepositorioUsuarios reúne as operações sobre usuários, seguidores e relacionamentos de uma organização.
+// Usuarios é a implementação no PostgreSQL e UsuariosEmMemoria a implementação em memória.
+type RepositorioUsuarios interface {
+	// Cadastro e perfil
+	Criar(ctx context.Context, usuario modelos.Usuario) (uint64, error)
+	Buscar(ctx context.Context, filtro modelos.FiltroDeUsuarios, visitanteID uint64, pagina paginacao.Pagina) ([]modelos.ResultadoDeBusca, *paginacao.Cursor, error)
+	BuscarPorID(ctx context.Context, ID uint64) (modelos.Usuario, error)
+	BuscarPorTermo(ctx context.Context, termoBusca string, usuarioID uint64) ([]modelos.Usuario, error)
+	BuscarPorEmail(ctx context.Context, email string) (modelos.Usuario, error)
+	ExisteNick(ctx context.Context, nick string) (bool, error)
+	Atualizar(ctx context.Context, ID uint64, usuario modelos.Usuario) error
+	Deletar(ctx context.Context, ID uint64) error
+
+	// Senha
+	BuscarSenha(ctx context.Context, usuarioID uint64) (string, error)
+	AtualizarSenha(ctx context.Context, usuarioID uint64, senha string) error
+	NovaSenha(ctx context.Context, usuarioID uint64, senha string) error
+
+	// Situação da conta
+	BuscarSituacao(ctx context.Context, ID uint64) (modelos.SituacaoDaConta, error)
+	Desativar(ctx context.Context, ID uint64) error
+	Reativar(ctx context.Context, ID uint64) error
+
+	// Seguidores
+	Seguir(ctx context.Context, usuarioID, seguidorID uint64) ([]modelos.Usuario, error)
+	PararDeSeguir(ctx context.Context, usuarioID, seguidorID uint64) ([]modelos.Usuario, error)
+	BuscarSeguidores(ctx context.Context, usuarioID uint64, pagina paginacao.Pagina) ([]modelos.Usuario, *paginacao.Cursor, error)
+	BuscarSeguindo(ctx context.Context, usuarioID uint64, pagina paginacao.Pagina) ([]modelos.Usuario, *paginacao.Cursor, error)
+	QuantidadeSeguindo(ctx context.Context, usuarioID uint64) (int, error)
+	QuantidadeSeguidores(ctx context.Context, usuarioID uint64) (int, error)
+	BuscarSugestoes(ctx context.Context, usuarioID uint64, limite int) ([]modelos.Sugestao, error)
+
+	// Bloqueio, silêncio e contas privadas
+	Bloqueado(ctx context.Context, usuarioID, outroID uint64) (bool, error)
+	Bloquear(ctx context.Context, usuarioID, bloqueadoID uint64) error
+	Desbloquear(ctx context.Context, usuarioID, bloqueadoID uint64) error
+	Silenciar(ctx context.Context, usuarioID, silenciadoID uint64) error
+	Dessilenciar(ctx context.Context, usuarioID, silenciadoID uint64) error
+	BuscarSolicitacoes(ctx context.Context, usuarioID uint64) ([]modelos.Usuario, error)
+	AprovarSolicitacao(ctx context.Context, usuarioID, seguidorID uint64) error
+	RejeitarSolicitacao(ctx context.Context, usuarioID, seguidorID uint64) error
+	PodeVerConexoes(ctx context.Context, usuario modelos.Usuario, visitanteID uint64) (bool, error)
+}
+
+// FabricaDeUsuarios cria o repositório de usuários de uma organização; é o que os controllers recebem por injeção
+type FabricaDeUsuarios func(orgID uint64) RepositorioUsuarios
+
+var (
+	_ RepositorioUsuarios = (*Usuarios)(nil)
+	_ RepositorioUsuarios = (*UsuariosEmMemoria)(nil)
+)
+
+// NovaFabricaDeUsuarios cria repositórios de usuários no PostgreSQL
+func NovaFabricaDeUsuarios(db *sql.DB) FabricaDeUsuarios {
+	return func(orgID uint64) RepositorioUsuarios {
+		return NovoRepositorioDeUsuarios(db, orgID)
+	}
+}
@@ -0,0 +1,49 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"context"
+	"fmt"
+)
+
+// BuscarSugestoes traz as pessoas seguidas por quem o usuário segue, ordenadas pela quantidade de seguidos em comum.
+// Ficam de fora o próprio usuário, quem ele já segue ou pediu para seguir, bloqueados e silenciados.
+func (repositorio Usuarios) BuscarSugestoes(ctx context.Context, usuarioID uint64, limite int) ([]modelos.Sugestao, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT u.id, u.nome, u.nick, u.criadoEm, COUNT(*) AS em_comum
+		FROM seguindo meus
+		INNER JOIN seguindo deles ON deles.seguindo_id = meus.usuario_id
+		INNER JOIN usuarios u ON u.id = deles.usuario_id
+		WHERE meus.seguindo_id = $1 AND u.id <> $1 AND u.org_id = $2 AND %s
+		AND NOT EXISTS (SELECT 1 FROM seguindo s WHERE s.usuario_id = u.id AND s.seguindo_id = $1)
+		AND NOT EXISTS (SELECT 1 FROM solicitacoes_seguir p WHERE p.usuario_id = u.id AND p.seguidor_id = $1)
+		AND %s
+		GROUP BY u.id, u.nome, u.nick, u.criadoEm
+		ORDER BY em_comum DESC, u.id
+		LIMIT $3`, contaAtiva("u"), filtroDeVisibilidade("u.id", 1)),
+		usuarioID, repositorio.orgID, limite,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	sugestoes := []modelos.Sugestao{}
+	for linhas.Next() {
+		var sugestao modelos.Sugestao
+
+		if erro = linhas.Scan(
+			&sugestao.ID,
+			&sugestao.Nome,
+			&sugestao.Nick,
+			&sugestao.CriadoEm,
+			&sugestao.EmComum,
+		); erro != nil {
+			return nil, erro
+		}
+
+		sugestoes = append(sugestoes, sugestao)
+	}
+
+	return sugestoes, linhas.Err()
+}
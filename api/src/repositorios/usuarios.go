@@ -2,94 +2,168 @@ package repositorios
 
 import (
 	"api/src/modelos"
+	"api/src/paginacao"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 )
 
-// Usuarios representa um repositório de usuarios
+// ErrUsuarioNaoEncontrado é retornado (envolvido) quando a busca não encontra o usuário
+var ErrUsuarioNaoEncontrado = errors.New("não encontrado")
+
+// ErrTermoMuitoLongo é retornado (envolvido) pela busca quando o termo passa de tamanhoMaximoDoTermo bytes
+var ErrTermoMuitoLongo = errors.New("termo de busca muito longo")
+
+// tamanhoMaximoDoTermo limita o termo da busca, para evitar DoS com consultas excessivas
+const tamanhoMaximoDoTermo = 100
+
+// Usuarios representa um repositório de usuarios.
+// Todas as consultas ficam restritas à organização (tenant) informada na criação do repositório.
 type Usuarios struct {
-	db *sql.DB
+	db    *sql.DB
+	orgID uint64
 }
 
-// NovoRepositorioDeUsuarios cria um repositório de usuários
-func NovoRepositorioDeUsuarios(db *sql.DB) *Usuarios {
-	return &Usuarios{db}
+// NovoRepositorioDeUsuarios cria um repositório de usuários da organização
+func NovoRepositorioDeUsuarios(db *sql.DB, orgID uint64) *Usuarios {
+	return &Usuarios{db, orgID}
 }
 
 // Criar insere um usuário no banco de dados
-func (repositorio Usuarios) Criar(usuario modelos.Usuario) (uint64, error) {
-	statement, erro := repositorio.db.Prepare(
-		"insert into usuarios (nome, nick, email, senha) values($1, $2, $3, $4) returning id", // Alterado para PostgreSQL com RETURNING
-	)
-	if erro != nil {
-		return 0, erro
-	}
-	defer statement.Close()
+func (repositorio Usuarios) Criar(ctx context.Context, usuario modelos.Usuario) (uint64, error) {
+	return inserirUsuario(ctx, repositorio.db, repositorio.orgID, usuario)
+}
 
-	// A consulta agora retorna o ID gerado, que é capturado na variável resultado
+// inserirUsuario grava a conta na organização e retorna o ID gerado; recebe o banco ou uma transação
+func inserirUsuario(ctx context.Context, executor executor, orgID uint64, usuario modelos.Usuario) (uint64, error) {
 	var id uint64
-	err := statement.QueryRow(usuario.Nome, usuario.Nick, usuario.Email, usuario.Senha).Scan(&id)
-	if err != nil {
-		return 0, err
+	erro := executor.QueryRowContext(ctx,
+		"insert into usuarios (nome, nick, email, senha, org_id) values($1, $2, $3, $4, $5) returning id",
+		usuario.Nome, usuario.Nick, usuario.Email, usuario.Senha, orgID,
+	).Scan(&id)
+	if erro != nil {
+		return 0, erro
 	}
 
-	// Retorna o ID gerado pelo INSERT
 	return id, nil
 }
 
-func (repositorio Usuarios) Buscar(nomeOuNick string) ([]modelos.Usuario, error) {
-	// Limitar o tamanho do input para evitar DoS com consultas excessivas
-	if len(nomeOuNick) > 100 {
-		return nil, fmt.Errorf("termo de busca muito longo")
+// colunasDeUsuarios são as ordenações aceitas pela busca de usuários
+var colunasDeUsuarios = map[string]paginacao.Coluna{
+	"criadoEm": {Expressao: "u.criadoEm", Tipo: "timestamp"},
+	"nome":     {Expressao: "u.nome", Tipo: "text"},
+	"nick":     {Expressao: "u.nick", Tipo: "text"},
+}
+
+// colunasDoGrafo ordenam seguidores e seguindo; criadoEm é a data em que o vínculo foi criado
+var colunasDoGrafo = map[string]paginacao.Coluna{
+	"criadoEm": {Expressao: "s.criadoEm", Tipo: "timestamp"},
+	"nome":     {Expressao: "u.nome", Tipo: "text"},
+	"nick":     {Expressao: "u.nick", Tipo: "text"},
+}
+
+// Buscar traz uma página dos usuários que atendem ao filtro, sem os bloqueados e os silenciados pelo visitante.
+// O termo é comparado sem acentos nem maiúsculas, por trecho ou por similaridade, usando os índices de trigramas.
+// Retorna também o cursor da próxima página, ou nil quando esta é a última.
+func (repositorio Usuarios) Buscar(ctx context.Context, filtro modelos.FiltroDeUsuarios, visitanteID uint64, pagina paginacao.Pagina) ([]modelos.ResultadoDeBusca, *paginacao.Cursor, error) {
+	if len(filtro.Termo) > tamanhoMaximoDoTermo {
+		return nil, nil, fmt.Errorf("%w: até %d bytes", ErrTermoMuitoLongo, tamanhoMaximoDoTermo)
 	}
 
-	// Sanitizar e preparar o filtro de busca
-	// Evita que caracteres como '%' ou '_' causem problemas no LIKE
-	nomeOuNick = "%" + nomeOuNick + "%"
+	condicoes := []string{"u.org_id = $1", contaAtiva("u"), filtroDeVisibilidade("u.id", 2)}
+	argumentos := []interface{}{repositorio.orgID, visitanteID}
+	adicionar := func(condicao string, valor interface{}) {
+		argumentos = append(argumentos, valor)
+		condicoes = append(condicoes, fmt.Sprintf(condicao, len(argumentos)))
+	}
 
-	// Query preparada, evitando SQL Injection
-	linhas, erro := repositorio.db.Query(
-		"select id, nome, nick, email, criadoEm from usuarios where nome ILIKE $1 or nick ILIKE $1", // ILIKE para busca sem case-sensitive
-		nomeOuNick,
-	)
+	colunas := colunasDeUsuarios
+	relevancia := "0"
+	if filtro.Termo != "" {
+		argumentos = append(argumentos, escaparLike(filtro.Termo), filtro.Termo)
+		condicoes = append(condicoes, condicaoDeBusca(len(argumentos)-1, len(argumentos)))
+
+		relevancia = relevanciaDaBusca(len(argumentos))
+		colunas = map[string]paginacao.Coluna{"relevancia": {Expressao: relevancia, Tipo: "real"}}
+		for campo, coluna := range colunasDeUsuarios {
+			colunas[campo] = coluna
+		}
+	}
+	if _, ok := colunas[pagina.Campo]; !ok {
+		return nil, nil, fmt.Errorf("ordenação por %s indisponível nesta busca", pagina.Campo)
+	}
+
+	if filtro.CriadoApos != nil {
+		adicionar("u.criadoEm >= $%d", *filtro.CriadoApos)
+	}
+	if filtro.CriadoAntes != nil {
+		adicionar("u.criadoEm < $%d", *filtro.CriadoAntes)
+	}
+	if filtro.Privado != nil {
+		adicionar("u.privado = $%d", *filtro.Privado)
+	}
+
+	condicaoDoCursor, chave, ordenacao, argumentosDaPagina := pagina.SQL(colunas, "u.id", len(argumentos)+1)
+	condicoes = append(condicoes, condicaoDoCursor)
 
+	// Query preparada, evitando SQL Injection: apenas expressões fixas entram no texto da consulta
+	linhas, erro := repositorio.db.QueryContext(ctx,
+		fmt.Sprintf("SELECT u.id, u.nome, u.nick, u.email, u.criadoEm, %s, %s FROM usuarios u WHERE %s %s",
+			relevancia, chave, strings.Join(condicoes, " AND "), ordenacao),
+		append(argumentos, argumentosDaPagina...)...,
+	)
 	if erro != nil {
-		return nil, erro
+		return nil, nil, erro
 	}
 	defer linhas.Close()
 
-	var usuarios []modelos.Usuario
-
-	// Iterar sobre os resultados e preencher a lista de usuários
+	resultados := []modelos.ResultadoDeBusca{}
+	var chaves []string
+	var IDs []uint64
 	for linhas.Next() {
-		var usuario modelos.Usuario
+		var (
+			resultado modelos.ResultadoDeBusca
+			chave     string
+		)
 
-		// Scan para mapear os resultados da consulta
 		if erro = linhas.Scan(
-			&usuario.ID,
-			&usuario.Nome,
-			&usuario.Nick,
-			&usuario.Email,
-			&usuario.CriadoEm,
+			&resultado.ID,
+			&resultado.Nome,
+			&resultado.Nick,
+			&resultado.Email,
+			&resultado.CriadoEm,
+			&resultado.Relevancia,
+			&chave,
 		); erro != nil {
-			return nil, erro
+			return nil, nil, erro
 		}
 
-		usuarios = append(usuarios, usuario)
+		if filtro.Termo != "" {
+			resultado.Destaques = destaques(resultado.Usuario, filtro.Termo)
+		}
+
+		resultados = append(resultados, resultado)
+		chaves = append(chaves, chave)
+		IDs = append(IDs, resultado.ID)
+	}
+	if erro = linhas.Err(); erro != nil {
+		return nil, nil, erro
 	}
 
-	// Retornar os usuários encontrados
-	return usuarios, nil
+	quantidade, proximo := pagina.Proximo(chaves, IDs)
+	return resultados[:quantidade], proximo, nil
 }
 
 // BuscarPorID traz um usuário do banco de dados
-func (repositorio Usuarios) BuscarPorID(ID uint64) (modelos.Usuario, error) {
+func (repositorio Usuarios) BuscarPorID(ctx context.Context, ID uint64) (modelos.Usuario, error) {
 	var usuario modelos.Usuario
 
 	// Usar QueryRow para buscar um único usuário
-	linha := repositorio.db.QueryRow(
-		"select id, nome, nick, email, criadoEm from usuarios where id = $1", // Alterado para PostgreSQL
-		ID,
+	linha := repositorio.db.QueryRowContext(ctx,
+		"select id, nome, nick, email, criadoEm, privado from usuarios where id = $1 and org_id = $2 and "+contaAtiva("usuarios"),
+		ID, repositorio.orgID,
 	)
 
 	// Scan para mapear os resultados
@@ -99,11 +173,12 @@ func (repositorio Usuarios) BuscarPorID(ID uint64) (modelos.Usuario, error) {
 		&usuario.Nick,
 		&usuario.Email,
 		&usuario.CriadoEm,
+		&usuario.Privado,
 	)
 
 	// Se não encontrar o usuário (não existe linha), retornar um erro mais claro
 	if err == sql.ErrNoRows {
-		return modelos.Usuario{}, fmt.Errorf("usuário com ID %d não encontrado", ID)
+		return modelos.Usuario{}, fmt.Errorf("usuário com ID %d %w", ID, ErrUsuarioNaoEncontrado)
 	}
 
 	// Se ocorrer outro erro, retorna ele
@@ -115,126 +190,69 @@ func (repositorio Usuarios) BuscarPorID(ID uint64) (modelos.Usuario, error) {
 	return usuario, nil
 }
 
-// BuscarPorTermo busca usuários cujo nome ou nick correspondem ao termo de busca
-func (repositorio Usuarios) BuscarPorTermo(termoBusca string, usuarioID uint64) ([]modelos.Usuario, error) {
-	// Limitar o tamanho do input para evitar DoS com consultas excessivas
-	if len(termoBusca) > 100 {
-		return nil, fmt.Errorf("termo de busca muito longo")
-	}
-
-	// Sanitizar e preparar o filtro de busca
-	termoBusca = "%" + termoBusca + "%" // Adiciona % para buscar qualquer parte da string
-
-	// Query com ILIKE para fazer a busca insensível a maiúsculas e minúsculas
-	linhas, erro := repositorio.db.Query(
-		"select id, nome, nick, email, criadoEm from usuarios where nome ILIKE $1 or nick ILIKE $1",
-		termoBusca,
-	)
+// BuscarPorTermo traz os usuários mais relevantes para o termo, sem quem está buscando, com a conexão entre os dois
+func (repositorio Usuarios) BuscarPorTermo(ctx context.Context, termoBusca string, usuarioID uint64) ([]modelos.Usuario, error) {
+	pagina := paginacao.Pagina{Limite: paginacao.LimiteMaximo, Campo: "relevancia", Descendente: true}
+	resultados, _, erro := repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{Termo: termoBusca}, usuarioID, pagina)
 	if erro != nil {
 		return nil, erro
 	}
-	defer linhas.Close()
 
 	var usuarios []modelos.Usuario
-
-	// Itera sobre os resultados e preenche a lista de usuários
-	for linhas.Next() {
-		var usuario modelos.Usuario
-
-		// Scan para mapear os resultados da consulta
-		if erro = linhas.Scan(
-			&usuario.ID,
-			&usuario.Nome,
-			&usuario.Nick,
-			&usuario.Email,
-			&usuario.CriadoEm,
-		); erro != nil {
-			return nil, erro
+	for _, resultado := range resultados {
+		// Ignora o usuário que está fazendo a busca
+		if resultado.ID == usuarioID {
+			continue
 		}
 
-		// Verifica se o usuário encontrado é o mesmo que está buscando
-		if usuario.ID == usuarioID {
-			continue // Ignora o usuário que está fazendo a busca
-		}
-
-		// Verifica a conexão entre os usuários
-		var segueVoce, voceSegue bool
-		repoErro := repositorio.db.QueryRow(
-			`SELECT EXISTS(SELECT 1 FROM seguidores WHERE seguidor_id = $1 AND usuario_id = $2)`, usuario.ID, usuarioID,
-		).Scan(&segueVoce)
-		if repoErro != nil {
-			return nil, repoErro
-		}
-
-		repoErro = repositorio.db.QueryRow(
-			`SELECT EXISTS(SELECT 1 FROM seguidores WHERE seguidor_id = $1 AND usuario_id = $2)`, usuarioID, usuario.ID,
-		).Scan(&voceSegue)
-		if repoErro != nil {
-			return nil, repoErro
-		}
-
-		// Define a conexão
-		if segueVoce && voceSegue {
-			usuario.Conexao = "se seguem"
-		} else if segueVoce {
-			usuario.Conexao = "segue você"
-		} else if voceSegue {
-			usuario.Conexao = "seguindo"
-		} else {
-			usuario.Conexao = "nenhuma"
+		usuario := resultado.Usuario
+		if usuario.Conexao, erro = repositorio.conexao(ctx, usuario.ID, usuarioID); erro != nil {
+			return nil, erro
 		}
 
 		usuarios = append(usuarios, usuario)
 	}
 
-	// Retornar a lista de usuários encontrados (ou lista vazia se não encontrar)
 	return usuarios, nil
 }
 
 // Atualizar altera as informações de um usuário no banco de dados
-func (repositorio Usuarios) Atualizar(ID uint64, usuario modelos.Usuario) error {
-	statement, erro := repositorio.db.Prepare(
-		"update usuarios set nome = $1, nick = $2, email = $3 where id = $4",
+func (repositorio Usuarios) Atualizar(ctx context.Context, ID uint64, usuario modelos.Usuario) error {
+	statement, erro := repositorio.db.PrepareContext(ctx,
+		"update usuarios set nome = $1, nick = $2, email = $3, privado = $6 where id = $4 and org_id = $5",
 	)
 	if erro != nil {
 		return erro
 	}
 	defer statement.Close()
 
-	if _, erro = statement.Exec(usuario.Nome, usuario.Nick, usuario.Email, ID); erro != nil {
+	if _, erro = statement.ExecContext(ctx, usuario.Nome, usuario.Nick, usuario.Email, ID, repositorio.orgID, usuario.Privado); erro != nil {
 		return erro
 	}
 
 	return nil
 }
 
-// Deletar exclui as informações de um usuário no banco de dados
-func (repositorio Usuarios) Deletar(ID uint64) error {
-	statement, erro := repositorio.db.Prepare("delete from usuarios where id = $1")
-	if erro != nil {
-		return erro
-	}
-	defer statement.Close()
-
-	if _, erro = statement.Exec(ID); erro != nil {
-		return erro
-	}
-
-	return nil
+// Deletar marca a conta como excluída. Ela some das buscas e pode ser restaurada com um login
+// até o fim da carência; depois disso o expurgo apaga a linha de vez (ver Contas.ExpurgarExcluidas).
+func (repositorio Usuarios) Deletar(ctx context.Context, ID uint64) error {
+	return repositorio.alterarSituacao(ctx,
+		"update usuarios set excluidoEm = now() where id = $1 and org_id = $2 and excluidoEm is null", ID,
+	)
 }
 
 // BuscarPorEmail busca um usuário por email e retorna o seu id e senha com hash
-func (repositorio Usuarios) BuscarPorEmail(email string) (modelos.Usuario, error) {
+func (repositorio Usuarios) BuscarPorEmail(ctx context.Context, email string) (modelos.Usuario, error) {
 	var usuario modelos.Usuario
 
 	// Usar QueryRow para otimizar e buscar apenas um resultado
-	linha := repositorio.db.QueryRow("select id, senha from usuarios where email = $1", email)
+	linha := repositorio.db.QueryRowContext(ctx, "select id, senha from usuarios where email = $1 and org_id = $2", email, repositorio.orgID)
 
 	// Verifica se houve erro durante o Scan ou se não foi encontrado nenhum usuário
 	if err := linha.Scan(&usuario.ID, &usuario.Senha); err != nil {
 		if err == sql.ErrNoRows {
 			// Se não encontrar o usuário, retornar um erro específico
-			return modelos.Usuario{}, fmt.Errorf("usuário com email %s não encontrado", email)
+			return modelos.Usuario{}, fmt.Errorf("usuário com email %s %w", email, ErrUsuarioNaoEncontrado)
 		}
 		// Retorna outros erros que possam ocorrer durante o Scan
 		return modelos.Usuario{}, err
@@ -244,200 +262,214 @@ func (repositorio Usuarios) BuscarPorEmail(email string) (modelos.Usuario, error
 	return usuario, nil
 }
 
-// Seguir permite que um usuário siga outro e retorna o usuário seguido com a conexão atualizada
-func (repositorio Usuarios) Seguir(usuarioID, seguidorID uint64) ([]modelos.Usuario, error) {
+// ExisteNick informa se já existe um usuário com o nick informado
+func (repositorio Usuarios) ExisteNick(ctx context.Context, nick string) (bool, error) {
+	var existe bool
+	erro := repositorio.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM usuarios WHERE nick = $1 AND org_id = $2)", nick, repositorio.orgID).Scan(&existe)
+	return existe, erro
+}
+
+// Seguir registra que seguidorID passou a seguir usuarioID e retorna o usuário seguido com a conexão atualizada.
+// Se a conta for privada, cria um pedido pendente e a conexão retornada é ConexaoSolicitada.
+func (repositorio Usuarios) Seguir(ctx context.Context, usuarioID, seguidorID uint64) ([]modelos.Usuario, error) {
 	// Validar se o usuário não está tentando seguir a si mesmo
 	if usuarioID == seguidorID {
 		return nil, fmt.Errorf("um usuário não pode seguir a si mesmo")
 	}
 
-	// Iniciar uma transação
-	tx, erro := repositorio.db.Begin()
-	if erro != nil {
-		return nil, fmt.Errorf("erro ao iniciar transação: %v", erro)
+	if erro := repositorio.verificarMesmaOrganizacao(ctx, usuarioID, seguidorID); erro != nil {
+		return nil, erro
 	}
-	defer tx.Rollback()
 
-	// Verificar se o seguidor já segue o usuário
-	var count int
-	erro = tx.QueryRow("SELECT COUNT(*) FROM seguindo WHERE usuario_id = $1 AND seguindo_id = $2", usuarioID, seguidorID).Scan(&count)
+	bloqueado, erro := repositorio.Bloqueado(ctx, usuarioID, seguidorID)
 	if erro != nil {
-		return nil, fmt.Errorf("erro ao verificar se já segue: %v", erro)
+		return nil, erro
 	}
-
-	if count > 0 {
-		return nil, fmt.Errorf("o usuário %d já segue o usuário %d", seguidorID, usuarioID)
+	if bloqueado {
+		return nil, ErrUsuarioBloqueado
 	}
 
-	// Inserir na tabela "seguindo"
-	_, erro = tx.Exec(
-		"INSERT INTO seguindo (usuario_id, seguindo_id) VALUES ($1, $2)",
-		usuarioID, seguidorID,
-	)
+	usuario, erro := repositorio.BuscarPorID(ctx, usuarioID)
 	if erro != nil {
-		return nil, fmt.Errorf("erro ao inserir na tabela 'seguindo': %v", erro)
+		return nil, erro
+	}
+
+	if usuario.Privado {
+		return repositorio.solicitarSeguir(ctx, usuario, seguidorID)
 	}
 
-	// Inserir na tabela "seguidores"
-	_, erro = tx.Exec(
-		"INSERT INTO seguidores (usuario_id, seguidor_id) VALUES ($1, $2)",
+	// Em "seguindo", usuario_id é quem é seguido e seguindo_id é o seguidor
+	resultado, erro := repositorio.db.ExecContext(ctx,
+		"INSERT INTO seguindo (usuario_id, seguindo_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
 		usuarioID, seguidorID,
 	)
 	if erro != nil {
-		return nil, fmt.Errorf("erro ao inserir na tabela 'seguidores': %v", erro)
-	}
-
-	// Confirmar a transação
-	if erro = tx.Commit(); erro != nil {
-		return nil, fmt.Errorf("erro ao confirmar transação: %v", erro)
-	}
-
-	// Buscar os dados do usuário seguido
-	linha := repositorio.db.QueryRow(`SELECT id, nome, nick, email, criadoEm FROM usuarios WHERE id = $1`, usuarioID)
-
-	var usuario modelos.Usuario
-	if erro = linha.Scan(&usuario.ID, &usuario.Nome, &usuario.Nick, &usuario.Email, &usuario.CriadoEm); erro != nil {
-		return nil, fmt.Errorf("erro ao buscar dados do usuário seguido: %v", erro)
+		return nil, fmt.Errorf("erro ao inserir na tabela 'seguindo': %v", erro)
 	}
 
-	// **Verifica a conexão entre os usuários APÓS a transação**
-	var segueVoce, voceSegue bool
-	repoErro := repositorio.db.QueryRow(
-		`SELECT EXISTS(SELECT 1 FROM seguidores WHERE seguidor_id = $1 AND usuario_id = $2)`, usuario.ID, seguidorID,
-	).Scan(&segueVoce)
-	if repoErro != nil {
-		return nil, repoErro
-	}
-
-	repoErro = repositorio.db.QueryRow(
-		`SELECT EXISTS(SELECT 1 FROM seguidores WHERE seguidor_id = $1 AND usuario_id = $2)`, seguidorID, usuario.ID,
-	).Scan(&voceSegue)
-	if repoErro != nil {
-		return nil, repoErro
-	}
-
-	// Atualiza a conexão do usuário
-	if segueVoce && voceSegue {
-		usuario.Conexao = "se seguem"
-	} else if segueVoce {
-		usuario.Conexao = "segue você"
-	} else if voceSegue {
-		usuario.Conexao = "seguindo"
-	} else {
-		usuario.Conexao = "nenhuma"
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 {
+		return nil, fmt.Errorf("o usuário %d já segue o usuário %d", seguidorID, usuarioID)
 	}
 
-	// Retorna o usuário seguido com a conexão atualizada
-	return []modelos.Usuario{usuario}, nil
+	return repositorio.usuarioComConexao(ctx, usuarioID, seguidorID)
 }
 
-// PararDeSeguir permite que um usuário pare de seguir outro
-func (repositorio Usuarios) PararDeSeguir(usuarioID, seguidorID uint64) ([]modelos.Usuario, error) {
+// PararDeSeguir remove o vínculo em que seguidorID segue usuarioID
+func (repositorio Usuarios) PararDeSeguir(ctx context.Context, usuarioID, seguidorID uint64) ([]modelos.Usuario, error) {
 	// Validar se o seguidor não está tentando parar de seguir a si mesmo
 	if usuarioID == seguidorID {
 		return nil, fmt.Errorf("um usuário não pode parar de seguir a si mesmo")
 	}
 
-	// Iniciar uma transação
-	tx, erro := repositorio.db.Begin()
-	if erro != nil {
-		return nil, fmt.Errorf("erro ao iniciar transação: %v", erro)
+	if erro := repositorio.verificarMesmaOrganizacao(ctx, usuarioID, seguidorID); erro != nil {
+		return nil, erro
 	}
-	defer tx.Rollback()
 
-	// Verificar se a relação já existe antes de tentar excluir
-	var count int
-	erro = tx.QueryRow("SELECT COUNT(*) FROM seguindo WHERE usuario_id = $1 AND seguindo_id = $2", usuarioID, seguidorID).Scan(&count)
+	resultado, erro := repositorio.db.ExecContext(ctx,
+		"DELETE FROM seguindo WHERE usuario_id = $1 AND seguindo_id = $2",
+		usuarioID, seguidorID,
+	)
 	if erro != nil {
-		return nil, fmt.Errorf("erro ao verificar se segue: %v", erro)
+		return nil, fmt.Errorf("erro ao remover da tabela 'seguindo': %v", erro)
 	}
 
-	if count == 0 {
+	// Deixar de seguir uma conta privada também cancela o pedido que ainda não foi aprovado
+	if linhas, _ := resultado.RowsAffected(); linhas == 0 && repositorio.RejeitarSolicitacao(ctx, usuarioID, seguidorID) != nil {
 		// Se o seguidor não segue o usuário, retornar uma mensagem informando
 		return nil, fmt.Errorf("o usuário %d não segue o usuário %d", seguidorID, usuarioID)
 	}
 
-	// Remover da tabela "seguindo" (indica que seguidorID não segue mais usuarioID)
-	_, erro = tx.Exec(
-		"DELETE FROM seguindo WHERE usuario_id = $1 AND seguindo_id = $2",
-		usuarioID, seguidorID,
+	return repositorio.usuarioComConexao(ctx, usuarioID, seguidorID)
+}
+
+// BuscarSeguidores traz uma página dos seguidores de um usuário
+func (repositorio Usuarios) BuscarSeguidores(ctx context.Context, usuarioID uint64, pagina paginacao.Pagina) ([]modelos.Usuario, *paginacao.Cursor, error) {
+	return repositorio.buscarNoGrafo(ctx, "u.id = s.seguindo_id AND s.usuario_id = $1", usuarioID, pagina)
+}
+
+// BuscarSeguindo traz uma página dos usuários que um determinado usuário está seguindo
+func (repositorio Usuarios) BuscarSeguindo(ctx context.Context, usuarioID uint64, pagina paginacao.Pagina) ([]modelos.Usuario, *paginacao.Cursor, error) {
+	return repositorio.buscarNoGrafo(ctx, "u.id = s.usuario_id AND s.seguindo_id = $1", usuarioID, pagina)
+}
+
+// buscarNoGrafo lista os usuários ligados a usuarioID em "seguindo" conforme a junção informada
+func (repositorio Usuarios) buscarNoGrafo(ctx context.Context, juncao string, usuarioID uint64, pagina paginacao.Pagina) ([]modelos.Usuario, *paginacao.Cursor, error) {
+	condicaoDoCursor, chave, ordenacao, argumentosDaPagina := pagina.SQL(colunasDoGrafo, "u.id", 3)
+
+	linhas, erro := repositorio.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT u.id, u.nome, u.nick, u.email, u.criadoEm, %s
+		FROM usuarios u
+		INNER JOIN seguindo s ON %s
+		WHERE u.org_id = $2 AND %s AND %s
+		%s`, chave, juncao, contaAtiva("u"), condicaoDoCursor, ordenacao),
+		append([]interface{}{usuarioID, repositorio.orgID}, argumentosDaPagina...)...,
 	)
 	if erro != nil {
-		return nil, fmt.Errorf("erro ao remover da tabela 'seguindo': %v", erro)
+		return nil, nil, erro
 	}
+	defer linhas.Close()
 
-	// Remover da tabela "seguidores" (indica que usuarioID não tem mais seguidorID como seguidor)
-	_, erro = tx.Exec(
-		"DELETE FROM seguidores WHERE usuario_id = $1 AND seguidor_id = $2",
-		usuarioID, seguidorID,
-	)
+	return escanearPagina(linhas, pagina)
+}
+
+// QuantidadeSeguindo retorna a quantidade de usuários ativos que um usuário está seguindo
+func (repositorio Usuarios) QuantidadeSeguindo(ctx context.Context, usuarioID uint64) (int, error) {
+	var quantidadeSeguindo int
+	erro := repositorio.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM seguindo s
+		INNER JOIN usuarios u ON u.id = s.usuario_id
+		WHERE s.seguindo_id = $1 AND u.org_id = $2 AND `+contaAtiva("u"), usuarioID, repositorio.orgID).Scan(&quantidadeSeguindo)
 	if erro != nil {
-		return nil, fmt.Errorf("erro ao remover da tabela 'seguidores': %v", erro)
+		return 0, erro
 	}
 
-	// Confirmar a transação
-	if erro = tx.Commit(); erro != nil {
-		return nil, fmt.Errorf("erro ao confirmar transação: %v", erro)
+	return quantidadeSeguindo, nil
+}
+
+// QuantidadeSeguidores retorna a quantidade de seguidores ativos de um usuário
+func (repositorio Usuarios) QuantidadeSeguidores(ctx context.Context, usuarioID uint64) (int, error) {
+	var quantidadeSeguidores int
+	erro := repositorio.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM seguindo s
+		INNER JOIN usuarios u ON u.id = s.seguindo_id
+		WHERE s.usuario_id = $1 AND u.org_id = $2 AND `+contaAtiva("u"), usuarioID, repositorio.orgID).Scan(&quantidadeSeguidores)
+	if erro != nil {
+		return 0, erro
 	}
 
-	// Buscar os dados do usuário seguido
-	linha := repositorio.db.QueryRow(`SELECT id, nome, nick, email, criadoEm FROM usuarios WHERE id = $1`, usuarioID)
+	return quantidadeSeguidores, nil
+}
 
-	var usuario modelos.Usuario
-	if erro = linha.Scan(&usuario.ID, &usuario.Nome, &usuario.Nick, &usuario.Email, &usuario.CriadoEm); erro != nil {
-		return nil, fmt.Errorf("erro ao buscar dados do usuário seguido: %v", erro)
+// solicitarSeguir registra o pedido para seguir uma conta privada
+func (repositorio Usuarios) solicitarSeguir(ctx context.Context, usuario modelos.Usuario, seguidorID uint64) ([]modelos.Usuario, error) {
+	conexao, erro := repositorio.conexao(ctx, usuario.ID, seguidorID)
+	if erro != nil {
+		return nil, erro
+	}
+	if conexao == "seguindo" || conexao == "se seguem" {
+		return nil, fmt.Errorf("o usuário %d já segue o usuário %d", seguidorID, usuario.ID)
 	}
 
-	// **Verifica a conexão entre os usuários APÓS a transação**
-	var segueVoce, voceSegue bool
-	repoErro := repositorio.db.QueryRow(
-		`SELECT EXISTS(SELECT 1 FROM seguidores WHERE seguidor_id = $1 AND usuario_id = $2)`, usuario.ID, seguidorID,
-	).Scan(&segueVoce)
-	if repoErro != nil {
-		return nil, repoErro
-	}
-
-	repoErro = repositorio.db.QueryRow(
-		`SELECT EXISTS(SELECT 1 FROM seguidores WHERE seguidor_id = $1 AND usuario_id = $2)`, seguidorID, usuario.ID,
-	).Scan(&voceSegue)
-	if repoErro != nil {
-		return nil, repoErro
-	}
-
-	// Atualiza a conexão do usuário
-	if segueVoce && voceSegue {
-		usuario.Conexao = "se seguem"
-	} else if segueVoce {
-		usuario.Conexao = "segue você"
-	} else if voceSegue {
-		usuario.Conexao = "seguindo"
-	} else {
-		usuario.Conexao = "nenhuma"
+	if _, erro = repositorio.db.ExecContext(ctx,
+		"INSERT INTO solicitacoes_seguir (usuario_id, seguidor_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		usuario.ID, seguidorID,
+	); erro != nil {
+		return nil, erro
 	}
 
+	usuario.Conexao = ConexaoSolicitada
 	return []modelos.Usuario{usuario}, nil
 }
 
-// BuscarSeguidores traz todos os seguidores de um usuário
-func (repositorio Usuarios) BuscarSeguidores(usuarioID uint64) ([]modelos.Usuario, error) {
-	// Buscar seguidores do usuário (quem está seguindo o usuário)
-	linhas, erro := repositorio.db.Query(`
-		SELECT u.id, u.nome, u.nick, u.email, u.criadoEm
-		FROM usuarios u 
-		INNER JOIN seguidores s ON u.id = s.seguidor_id 
-		WHERE s.usuario_id = $1`, usuarioID,
-	)
+// usuarioComConexao busca o usuário e descreve a conexão dele com quem está vendo
+func (repositorio Usuarios) usuarioComConexao(ctx context.Context, usuarioID, visitanteID uint64) ([]modelos.Usuario, error) {
+	usuario, erro := repositorio.BuscarPorID(ctx, usuarioID)
+	if erro != nil {
+		return nil, fmt.Errorf("erro ao buscar dados do usuário seguido: %w", erro)
+	}
+
+	usuario.Conexao, erro = repositorio.conexao(ctx, usuario.ID, visitanteID)
 	if erro != nil {
-		fmt.Println("Erro ao executar query:", erro)
 		return nil, erro
 	}
-	defer linhas.Close()
 
+	return []modelos.Usuario{usuario}, nil
+}
+
+// conexao descreve, do ponto de vista de visitanteID, como os dois usuários se seguem
+func (repositorio Usuarios) conexao(ctx context.Context, usuarioID, visitanteID uint64) (string, error) {
+	var segueVoce, voceSegue bool
+	erro := repositorio.db.QueryRowContext(ctx, `
+		SELECT
+			EXISTS(SELECT 1 FROM seguindo WHERE usuario_id = $2 AND seguindo_id = $1),
+			EXISTS(SELECT 1 FROM seguindo WHERE usuario_id = $1 AND seguindo_id = $2)`,
+		usuarioID, visitanteID,
+	).Scan(&segueVoce, &voceSegue)
+	if erro != nil {
+		return "", erro
+	}
+
+	switch {
+	case segueVoce && voceSegue:
+		return "se seguem", nil
+	case segueVoce:
+		return "segue você", nil
+	case voceSegue:
+		return "seguindo", nil
+	default:
+		return "nenhuma", nil
+	}
+}
+
+// escanearUsuarios lê as linhas no formato id, nome, nick, email, criadoEm
+func escanearUsuarios(linhas *sql.Rows) ([]modelos.Usuario, error) {
 	var usuarios []modelos.Usuario
 	for linhas.Next() {
 		var usuario modelos.Usuario
 
-		if erro = linhas.Scan(
+		if erro := linhas.Scan(
 			&usuario.ID,
 			&usuario.Nome,
 			&usuario.Nick,
@@ -450,81 +482,49 @@ func (repositorio Usuarios) BuscarSeguidores(usuarioID uint64) ([]modelos.Usuari
 		usuarios = append(usuarios, usuario)
 	}
 
-	return usuarios, nil
+	return usuarios, linhas.Err()
 }
 
-// BuscarSeguindo traz todos os usuários que um determinado usuário está seguindo
-func (repositorio Usuarios) BuscarSeguindo(usuarioID uint64) ([]modelos.Usuario, error) {
-	linhas, erro := repositorio.db.Query(`
-		SELECT u.id, u.nome, u.nick, u.email, u.criadoEm
-		FROM usuarios u 
-		INNER JOIN seguindo s ON u.id = s.usuario_id 
-		WHERE s.seguindo_id = $1`, usuarioID,
-	)
-	if erro != nil {
-		return nil, erro
-	}
-	defer linhas.Close()
-
-	var usuarios []modelos.Usuario
+// escanearPagina lê as linhas no formato id, nome, nick, email, criadoEm, chave da ordenação
+// e separa a linha extra pedida pela paginação para gerar o próximo cursor
+func escanearPagina(linhas *sql.Rows, pagina paginacao.Pagina) ([]modelos.Usuario, *paginacao.Cursor, error) {
+	usuarios := []modelos.Usuario{}
+	var chaves []string
+	var IDs []uint64
 
 	for linhas.Next() {
-		var usuario modelos.Usuario
+		var (
+			usuario modelos.Usuario
+			chave   string
+		)
 
-		if erro = linhas.Scan(
+		if erro := linhas.Scan(
 			&usuario.ID,
 			&usuario.Nome,
 			&usuario.Nick,
 			&usuario.Email,
 			&usuario.CriadoEm,
+			&chave,
 		); erro != nil {
-			return nil, erro
+			return nil, nil, erro
 		}
 
 		usuarios = append(usuarios, usuario)
+		chaves = append(chaves, chave)
+		IDs = append(IDs, usuario.ID)
 	}
-
-	return usuarios, nil
-}
-
-// QuantidadeSeguindo retorna a quantidade de usuários que um usuário está seguindo
-func (repositorio Usuarios) QuantidadeSeguindo(usuarioID uint64) (int, error) {
-	// Contar o número de pessoas que o usuário está seguindo
-	var quantidadeSeguindo int
-	erro := repositorio.db.QueryRow(`
-		SELECT COUNT(*)
-		FROM seguindo s
-		WHERE s.seguindo_id = $1`, usuarioID).Scan(&quantidadeSeguindo)
-
-	if erro != nil {
-		fmt.Println("Erro ao executar query:", erro)
-		return 0, erro
-	}
-
-	return quantidadeSeguindo, nil
-}
-
-// QuantidadeSeguidores retorna a quantidade de seguidores de um usuário
-func (repositorio Usuarios) QuantidadeSeguidores(usuarioID uint64) (int, error) {
-	// Contar o número de seguidores de um usuário
-	var quantidadeSeguidores int
-	erro := repositorio.db.QueryRow(`
-		SELECT COUNT(*)
-		FROM seguidores s
-		WHERE s.usuario_id = $1`, usuarioID).Scan(&quantidadeSeguidores)
-
-	if erro != nil {
-		fmt.Println("Erro ao executar query:", erro)
-		return 0, erro
+	if erro := linhas.Err(); erro != nil {
+		return nil, nil, erro
 	}
 
-	return quantidadeSeguidores, nil
+	quantidade, proximo := pagina.Proximo(chaves, IDs)
+	return usuarios[:quantidade], proximo, nil
 }
 
 // BuscarSenha traz a senha de um usuário pelo ID
-func (repositorio Usuarios) BuscarSenha(usuarioID uint64) (string, error) {
+func (repositorio Usuarios) BuscarSenha(ctx context.Context, usuarioID uint64) (string, error) {
 	// Consultar a senha do usuário pelo ID
-	linha, erro := repositorio.db.Query("select senha from usuarios where id = $1", usuarioID)
+	linha, erro := repositorio.db.QueryContext(ctx, "select senha from usuarios where id = $1 and org_id = $2", usuarioID, repositorio.orgID)
 	if erro != nil {
 		return "", fmt.Errorf("erro ao executar consulta: %v", erro)
 	}
@@ -548,16 +548,16 @@ func (repositorio Usuarios) BuscarSenha(usuarioID uint64) (string, error) {
 }
 
 // AtualizarSenha altera a senha de um usuário no banco de dados
-func (repositorio Usuarios) AtualizarSenha(usuarioID uint64, senha string) error {
+func (repositorio Usuarios) AtualizarSenha(ctx context.Context, usuarioID uint64, senha string) error {
 	// Preparar a query de atualização
-	statement, erro := repositorio.db.Prepare("update usuarios set senha = $1 where id = $2")
+	statement, erro := repositorio.db.PrepareContext(ctx, "update usuarios set senha = $1 where id = $2 and org_id = $3")
 	if erro != nil {
 		return fmt.Errorf("erro ao preparar consulta para atualização de senha: %v", erro)
 	}
 	defer statement.Close()
 
 	// Executar a atualização da senha
-	if _, erro = statement.Exec(senha, usuarioID); erro != nil {
+	if _, erro = statement.ExecContext(ctx, senha, usuarioID, repositorio.orgID); erro != nil {
 		return fmt.Errorf("erro ao executar atualização de senha: %v", erro)
 	}
 
@@ -565,18 +565,35 @@ func (repositorio Usuarios) AtualizarSenha(usuarioID uint64, senha string) error
 }
 
 // NovaSenha altera a senha de um usuário no banco de dados
-func (repositorio Usuarios) NovaSenha(usuarioID uint64, senha string) error {
+func (repositorio Usuarios) NovaSenha(ctx context.Context, usuarioID uint64, senha string) error {
 	// Preparar a query de atualização
-	statement, erro := repositorio.db.Prepare("update usuarios set senha = $1 where id = $2")
+	statement, erro := repositorio.db.PrepareContext(ctx, "update usuarios set senha = $1 where id = $2 and org_id = $3")
 	if erro != nil {
 		return fmt.Errorf("erro ao preparar consulta para atualização de senha: %v", erro)
 	}
 	defer statement.Close()
 
 	// Executar a atualização da senha
-	if _, erro = statement.Exec(senha, usuarioID); erro != nil {
+	if _, erro = statement.ExecContext(ctx, senha, usuarioID, repositorio.orgID); erro != nil {
 		return fmt.Errorf("erro ao executar atualização de senha: %v", erro)
 	}
 
 	return nil
 }
+
+// verificarMesmaOrganizacao garante que os dois usuários existem e pertencem à organização do repositório
+func (repositorio Usuarios) verificarMesmaOrganizacao(ctx context.Context, usuarioID, outroID uint64) error {
+	var quantidade int
+	erro := repositorio.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM usuarios WHERE id IN ($1, $2) AND org_id = $3",
+		usuarioID, outroID, repositorio.orgID,
+	).Scan(&quantidade)
+	if erro != nil {
+		return erro
+	}
+
+	if quantidade != 2 {
+		return fmt.Errorf("usuário %w", ErrUsuarioNaoEncontrado)
+	}
+	return nil
+}
@@ -0,0 +1,662 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/paginacao"
+	"api/src/testes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestUsuariosEmMemoria(t *testing.T) {
+	testarRepositorioDeUsuarios(t, NovaFabricaDeUsuariosEmMemoria())
+}
+
+func TestUsuariosNoPostgres(t *testing.T) {
+	db := testes.BancoDeDados(t)
+
+	// A suíte usa organizações numeradas a partir de 1; cada número vira uma organização nova no banco
+	var mutex sync.Mutex
+	organizacoes := map[uint64]uint64{}
+	testarRepositorioDeUsuarios(t, func(orgID uint64) RepositorioUsuarios {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if _, ok := organizacoes[orgID]; !ok {
+			organizacoes[orgID] = testes.NovaOrganizacao(t, db)
+		}
+		return NovoRepositorioDeUsuarios(db, organizacoes[orgID])
+	})
+}
+
+// testarRepositorioDeUsuarios é o contrato de RepositorioUsuarios: toda implementação precisa passar por ele.
+// Cada caso recebe organizações que nenhum outro caso usou.
+func testarRepositorioDeUsuarios(t *testing.T, fabrica FabricaDeUsuarios) {
+	var ultimaOrganizacao uint64
+	casos := []struct {
+		nome   string
+		testar func(t *testing.T, novaOrganizacao func() RepositorioUsuarios)
+	}{
+		{"cadastro e perfil", contratoCadastro},
+		{"isolamento entre organizações", contratoIsolamento},
+		{"senha", contratoSenha},
+		{"situação da conta", contratoSituacao},
+		{"seguidores", contratoSeguidores},
+		{"conta privada", contratoContaPrivada},
+		{"bloqueio e silêncio", contratoBloqueio},
+		{"busca", contratoBusca},
+		{"paginação", contratoPaginacao},
+		{"sugestões", contratoSugestoes},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			caso.testar(t, func() RepositorioUsuarios {
+				ultimaOrganizacao++
+				return fabrica(ultimaOrganizacao)
+			})
+		})
+	}
+}
+
+var ctx = context.Background()
+
+// criar cadastra um usuário com nome, e-mail e senha derivados do nick
+func criar(t *testing.T, repositorio RepositorioUsuarios, nick, nome string) uint64 {
+	t.Helper()
+
+	ID, erro := repositorio.Criar(ctx, modelos.Usuario{Nome: nome, Nick: nick, Email: nick + "@exemplo.com", Senha: "hash-" + nick})
+	if erro != nil {
+		t.Fatalf("Criar(%s): %v", nick, erro)
+	}
+	if ID == 0 {
+		t.Fatalf("Criar(%s) retornou ID 0", nick)
+	}
+	return ID
+}
+
+func naoEncontrado(t *testing.T, erro error, operacao string) {
+	t.Helper()
+
+	if !errors.Is(erro, ErrUsuarioNaoEncontrado) {
+		t.Fatalf("%s: esperava ErrUsuarioNaoEncontrado, veio %v", operacao, erro)
+	}
+}
+
+// duplicado aceita as duas formas do contrato: ErrRegistroDuplicado ou a violação de unicidade do PostgreSQL
+func duplicado(erro error) bool {
+	var erroPostgres *pgconn.PgError
+	return errors.Is(erro, ErrRegistroDuplicado) || errors.As(erro, &erroPostgres) && erroPostgres.Code == "23505"
+}
+
+func nicks(usuarios []modelos.Usuario) []string {
+	lista := []string{}
+	for _, usuario := range usuarios {
+		lista = append(lista, usuario.Nick)
+	}
+	return lista
+}
+
+func iguais(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contratoCadastro(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	repositorio := novaOrganizacao()
+	ID := criar(t, repositorio, "ana", "Ana Souza")
+
+	usuario, erro := repositorio.BuscarPorID(ctx, ID)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if usuario.ID != ID || usuario.Nome != "Ana Souza" || usuario.Nick != "ana" || usuario.Email != "ana@exemplo.com" {
+		t.Fatalf("BuscarPorID trouxe %+v", usuario)
+	}
+	if usuario.Senha != "" {
+		t.Fatal("BuscarPorID não deve trazer a senha")
+	}
+	if usuario.CriadoEm.IsZero() {
+		t.Fatal("BuscarPorID deve trazer a data de criação")
+	}
+
+	_, erro = repositorio.BuscarPorID(ctx, ID+1000)
+	naoEncontrado(t, erro, "BuscarPorID de ID inexistente")
+
+	porEmail, erro := repositorio.BuscarPorEmail(ctx, "ana@exemplo.com")
+	if erro != nil || porEmail.ID != ID || porEmail.Senha != "hash-ana" {
+		t.Fatalf("BuscarPorEmail = %+v, %v", porEmail, erro)
+	}
+	_, erro = repositorio.BuscarPorEmail(ctx, "ninguem@exemplo.com")
+	naoEncontrado(t, erro, "BuscarPorEmail de e-mail inexistente")
+
+	if existe, erro := repositorio.ExisteNick(ctx, "ana"); erro != nil || !existe {
+		t.Fatalf("ExisteNick(ana) = %v, %v", existe, erro)
+	}
+	if existe, erro := repositorio.ExisteNick(ctx, "bia"); erro != nil || existe {
+		t.Fatalf("ExisteNick(bia) = %v, %v", existe, erro)
+	}
+
+	_, erro = repositorio.Criar(ctx, modelos.Usuario{Nome: "Outra Ana", Nick: "ana", Email: "outra@exemplo.com", Senha: "x"})
+	if !duplicado(erro) {
+		t.Fatalf("nick repetido: esperava registro duplicado, veio %v", erro)
+	}
+	_, erro = repositorio.Criar(ctx, modelos.Usuario{Nome: "Outra Ana", Nick: "ana2", Email: "ana@exemplo.com", Senha: "x"})
+	if !duplicado(erro) {
+		t.Fatalf("e-mail repetido: esperava registro duplicado, veio %v", erro)
+	}
+
+	if erro = repositorio.Atualizar(ctx, ID, modelos.Usuario{Nome: "Ana S.", Nick: "anas", Email: "anas@exemplo.com", Privado: true}); erro != nil {
+		t.Fatal(erro)
+	}
+	usuario, erro = repositorio.BuscarPorID(ctx, ID)
+	if erro != nil || usuario.Nome != "Ana S." || usuario.Nick != "anas" || usuario.Email != "anas@exemplo.com" || !usuario.Privado {
+		t.Fatalf("depois de Atualizar, BuscarPorID = %+v, %v", usuario, erro)
+	}
+
+	outroID := criar(t, repositorio, "bia", "Bia")
+	erro = repositorio.Atualizar(ctx, outroID, modelos.Usuario{Nome: "Bia", Nick: "anas", Email: "bia@exemplo.com"})
+	if !duplicado(erro) {
+		t.Fatalf("Atualizar para nick em uso: esperava registro duplicado, veio %v", erro)
+	}
+}
+
+func contratoIsolamento(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	primeira, segunda := novaOrganizacao(), novaOrganizacao()
+	ID := criar(t, primeira, "ana", "Ana")
+
+	// Nick e e-mail são únicos só dentro da organização
+	outroID := criar(t, segunda, "ana", "Ana da outra")
+
+	_, erro := segunda.BuscarPorID(ctx, ID)
+	naoEncontrado(t, erro, "BuscarPorID de usuário de outra organização")
+
+	if usuario, erro := segunda.BuscarPorEmail(ctx, "ana@exemplo.com"); erro != nil || usuario.ID != outroID {
+		t.Fatalf("BuscarPorEmail na segunda organização = %+v, %v", usuario, erro)
+	}
+
+	_, erro = primeira.Seguir(ctx, outroID, ID)
+	naoEncontrado(t, erro, "Seguir usuário de outra organização")
+
+	_, erro = segunda.BuscarSituacao(ctx, ID)
+	naoEncontrado(t, erro, "BuscarSituacao de usuário de outra organização")
+
+	resultados, _, erro := segunda.Buscar(ctx, modelos.FiltroDeUsuarios{}, outroID, paginacao.Pagina{Limite: 10, Campo: "nick"})
+	if erro != nil || len(resultados) != 1 || resultados[0].ID != outroID {
+		t.Fatalf("Buscar na segunda organização = %+v, %v", resultados, erro)
+	}
+}
+
+func contratoSenha(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	repositorio := novaOrganizacao()
+	ID := criar(t, repositorio, "ana", "Ana")
+
+	if senha, erro := repositorio.BuscarSenha(ctx, ID); erro != nil || senha != "hash-ana" {
+		t.Fatalf("BuscarSenha = %q, %v", senha, erro)
+	}
+
+	if erro := repositorio.AtualizarSenha(ctx, ID, "nova"); erro != nil {
+		t.Fatal(erro)
+	}
+	if senha, _ := repositorio.BuscarSenha(ctx, ID); senha != "nova" {
+		t.Fatalf("depois de AtualizarSenha, BuscarSenha = %q", senha)
+	}
+
+	if erro := repositorio.NovaSenha(ctx, ID, "redefinida"); erro != nil {
+		t.Fatal(erro)
+	}
+	if senha, _ := repositorio.BuscarSenha(ctx, ID); senha != "redefinida" {
+		t.Fatalf("depois de NovaSenha, BuscarSenha = %q", senha)
+	}
+
+	if _, erro := repositorio.BuscarSenha(ctx, ID+1000); erro == nil {
+		t.Fatal("BuscarSenha de ID inexistente deveria falhar")
+	}
+}
+
+func contratoSituacao(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	repositorio := novaOrganizacao()
+	ID := criar(t, repositorio, "ana", "Ana")
+	visitanteID := criar(t, repositorio, "bia", "Bia")
+
+	situacao, erro := repositorio.BuscarSituacao(ctx, ID)
+	if erro != nil || situacao.DesativadoEm != nil || situacao.ExcluidoEm != nil {
+		t.Fatalf("conta nova: BuscarSituacao = %+v, %v", situacao, erro)
+	}
+
+	if erro = repositorio.Desativar(ctx, ID); erro != nil {
+		t.Fatal(erro)
+	}
+	naoEncontrado(t, repositorio.Desativar(ctx, ID), "Desativar conta já desativada")
+	_, erro = repositorio.BuscarPorID(ctx, ID)
+	naoEncontrado(t, erro, "BuscarPorID de conta desativada")
+	if situacao, _ = repositorio.BuscarSituacao(ctx, ID); situacao.DesativadoEm == nil {
+		t.Fatal("BuscarSituacao deve trazer a data de desativação")
+	}
+
+	resultados, _, erro := repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{}, visitanteID, paginacao.Pagina{Limite: 10, Campo: "nick"})
+	if erro != nil || len(resultados) != 1 || resultados[0].ID != visitanteID {
+		t.Fatalf("Buscar não deve trazer contas desativadas: %+v, %v", resultados, erro)
+	}
+
+	if erro = repositorio.Reativar(ctx, ID); erro != nil {
+		t.Fatal(erro)
+	}
+	if _, erro = repositorio.BuscarPorID(ctx, ID); erro != nil {
+		t.Fatalf("BuscarPorID depois de Reativar: %v", erro)
+	}
+
+	if erro = repositorio.Deletar(ctx, ID); erro != nil {
+		t.Fatal(erro)
+	}
+	naoEncontrado(t, repositorio.Deletar(ctx, ID), "Deletar conta já excluída")
+	if situacao, _ = repositorio.BuscarSituacao(ctx, ID); situacao.ExcluidoEm == nil {
+		t.Fatal("BuscarSituacao deve trazer a data de exclusão")
+	}
+	_, erro = repositorio.BuscarPorID(ctx, ID)
+	naoEncontrado(t, erro, "BuscarPorID de conta excluída")
+
+	// A exclusão ainda na carência pode ser desfeita
+	if erro = repositorio.Reativar(ctx, ID); erro != nil {
+		t.Fatal(erro)
+	}
+	if situacao, _ = repositorio.BuscarSituacao(ctx, ID); situacao.ExcluidoEm != nil || situacao.DesativadoEm != nil {
+		t.Fatalf("depois de Reativar, BuscarSituacao = %+v", situacao)
+	}
+
+	naoEncontrado(t, repositorio.Reativar(ctx, ID+1000), "Reativar ID inexistente")
+}
+
+func contratoSeguidores(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	repositorio := novaOrganizacao()
+	anaID := criar(t, repositorio, "ana", "Ana")
+	biaID := criar(t, repositorio, "bia", "Bia")
+	caioID := criar(t, repositorio, "caio", "Caio")
+
+	if _, erro := repositorio.Seguir(ctx, anaID, anaID); erro == nil {
+		t.Fatal("seguir a si mesmo deveria falhar")
+	}
+
+	// Bia segue Ana
+	seguido, erro := repositorio.Seguir(ctx, anaID, biaID)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if len(seguido) != 1 || seguido[0].ID != anaID || seguido[0].Conexao != "seguindo" {
+		t.Fatalf("Seguir = %+v", seguido)
+	}
+	if _, erro = repositorio.Seguir(ctx, anaID, biaID); erro == nil {
+		t.Fatal("seguir duas vezes deveria falhar")
+	}
+
+	// Ana segue Bia de volta e Caio segue Ana
+	if seguido, erro = repositorio.Seguir(ctx, biaID, anaID); erro != nil || seguido[0].Conexao != "se seguem" {
+		t.Fatalf("Seguir de volta = %+v, %v", seguido, erro)
+	}
+	if _, erro = repositorio.Seguir(ctx, anaID, caioID); erro != nil {
+		t.Fatal(erro)
+	}
+
+	pagina := paginacao.Pagina{Limite: 10, Campo: "nick"}
+	seguidores, _, erro := repositorio.BuscarSeguidores(ctx, anaID, pagina)
+	if erro != nil || !iguais(nicks(seguidores), []string{"bia", "caio"}) {
+		t.Fatalf("BuscarSeguidores(ana) = %v, %v", nicks(seguidores), erro)
+	}
+	seguindo, _, erro := repositorio.BuscarSeguindo(ctx, caioID, pagina)
+	if erro != nil || !iguais(nicks(seguindo), []string{"ana"}) {
+		t.Fatalf("BuscarSeguindo(caio) = %v, %v", nicks(seguindo), erro)
+	}
+
+	if quantidade, erro := repositorio.QuantidadeSeguidores(ctx, anaID); erro != nil || quantidade != 2 {
+		t.Fatalf("QuantidadeSeguidores(ana) = %d, %v", quantidade, erro)
+	}
+	if quantidade, erro := repositorio.QuantidadeSeguindo(ctx, anaID); erro != nil || quantidade != 1 {
+		t.Fatalf("QuantidadeSeguindo(ana) = %d, %v", quantidade, erro)
+	}
+
+	// A busca por termo mostra a conexão do ponto de vista de quem busca e não traz quem busca
+	usuarios, erro := repositorio.BuscarPorTermo(ctx, "a", caioID)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	for _, usuario := range usuarios {
+		if usuario.ID == caioID {
+			t.Fatal("BuscarPorTermo não deve trazer quem está buscando")
+		}
+		if usuario.ID == anaID && usuario.Conexao != "seguindo" {
+			t.Fatalf("BuscarPorTermo: conexão de caio com ana = %q", usuario.Conexao)
+		}
+	}
+
+	if seguido, erro = repositorio.PararDeSeguir(ctx, anaID, caioID); erro != nil || seguido[0].Conexao != "nenhuma" {
+		t.Fatalf("PararDeSeguir = %+v, %v", seguido, erro)
+	}
+	if _, erro = repositorio.PararDeSeguir(ctx, anaID, caioID); erro == nil {
+		t.Fatal("parar de seguir quem não segue deveria falhar")
+	}
+	if quantidade, _ := repositorio.QuantidadeSeguidores(ctx, anaID); quantidade != 1 {
+		t.Fatalf("depois de PararDeSeguir, QuantidadeSeguidores(ana) = %d", quantidade)
+	}
+
+	// Seguidores com a conta desativada somem da lista
+	if erro = repositorio.Desativar(ctx, biaID); erro != nil {
+		t.Fatal(erro)
+	}
+	if seguidores, _, _ = repositorio.BuscarSeguidores(ctx, anaID, pagina); len(seguidores) != 0 {
+		t.Fatalf("BuscarSeguidores não deve trazer contas desativadas: %v", nicks(seguidores))
+	}
+
+	// e das contagens, dos dois lados do vínculo
+	if quantidade, erro := repositorio.QuantidadeSeguidores(ctx, anaID); erro != nil || quantidade != 0 {
+		t.Fatalf("QuantidadeSeguidores(ana) com a seguidora desativada = %d, %v", quantidade, erro)
+	}
+	if quantidade, erro := repositorio.QuantidadeSeguindo(ctx, anaID); erro != nil || quantidade != 0 {
+		t.Fatalf("QuantidadeSeguindo(ana) com a seguida desativada = %d, %v", quantidade, erro)
+	}
+}
+
+func contratoContaPrivada(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	repositorio := novaOrganizacao()
+	anaID := criar(t, repositorio, "ana", "Ana")
+	biaID := criar(t, repositorio, "bia", "Bia")
+	caioID := criar(t, repositorio, "caio", "Caio")
+
+	if erro := repositorio.Atualizar(ctx, anaID, modelos.Usuario{Nome: "Ana", Nick: "ana", Email: "ana@exemplo.com", Privado: true}); erro != nil {
+		t.Fatal(erro)
+	}
+	ana, _ := repositorio.BuscarPorID(ctx, anaID)
+
+	seguido, erro := repositorio.Seguir(ctx, anaID, biaID)
+	if erro != nil || seguido[0].Conexao != ConexaoSolicitada {
+		t.Fatalf("Seguir conta privada = %+v, %v", seguido, erro)
+	}
+	if _, erro = repositorio.Seguir(ctx, anaID, caioID); erro != nil {
+		t.Fatal(erro)
+	}
+	if quantidade, _ := repositorio.QuantidadeSeguidores(ctx, anaID); quantidade != 0 {
+		t.Fatalf("o pedido não deve contar como seguidor: %d", quantidade)
+	}
+
+	solicitacoes, erro := repositorio.BuscarSolicitacoes(ctx, anaID)
+	if erro != nil || !iguais(nicks(solicitacoes), []string{"bia", "caio"}) {
+		t.Fatalf("BuscarSolicitacoes = %v, %v", nicks(solicitacoes), erro)
+	}
+
+	if pode, erro := repositorio.PodeVerConexoes(ctx, ana, biaID); erro != nil || pode {
+		t.Fatalf("PodeVerConexoes antes da aprovação = %v, %v", pode, erro)
+	}
+	if pode, _ := repositorio.PodeVerConexoes(ctx, ana, anaID); !pode {
+		t.Fatal("a dona da conta sempre vê as próprias conexões")
+	}
+
+	if erro = repositorio.AprovarSolicitacao(ctx, anaID, biaID); erro != nil {
+		t.Fatal(erro)
+	}
+	if pode, _ := repositorio.PodeVerConexoes(ctx, ana, biaID); !pode {
+		t.Fatal("PodeVerConexoes depois da aprovação deveria ser true")
+	}
+	if erro = repositorio.AprovarSolicitacao(ctx, anaID, biaID); erro == nil {
+		t.Fatal("aprovar pedido inexistente deveria falhar")
+	}
+
+	if erro = repositorio.RejeitarSolicitacao(ctx, anaID, caioID); erro != nil {
+		t.Fatal(erro)
+	}
+	if erro = repositorio.RejeitarSolicitacao(ctx, anaID, caioID); erro == nil {
+		t.Fatal("rejeitar pedido inexistente deveria falhar")
+	}
+
+	if solicitacoes, _ = repositorio.BuscarSolicitacoes(ctx, anaID); len(solicitacoes) != 0 {
+		t.Fatalf("depois de aprovar e rejeitar, BuscarSolicitacoes = %v", nicks(solicitacoes))
+	}
+	if quantidade, _ := repositorio.QuantidadeSeguidores(ctx, anaID); quantidade != 1 {
+		t.Fatalf("depois da aprovação, QuantidadeSeguidores = %d", quantidade)
+	}
+
+	// Parar de seguir também cancela o pedido ainda não aprovado
+	if _, erro = repositorio.Seguir(ctx, anaID, caioID); erro != nil {
+		t.Fatal(erro)
+	}
+	if _, erro = repositorio.PararDeSeguir(ctx, anaID, caioID); erro != nil {
+		t.Fatalf("PararDeSeguir com pedido pendente: %v", erro)
+	}
+	if solicitacoes, _ = repositorio.BuscarSolicitacoes(ctx, anaID); len(solicitacoes) != 0 {
+		t.Fatalf("PararDeSeguir deveria cancelar o pedido: %v", nicks(solicitacoes))
+	}
+}
+
+func contratoBloqueio(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	repositorio := novaOrganizacao()
+	anaID := criar(t, repositorio, "ana", "Ana")
+	biaID := criar(t, repositorio, "bia", "Bia")
+	caioID := criar(t, repositorio, "caio", "Caio")
+
+	for _, par := range [][2]uint64{{anaID, biaID}, {biaID, anaID}, {anaID, caioID}} {
+		if _, erro := repositorio.Seguir(ctx, par[0], par[1]); erro != nil {
+			t.Fatal(erro)
+		}
+	}
+
+	if erro := repositorio.Bloquear(ctx, anaID, anaID); erro == nil {
+		t.Fatal("bloquear a si mesmo deveria falhar")
+	}
+
+	// O bloqueio desfaz os vínculos nos dois sentidos e vale para os dois lados
+	if erro := repositorio.Bloquear(ctx, anaID, biaID); erro != nil {
+		t.Fatal(erro)
+	}
+	for _, par := range [][2]uint64{{anaID, biaID}, {biaID, anaID}} {
+		if bloqueado, erro := repositorio.Bloqueado(ctx, par[0], par[1]); erro != nil || !bloqueado {
+			t.Fatalf("Bloqueado(%d, %d) = %v, %v", par[0], par[1], bloqueado, erro)
+		}
+	}
+	if quantidade, _ := repositorio.QuantidadeSeguidores(ctx, anaID); quantidade != 1 {
+		t.Fatalf("o bloqueio deveria desfazer o vínculo: QuantidadeSeguidores(ana) = %d", quantidade)
+	}
+	if _, erro := repositorio.Seguir(ctx, anaID, biaID); !errors.Is(erro, ErrUsuarioBloqueado) {
+		t.Fatalf("Seguir com bloqueio: esperava ErrUsuarioBloqueado, veio %v", erro)
+	}
+
+	pagina := paginacao.Pagina{Limite: 10, Campo: "nick"}
+	resultados, _, erro := repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{}, biaID, pagina)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	for _, resultado := range resultados {
+		if resultado.ID == anaID {
+			t.Fatal("quem foi bloqueado não deve encontrar quem bloqueou")
+		}
+	}
+
+	if erro = repositorio.Desbloquear(ctx, anaID, biaID); erro != nil {
+		t.Fatal(erro)
+	}
+	if erro = repositorio.Desbloquear(ctx, anaID, biaID); erro == nil {
+		t.Fatal("desbloquear quem não está bloqueado deveria falhar")
+	}
+	if bloqueado, _ := repositorio.Bloqueado(ctx, biaID, anaID); bloqueado {
+		t.Fatal("Bloqueado depois de Desbloquear deveria ser false")
+	}
+
+	// Silenciar esconde das buscas de quem silenciou, mas mantém o vínculo
+	if erro = repositorio.Silenciar(ctx, caioID, anaID); erro != nil {
+		t.Fatal(erro)
+	}
+	if erro = repositorio.Silenciar(ctx, caioID, caioID); erro == nil {
+		t.Fatal("silenciar a si mesmo deveria falhar")
+	}
+	resultados, _, _ = repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{}, caioID, pagina)
+	for _, resultado := range resultados {
+		if resultado.ID == anaID {
+			t.Fatal("quem silenciou não deve encontrar o silenciado na busca")
+		}
+	}
+	if quantidade, _ := repositorio.QuantidadeSeguindo(ctx, caioID); quantidade != 1 {
+		t.Fatalf("silenciar não deve desfazer o vínculo: QuantidadeSeguindo(caio) = %d", quantidade)
+	}
+
+	if erro = repositorio.Dessilenciar(ctx, caioID, anaID); erro != nil {
+		t.Fatal(erro)
+	}
+	if erro = repositorio.Dessilenciar(ctx, caioID, anaID); erro == nil {
+		t.Fatal("dessilenciar quem não está silenciado deveria falhar")
+	}
+}
+
+func contratoBusca(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	repositorio := novaOrganizacao()
+	joseID := criar(t, repositorio, "zezinho", "José Antônio")
+	criar(t, repositorio, "maria", "Maria Clara")
+	visitanteID := criar(t, repositorio, "visitante", "Visitante")
+
+	pagina := paginacao.Pagina{Limite: 10, Campo: "relevancia", Descendente: true}
+
+	// Sem acento e sem diferenciar maiúsculas
+	resultados, _, erro := repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{Termo: "JOSE"}, visitanteID, pagina)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if len(resultados) != 1 || resultados[0].ID != joseID {
+		t.Fatalf("Buscar(JOSE) = %+v", resultados)
+	}
+	if resultados[0].Destaques == nil || resultados[0].Destaques.Nome != "<mark>José</mark> Antônio" {
+		t.Fatalf("Buscar(JOSE) destaques = %+v", resultados[0].Destaques)
+	}
+
+	// Por similaridade, com um erro de digitação
+	resultados, _, erro = repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{Termo: "zezinhu"}, visitanteID, pagina)
+	if erro != nil || len(resultados) != 1 || resultados[0].ID != joseID || resultados[0].Relevancia <= 0 {
+		t.Fatalf("Buscar(zezinhu) = %+v, %v", resultados, erro)
+	}
+
+	// Os curingas do LIKE são comparados literalmente
+	if resultados, _, erro = repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{Termo: "%"}, visitanteID, pagina); erro != nil || len(resultados) != 0 {
+		t.Fatalf("Buscar(%%) = %+v, %v", resultados, erro)
+	}
+
+	if _, _, erro = repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{Termo: string(make([]byte, 101))}, visitanteID, pagina); !errors.Is(erro, ErrTermoMuitoLongo) {
+		t.Fatalf("termo com mais de 100 caracteres: erro = %v, quer ErrTermoMuitoLongo", erro)
+	}
+
+	// Relevância só existe quando há termo
+	if _, _, erro = repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{}, visitanteID, pagina); erro == nil {
+		t.Fatal("ordenar por relevância sem termo deveria ser recusado")
+	}
+}
+
+func contratoPaginacao(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	repositorio := novaOrganizacao()
+	var seguidorID uint64
+	for _, nick := range []string{"e", "c", "a", "d", "b"} {
+		ID := criar(t, repositorio, nick, "Usuário "+nick)
+		if seguidorID == 0 {
+			seguidorID = criar(t, repositorio, "z", "Seguidor")
+		}
+		if _, erro := repositorio.Seguir(ctx, ID, seguidorID); erro != nil {
+			t.Fatal(erro)
+		}
+	}
+
+	for _, caso := range []struct {
+		descendente bool
+		esperado    []string
+	}{
+		{false, []string{"a", "b", "c", "d", "e", "z"}},
+		{true, []string{"z", "e", "d", "c", "b", "a"}},
+	} {
+		var (
+			lidos  []string
+			cursor *paginacao.Cursor
+		)
+		for paginas := 0; paginas < 10; paginas++ {
+			pagina := paginacao.Pagina{Limite: 2, Campo: "nick", Descendente: caso.descendente, Cursor: cursor}
+			resultados, proximo, erro := repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{}, seguidorID, pagina)
+			if erro != nil {
+				t.Fatal(erro)
+			}
+			if len(resultados) > 2 {
+				t.Fatalf("página com %d itens, limite 2", len(resultados))
+			}
+			for _, resultado := range resultados {
+				lidos = append(lidos, resultado.Nick)
+			}
+			if cursor = proximo; cursor == nil {
+				break
+			}
+		}
+		if !iguais(lidos, caso.esperado) {
+			t.Fatalf("Buscar paginado (descendente=%v) = %v, esperado %v", caso.descendente, lidos, caso.esperado)
+		}
+	}
+
+	// A última página cheia não tem próximo cursor quando não sobra ninguém
+	seguindo, proximo, erro := repositorio.BuscarSeguindo(ctx, seguidorID, paginacao.Pagina{Limite: 5, Campo: "nick"})
+	if erro != nil || !iguais(nicks(seguindo), []string{"a", "b", "c", "d", "e"}) || proximo != nil {
+		t.Fatalf("BuscarSeguindo = %v, %v, %v", nicks(seguindo), proximo, erro)
+	}
+
+	seguindo, proximo, _ = repositorio.BuscarSeguindo(ctx, seguidorID, paginacao.Pagina{Limite: 3, Campo: "nick", Descendente: true})
+	if !iguais(nicks(seguindo), []string{"e", "d", "c"}) || proximo == nil {
+		t.Fatalf("BuscarSeguindo descendente = %v, %v", nicks(seguindo), proximo)
+	}
+	seguindo, proximo, _ = repositorio.BuscarSeguindo(ctx, seguidorID, paginacao.Pagina{Limite: 3, Campo: "nick", Descendente: true, Cursor: proximo})
+	if !iguais(nicks(seguindo), []string{"b", "a"}) || proximo != nil {
+		t.Fatalf("BuscarSeguindo descendente, segunda página = %v, %v", nicks(seguindo), proximo)
+	}
+}
+
+func contratoSugestoes(t *testing.T, novaOrganizacao func() RepositorioUsuarios) {
+	repositorio := novaOrganizacao()
+	anaID := criar(t, repositorio, "ana", "Ana")
+	biaID := criar(t, repositorio, "bia", "Bia")
+	caioID := criar(t, repositorio, "caio", "Caio")
+	duduID := criar(t, repositorio, "dudu", "Dudu")
+	eliID := criar(t, repositorio, "eli", "Eli")
+	fabiID := criar(t, repositorio, "fabi", "Fabi")
+
+	// Ana segue Bia e Caio; os dois seguem Dudu, só Bia segue Eli, e Caio segue Fabi, que Ana bloqueou
+	for _, par := range [][2]uint64{
+		{biaID, anaID}, {caioID, anaID},
+		{duduID, biaID}, {duduID, caioID},
+		{eliID, biaID}, {fabiID, caioID},
+		{anaID, biaID},
+	} {
+		if _, erro := repositorio.Seguir(ctx, par[0], par[1]); erro != nil {
+			t.Fatal(erro)
+		}
+	}
+	if erro := repositorio.Bloquear(ctx, anaID, fabiID); erro != nil {
+		t.Fatal(erro)
+	}
+
+	sugestoes, erro := repositorio.BuscarSugestoes(ctx, anaID, 10)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	if len(sugestoes) != 2 || sugestoes[0].ID != duduID || sugestoes[0].EmComum != 2 || sugestoes[1].ID != eliID || sugestoes[1].EmComum != 1 {
+		t.Fatalf("BuscarSugestoes = %+v", sugestoes)
+	}
+
+	if sugestoes, _ = repositorio.BuscarSugestoes(ctx, anaID, 1); len(sugestoes) != 1 {
+		t.Fatalf("BuscarSugestoes com limite 1 trouxe %d", len(sugestoes))
+	}
+
+	// Quem já é seguido deixa de ser sugerido
+	if _, erro = repositorio.Seguir(ctx, duduID, anaID); erro != nil {
+		t.Fatal(erro)
+	}
+	if sugestoes, _ = repositorio.BuscarSugestoes(ctx, anaID, 10); len(sugestoes) != 1 || sugestoes[0].ID != eliID {
+		t.Fatalf("depois de seguir Dudu, BuscarSugestoes = %+v", sugestoes)
+	}
+}
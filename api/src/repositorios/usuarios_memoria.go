@@ -0,0 +1,900 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/paginacao"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// limiarDeSimilaridade é o mesmo limite padrão do operador % do pg_trgm
+const limiarDeSimilaridade = 0.3
+
+// UsuariosEmMemoria guarda usuários, seguidores e relacionamentos em memória, com as mesmas regras do PostgreSQL.
+// Serve para rodar os controllers sem banco (testes e desenvolvimento); os dados somem quando o processo termina.
+type UsuariosEmMemoria struct {
+	memoria *memoriaDeUsuarios
+	orgID   uint64
+}
+
+// usuarioEmMemoria é a linha da tabela usuarios
+type usuarioEmMemoria struct {
+	modelos.Usuario
+	orgID    uint64
+	situacao modelos.SituacaoDaConta
+}
+
+// vinculo liga dois usuários na mesma ordem das colunas das tabelas (usuario_id, outro_id)
+type vinculo struct {
+	usuarioID, outroID uint64
+}
+
+// memoriaDeUsuarios é o armazenamento compartilhado pelos repositórios de todas as organizações de uma fábrica
+type memoriaDeUsuarios struct {
+	mutex    sync.RWMutex
+	ultimoID uint64
+	usuarios map[uint64]*usuarioEmMemoria
+
+	// Em seguindo, usuarioID é quem é seguido e outroID o seguidor; em solicitacoes, usuarioID é a conta privada.
+	// O valor é a data em que o vínculo foi criado.
+	seguindo     map[vinculo]time.Time
+	solicitacoes map[vinculo]time.Time
+	bloqueios    map[vinculo]time.Time
+	silenciados  map[vinculo]time.Time
+}
+
+// NovaFabricaDeUsuariosEmMemoria cria repositórios de usuários em memória que compartilham o mesmo armazenamento
+func NovaFabricaDeUsuariosEmMemoria() FabricaDeUsuarios {
+	memoria := &memoriaDeUsuarios{
+		usuarios:     map[uint64]*usuarioEmMemoria{},
+		seguindo:     map[vinculo]time.Time{},
+		solicitacoes: map[vinculo]time.Time{},
+		bloqueios:    map[vinculo]time.Time{},
+		silenciados:  map[vinculo]time.Time{},
+	}
+
+	return func(orgID uint64) RepositorioUsuarios {
+		return &UsuariosEmMemoria{memoria, orgID}
+	}
+}
+
+// Criar insere um usuário; nick e e-mail são únicos na organização, inclusive entre contas excluídas
+func (repositorio *UsuariosEmMemoria) Criar(ctx context.Context, usuario modelos.Usuario) (uint64, error) {
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	if erro := repositorio.verificarUnicidade(0, usuario); erro != nil {
+		return 0, erro
+	}
+
+	repositorio.memoria.ultimoID++
+	linha := &usuarioEmMemoria{orgID: repositorio.orgID}
+	linha.ID = repositorio.memoria.ultimoID
+	linha.Nome, linha.Nick, linha.Email, linha.Senha = usuario.Nome, usuario.Nick, usuario.Email, usuario.Senha
+	linha.CriadoEm = time.Now()
+	repositorio.memoria.usuarios[linha.ID] = linha
+
+	return linha.ID, nil
+}
+
+// Buscar traz uma página dos usuários que atendem ao filtro, sem os bloqueados e os silenciados pelo visitante.
+// O termo casa por trecho ou por similaridade de trigramas, como no pg_trgm.
+func (repositorio *UsuariosEmMemoria) Buscar(ctx context.Context, filtro modelos.FiltroDeUsuarios, visitanteID uint64, pagina paginacao.Pagina) ([]modelos.ResultadoDeBusca, *paginacao.Cursor, error) {
+	if len(filtro.Termo) > tamanhoMaximoDoTermo {
+		return nil, nil, fmt.Errorf("%w: até %d bytes", ErrTermoMuitoLongo, tamanhoMaximoDoTermo)
+	}
+
+	if _, ok := colunasDeUsuarios[pagina.Campo]; !ok && (pagina.Campo != "relevancia" || filtro.Termo == "") {
+		return nil, nil, fmt.Errorf("ordenação por %s indisponível nesta busca", pagina.Campo)
+	}
+
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	var (
+		resultados []modelos.ResultadoDeBusca
+		itens      []itemDaPagina
+	)
+	for _, linha := range repositorio.memoria.usuarios {
+		if linha.orgID != repositorio.orgID || !repositorio.ativo(linha) || !repositorio.memoria.visivel(linha.ID, visitanteID) {
+			continue
+		}
+		if filtro.CriadoApos != nil && linha.CriadoEm.Before(*filtro.CriadoApos) {
+			continue
+		}
+		if filtro.CriadoAntes != nil && !linha.CriadoEm.Before(*filtro.CriadoAntes) {
+			continue
+		}
+		if filtro.Privado != nil && linha.Privado != *filtro.Privado {
+			continue
+		}
+
+		resultado := modelos.ResultadoDeBusca{Usuario: publico(linha)}
+		if filtro.Termo != "" {
+			var casou bool
+			if resultado.Relevancia, casou = casarTermo(linha.Usuario, filtro.Termo); !casou {
+				continue
+			}
+			resultado.Destaques = destaques(resultado.Usuario, filtro.Termo)
+		}
+
+		chave := strconv.FormatFloat(resultado.Relevancia, 'g', -1, 64)
+		if pagina.Campo != "relevancia" {
+			chave = chaveDoUsuario(linha.Usuario, pagina.Campo, linha.CriadoEm)
+		}
+
+		itens = append(itens, itemDaPagina{ID: linha.ID, chave: chave, posicao: len(resultados)})
+		resultados = append(resultados, resultado)
+	}
+
+	posicoes, proximo, erro := paginarEmMemoria(itens, pagina)
+	if erro != nil {
+		return nil, nil, erro
+	}
+
+	pagos := []modelos.ResultadoDeBusca{}
+	for _, posicao := range posicoes {
+		pagos = append(pagos, resultados[posicao])
+	}
+	return pagos, proximo, nil
+}
+
+// BuscarPorID traz um usuário ativo da organização
+func (repositorio *UsuariosEmMemoria) BuscarPorID(ctx context.Context, ID uint64) (modelos.Usuario, error) {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	return repositorio.buscarPorID(ID)
+}
+
+// BuscarPorTermo traz os usuários mais relevantes para o termo, sem quem está buscando, com a conexão entre os dois
+func (repositorio *UsuariosEmMemoria) BuscarPorTermo(ctx context.Context, termoBusca string, usuarioID uint64) ([]modelos.Usuario, error) {
+	pagina := paginacao.Pagina{Limite: paginacao.LimiteMaximo, Campo: "relevancia", Descendente: true}
+	resultados, _, erro := repositorio.Buscar(ctx, modelos.FiltroDeUsuarios{Termo: termoBusca}, usuarioID, pagina)
+	if erro != nil {
+		return nil, erro
+	}
+
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	var usuarios []modelos.Usuario
+	for _, resultado := range resultados {
+		if resultado.ID == usuarioID {
+			continue
+		}
+
+		usuario := resultado.Usuario
+		usuario.Conexao = repositorio.memoria.conexao(usuario.ID, usuarioID)
+		usuarios = append(usuarios, usuario)
+	}
+
+	return usuarios, nil
+}
+
+// BuscarPorEmail busca um usuário por email e retorna o seu id e senha com hash
+func (repositorio *UsuariosEmMemoria) BuscarPorEmail(ctx context.Context, email string) (modelos.Usuario, error) {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	for _, linha := range repositorio.memoria.usuarios {
+		if linha.orgID == repositorio.orgID && linha.Email == email {
+			return modelos.Usuario{ID: linha.ID, Senha: linha.Senha}, nil
+		}
+	}
+
+	return modelos.Usuario{}, fmt.Errorf("usuário com email %s %w", email, ErrUsuarioNaoEncontrado)
+}
+
+// ExisteNick informa se já existe um usuário com o nick informado
+func (repositorio *UsuariosEmMemoria) ExisteNick(ctx context.Context, nick string) (bool, error) {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	for _, linha := range repositorio.memoria.usuarios {
+		if linha.orgID == repositorio.orgID && linha.Nick == nick {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Atualizar altera nome, nick, e-mail e privacidade do usuário
+func (repositorio *UsuariosEmMemoria) Atualizar(ctx context.Context, ID uint64, usuario modelos.Usuario) error {
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	linha, ok := repositorio.daOrganizacao(ID)
+	if !ok {
+		return nil
+	}
+
+	if erro := repositorio.verificarUnicidade(ID, usuario); erro != nil {
+		return erro
+	}
+
+	linha.Nome, linha.Nick, linha.Email, linha.Privado = usuario.Nome, usuario.Nick, usuario.Email, usuario.Privado
+	return nil
+}
+
+// Deletar marca a conta como excluída
+func (repositorio *UsuariosEmMemoria) Deletar(ctx context.Context, ID uint64) error {
+	return repositorio.alterarSituacao(ID, func(linha *usuarioEmMemoria) bool {
+		if linha.situacao.ExcluidoEm != nil {
+			return false
+		}
+		agora := time.Now()
+		linha.situacao.ExcluidoEm = &agora
+		return true
+	})
+}
+
+// BuscarSenha traz a senha de um usuário pelo ID
+func (repositorio *UsuariosEmMemoria) BuscarSenha(ctx context.Context, usuarioID uint64) (string, error) {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	linha, ok := repositorio.daOrganizacao(usuarioID)
+	if !ok {
+		return "", fmt.Errorf("usuário com ID %d não encontrado", usuarioID)
+	}
+	return linha.Senha, nil
+}
+
+// AtualizarSenha altera a senha de um usuário
+func (repositorio *UsuariosEmMemoria) AtualizarSenha(ctx context.Context, usuarioID uint64, senha string) error {
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	if linha, ok := repositorio.daOrganizacao(usuarioID); ok {
+		linha.Senha = senha
+	}
+	return nil
+}
+
+// NovaSenha altera a senha de um usuário
+func (repositorio *UsuariosEmMemoria) NovaSenha(ctx context.Context, usuarioID uint64, senha string) error {
+	return repositorio.AtualizarSenha(ctx, usuarioID, senha)
+}
+
+// BuscarSituacao traz as datas de desativação e exclusão da conta, inclusive de contas escondidas das buscas
+func (repositorio *UsuariosEmMemoria) BuscarSituacao(ctx context.Context, ID uint64) (modelos.SituacaoDaConta, error) {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	linha, ok := repositorio.daOrganizacao(ID)
+	if !ok {
+		return modelos.SituacaoDaConta{}, fmt.Errorf("usuário com ID %d %w", ID, ErrUsuarioNaoEncontrado)
+	}
+	return linha.situacao, nil
+}
+
+// Desativar esconde a conta e impede o login até que o próprio usuário a reative
+func (repositorio *UsuariosEmMemoria) Desativar(ctx context.Context, ID uint64) error {
+	return repositorio.alterarSituacao(ID, func(linha *usuarioEmMemoria) bool {
+		if !repositorio.ativo(linha) {
+			return false
+		}
+		agora := time.Now()
+		linha.situacao.DesativadoEm = &agora
+		return true
+	})
+}
+
+// Reativar desfaz a desativação e a exclusão ainda dentro da carência
+func (repositorio *UsuariosEmMemoria) Reativar(ctx context.Context, ID uint64) error {
+	return repositorio.alterarSituacao(ID, func(linha *usuarioEmMemoria) bool {
+		linha.situacao = modelos.SituacaoDaConta{}
+		return true
+	})
+}
+
+// Seguir registra que seguidorID passou a seguir usuarioID; em contas privadas cria um pedido pendente
+func (repositorio *UsuariosEmMemoria) Seguir(ctx context.Context, usuarioID, seguidorID uint64) ([]modelos.Usuario, error) {
+	if usuarioID == seguidorID {
+		return nil, fmt.Errorf("um usuário não pode seguir a si mesmo")
+	}
+
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	if erro := repositorio.verificarMesmaOrganizacao(usuarioID, seguidorID); erro != nil {
+		return nil, erro
+	}
+
+	if repositorio.memoria.bloqueado(usuarioID, seguidorID) {
+		return nil, ErrUsuarioBloqueado
+	}
+
+	usuario, erro := repositorio.buscarPorID(usuarioID)
+	if erro != nil {
+		return nil, erro
+	}
+
+	chave := vinculo{usuarioID, seguidorID}
+	if _, segue := repositorio.memoria.seguindo[chave]; segue {
+		return nil, fmt.Errorf("o usuário %d já segue o usuário %d", seguidorID, usuarioID)
+	}
+
+	if usuario.Privado {
+		if _, existe := repositorio.memoria.solicitacoes[chave]; !existe {
+			repositorio.memoria.solicitacoes[chave] = time.Now()
+		}
+
+		usuario.Conexao = ConexaoSolicitada
+		return []modelos.Usuario{usuario}, nil
+	}
+
+	repositorio.memoria.seguindo[chave] = time.Now()
+	return repositorio.usuarioComConexao(usuarioID, seguidorID)
+}
+
+// PararDeSeguir remove o vínculo em que seguidorID segue usuarioID, ou o pedido ainda não aprovado
+func (repositorio *UsuariosEmMemoria) PararDeSeguir(ctx context.Context, usuarioID, seguidorID uint64) ([]modelos.Usuario, error) {
+	if usuarioID == seguidorID {
+		return nil, fmt.Errorf("um usuário não pode parar de seguir a si mesmo")
+	}
+
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	if erro := repositorio.verificarMesmaOrganizacao(usuarioID, seguidorID); erro != nil {
+		return nil, erro
+	}
+
+	chave := vinculo{usuarioID, seguidorID}
+	if !remover(repositorio.memoria.seguindo, chave) && !remover(repositorio.memoria.solicitacoes, chave) {
+		return nil, fmt.Errorf("o usuário %d não segue o usuário %d", seguidorID, usuarioID)
+	}
+
+	return repositorio.usuarioComConexao(usuarioID, seguidorID)
+}
+
+// BuscarSeguidores traz uma página dos seguidores de um usuário
+func (repositorio *UsuariosEmMemoria) BuscarSeguidores(ctx context.Context, usuarioID uint64, pagina paginacao.Pagina) ([]modelos.Usuario, *paginacao.Cursor, error) {
+	return repositorio.buscarNoGrafo(pagina, func(chave vinculo) (uint64, bool) {
+		return chave.outroID, chave.usuarioID == usuarioID
+	})
+}
+
+// BuscarSeguindo traz uma página dos usuários que um determinado usuário está seguindo
+func (repositorio *UsuariosEmMemoria) BuscarSeguindo(ctx context.Context, usuarioID uint64, pagina paginacao.Pagina) ([]modelos.Usuario, *paginacao.Cursor, error) {
+	return repositorio.buscarNoGrafo(pagina, func(chave vinculo) (uint64, bool) {
+		return chave.usuarioID, chave.outroID == usuarioID
+	})
+}
+
+// QuantidadeSeguindo retorna a quantidade de usuários ativos que um usuário está seguindo
+func (repositorio *UsuariosEmMemoria) QuantidadeSeguindo(ctx context.Context, usuarioID uint64) (int, error) {
+	return repositorio.contarNoGrafo(func(chave vinculo) (uint64, bool) {
+		return chave.usuarioID, chave.outroID == usuarioID
+	}), nil
+}
+
+// QuantidadeSeguidores retorna a quantidade de seguidores ativos de um usuário
+func (repositorio *UsuariosEmMemoria) QuantidadeSeguidores(ctx context.Context, usuarioID uint64) (int, error) {
+	return repositorio.contarNoGrafo(func(chave vinculo) (uint64, bool) {
+		return chave.outroID, chave.usuarioID == usuarioID
+	}), nil
+}
+
+// BuscarSugestoes traz as pessoas seguidas por quem o usuário segue, ordenadas pela quantidade de seguidos em comum
+func (repositorio *UsuariosEmMemoria) BuscarSugestoes(ctx context.Context, usuarioID uint64, limite int) ([]modelos.Sugestao, error) {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	emComum := map[uint64]int{}
+	for meu := range repositorio.memoria.seguindo {
+		if meu.outroID != usuarioID {
+			continue
+		}
+		for deles := range repositorio.memoria.seguindo {
+			if deles.outroID == meu.usuarioID {
+				emComum[deles.usuarioID]++
+			}
+		}
+	}
+
+	sugestoes := []modelos.Sugestao{}
+	for candidatoID, quantidade := range emComum {
+		linha, ok := repositorio.memoria.usuarios[candidatoID]
+		if candidatoID == usuarioID || !ok || linha.orgID != repositorio.orgID || !repositorio.ativo(linha) {
+			continue
+		}
+
+		chave := vinculo{candidatoID, usuarioID}
+		_, segue := repositorio.memoria.seguindo[chave]
+		_, pediu := repositorio.memoria.solicitacoes[chave]
+		if segue || pediu || !repositorio.memoria.visivel(candidatoID, usuarioID) {
+			continue
+		}
+
+		sugestoes = append(sugestoes, modelos.Sugestao{
+			Usuario: modelos.Usuario{ID: linha.ID, Nome: linha.Nome, Nick: linha.Nick, CriadoEm: linha.CriadoEm},
+			EmComum: quantidade,
+		})
+	}
+
+	sort.Slice(sugestoes, func(i, j int) bool {
+		if sugestoes[i].EmComum != sugestoes[j].EmComum {
+			return sugestoes[i].EmComum > sugestoes[j].EmComum
+		}
+		return sugestoes[i].ID < sugestoes[j].ID
+	})
+	if len(sugestoes) > limite {
+		sugestoes = sugestoes[:limite]
+	}
+
+	return sugestoes, nil
+}
+
+// Bloqueado informa se algum dos dois usuários bloqueou o outro
+func (repositorio *UsuariosEmMemoria) Bloqueado(ctx context.Context, usuarioID, outroID uint64) (bool, error) {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	return repositorio.memoria.bloqueado(usuarioID, outroID), nil
+}
+
+// Bloquear impede qualquer interação entre os dois usuários e desfaz os vínculos e pedidos existentes
+func (repositorio *UsuariosEmMemoria) Bloquear(ctx context.Context, usuarioID, bloqueadoID uint64) error {
+	if usuarioID == bloqueadoID {
+		return errors.New("um usuário não pode bloquear a si mesmo")
+	}
+
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	if erro := repositorio.verificarMesmaOrganizacao(usuarioID, bloqueadoID); erro != nil {
+		return erro
+	}
+
+	if _, existe := repositorio.memoria.bloqueios[vinculo{usuarioID, bloqueadoID}]; !existe {
+		repositorio.memoria.bloqueios[vinculo{usuarioID, bloqueadoID}] = time.Now()
+	}
+
+	for _, tabela := range []map[vinculo]time.Time{repositorio.memoria.seguindo, repositorio.memoria.solicitacoes} {
+		delete(tabela, vinculo{usuarioID, bloqueadoID})
+		delete(tabela, vinculo{bloqueadoID, usuarioID})
+	}
+
+	return nil
+}
+
+// Desbloquear remove o bloqueio; os vínculos desfeitos não são restaurados
+func (repositorio *UsuariosEmMemoria) Desbloquear(ctx context.Context, usuarioID, bloqueadoID uint64) error {
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	if !remover(repositorio.memoria.bloqueios, vinculo{usuarioID, bloqueadoID}) {
+		return fmt.Errorf("o usuário %d não está bloqueado", bloqueadoID)
+	}
+	return nil
+}
+
+// Silenciar esconde o usuário das buscas e sugestões de quem silenciou, sem desfazer o vínculo
+func (repositorio *UsuariosEmMemoria) Silenciar(ctx context.Context, usuarioID, silenciadoID uint64) error {
+	if usuarioID == silenciadoID {
+		return errors.New("um usuário não pode silenciar a si mesmo")
+	}
+
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	if erro := repositorio.verificarMesmaOrganizacao(usuarioID, silenciadoID); erro != nil {
+		return erro
+	}
+
+	if _, existe := repositorio.memoria.silenciados[vinculo{usuarioID, silenciadoID}]; !existe {
+		repositorio.memoria.silenciados[vinculo{usuarioID, silenciadoID}] = time.Now()
+	}
+	return nil
+}
+
+// Dessilenciar volta a mostrar o usuário silenciado
+func (repositorio *UsuariosEmMemoria) Dessilenciar(ctx context.Context, usuarioID, silenciadoID uint64) error {
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	if !remover(repositorio.memoria.silenciados, vinculo{usuarioID, silenciadoID}) {
+		return fmt.Errorf("o usuário %d não está silenciado", silenciadoID)
+	}
+	return nil
+}
+
+// BuscarSolicitacoes traz os pedidos pendentes para seguir a conta privada do usuário, do mais antigo ao mais novo
+func (repositorio *UsuariosEmMemoria) BuscarSolicitacoes(ctx context.Context, usuarioID uint64) ([]modelos.Usuario, error) {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	var (
+		usuarios []modelos.Usuario
+		pedidoEm = map[uint64]time.Time{}
+	)
+	for chave, criadoEm := range repositorio.memoria.solicitacoes {
+		linha, ok := repositorio.daOrganizacao(chave.outroID)
+		if chave.usuarioID != usuarioID || !ok || !repositorio.ativo(linha) {
+			continue
+		}
+
+		usuarios = append(usuarios, publico(linha))
+		pedidoEm[linha.ID] = criadoEm
+	}
+
+	sort.Slice(usuarios, func(i, j int) bool {
+		if !pedidoEm[usuarios[i].ID].Equal(pedidoEm[usuarios[j].ID]) {
+			return pedidoEm[usuarios[i].ID].Before(pedidoEm[usuarios[j].ID])
+		}
+		return usuarios[i].ID < usuarios[j].ID
+	})
+
+	return usuarios, nil
+}
+
+// AprovarSolicitacao transforma o pedido pendente em um vínculo em seguindo
+func (repositorio *UsuariosEmMemoria) AprovarSolicitacao(ctx context.Context, usuarioID, seguidorID uint64) error {
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	chave := vinculo{usuarioID, seguidorID}
+	if !remover(repositorio.memoria.solicitacoes, chave) {
+		return fmt.Errorf("não há pedido do usuário %d para seguir o usuário %d", seguidorID, usuarioID)
+	}
+
+	if _, existe := repositorio.memoria.seguindo[chave]; !existe {
+		repositorio.memoria.seguindo[chave] = time.Now()
+	}
+	return nil
+}
+
+// RejeitarSolicitacao descarta o pedido pendente
+func (repositorio *UsuariosEmMemoria) RejeitarSolicitacao(ctx context.Context, usuarioID, seguidorID uint64) error {
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	if !remover(repositorio.memoria.solicitacoes, vinculo{usuarioID, seguidorID}) {
+		return fmt.Errorf("não há pedido do usuário %d para seguir o usuário %d", seguidorID, usuarioID)
+	}
+	return nil
+}
+
+// PodeVerConexoes informa se o visitante pode ver seguidores e seguindo do usuário
+func (repositorio *UsuariosEmMemoria) PodeVerConexoes(ctx context.Context, usuario modelos.Usuario, visitanteID uint64) (bool, error) {
+	if !usuario.Privado || usuario.ID == visitanteID {
+		return true, nil
+	}
+
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	_, segue := repositorio.memoria.seguindo[vinculo{usuario.ID, visitanteID}]
+	return segue, nil
+}
+
+// Os métodos abaixo esperam que quem chama já tenha o mutex
+
+// daOrganizacao traz a linha do usuário se ele pertence à organização, em qualquer situação
+func (repositorio *UsuariosEmMemoria) daOrganizacao(ID uint64) (*usuarioEmMemoria, bool) {
+	linha, ok := repositorio.memoria.usuarios[ID]
+	if !ok || linha.orgID != repositorio.orgID {
+		return nil, false
+	}
+	return linha, true
+}
+
+// ativo equivale a contaAtiva: nem desativada nem excluída
+func (repositorio *UsuariosEmMemoria) ativo(linha *usuarioEmMemoria) bool {
+	return linha.situacao.DesativadoEm == nil && linha.situacao.ExcluidoEm == nil
+}
+
+func (repositorio *UsuariosEmMemoria) buscarPorID(ID uint64) (modelos.Usuario, error) {
+	linha, ok := repositorio.daOrganizacao(ID)
+	if !ok || !repositorio.ativo(linha) {
+		return modelos.Usuario{}, fmt.Errorf("usuário com ID %d %w", ID, ErrUsuarioNaoEncontrado)
+	}
+
+	usuario := publico(linha)
+	usuario.Privado = linha.Privado
+	return usuario, nil
+}
+
+func (repositorio *UsuariosEmMemoria) usuarioComConexao(usuarioID, visitanteID uint64) ([]modelos.Usuario, error) {
+	usuario, erro := repositorio.buscarPorID(usuarioID)
+	if erro != nil {
+		return nil, fmt.Errorf("erro ao buscar dados do usuário seguido: %w", erro)
+	}
+
+	usuario.Conexao = repositorio.memoria.conexao(usuario.ID, visitanteID)
+	return []modelos.Usuario{usuario}, nil
+}
+
+// verificarUnicidade faz o papel dos índices únicos de nick e e-mail por organização; ID é o próprio usuário, que não conta
+func (repositorio *UsuariosEmMemoria) verificarUnicidade(ID uint64, usuario modelos.Usuario) error {
+	for _, linha := range repositorio.memoria.usuarios {
+		if linha.orgID != repositorio.orgID || linha.ID == ID {
+			continue
+		}
+		if linha.Nick == usuario.Nick || linha.Email == usuario.Email {
+			return fmt.Errorf("nick ou e-mail em uso na organização: %w", ErrRegistroDuplicado)
+		}
+	}
+	return nil
+}
+
+func (repositorio *UsuariosEmMemoria) verificarMesmaOrganizacao(usuarioID, outroID uint64) error {
+	_, existeUsuario := repositorio.daOrganizacao(usuarioID)
+	_, existeOutro := repositorio.daOrganizacao(outroID)
+	if !existeUsuario || !existeOutro {
+		return fmt.Errorf("usuário %w", ErrUsuarioNaoEncontrado)
+	}
+	return nil
+}
+
+// alterarSituacao aplica a alteração na conta; alterar retorna false quando a conta não está na situação esperada
+func (repositorio *UsuariosEmMemoria) alterarSituacao(ID uint64, alterar func(*usuarioEmMemoria) bool) error {
+	repositorio.memoria.mutex.Lock()
+	defer repositorio.memoria.mutex.Unlock()
+
+	linha, ok := repositorio.daOrganizacao(ID)
+	if !ok || !alterar(linha) {
+		return fmt.Errorf("usuário com ID %d %w", ID, ErrUsuarioNaoEncontrado)
+	}
+	return nil
+}
+
+// buscarNoGrafo lista os usuários ativos da organização ligados em seguindo; ligado diz qual ponta do vínculo listar.
+// Na ordenação por criadoEm vale a data do vínculo, como em colunasDoGrafo.
+func (repositorio *UsuariosEmMemoria) buscarNoGrafo(pagina paginacao.Pagina, ligado func(vinculo) (uint64, bool)) ([]modelos.Usuario, *paginacao.Cursor, error) {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	var (
+		usuarios []modelos.Usuario
+		itens    []itemDaPagina
+	)
+	for chave, criadoEm := range repositorio.memoria.seguindo {
+		ID, ok := ligado(chave)
+		if !ok {
+			continue
+		}
+
+		linha, ok := repositorio.daOrganizacao(ID)
+		if !ok || !repositorio.ativo(linha) {
+			continue
+		}
+
+		itens = append(itens, itemDaPagina{ID: ID, chave: chaveDoUsuario(linha.Usuario, pagina.Campo, criadoEm), posicao: len(usuarios)})
+		usuarios = append(usuarios, publico(linha))
+	}
+
+	posicoes, proximo, erro := paginarEmMemoria(itens, pagina)
+	if erro != nil {
+		return nil, nil, erro
+	}
+
+	pagos := []modelos.Usuario{}
+	for _, posicao := range posicoes {
+		pagos = append(pagos, usuarios[posicao])
+	}
+	return pagos, proximo, nil
+}
+
+// contarNoGrafo conta os vínculos cuja outra ponta é um usuário ativo da organização, como buscarNoGrafo
+func (repositorio *UsuariosEmMemoria) contarNoGrafo(ligado func(vinculo) (uint64, bool)) int {
+	repositorio.memoria.mutex.RLock()
+	defer repositorio.memoria.mutex.RUnlock()
+
+	quantidade := 0
+	for chave := range repositorio.memoria.seguindo {
+		if ID, ok := ligado(chave); ok {
+			if linha, daOrganizacao := repositorio.daOrganizacao(ID); daOrganizacao && repositorio.ativo(linha) {
+				quantidade++
+			}
+		}
+	}
+	return quantidade
+}
+
+func (memoria *memoriaDeUsuarios) bloqueado(usuarioID, outroID uint64) bool {
+	_, bloqueou := memoria.bloqueios[vinculo{usuarioID, outroID}]
+	_, foiBloqueado := memoria.bloqueios[vinculo{outroID, usuarioID}]
+	return bloqueou || foiBloqueado
+}
+
+// visivel equivale a filtroDeVisibilidade: sem bloqueio nos dois sentidos e não silenciado pelo visitante
+func (memoria *memoriaDeUsuarios) visivel(usuarioID, visitanteID uint64) bool {
+	_, silenciado := memoria.silenciados[vinculo{visitanteID, usuarioID}]
+	return !silenciado && !memoria.bloqueado(usuarioID, visitanteID)
+}
+
+// conexao descreve, do ponto de vista de visitanteID, como os dois usuários se seguem
+func (memoria *memoriaDeUsuarios) conexao(usuarioID, visitanteID uint64) string {
+	_, segueVoce := memoria.seguindo[vinculo{visitanteID, usuarioID}]
+	_, voceSegue := memoria.seguindo[vinculo{usuarioID, visitanteID}]
+
+	switch {
+	case segueVoce && voceSegue:
+		return "se seguem"
+	case segueVoce:
+		return "segue você"
+	case voceSegue:
+		return "seguindo"
+	default:
+		return "nenhuma"
+	}
+}
+
+// publico copia os campos que as listagens retornam (id, nome, nick, email, criadoEm)
+func publico(linha *usuarioEmMemoria) modelos.Usuario {
+	return modelos.Usuario{ID: linha.ID, Nome: linha.Nome, Nick: linha.Nick, Email: linha.Email, CriadoEm: linha.CriadoEm}
+}
+
+func remover(tabela map[vinculo]time.Time, chave vinculo) bool {
+	_, existe := tabela[chave]
+	delete(tabela, chave)
+	return existe
+}
+
+// casarTermo reproduz condicaoDeBusca e relevanciaDaBusca: casa por trecho ou por similaridade, sem acentos nem maiúsculas
+func casarTermo(usuario modelos.Usuario, termo string) (float64, bool) {
+	termoNormalizado, _ := semAcento(termo)
+	nome, _ := semAcento(usuario.Nome)
+	nick, _ := semAcento(usuario.Nick)
+
+	similaridadeNome := similaridade(nome, termoNormalizado)
+	similaridadeNick := similaridade(nick, termoNormalizado)
+	relevancia := similaridadeNome
+	if similaridadeNick > relevancia {
+		relevancia = similaridadeNick
+	}
+
+	casou := strings.Contains(nome, termoNormalizado) || strings.Contains(nick, termoNormalizado) ||
+		similaridadeNome >= limiarDeSimilaridade || similaridadeNick >= limiarDeSimilaridade
+	return relevancia, casou
+}
+
+// similaridade calcula a similarity do pg_trgm: trigramas em comum sobre o total de trigramas distintos dos dois textos
+func similaridade(a, b string) float64 {
+	trigramasA, trigramasB := trigramas(a), trigramas(b)
+	if len(trigramasA) == 0 || len(trigramasB) == 0 {
+		return 0
+	}
+
+	comuns := 0
+	for trigrama := range trigramasA {
+		if trigramasB[trigrama] {
+			comuns++
+		}
+	}
+	return float64(comuns) / float64(len(trigramasA)+len(trigramasB)-comuns)
+}
+
+// trigramas separa o texto em palavras alfanuméricas e gera os trigramas de cada uma com o preenchimento do pg_trgm
+// (dois espaços antes e um depois)
+func trigramas(texto string) map[string]bool {
+	conjunto := map[string]bool{}
+	palavras := strings.FieldsFunc(texto, func(caractere rune) bool {
+		return !unicode.IsLetter(caractere) && !unicode.IsDigit(caractere)
+	})
+
+	for _, palavra := range palavras {
+		runas := []rune("  " + palavra + " ")
+		for i := 0; i+3 <= len(runas); i++ {
+			conjunto[string(runas[i:i+3])] = true
+		}
+	}
+	return conjunto
+}
+
+// itemDaPagina é uma linha candidata à paginação em memória: a chave da ordenação, o ID e a posição no resultado
+type itemDaPagina struct {
+	ID      uint64
+	chave   string
+	posicao int
+}
+
+// chaveDoUsuario é o valor da coluna de ordenação no formato guardado no cursor
+func chaveDoUsuario(usuario modelos.Usuario, campo string, criadoEm time.Time) string {
+	switch campo {
+	case "nome":
+		return usuario.Nome
+	case "nick":
+		return usuario.Nick
+	default:
+		return criadoEm.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// compararChaves compara duas chaves de acordo com o tipo da coluna, como o cast feito em Pagina.SQL
+func compararChaves(campo, a, b string) (int, error) {
+	switch campo {
+	case "criadoEm":
+		tempoA, erroA := time.Parse(time.RFC3339Nano, a)
+		tempoB, erroB := time.Parse(time.RFC3339Nano, b)
+		if erroA != nil || erroB != nil {
+			return 0, paginacao.ErrCursorInvalido
+		}
+		return tempoA.Compare(tempoB), nil
+	case "relevancia":
+		numeroA, erroA := strconv.ParseFloat(a, 64)
+		numeroB, erroB := strconv.ParseFloat(b, 64)
+		if erroA != nil || erroB != nil {
+			return 0, paginacao.ErrCursorInvalido
+		}
+		switch {
+		case numeroA < numeroB:
+			return -1, nil
+		case numeroA > numeroB:
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return strings.Compare(a, b), nil
+	}
+}
+
+// compararItens ordena por chave e desempata pelo ID
+func compararItens(campo string, a, b itemDaPagina) int {
+	if comparacao, _ := compararChaves(campo, a.chave, b.chave); comparacao != 0 {
+		return comparacao
+	}
+	switch {
+	case a.ID < b.ID:
+		return -1
+	case a.ID > b.ID:
+		return 1
+	}
+	return 0
+}
+
+// paginarEmMemoria faz o mesmo que Pagina.SQL e Pagina.Proximo: ordena, pula até depois do cursor e corta no limite.
+// Retorna as posições dos itens da página, na ordem pedida, e o cursor da próxima.
+func paginarEmMemoria(itens []itemDaPagina, pagina paginacao.Pagina) ([]int, *paginacao.Cursor, error) {
+	direcao := 1
+	if pagina.Descendente {
+		direcao = -1
+	}
+
+	if pagina.Cursor != nil {
+		if _, erro := compararChaves(pagina.Campo, pagina.Cursor.Valor, pagina.Cursor.Valor); erro != nil {
+			return nil, nil, erro
+		}
+
+		cursor := itemDaPagina{ID: pagina.Cursor.ID, chave: pagina.Cursor.Valor}
+		depois := itens[:0]
+		for _, item := range itens {
+			if compararItens(pagina.Campo, item, cursor)*direcao > 0 {
+				depois = append(depois, item)
+			}
+		}
+		itens = depois
+	}
+
+	sort.Slice(itens, func(i, j int) bool {
+		return compararItens(pagina.Campo, itens[i], itens[j])*direcao < 0
+	})
+	if len(itens) > pagina.Limite+1 {
+		itens = itens[:pagina.Limite+1]
+	}
+
+	var (
+		chaves   []string
+		IDs      []uint64
+		posicoes []int
+	)
+	for _, item := range itens {
+		chaves = append(chaves, item.chave)
+		IDs = append(IDs, item.ID)
+		posicoes = append(posicoes, item.posicao)
+	}
+
+	quantidade, proximo := pagina.Proximo(chaves, IDs)
+	return posicoes[:quantidade], proximo, nil
+}
@@ -27,3 +27,15 @@ func Erro(w http.ResponseWriter, statusCode int, erro error) {
 		Erro: erro.Error(),
 	})
 }
+
+// ErroOAuth retorna um erro no formato definido pela RFC 6749 (seção 5.2)
+func ErroOAuth(w http.ResponseWriter, statusCode int, codigo, descricao string) {
+	w.Header().Set("Cache-Control", "no-store")
+	JSON(w, statusCode, struct {
+		Erro      string `json:"error"`
+		Descricao string `json:"error_description,omitempty"`
+	}{
+		Erro:      codigo,
+		Descricao: descricao,
+	})
+}
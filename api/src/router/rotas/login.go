@@ -5,17 +5,31 @@ import (
 	"net/http"
 )
 
-var rotaLogin = []Rota{
-	{
-		URI:                "/login",
-		Metodo:             http.MethodPost,
-		Funcao:             controllers.Login,
-		RequerAutenticacao: false,
-	},
-	{
-		URI:                "/anonimo",
-		Metodo:             http.MethodPost,
-		Funcao:             controllers.LoginAnonimo,
-		RequerAutenticacao: false,
-	},
+func rotaLogin(controladores *controllers.Controladores) []Rota {
+	return []Rota{
+		{
+			URI:                "/login",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.Login,
+			RequerAutenticacao: false,
+		},
+		{
+			URI:                "/anonimo",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.LoginAnonimo,
+			RequerAutenticacao: false,
+		},
+		{
+			URI:                "/login/{provedor}",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.IniciarLoginExterno,
+			RequerAutenticacao: false,
+		},
+		{
+			URI:                "/login/{provedor}/callback",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.ConcluirLoginExterno,
+			RequerAutenticacao: false,
+		},
+	}
 }
@@ -0,0 +1,17 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func rotasOAuth(controladores *controllers.Controladores) []Rota {
+	return []Rota{
+		{
+			URI:                "/oauth/token",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.TrocarToken,
+			RequerAutenticacao: false,
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func rotasOrganizacao(controladores *controllers.Controladores) []Rota {
+	return []Rota{
+		{
+			URI:                "/organizacoes",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.CriarOrganizacao,
+			RequerAutenticacao: false,
+		},
+		{
+			URI:                "/organizacao/configuracoes",
+			Metodo:             http.MethodPut,
+			Funcao:             controladores.AtualizarConfiguracoesDaOrganizacao,
+			RequerAutenticacao: true,
+			RequerPermissao:    "organizacao:configurar",
+		},
+		{
+			URI:                "/organizacao/membros",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarMembros,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/organizacao/membros/{usuarioId}",
+			Metodo:             http.MethodPut,
+			Funcao:             controladores.AlterarPapelDoMembro,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/organizacao/membros/{usuarioId}",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.RemoverMembro,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/organizacao/transferir-propriedade",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.TransferirPropriedade,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/organizacao/convites",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.ConvidarMembro,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/organizacao/convites",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarConvites,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/organizacao/convites/{conviteId}",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.RevogarConvite,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/convites/{token}",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarConvite,
+			RequerAutenticacao: false,
+		},
+		{
+			URI:                "/convites/{token}/aceitar",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.AceitarConvite,
+			RequerAutenticacao: false,
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func rotasPapeis(controladores *controllers.Controladores) []Rota {
+	return []Rota{
+		{
+			URI:                "/papeis",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarPapeis,
+			RequerAutenticacao: true,
+			RequerPermissao:    "papeis:gerenciar",
+		},
+		{
+			URI:                "/papeis",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.CriarPapel,
+			RequerAutenticacao: true,
+			RequerPermissao:    "papeis:gerenciar",
+		},
+		{
+			URI:                "/papeis/{papel}",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.ExcluirPapel,
+			RequerAutenticacao: true,
+			RequerPermissao:    "papeis:gerenciar",
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/papeis",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarPapeisDoUsuario,
+			RequerAutenticacao: true,
+			RequerPermissao:    "papeis:gerenciar",
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/papeis",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.AtribuirPapel,
+			RequerAutenticacao: true,
+			RequerPermissao:    "papeis:gerenciar",
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/papeis/{papel}",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.RemoverPapel,
+			RequerAutenticacao: true,
+			RequerPermissao:    "papeis:gerenciar",
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package rotas
 
 import (
+	"api/src/controllers"
 	"api/src/middlewares"
 	"net/http"
 
@@ -13,21 +14,35 @@ type Rota struct {
 	Metodo             string
 	Funcao             func(http.ResponseWriter, *http.Request)
 	RequerAutenticacao bool
+	// RequerPermissao exige que o token conceda a permissão de RBAC informada (implica autenticação)
+	RequerPermissao string
+	// Escopo libera a rota autenticada para tokens da troca que tenham este escopo; sem ele, esses tokens são recusados.
+	// Nas rotas com RequerPermissao, o escopo exigido é a própria permissão.
+	Escopo string
 }
 
-// Configurar coloca todas as rotas dentro do router
-func Configurar(r *mux.Router) *mux.Router {
-	rotas := rotasUsuarios
-	rotas = append(rotas, rotaLogin...)
+// Configurar coloca todas as rotas dentro do router, atendidas pelos controladores informados
+func Configurar(r *mux.Router, controladores *controllers.Controladores) *mux.Router {
+	rotas := rotasUsuarios(controladores)
+	rotas = append(rotas, rotaLogin(controladores)...)
+	rotas = append(rotas, rotasOAuth(controladores)...)
+	rotas = append(rotas, rotasSAML(controladores)...)
+	rotas = append(rotas, rotasPapeis(controladores)...)
+	rotas = append(rotas, rotasOrganizacao(controladores)...)
 
 	for _, rota := range rotas {
 
-		if rota.RequerAutenticacao {
+		// As métricas envolvem tudo, para contar também as requisições recusadas na autenticação
+		if rota.RequerPermissao != "" {
 			r.HandleFunc(rota.URI,
-				middlewares.Logger(middlewares.Autenticar(rota.Funcao)),
+				middlewares.Metricas(rota.URI, middlewares.Logger(middlewares.Autorizar(rota.RequerPermissao, rota.Funcao))),
+			).Methods(rota.Metodo)
+		} else if rota.RequerAutenticacao {
+			r.HandleFunc(rota.URI,
+				middlewares.Metricas(rota.URI, middlewares.Logger(middlewares.Autenticar(rota.Escopo, rota.Funcao))),
 			).Methods(rota.Metodo)
 		} else {
-			r.HandleFunc(rota.URI, middlewares.Logger(rota.Funcao)).Methods(rota.Metodo)
+			r.HandleFunc(rota.URI, middlewares.Metricas(rota.URI, middlewares.Logger(rota.Funcao))).Methods(rota.Metodo)
 		}
 
 	}
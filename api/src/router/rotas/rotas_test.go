@@ -0,0 +1,142 @@
+package rotas
+
+import (
+	"api/src/autenticacao"
+	"api/src/controllers"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/tenancia"
+	"api/src/testes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+var organizacaoDeTeste = modelos.Organizacao{ID: tenancia.IDPadrao, Slug: tenancia.SlugPadrao, Nome: "Padrão"}
+
+// fonteDeTeste serve a organização de teste e dá saml:gerenciar e organizacao:configurar só ao usuário 1
+type fonteDeTeste struct{}
+
+func (fonteDeTeste) BuscarPorID(_ context.Context, ID uint64) (modelos.Organizacao, error) {
+	if ID == organizacaoDeTeste.ID {
+		return organizacaoDeTeste, nil
+	}
+	return modelos.Organizacao{}, repositorios.ErrOrganizacaoNaoEncontrada
+}
+
+func (fonte fonteDeTeste) BuscarPorSlug(ctx context.Context, _ string) (modelos.Organizacao, error) {
+	return fonte.BuscarPorID(ctx, organizacaoDeTeste.ID)
+}
+
+func (fonte fonteDeTeste) BuscarPorDominio(ctx context.Context, _ string) (modelos.Organizacao, error) {
+	return fonte.BuscarPorID(ctx, organizacaoDeTeste.ID)
+}
+
+func (fonteDeTeste) Acesso(_ context.Context, _, usuarioID uint64, _ []string) (modelos.Acesso, error) {
+	if usuarioID == 1 {
+		return modelos.Acesso{Permissoes: []string{"saml:gerenciar", "organizacao:configurar"}}, nil
+	}
+	return modelos.Acesso{Permissoes: []string{"usuarios:ler"}}, nil
+}
+
+func TestMain(m *testing.M) {
+	tenancia.Configurar(fonteDeTeste{})
+	autenticacao.ConfigurarAcesso(fonteDeTeste{})
+	m.Run()
+}
+
+// TestConfigurarIdPSAMLExigePermissao: o metadata do IdP decide quem entra na organização, então só quem tem
+// saml:gerenciar pode trocá-lo. Um token anônimo ou de um usuário comum não chega ao handler.
+func TestConfigurarIdPSAMLExigePermissao(t *testing.T) {
+	testes.Configurar(t, nil)
+	router := tenancia.Middleware(Configurar(mux.NewRouter(), controllers.NovosControladores(controllers.Dependencias{
+		Usuarios: repositorios.NovaFabricaDeUsuariosEmMemoria(),
+	})))
+
+	anonimo, erro := autenticacao.CriarTokenAnonimo(organizacaoDeTeste)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	comum, erro := autenticacao.CriarToken(context.Background(), organizacaoDeTeste, 2, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	administrador, erro := autenticacao.CriarToken(context.Background(), organizacaoDeTeste, 1, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	casos := []struct {
+		nome   string
+		token  string
+		status int
+	}{
+		{"sem token", "", http.StatusUnauthorized},
+		{"token anônimo", anonimo, http.StatusForbidden},
+		{"usuário sem saml:gerenciar", comum, http.StatusForbidden},
+		// Passou pela autorização: o handler recusa o metadata inválido antes de tocar no banco
+		{"usuário com saml:gerenciar", administrador, http.StatusBadRequest},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			requisicao := httptest.NewRequest(http.MethodPut, "/saml/"+tenancia.SlugPadrao+"/idp?confiarEmail=true", strings.NewReader("<nao-e-metadata/>"))
+			if caso.token != "" {
+				requisicao.Header.Set("Authorization", "Bearer "+caso.token)
+			}
+
+			resposta := httptest.NewRecorder()
+			router.ServeHTTP(resposta, requisicao)
+			if resposta.Code != caso.status {
+				t.Errorf("status = %d, quer %d: %s", resposta.Code, caso.status, resposta.Body.String())
+			}
+		})
+	}
+}
+
+// TestConfiguracoesDaOrganizacaoExigemPermissao: a chave de assinatura e a duração dos tokens valem para a
+// organização inteira, então só quem tem organizacao:configurar pode trocá-las
+func TestConfiguracoesDaOrganizacaoExigemPermissao(t *testing.T) {
+	testes.Configurar(t, nil)
+	router := tenancia.Middleware(Configurar(mux.NewRouter(), controllers.NovosControladores(controllers.Dependencias{
+		Usuarios: repositorios.NovaFabricaDeUsuariosEmMemoria(),
+	})))
+
+	comum, erro := autenticacao.CriarToken(context.Background(), organizacaoDeTeste, 2, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	administrador, erro := autenticacao.CriarToken(context.Background(), organizacaoDeTeste, 1, nil)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	casos := []struct {
+		nome   string
+		token  string
+		status int
+	}{
+		{"sem token", "", http.StatusUnauthorized},
+		{"usuário sem organizacao:configurar", comum, http.StatusForbidden},
+		// Passou pela autorização: o handler recusa a chave curta antes de tocar no banco
+		{"usuário com organizacao:configurar", administrador, http.StatusBadRequest},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			corpo := `{"politicaDeSenha": {"tamanhoMinimo": 12}, "chaveAssinatura": "curta"}`
+			requisicao := httptest.NewRequest(http.MethodPut, "/organizacao/configuracoes", strings.NewReader(corpo))
+			if caso.token != "" {
+				requisicao.Header.Set("Authorization", "Bearer "+caso.token)
+			}
+
+			resposta := httptest.NewRecorder()
+			router.ServeHTTP(resposta, requisicao)
+			if resposta.Code != caso.status {
+				t.Errorf("status = %d, quer %d: %s", resposta.Code, caso.status, resposta.Body.String())
+			}
+		})
+	}
+}
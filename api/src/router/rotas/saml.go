@@ -0,0 +1,36 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func rotasSAML(controladores *controllers.Controladores) []Rota {
+	return []Rota{
+		{
+			URI:                "/saml/{tenant}/metadata",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.MetadataSAML,
+			RequerAutenticacao: false,
+		},
+		{
+			URI:                "/saml/{tenant}/login",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.IniciarLoginSAML,
+			RequerAutenticacao: false,
+		},
+		{
+			URI:                "/saml/{tenant}/acs",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.ConsumirAssercaoSAML,
+			RequerAutenticacao: false,
+		},
+		{
+			URI:                "/saml/{tenant}/idp",
+			Metodo:             http.MethodPut,
+			Funcao:             controladores.SalvarProvedorSAML,
+			RequerAutenticacao: true,
+			RequerPermissao:    "saml:gerenciar",
+		},
+	}
+}
@@ -0,0 +1,40 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// rotasInfraestrutura são as sondas do orquestrador e a coleta do Prometheus: não passam pela resolução
+// da organização, pelo CORS nem pelo log de requisições, que elas encheriam a cada poucos segundos
+var rotasInfraestrutura = []Rota{
+	{
+		URI:                "/healthz",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.Vivacidade,
+		RequerAutenticacao: false,
+	},
+	{
+		URI:                "/readyz",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.Prontidao,
+		RequerAutenticacao: false,
+	},
+	{
+		URI:                "/metrics",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.Metricas,
+		RequerAutenticacao: false,
+	},
+}
+
+// ConfigurarInfraestrutura coloca as rotas de infraestrutura no router
+func ConfigurarInfraestrutura(r *mux.Router) *mux.Router {
+	for _, rota := range rotasInfraestrutura {
+		r.HandleFunc(rota.URI, rota.Funcao).Methods(rota.Metodo)
+	}
+
+	return r
+}
@@ -5,29 +5,180 @@ import (
 	"net/http"
 )
 
-var rotasUsuarios = []Rota{
-	{
-		URI:                "/usuarios",
-		Metodo:             http.MethodPost,
-		Funcao:             controllers.CriarUsuario,
-		RequerAutenticacao: false,
-	},
-	{
-		URI:                "/usuarios",
-		Metodo:             http.MethodGet,
-		Funcao:             controllers.BuscarUsuarios,
-		RequerAutenticacao: true,
-	},
-	{
-		URI:                "/usuarios/{usuarioId}/atualizar-senha",
-		Metodo:             http.MethodPost,
-		Funcao:             controllers.AtualizarSenha,
-		RequerAutenticacao: true,
-	},
-	{
-		URI:                "/usuarios/{usuarioId}/nova-senha",
-		Metodo:             http.MethodPost,
-		Funcao:             controllers.NovaSenha,
-		RequerAutenticacao: true,
-	},
+func rotasUsuarios(controladores *controllers.Controladores) []Rota {
+	return []Rota{
+		{
+			URI:                "/usuarios",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.CriarUsuario,
+			RequerAutenticacao: false,
+		},
+		{
+			URI:                "/usuarios",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarUsuarios,
+			RequerAutenticacao: true,
+			Escopo:             "usuarios:ler",
+		},
+		{
+			URI:                "/usuarios/{usuarioId}",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarUsuario,
+			RequerAutenticacao: true,
+			Escopo:             "usuarios:ler",
+		},
+		{
+			URI:                "/usuarios/{usuarioId}",
+			Metodo:             http.MethodPut,
+			Funcao:             controladores.AtualizarUsuario,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}",
+			Metodo:             http.MethodPatch,
+			Funcao:             controladores.AtualizarUsuarioParcialmente,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.DeletarUsuario,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/desativar",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.DesativarUsuario,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/atualizar-senha",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.AtualizarSenha,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/nova-senha",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.NovaSenha,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/redefinir-senha",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.RedefinirSenha,
+			RequerAutenticacao: true,
+			RequerPermissao:    "usuarios:editar",
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/seguir",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.SeguirUsuario,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/seguir",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.PararDeSeguirUsuario,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/seguidores",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarSeguidores,
+			RequerAutenticacao: true,
+			Escopo:             "usuarios:ler",
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/seguindo",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarSeguindo,
+			RequerAutenticacao: true,
+			Escopo:             "usuarios:ler",
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/bloquear",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.BloquearUsuario,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/bloquear",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.DesbloquearUsuario,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/silenciar",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.SilenciarUsuario,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/silenciar",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.DessilenciarUsuario,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/solicitacoes",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarSolicitacoes,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/solicitacoes/{seguidorId}/aprovar",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.AprovarSolicitacao,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/solicitacoes/{seguidorId}",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.RejeitarSolicitacao,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/sugestoes",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarSugestoes,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/exportacao",
+			Metodo:             http.MethodPost,
+			Funcao:             controladores.SolicitarExportacao,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/exportacao/{exportacaoId}",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarExportacao,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/consentimentos",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BuscarConsentimentos,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/consentimentos/{finalidade}",
+			Metodo:             http.MethodPut,
+			Funcao:             controladores.ConcederConsentimento,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/usuarios/{usuarioId}/consentimentos/{finalidade}",
+			Metodo:             http.MethodDelete,
+			Funcao:             controladores.RevogarConsentimento,
+			RequerAutenticacao: true,
+		},
+		{
+			URI:                "/exportacoes/{exportacaoId}/download",
+			Metodo:             http.MethodGet,
+			Funcao:             controladores.BaixarExportacao,
+			RequerAutenticacao: false,
+		},
+	}
 }
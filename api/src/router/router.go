@@ -1,13 +1,20 @@
 package router
 
 import (
+	"api/src/controllers"
 	"api/src/router/rotas"
 
 	"github.com/gorilla/mux"
 )
 
-// Gerar vai retornar um router com as rotas configuradas
-func Gerar() *mux.Router {
+// Gerar vai retornar um router com as rotas configuradas, atendidas pelos controladores informados
+func Gerar(controladores *controllers.Controladores) *mux.Router {
 	r := mux.NewRouter()
-	return rotas.Configurar(r)
+	return rotas.Configurar(r, controladores)
+}
+
+// GerarInfraestrutura vai retornar um router só com as sondas de saúde e as métricas, na frente do router da API
+func GerarInfraestrutura() *mux.Router {
+	r := mux.NewRouter()
+	return rotas.ConfigurarInfraestrutura(r)
 }
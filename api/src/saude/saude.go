@@ -0,0 +1,136 @@
+package saude
+
+import (
+	"api/src/config"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrDesativado é retornado pela verificação de uma dependência que não está configurada (Redis, e-mail...)
+var ErrDesativado = errors.New("não configurado")
+
+// Situações de uma verificação e do relatório
+const (
+	SituacaoOK           = "ok"
+	SituacaoFalha        = "falha"
+	SituacaoDesativado   = "desativado"
+	SituacaoPronto       = "pronto"
+	SituacaoDegradado    = "degradado"
+	SituacaoIndisponivel = "indisponivel"
+)
+
+// Resultado é o resultado de uma verificação, como aparece no JSON do /readyz
+type Resultado struct {
+	Nome         string    `json:"nome"`
+	Situacao     string    `json:"situacao"`
+	Obrigatoria  bool      `json:"obrigatoria"`
+	LatenciaMs   float64   `json:"latenciaMs"`
+	Detalhe      string    `json:"detalhe,omitempty"`
+	VerificadoEm time.Time `json:"verificadoEm"`
+}
+
+// Relatorio reúne as verificações. Falha numa verificação obrigatória deixa a instância indisponível;
+// nas outras, degradada, mas ainda recebendo tráfego.
+type Relatorio struct {
+	Situacao     string      `json:"situacao"`
+	Verificacoes []Resultado `json:"verificacoes"`
+}
+
+// SemDetalhes retorna uma cópia do relatório sem o detalhe das verificações. O detalhe traz a mensagem de erro
+// da dependência (endereço, usuário, versão), que só vai para quem apresenta o token de métricas.
+func (relatorio Relatorio) SemDetalhes() Relatorio {
+	copia := Relatorio{Situacao: relatorio.Situacao, Verificacoes: make([]Resultado, len(relatorio.Verificacoes))}
+	for i, resultado := range relatorio.Verificacoes {
+		resultado.Detalhe = ""
+		copia.Verificacoes[i] = resultado
+	}
+	return copia
+}
+
+// verificacao guarda o último resultado para que várias sondas seguidas não repitam a consulta
+type verificacao struct {
+	nome        string
+	obrigatoria bool
+	verificar   func(ctx context.Context) (string, error)
+
+	mutex     sync.Mutex
+	ultimo    Resultado
+	validoAte time.Time
+}
+
+var verificacoes = []*verificacao{
+	{nome: "postgres", obrigatoria: true, verificar: verificarPostgres},
+	{nome: "chaves", obrigatoria: true, verificar: verificarChaves},
+	// Sem o Redis o cache passa para o PostgreSQL, e sem e-mail ou certificado SAML só uma parte da API para
+	{nome: "redis", verificar: verificarRedis},
+	{nome: "email", verificar: verificarEmail},
+	{nome: "certificado_saml", verificar: verificarCertificadoSAML},
+}
+
+// Verificar roda as verificações em paralelo e monta o relatório. Cada resultado é reaproveitado por SAUDE_CACHE,
+// e sondas simultâneas esperam a mesma verificação em vez de abrir outra.
+func Verificar() Relatorio {
+	relatorio := Relatorio{Situacao: SituacaoPronto, Verificacoes: make([]Resultado, len(verificacoes))}
+
+	var grupo sync.WaitGroup
+	for i, item := range verificacoes {
+		grupo.Add(1)
+		go func(i int, item *verificacao) {
+			defer grupo.Done()
+			relatorio.Verificacoes[i] = item.resultado()
+		}(i, item)
+	}
+	grupo.Wait()
+
+	for _, resultado := range relatorio.Verificacoes {
+		if resultado.Situacao != SituacaoFalha {
+			continue
+		}
+		if resultado.Obrigatoria {
+			relatorio.Situacao = SituacaoIndisponivel
+			break
+		}
+		relatorio.Situacao = SituacaoDegradado
+	}
+	return relatorio
+}
+
+func (item *verificacao) resultado() Resultado {
+	item.mutex.Lock()
+	defer item.mutex.Unlock()
+
+	agora := time.Now()
+	if agora.Before(item.validoAte) {
+		return item.ultimo
+	}
+
+	// O resultado é compartilhado entre as sondas, então não usa o contexto de nenhuma requisição
+	configuracao := config.Atual().Servidor
+	ctx, cancelar := context.WithTimeout(context.Background(), configuracao.TimeoutSaude)
+	defer cancelar()
+
+	detalhe, erro := item.verificar(ctx)
+	resultado := Resultado{
+		Nome:         item.nome,
+		Situacao:     SituacaoOK,
+		Obrigatoria:  item.obrigatoria,
+		LatenciaMs:   float64(time.Since(agora).Microseconds()) / 1000,
+		Detalhe:      detalhe,
+		VerificadoEm: agora,
+	}
+	if errors.Is(erro, ErrDesativado) {
+		resultado.Situacao = SituacaoDesativado
+	} else if erro != nil {
+		resultado.Situacao, resultado.Detalhe = SituacaoFalha, erro.Error()
+		// O erro fica no log do servidor; a resposta anônima do /readyz não o mostra
+		if item.ultimo.Situacao != SituacaoFalha || item.ultimo.Detalhe != resultado.Detalhe {
+			log.Printf("Verificação de saúde %s falhou: %v", item.nome, erro)
+		}
+	}
+
+	item.ultimo, item.validoAte = resultado, agora.Add(configuracao.CacheSaude)
+	return resultado
+}
@@ -0,0 +1,59 @@
+package saude
+
+import (
+	"api/src/testes"
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// trocarVerificacoes põe verificações de teste no lugar das reais até o fim do teste
+func trocarVerificacoes(t *testing.T, novas ...*verificacao) {
+	t.Helper()
+	originais := verificacoes
+	verificacoes = novas
+	t.Cleanup(func() { verificacoes = originais })
+}
+
+func TestDetalheDoErroSoNoLog(t *testing.T) {
+	// Sem cache do resultado, cada Verificar consulta de novo
+	testes.Configurar(t, map[string]string{"SAUDE_CACHE": "1ns"})
+
+	const segredo = "dial tcp 10.0.3.7:6379: senha recusada para o usuário cache"
+	trocarVerificacoes(t,
+		&verificacao{nome: "postgres", obrigatoria: true, verificar: func(context.Context) (string, error) { return "3 de 10 conexões em uso", nil }},
+		&verificacao{nome: "redis", verificar: func(context.Context) (string, error) { return "", errors.New(segredo) }},
+	)
+
+	var saida bytes.Buffer
+	log.SetOutput(&saida)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	relatorio := Verificar()
+	if relatorio.Situacao != SituacaoDegradado || relatorio.Verificacoes[1].Detalhe != segredo {
+		t.Fatalf("relatorio = %+v", relatorio)
+	}
+
+	anonimo := relatorio.SemDetalhes()
+	if anonimo.Situacao != SituacaoDegradado || len(anonimo.Verificacoes) != 2 || anonimo.Verificacoes[1].Situacao != SituacaoFalha {
+		t.Errorf("sem detalhes = %+v, quer a mesma situação de cada verificação", anonimo)
+	}
+	for _, resultado := range anonimo.Verificacoes {
+		if resultado.Detalhe != "" {
+			t.Errorf("%s: detalhe %q na resposta sem token", resultado.Nome, resultado.Detalhe)
+		}
+	}
+	if relatorio.Verificacoes[1].Detalhe != segredo {
+		t.Error("SemDetalhes alterou o relatório original")
+	}
+
+	// O erro vai para o log uma vez, e não a cada sonda enquanto continuar o mesmo
+	Verificar()
+	if vezes := strings.Count(saida.String(), segredo); vezes != 1 {
+		t.Errorf("o erro apareceu %d vez(es) no log, quer 1: %s", vezes, saida.String())
+	}
+}
@@ -0,0 +1,77 @@
+package saude
+
+import (
+	"api/src/banco"
+	"api/src/config"
+	"api/src/email"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// avisoCertificado é a antecedência com que o vencimento do certificado SAML passa a aparecer no detalhe
+const avisoCertificado = 30 * 24 * time.Hour
+
+func verificarPostgres(ctx context.Context) (string, error) {
+	pool := banco.Pool()
+	if pool == nil {
+		return "", errors.New("pool de conexões não iniciado")
+	}
+	if erro := pool.Ping(ctx); erro != nil {
+		return "", erro
+	}
+
+	estatisticas := pool.Stat()
+	return fmt.Sprintf("%d de %d conexões em uso", estatisticas.AcquiredConns(), estatisticas.MaxConns()), nil
+}
+
+func verificarRedis(ctx context.Context) (string, error) {
+	if config.RedisClient == nil {
+		return "", ErrDesativado
+	}
+	if erro := config.TestarRedis(ctx); erro != nil {
+		return "", fmt.Errorf("%v (o cache usa o PostgreSQL enquanto isso)", erro)
+	}
+	return "", nil
+}
+
+func verificarEmail(ctx context.Context) (string, error) {
+	erro := email.Verificar(ctx)
+	if errors.Is(erro, email.ErrNaoConfigurado) {
+		return "", ErrDesativado
+	}
+	return "", erro
+}
+
+// verificarChaves confere a chave que assina os tokens das organizações sem chave própria
+func verificarChaves(ctx context.Context) (string, error) {
+	chave := config.Atual().SecretKey
+	if chave == "" {
+		return "", errors.New("SECRET_KEY vazia: não é possível assinar tokens")
+	}
+	if len(chave) < 32 {
+		return "SECRET_KEY com menos de 32 bytes", nil
+	}
+	return "", nil
+}
+
+// verificarCertificadoSAML confere a validade do certificado publicado nos metadados SAML
+func verificarCertificadoSAML(ctx context.Context) (string, error) {
+	certificado := config.Atual().SAML.Certificado
+	if certificado == nil {
+		return "", ErrDesativado
+	}
+
+	agora := time.Now()
+	if agora.After(certificado.NotAfter) {
+		return "", fmt.Errorf("certificado vencido em %s", certificado.NotAfter.Format(time.RFC3339))
+	}
+	if agora.Before(certificado.NotBefore) {
+		return "", fmt.Errorf("certificado válido só a partir de %s", certificado.NotBefore.Format(time.RFC3339))
+	}
+	if certificado.NotAfter.Sub(agora) < avisoCertificado {
+		return fmt.Sprintf("certificado vence em %s", certificado.NotAfter.Format(time.RFC3339)), nil
+	}
+	return "", nil
+}
@@ -1,11 +1,19 @@
 package seguranca
 
 import (
+	"api/src/metricas"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/hkdf"
 )
 
 // Hash recebe uma string e coloca um hash nela
@@ -13,7 +21,9 @@ func Hash(senha string) ([]byte, error) {
 	log.Println("Iniciando o processo de hash para a senha.")
 
 	// Gerar o hash da senha
+	inicio := time.Now()
 	hash, err := bcrypt.GenerateFromPassword([]byte(senha), bcrypt.DefaultCost)
+	metricas.ObservarHash(metricas.HashGerar, inicio)
 	if err != nil {
 		log.Println("Erro ao gerar hash da senha:", err)
 		return nil, err
@@ -28,7 +38,9 @@ func VerificarSenha(senhaComHash, senhaString string) error {
 	log.Println("Iniciando a verificação da senha.")
 
 	// Comparar o hash da senha com o valor passado
+	inicio := time.Now()
 	err := bcrypt.CompareHashAndPassword([]byte(senhaComHash), []byte(senhaString))
+	metricas.ObservarHash(metricas.HashVerificar, inicio)
 	if err != nil {
 		log.Println("Erro ao comparar a senha com o hash:", err)
 		return err
@@ -50,9 +62,21 @@ func GerarCodigo() (string, error) {
 	return codigo, nil
 }
 
+// GerarTokenAleatorio gera um valor aleatório seguro codificado em base64 (URL)
+func GerarTokenAleatorio(tamanho int) (string, error) {
+	bytes := make([]byte, tamanho)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
 // HashCodigo criptografa o código de recuperação
 func HashCodigo(codigo string) (string, error) {
+	inicio := time.Now()
 	hash, err := bcrypt.GenerateFromPassword([]byte(codigo), bcrypt.DefaultCost)
+	metricas.ObservarHash(metricas.HashGerar, inicio)
 	if err != nil {
 		return "", err
 	}
@@ -62,7 +86,9 @@ func HashCodigo(codigo string) (string, error) {
 // VerificarCodigo compara um código digitado com o hash salvo e retorna se são iguais
 func VerificarCodigo(hash, codigo string) (bool, error) {
 	// Tenta comparar o código com o hash salvo
+	inicio := time.Now()
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(codigo))
+	metricas.ObservarHash(metricas.HashVerificar, inicio)
 	if err != nil {
 		// Se houver erro na comparação, retorna falso e o erro
 		return false, fmt.Errorf("código de recuperação inválido")
@@ -70,3 +96,29 @@ func VerificarCodigo(hash, codigo string) (bool, error) {
 	// Retorna verdadeiro caso o código seja válido
 	return true, nil
 }
+
+// HashToken retorna o SHA-256 (hex) de um token aleatório, para que ele possa ser buscado no banco sem ser salvo
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// DerivarChave gera a partir do segredo, com HKDF-SHA256, uma chave só para a finalidade informada. Uma assinatura
+// feita com ela não vale em nenhum outro lugar que use o mesmo segredo, como os tokens JWT.
+func DerivarChave(segredo []byte, finalidade string) []byte {
+	chave := make([]byte, sha256.Size)
+	io.ReadFull(hkdf.New(sha256.New, segredo, nil, []byte(finalidade)), chave)
+	return chave
+}
+
+// Assinar retorna o HMAC-SHA256 (base64 URL) da mensagem, usado em links assinados
+func Assinar(chave []byte, mensagem string) string {
+	mac := hmac.New(sha256.New, chave)
+	mac.Write([]byte(mensagem))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// AssinaturaValida compara a assinatura recebida com a esperada em tempo constante
+func AssinaturaValida(chave []byte, mensagem, assinatura string) bool {
+	return hmac.Equal([]byte(Assinar(chave, mensagem)), []byte(assinatura))
+}
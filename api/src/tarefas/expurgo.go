@@ -0,0 +1,64 @@
+package tarefas
+
+import (
+	"api/src/banco"
+	"api/src/cache"
+	"api/src/config"
+	"api/src/exportacao"
+	"api/src/repositorios"
+	"context"
+	"log"
+	"time"
+)
+
+// Expurgo apaga, a cada INTERVALO_EXPURGO, as contas cuja carência de exclusão terminou, as exportações de dados
+// expiradas e as chaves vencidas do cache no PostgreSQL, e dá como falha as exportações que ficaram pendentes. Roda até ctx acabar; um expurgo interrompido continua
+// na próxima execução.
+func Expurgo(ctx context.Context) {
+	ticker := time.NewTicker(config.Atual().Contas.IntervaloExpurgo)
+	defer ticker.Stop()
+
+	for {
+		expurgar(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func expurgar(ctx context.Context) {
+	repositorio := repositorios.NovoRepositorioDeContas(banco.Conexao())
+	carencia := config.Atual().Contas.CarenciaExclusao
+
+	apagadas, erro := repositorio.ExpurgarExcluidas(ctx, carencia)
+	if erro != nil {
+		log.Printf("Erro no expurgo de contas excluídas: %v", erro)
+		return
+	}
+
+	if apagadas > 0 {
+		log.Printf("Expurgo apagou %d conta(s) excluída(s) há mais de %v.", apagadas, carencia)
+	}
+
+	// Os arquivos das exportações de dados (LGPD) só ficam guardados enquanto o link de download vale
+	if apagadas, erro = repositorio.ApagarExportacoesExpiradas(ctx); erro != nil {
+		log.Printf("Erro ao apagar exportações expiradas: %v", erro)
+	} else if apagadas > 0 {
+		log.Printf("Expurgo apagou %d exportação(ões) de dados expirada(s).", apagadas)
+	}
+
+	// Uma exportação pendente além do tempo máximo não está mais sendo gerada por ninguém
+	if interrompidas, erro := repositorio.InterromperExportacoes(ctx, exportacao.TempoMaximoDeGeracao); erro != nil {
+		log.Printf("Erro ao encerrar exportações interrompidas: %v", erro)
+	} else if interrompidas > 0 {
+		log.Printf("Expurgo marcou %d exportação(ões) interrompida(s) como falha.", interrompidas)
+	}
+
+	// A tabela cache é usada quando o Redis não está configurado ou está fora do ar
+	if _, erro = cache.NovoPostgres(banco.Conexao()).ApagarExpirados(ctx); erro != nil {
+		log.Printf("Erro ao apagar chaves expiradas do cache: %v", erro)
+	}
+}
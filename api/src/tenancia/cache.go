@@ -0,0 +1,146 @@
+package tenancia
+
+import (
+	"api/src/modelos"
+	"container/list"
+	"sync"
+	"time"
+)
+
+// itemCache é o resultado de uma busca: a organização ou ErrOrganizacaoNaoEncontrada
+type itemCache struct {
+	chave       string
+	organizacao modelos.Organizacao
+	erro        error
+	expiraEm    time.Time
+}
+
+// lru guarda até capacidade itens, descartando o usado há mais tempo quando enche
+type lru struct {
+	capacidade int
+	itens      map[string]*list.Element
+	ordem      *list.List // do usado mais recentemente para o menos
+}
+
+func novoLRU(capacidade int) *lru {
+	return &lru{capacidade: capacidade, itens: map[string]*list.Element{}, ordem: list.New()}
+}
+
+func (cache *lru) buscar(chave string) (*itemCache, bool) {
+	elemento, ok := cache.itens[chave]
+	if !ok {
+		return nil, false
+	}
+	cache.ordem.MoveToFront(elemento)
+	return elemento.Value.(*itemCache), true
+}
+
+func (cache *lru) guardar(item *itemCache) {
+	if elemento, ok := cache.itens[item.chave]; ok {
+		elemento.Value = item
+		cache.ordem.MoveToFront(elemento)
+		return
+	}
+
+	cache.itens[item.chave] = cache.ordem.PushFront(item)
+	for cache.ordem.Len() > cache.capacidade {
+		cache.remover(cache.ordem.Back())
+	}
+}
+
+func (cache *lru) remover(elemento *list.Element) {
+	cache.ordem.Remove(elemento)
+	delete(cache.itens, elemento.Value.(*itemCache).chave)
+}
+
+// removerExpirados varre a lista inteira: a ordem é de uso, não de expiração
+func (cache *lru) removerExpirados(agora time.Time) {
+	for elemento := cache.ordem.Front(); elemento != nil; {
+		proximo := elemento.Next()
+		if !agora.Before(elemento.Value.(*itemCache).expiraEm) {
+			cache.remover(elemento)
+		}
+		elemento = proximo
+	}
+}
+
+// cacheDeOrganizacoes separa as organizações encontradas das buscas sem resultado, cada uma com o seu limite,
+// para que slugs e hosts inventados pelo cliente só disputem espaço entre si
+type cacheDeOrganizacoes struct {
+	mutex         sync.Mutex
+	encontradas   *lru
+	ausentes      *lru
+	ultimaVarrida time.Time
+}
+
+var cache = &cacheDeOrganizacoes{
+	encontradas: novoLRU(capacidadeCache),
+	ausentes:    novoLRU(capacidadeAusentes),
+}
+
+// buscar traz o item ainda válido da chave; o expirado é removido e conta como ausente do cache
+func (cache *cacheDeOrganizacoes) buscar(chave string) (*itemCache, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	agora := time.Now()
+	for _, lista := range []*lru{cache.encontradas, cache.ausentes} {
+		if item, ok := lista.buscar(chave); ok {
+			if agora.Before(item.expiraEm) {
+				return item, true
+			}
+			lista.remover(lista.itens[chave])
+		}
+	}
+	return nil, false
+}
+
+// guardar registra o resultado da busca e, no máximo uma vez por duracaoCache, descarta os itens expirados
+func (cache *cacheDeOrganizacoes) guardar(chave string, organizacao modelos.Organizacao, erro error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	agora := time.Now()
+	if agora.Sub(cache.ultimaVarrida) >= duracaoCache {
+		cache.encontradas.removerExpirados(agora)
+		cache.ausentes.removerExpirados(agora)
+		cache.ultimaVarrida = agora
+	}
+
+	item := &itemCache{chave: chave, organizacao: organizacao, erro: erro, expiraEm: agora.Add(duracaoCache)}
+	if erro != nil {
+		cache.ausentes.guardar(item)
+	} else {
+		cache.encontradas.guardar(item)
+	}
+}
+
+// descartar tira as chaves das duas listas, para que a próxima busca vá à fonte
+func (cache *cacheDeOrganizacoes) descartar(chaves ...string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for _, chave := range chaves {
+		for _, lista := range []*lru{cache.encontradas, cache.ausentes} {
+			if elemento, ok := lista.itens[chave]; ok {
+				lista.remover(elemento)
+			}
+		}
+	}
+}
+
+func (cache *cacheDeOrganizacoes) esvaziar() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.encontradas = novoLRU(cache.encontradas.capacidade)
+	cache.ausentes = novoLRU(cache.ausentes.capacidade)
+}
+
+// tamanho retorna quantos itens estão guardados, encontrados e ausentes
+func (cache *cacheDeOrganizacoes) tamanho() (int, int) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.encontradas.ordem.Len(), cache.ausentes.ordem.Len()
+}
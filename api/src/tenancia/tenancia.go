@@ -0,0 +1,194 @@
+package tenancia
+
+import (
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CabecalhoOrganizacao permite escolher a organização explicitamente pelo slug
+	CabecalhoOrganizacao = "X-Organizacao"
+
+	// SlugPadrao é a organização usada quando a requisição não indica nenhuma
+	SlugPadrao = "padrao"
+
+	// IDPadrao é o ID da organização padrão; tokens emitidos antes da multi-tenancy pertencem a ela
+	IDPadrao uint64 = 1
+
+	// prefixoCaminho identifica a organização na URL: /o/{slug}/usuarios
+	prefixoCaminho = "/o/"
+
+	duracaoCache = time.Minute
+
+	// capacidadeCache limita as organizações guardadas; cada uma ocupa até três chaves (id, slug e domínio)
+	capacidadeCache = 1000
+
+	// capacidadeAusentes limita as buscas sem resultado guardadas. Slug e host vêm do cliente: sem limite próprio,
+	// valores inventados encheriam a memória e tirariam do cache as organizações que existem.
+	capacidadeAusentes = 100
+)
+
+type chaveContexto struct{}
+
+// Fonte é de onde vêm as organizações que não estão no cache; em produção, o repositório do PostgreSQL
+type Fonte interface {
+	BuscarPorID(ctx context.Context, ID uint64) (modelos.Organizacao, error)
+	BuscarPorSlug(ctx context.Context, slug string) (modelos.Organizacao, error)
+	BuscarPorDominio(ctx context.Context, dominio string) (modelos.Organizacao, error)
+}
+
+var (
+	fonte      Fonte
+	mutexFonte sync.RWMutex
+)
+
+// Configurar define a fonte das organizações e esvazia o cache; deve ser chamada antes de o servidor atender
+func Configurar(novaFonte Fonte) {
+	mutexFonte.Lock()
+	fonte = novaFonte
+	mutexFonte.Unlock()
+
+	cache.esvaziar()
+}
+
+// Middleware descobre a organização da requisição (cabeçalho, caminho /o/{slug} ou host) e a guarda no contexto
+func Middleware(proximo http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		organizacao, erro := resolver(r)
+		if errors.Is(erro, repositorios.ErrOrganizacaoNaoEncontrada) {
+			respostas.Erro(w, http.StatusNotFound, erro)
+			return
+		}
+		if erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+
+		proximo.ServeHTTP(w, r.WithContext(NoContexto(r.Context(), organizacao)))
+	})
+}
+
+// resolver aplica as estratégias de resolução em ordem de prioridade.
+// Quando a organização vem do caminho, o prefixo /o/{slug} é removido para que as rotas casem normalmente.
+func resolver(r *http.Request) (modelos.Organizacao, error) {
+	if slug := strings.TrimSpace(r.Header.Get(CabecalhoOrganizacao)); slug != "" {
+		return BuscarPorSlug(slug)
+	}
+
+	if strings.HasPrefix(r.URL.Path, prefixoCaminho) {
+		resto := strings.TrimPrefix(r.URL.Path, prefixoCaminho)
+		slug, caminho, _ := strings.Cut(resto, "/")
+		organizacao, erro := BuscarPorSlug(slug)
+		if erro != nil {
+			return modelos.Organizacao{}, erro
+		}
+
+		r.URL.Path = "/" + caminho
+		r.URL.RawPath = ""
+		return organizacao, nil
+	}
+
+	host := r.Host
+	if semPorta, _, erro := net.SplitHostPort(host); erro == nil {
+		host = semPorta
+	}
+	if organizacao, erro := buscar("dominio:" + strings.ToLower(host)); erro == nil {
+		return organizacao, nil
+	} else if !errors.Is(erro, repositorios.ErrOrganizacaoNaoEncontrada) {
+		return modelos.Organizacao{}, erro
+	}
+
+	return BuscarPorSlug(SlugPadrao)
+}
+
+// NoContexto retorna um contexto que carrega a organização
+func NoContexto(ctx context.Context, organizacao modelos.Organizacao) context.Context {
+	return context.WithValue(ctx, chaveContexto{}, organizacao)
+}
+
+// DaRequisicao retorna a organização resolvida pelo Middleware.
+// Fora do middleware (tarefas internas, por exemplo) retorna a organização padrão.
+func DaRequisicao(r *http.Request) modelos.Organizacao {
+	if organizacao, ok := r.Context().Value(chaveContexto{}).(modelos.Organizacao); ok {
+		return organizacao
+	}
+
+	organizacao, erro := BuscarPorID(IDPadrao)
+	if erro != nil {
+		log.Printf("Erro ao carregar a organização padrão: %v", erro)
+		return modelos.Organizacao{ID: IDPadrao, Slug: SlugPadrao}
+	}
+	return organizacao
+}
+
+// BuscarPorID traz a organização pelo ID, usando o cache em memória
+func BuscarPorID(ID uint64) (modelos.Organizacao, error) {
+	return buscar("id:" + strconv.FormatUint(ID, 10))
+}
+
+// BuscarPorSlug traz a organização pelo slug, usando o cache em memória
+func BuscarPorSlug(slug string) (modelos.Organizacao, error) {
+	return buscar("slug:" + strings.ToLower(slug))
+}
+
+// Invalidar descarta o que o cache guarda da organização, encontrada ou ausente, pelo ID, pelo slug e pelo domínio.
+// Deve ser chamada depois de criar ou alterar uma organização: sem isso, um slug recém-criado continuaria dando 404
+// e uma chave de assinatura trocada só valeria depois de duracaoCache.
+func Invalidar(organizacao modelos.Organizacao) {
+	chaves := []string{
+		"id:" + strconv.FormatUint(organizacao.ID, 10),
+		"slug:" + strings.ToLower(organizacao.Slug),
+	}
+	if organizacao.Dominio != "" {
+		chaves = append(chaves, "dominio:"+strings.ToLower(organizacao.Dominio))
+	}
+	cache.descartar(chaves...)
+}
+
+// buscar consulta o cache e, se necessário, a fonte. A chave tem o formato "tipo:valor".
+func buscar(chave string) (modelos.Organizacao, error) {
+	if item, ok := cache.buscar(chave); ok {
+		return item.organizacao, item.erro
+	}
+
+	mutexFonte.RLock()
+	repositorio := fonte
+	mutexFonte.RUnlock()
+	if repositorio == nil {
+		return modelos.Organizacao{}, errors.New("fonte das organizações não configurada")
+	}
+
+	// O resultado fica no cache e serve a outras requisições, por isso a consulta não usa o contexto de quem a disparou
+	ctx := context.Background()
+	tipo, valor, _ := strings.Cut(chave, ":")
+
+	var (
+		organizacao modelos.Organizacao
+		erro        error
+	)
+	switch tipo {
+	case "id":
+		ID, _ := strconv.ParseUint(valor, 10, 64)
+		organizacao, erro = repositorio.BuscarPorID(ctx, ID)
+	case "slug":
+		organizacao, erro = repositorio.BuscarPorSlug(ctx, valor)
+	default:
+		organizacao, erro = repositorio.BuscarPorDominio(ctx, valor)
+	}
+	if erro != nil && !errors.Is(erro, repositorios.ErrOrganizacaoNaoEncontrada) {
+		return modelos.Organizacao{}, erro
+	}
+
+	cache.guardar(chave, organizacao, erro)
+	return organizacao, erro
+}
@@ -0,0 +1,241 @@
+package tenancia
+
+import (
+	"api/src/modelos"
+	"api/src/repositorios"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fonteDeTeste conta as consultas para mostrar quando o cache foi usado
+type fonteDeTeste struct {
+	mutex        sync.Mutex
+	organizacoes []modelos.Organizacao
+	consultas    int
+}
+
+func (fonte *fonteDeTeste) procurar(encontrou func(modelos.Organizacao) bool) (modelos.Organizacao, error) {
+	fonte.mutex.Lock()
+	defer fonte.mutex.Unlock()
+
+	fonte.consultas++
+	for _, organizacao := range fonte.organizacoes {
+		if encontrou(organizacao) {
+			return organizacao, nil
+		}
+	}
+	return modelos.Organizacao{}, repositorios.ErrOrganizacaoNaoEncontrada
+}
+
+func (fonte *fonteDeTeste) BuscarPorID(_ context.Context, ID uint64) (modelos.Organizacao, error) {
+	return fonte.procurar(func(organizacao modelos.Organizacao) bool { return organizacao.ID == ID })
+}
+
+func (fonte *fonteDeTeste) BuscarPorSlug(_ context.Context, slug string) (modelos.Organizacao, error) {
+	return fonte.procurar(func(organizacao modelos.Organizacao) bool { return organizacao.Slug == slug })
+}
+
+func (fonte *fonteDeTeste) BuscarPorDominio(_ context.Context, dominio string) (modelos.Organizacao, error) {
+	return fonte.procurar(func(organizacao modelos.Organizacao) bool { return organizacao.Dominio == dominio })
+}
+
+func (fonte *fonteDeTeste) totalDeConsultas() int {
+	fonte.mutex.Lock()
+	defer fonte.mutex.Unlock()
+	return fonte.consultas
+}
+
+func configurarFonte(t *testing.T) *fonteDeTeste {
+	t.Helper()
+
+	fonte := &fonteDeTeste{organizacoes: []modelos.Organizacao{
+		{ID: IDPadrao, Slug: SlugPadrao, Nome: "Padrão"},
+		{ID: 2, Slug: "acme", Nome: "Acme", Dominio: "acme.exemplo.com"},
+	}}
+	Configurar(fonte)
+	t.Cleanup(func() { Configurar(nil) })
+	return fonte
+}
+
+func TestBuscarUsaOCache(t *testing.T) {
+	fonte := configurarFonte(t)
+
+	for i := 0; i < 3; i++ {
+		organizacao, erro := BuscarPorSlug("ACME")
+		if erro != nil || organizacao.ID != 2 {
+			t.Fatalf("BuscarPorSlug = %+v, %v", organizacao, erro)
+		}
+	}
+	if consultas := fonte.totalDeConsultas(); consultas != 1 {
+		t.Errorf("consultas à fonte = %d, quer 1", consultas)
+	}
+}
+
+func TestBuscaSemResultadoFicaNoCache(t *testing.T) {
+	fonte := configurarFonte(t)
+
+	for i := 0; i < 2; i++ {
+		if _, erro := BuscarPorSlug("inexistente"); !errors.Is(erro, repositorios.ErrOrganizacaoNaoEncontrada) {
+			t.Fatalf("erro = %v, quer ErrOrganizacaoNaoEncontrada", erro)
+		}
+	}
+	if consultas := fonte.totalDeConsultas(); consultas != 1 {
+		t.Errorf("consultas à fonte = %d, quer 1", consultas)
+	}
+}
+
+func TestInvalidarDescartaAusentesEEncontradas(t *testing.T) {
+	fonte := configurarFonte(t)
+
+	if _, erro := BuscarPorSlug("nova"); !errors.Is(erro, repositorios.ErrOrganizacaoNaoEncontrada) {
+		t.Fatalf("erro = %v, quer ErrOrganizacaoNaoEncontrada", erro)
+	}
+	if _, erro := BuscarPorID(2); erro != nil {
+		t.Fatal(erro)
+	}
+
+	// A organização é criada e a acme troca de nome depois de as buscas ficarem no cache
+	nova := modelos.Organizacao{ID: 3, Slug: "nova", Nome: "Nova"}
+	fonte.mutex.Lock()
+	fonte.organizacoes = append(fonte.organizacoes, nova)
+	fonte.organizacoes[1].Nome = "Acme S.A."
+	acme := fonte.organizacoes[1]
+	fonte.mutex.Unlock()
+
+	Invalidar(nova)
+	Invalidar(acme)
+
+	if organizacao, erro := BuscarPorSlug("nova"); erro != nil || organizacao.ID != nova.ID {
+		t.Errorf("BuscarPorSlug(nova) = %+v, %v", organizacao, erro)
+	}
+	if organizacao, erro := BuscarPorID(2); erro != nil || organizacao.Nome != "Acme S.A." {
+		t.Errorf("BuscarPorID(2) = %+v, %v; quer o nome novo", organizacao, erro)
+	}
+}
+
+func TestBuscasSemResultadoSaoLimitadas(t *testing.T) {
+	fonte := configurarFonte(t)
+
+	if _, erro := BuscarPorSlug("acme"); erro != nil {
+		t.Fatal(erro)
+	}
+
+	// Um cliente inventando slugs não passa do limite nem tira do cache as organizações que existem
+	for i := 0; i < capacidadeAusentes*3; i++ {
+		BuscarPorSlug(fmt.Sprintf("inventado-%d", i))
+	}
+
+	encontradas, ausentes := cache.tamanho()
+	if ausentes != capacidadeAusentes {
+		t.Errorf("ausentes = %d, quer %d", ausentes, capacidadeAusentes)
+	}
+	if encontradas != 1 {
+		t.Errorf("encontradas = %d, quer 1", encontradas)
+	}
+
+	antes := fonte.totalDeConsultas()
+	if _, erro := BuscarPorSlug("acme"); erro != nil {
+		t.Fatal(erro)
+	}
+	if fonte.totalDeConsultas() != antes {
+		t.Error("a organização existente saiu do cache")
+	}
+}
+
+func TestLRUDescartaOUsadoHaMaisTempo(t *testing.T) {
+	lista := novoLRU(2)
+	expira := time.Now().Add(time.Minute)
+
+	lista.guardar(&itemCache{chave: "a", expiraEm: expira})
+	lista.guardar(&itemCache{chave: "b", expiraEm: expira})
+	lista.buscar("a")
+	lista.guardar(&itemCache{chave: "c", expiraEm: expira})
+
+	if _, ok := lista.buscar("b"); ok {
+		t.Error("b deveria ter sido descartado")
+	}
+	for _, chave := range []string{"a", "c"} {
+		if _, ok := lista.buscar(chave); !ok {
+			t.Errorf("%s deveria continuar no cache", chave)
+		}
+	}
+}
+
+func TestItensExpiradosSaoRemovidos(t *testing.T) {
+	fonte := configurarFonte(t)
+
+	if _, erro := BuscarPorSlug("acme"); erro != nil {
+		t.Fatal(erro)
+	}
+
+	// Vence o item sem esperar duracaoCache
+	cache.mutex.Lock()
+	cache.encontradas.itens["slug:acme"].Value.(*itemCache).expiraEm = time.Now().Add(-time.Second)
+	cache.mutex.Unlock()
+
+	if _, erro := BuscarPorSlug("acme"); erro != nil {
+		t.Fatal(erro)
+	}
+	if consultas := fonte.totalDeConsultas(); consultas != 2 {
+		t.Errorf("consultas à fonte = %d, quer 2 (o item vencido não pode ser usado)", consultas)
+	}
+
+	// A varredura periódica tira os vencidos mesmo que ninguém volte a procurá-los
+	cache.mutex.Lock()
+	cache.encontradas.removerExpirados(time.Now().Add(2 * duracaoCache))
+	cache.mutex.Unlock()
+	if encontradas, _ := cache.tamanho(); encontradas != 0 {
+		t.Errorf("encontradas = %d depois da varredura, quer 0", encontradas)
+	}
+}
+
+func TestMiddlewareResolveAOrganizacao(t *testing.T) {
+	configurarFonte(t)
+
+	casos := []struct {
+		nome        string
+		url         string
+		cabecalho   string
+		querID      uint64
+		querCaminho string
+	}{
+		{"cabeçalho", "http://localhost/usuarios", "acme", 2, "/usuarios"},
+		{"caminho", "http://localhost/o/acme/usuarios", "", 2, "/usuarios"},
+		{"domínio", "http://acme.exemplo.com:5000/usuarios", "", 2, "/usuarios"},
+		{"padrão", "http://localhost/usuarios", "", IDPadrao, "/usuarios"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			requisicao := httptest.NewRequest(http.MethodGet, caso.url, nil)
+			if caso.cabecalho != "" {
+				requisicao.Header.Set(CabecalhoOrganizacao, caso.cabecalho)
+			}
+
+			var (
+				organizacao modelos.Organizacao
+				caminho     string
+			)
+			resposta := httptest.NewRecorder()
+			Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				organizacao = DaRequisicao(r)
+				caminho = r.URL.Path
+			})).ServeHTTP(resposta, requisicao)
+
+			if organizacao.ID != caso.querID || caminho != caso.querCaminho {
+				t.Errorf("organização %d em %s, quer %d em %s", organizacao.ID, caminho, caso.querID, caso.querCaminho)
+			}
+		})
+	}
+
+	resposta := httptest.NewRecorder()
+	Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(resposta, httptest.NewRequest(http.MethodGet, "http://localhost/o/inexistente/usuarios", nil))
+	if resposta.Code != http.StatusNotFound {
+		t.Errorf("slug inexistente: status %d, quer 404", resposta.Code)
+	}
+}
@@ -0,0 +1,56 @@
+// Package testes reúne o que os testes de mais de um pacote precisam para rodar contra serviços reais
+package testes
+
+import (
+	"api/src/migracoes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Registra o driver "pgx" no database/sql
+)
+
+// VariavelBanco aponta para um PostgreSQL descartável; sem ela os testes que precisam do banco são pulados
+const VariavelBanco = "TEST_DATABASE_URL"
+
+var sequencia atomic.Uint64
+
+// BancoDeDados abre o banco de VariavelBanco com as migrações aplicadas, ou pula o teste quando ela não está definida.
+// O banco é compartilhado entre os testes: cada um deve criar os próprios dados (ver NovaOrganizacao).
+func BancoDeDados(t testing.TB) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv(VariavelBanco)
+	if url == "" {
+		t.Skipf("%s não definida: teste com PostgreSQL pulado", VariavelBanco)
+	}
+
+	db, erro := sql.Open("pgx", url)
+	if erro != nil {
+		t.Fatalf("abrir %s: %v", VariavelBanco, erro)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if erro = migracoes.Preparar(db, true); erro != nil {
+		t.Fatalf("aplicar migrações: %v", erro)
+	}
+	return db
+}
+
+// NovaOrganizacao cria uma organização com slug único, para o teste não enxergar os dados dos outros
+func NovaOrganizacao(t testing.TB, db *sql.DB) uint64 {
+	t.Helper()
+
+	slug := fmt.Sprintf("teste-%d-%d", time.Now().UnixNano(), sequencia.Add(1))
+	var ID uint64
+	if erro := db.QueryRowContext(context.Background(),
+		"INSERT INTO organizacoes (slug, nome) VALUES ($1, $1) RETURNING id", slug,
+	).Scan(&ID); erro != nil {
+		t.Fatalf("criar organização: %v", erro)
+	}
+	return ID
+}
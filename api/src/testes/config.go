@@ -0,0 +1,30 @@
+package testes
+
+import (
+	"api/src/config"
+	"testing"
+)
+
+// Configurar carrega a configuração com o mínimo obrigatório mais as variáveis informadas.
+// As variáveis voltam ao valor anterior no fim do teste, mas a configuração carregada continua valendo.
+func Configurar(t testing.TB, variaveis map[string]string) *config.Config {
+	t.Helper()
+
+	minimo := map[string]string{
+		"SECRET_KEY": "segredo-de-teste",
+		"DB_HOST":    "localhost",
+		"DB_USUARIO": "teste",
+		"DB_NOME":    "teste",
+	}
+	for nome, valor := range minimo {
+		t.Setenv(nome, valor)
+	}
+	for nome, valor := range variaveis {
+		t.Setenv(nome, valor)
+	}
+
+	if _, erro := config.Carregar(nil); erro != nil {
+		t.Fatalf("carregar a configuração: %v", erro)
+	}
+	return config.Atual()
+}
@@ -0,0 +1,35 @@
+package testes
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// VariavelRedis aponta para um Redis descartável; sem ela os testes que precisam do Redis são pulados
+const VariavelRedis = "TEST_REDIS_URL"
+
+// Redis conecta ao Redis de VariavelRedis, ou pula o teste quando ela não está definida.
+// O Redis é compartilhado entre os testes: cada um deve usar chaves próprias.
+func Redis(t testing.TB) redis.UniversalClient {
+	t.Helper()
+
+	url := os.Getenv(VariavelRedis)
+	if url == "" {
+		t.Skipf("%s não definida: teste com Redis pulado", VariavelRedis)
+	}
+
+	opcoes, erro := redis.ParseURL(url)
+	if erro != nil {
+		t.Fatalf("%s inválida: %v", VariavelRedis, erro)
+	}
+	cliente := redis.NewClient(opcoes)
+	t.Cleanup(func() { cliente.Close() })
+
+	if erro = cliente.Ping(context.Background()).Err(); erro != nil {
+		t.Fatalf("conectar ao Redis: %v", erro)
+	}
+	return cliente
+}
@@ -0,0 +1,142 @@
+package testes
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"encoding/xml"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+)
+
+// ParDeChaves gera uma chave RSA e um certificado autoassinado para o nome informado
+func ParDeChaves(t testing.TB, nome string) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+
+	chave, erro := rsa.GenerateKey(rand.Reader, 2048)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	modelo := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: nome},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	bruto, erro := x509.CreateCertificate(rand.Reader, modelo, modelo, &chave.PublicKey, chave)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	certificado, erro := x509.ParseCertificate(bruto)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	return chave, certificado
+}
+
+// ArquivosSAML grava um par de chaves do provedor de serviço e retorna as variáveis que apontam para ele,
+// para juntar às de Configurar
+func ArquivosSAML(t testing.TB) map[string]string {
+	t.Helper()
+
+	chave, certificado := ParDeChaves(t, "sp-de-teste")
+	pasta := t.TempDir()
+	arquivoCertificado, arquivoChave := filepath.Join(pasta, "sp.crt"), filepath.Join(pasta, "sp.key")
+
+	gravar := func(arquivo, tipo string, conteudo []byte) {
+		if erro := os.WriteFile(arquivo, pem.EncodeToMemory(&pem.Block{Type: tipo, Bytes: conteudo}), 0o600); erro != nil {
+			t.Fatal(erro)
+		}
+	}
+	gravar(arquivoCertificado, "CERTIFICATE", certificado.Raw)
+	gravar(arquivoChave, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(chave))
+
+	return map[string]string{"SAML_CERTIFICADO_ARQUIVO": arquivoCertificado, "SAML_CHAVE_ARQUIVO": arquivoChave}
+}
+
+// IdPSAML é um provedor de identidade SAML em memória: publica o metadata e assina as respostas com a própria chave
+type IdPSAML struct {
+	idp *saml.IdentityProvider
+}
+
+// NovoIdPSAML cria um IdP com um par de chaves novo
+func NovoIdPSAML(t testing.TB) *IdPSAML {
+	t.Helper()
+
+	chave, certificado := ParDeChaves(t, "idp-de-teste")
+	metadata, _ := url.Parse("https://idp.exemplo.com/metadata")
+	sso, _ := url.Parse("https://idp.exemplo.com/sso")
+
+	return &IdPSAML{&saml.IdentityProvider{
+		Key:         chave,
+		Certificate: certificado,
+		MetadataURL: *metadata,
+		SSOURL:      *sso,
+	}}
+}
+
+// Metadata é o XML que o administrador da organização envia em PUT /saml/{tenant}/idp
+func (idp *IdPSAML) Metadata(t testing.TB) []byte {
+	t.Helper()
+
+	metadata, erro := xml.Marshal(idp.idp.Metadata())
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	return metadata
+}
+
+// Resposta monta a resposta assinada do IdP para o provedor de serviço, como o navegador a posta no ACS
+// (em XML; o campo SAMLResponse leva o base64). A asserção vai sem criptografia, para o teste poder adulterá-la.
+func (idp *IdPSAML) Resposta(t testing.TB, sp *saml.ServiceProvider, idRequisicao, nameID, email string) []byte {
+	t.Helper()
+
+	metadataDoSP := sp.Metadata()
+	descritor := metadataDoSP.SPSSODescriptors[0]
+	descritor.KeyDescriptors = nil
+
+	requisicao := &saml.IdpAuthnRequest{
+		IDP:                     idp.idp,
+		HTTPRequest:             &http.Request{RemoteAddr: "192.0.2.10:40000"},
+		Request:                 saml.AuthnRequest{ID: idRequisicao},
+		ServiceProviderMetadata: metadataDoSP,
+		SPSSODescriptor:         &descritor,
+		ACSEndpoint:             &saml.IndexedEndpoint{Binding: saml.HTTPPostBinding, Location: sp.AcsURL.String()},
+		Now:                     saml.TimeNow(),
+	}
+	sessao := &saml.Session{NameID: nameID, NameIDFormat: string(saml.PersistentNameIDFormat), UserEmail: email}
+	if erro := (saml.DefaultAssertionMaker{}).MakeAssertion(requisicao, sessao); erro != nil {
+		t.Fatal(erro)
+	}
+
+	// O e-mail vai num atributo "email", como no mapeamento padrão de PUT /saml/{tenant}/idp
+	requisicao.Assertion.AttributeStatements = []saml.AttributeStatement{{Attributes: []saml.Attribute{{
+		Name:       "email",
+		NameFormat: "urn:oasis:names:tc:SAML:2.0:attrname-format:basic",
+		Values:     []saml.AttributeValue{{Type: "xs:string", Value: email}},
+	}}}}
+
+	if erro := requisicao.MakeResponse(); erro != nil {
+		t.Fatal(erro)
+	}
+
+	documento := etree.NewDocument()
+	documento.SetRoot(requisicao.ResponseEl)
+	resposta, erro := documento.WriteToBytes()
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	return resposta
+}